@@ -37,9 +37,11 @@ const (
 	AdHocRequester              = "ad_hoc"
 
 	// Stats cache names.
-	StatsCacheBuildlogger = "buildlogger"
-	StatsCacheTestResults = "test_results"
-	StatsCachePerf        = "perf"
+	StatsCacheBuildlogger            = "buildlogger"
+	StatsCacheBuildloggerCacheHits   = "buildlogger_cache_hits"
+	StatsCacheBuildloggerCacheMisses = "buildlogger_cache_misses"
+	StatsCacheTestResults            = "test_results"
+	StatsCachePerf                   = "perf"
 )
 
 var (
@@ -53,6 +55,8 @@ var (
 	// Convenience slice for slice cache names.
 	StatsCacheNames = []string{
 		StatsCacheBuildlogger,
+		StatsCacheBuildloggerCacheHits,
+		StatsCacheBuildloggerCacheMisses,
 		StatsCacheTestResults,
 		StatsCachePerf,
 	}
@@ -9,6 +9,8 @@ import (
 	"hash"
 	"io"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/evergreen-ci/cedar"
@@ -47,6 +49,14 @@ type Log struct {
 	CreatedAt   time.Time       `bson:"created_at"`
 	CompletedAt time.Time       `bson:"completed_at"`
 	Artifact    LogArtifactInfo `bson:"artifact"`
+	Stats       LogStats        `bson:"stats,omitempty"`
+	Sequences   LogSequences    `bson:"sequences,omitempty"`
+	// Truncated indicates that the log exceeded its size quota and no
+	// longer accepts new lines.
+	Truncated bool `bson:"truncated,omitempty"`
+	// FailureSignatures are the normalized error lines extracted from the
+	// log once it is closed with a non-zero exit code.
+	FailureSignatures []LogFailureSignature `bson:"failure_signatures,omitempty"`
 
 	env       cedar.Environment
 	populated bool
@@ -58,6 +68,11 @@ var (
 	logCreatedAtKey   = bsonutil.MustHaveTag(Log{}, "CreatedAt")
 	logCompletedAtKey = bsonutil.MustHaveTag(Log{}, "CompletedAt")
 	logArtifactKey    = bsonutil.MustHaveTag(Log{}, "Artifact")
+	logStatsKey       = bsonutil.MustHaveTag(Log{}, "Stats")
+	logSequencesKey   = bsonutil.MustHaveTag(Log{}, "Sequences")
+	logTruncatedKey   = bsonutil.MustHaveTag(Log{}, "Truncated")
+
+	logFailureSignaturesKey = bsonutil.MustHaveTag(Log{}, "FailureSignatures")
 )
 
 // Setup sets the environment for the log. The environment is required for
@@ -133,12 +148,89 @@ func (l *Log) Remove(ctx context.Context) error {
 	return errors.Wrapf(err, "removing log record '%s'", l.ID)
 }
 
+// RemoveArtifacts removes the log's chunks from the offline blob storage
+// bucket configured for the log. The log should be populated and the
+// environment should not be nil.
+func (l *Log) RemoveArtifacts(ctx context.Context) error {
+	if !l.populated {
+		return errors.New("cannot remove artifacts of an unpopulated log")
+	}
+	if l.env == nil {
+		return errors.New("cannot remove artifacts with a nil environment")
+	}
+	// The chunks of a log without a prefix would be removed from the
+	// root of the bucket, along with those of every other log.
+	if l.Artifact.Prefix == "" {
+		return errors.Errorf("cannot remove artifacts of log '%s' without an artifact prefix", l.ID)
+	}
+
+	bucket, err := l.getBucket(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrapf(bucket.RemovePrefix(ctx, ""), "removing chunks of log '%s'", l.ID)
+}
+
+// LogCompletedError is returned when appending to, or closing, a log that was
+// already closed.
+type LogCompletedError struct {
+	LogID string
+}
+
+func (e *LogCompletedError) Error() string {
+	return fmt.Sprintf("log '%s' is already closed", e.LogID)
+}
+
+// IsLogCompleted returns whether the cause of the given error is a log that
+// was already closed.
+func IsLogCompleted(err error) bool {
+	_, ok := errors.Cause(err).(*LogCompletedError)
+	return ok
+}
+
+// LogLineInvalidError is returned when appending a line that is malformed for
+// the format of the log.
+type LogLineInvalidError struct {
+	LogID string
+	// Line is the index of the malformed line in the appended lines.
+	Line int
+	Err  error
+}
+
+func (e *LogLineInvalidError) Error() string {
+	return fmt.Sprintf("line %d appended to log '%s' is invalid: %s", e.Line, e.LogID, e.Err)
+}
+
+// IsLogLineInvalid returns whether the cause of the given error is an
+// appended line that is malformed for the format of the log.
+func IsLogLineInvalid(err error) bool {
+	_, ok := errors.Cause(err).(*LogLineInvalidError)
+	return ok
+}
+
+// openQuery returns the DB query matching the log only while it is open.
+func (l *Log) openQuery() bson.M {
+	return bson.M{
+		"_id":             l.ID,
+		logCompletedAtKey: bson.M{"$lte": time.Time{}},
+	}
+}
+
 // Append uploads a chunk of log lines to the offline blob storage bucket
-// configured for the log. The environment should not be nil.
+// configured for the log. Lines of structured log formats are validated and
+// stored as single-line JSON documents, see LogFormat.ValidateLine. Secrets
+// are redacted from the lines before they are stored, see RedactionConfig.
+// Lines exceeding a quota of the log's project are rejected with a
+// LogQuotaExceededError, see QuotaConfig. Appending to a closed log is
+// rejected with a LogCompletedError. The environment should not be nil.
 func (l *Log) Append(ctx context.Context, lines []LogLine) error {
 	if l.env == nil {
 		return errors.New("cannot not append log lines with a nil environment")
 	}
+	if !l.CompletedAt.IsZero() {
+		return &LogCompletedError{LogID: l.ID}
+	}
 	if len(lines) == 0 {
 		grip.Warning(message.Fields{
 			"collection": buildloggerCollection,
@@ -148,8 +240,42 @@ func (l *Log) Append(ctx context.Context, lines []LogLine) error {
 		return nil
 	}
 
+	conf, err := l.getConfig()
+	if err != nil {
+		return err
+	}
+	redactor, err := conf.Redaction.redactor(l.Info.Project)
+	if err != nil {
+		return errors.Wrapf(err, "getting redaction rules of log '%s'", l.ID)
+	}
+	lineBuffer, stats, err := l.bufferLines(lines, redactor)
+	if err != nil {
+		return err
+	}
+	if err = l.checkQuota(ctx, conf.Quota, stats); err != nil {
+		return err
+	}
+	if err = l.uploadLines(ctx, lineBuffer, lines); err != nil {
+		l.releaseUsage(ctx, stats)
+		return err
+	}
+	if err = l.updateStats(ctx, stats, conf.Quota.GetRule(l.Info.Project).MaxLogBytes); err != nil {
+		l.releaseUsage(ctx, stats)
+		l.discardLines(ctx, lines)
+		return err
+	}
+
+	l.addToStatsCache(lines)
+
+	return nil
+}
+
+// bufferLines formats the given lines for storage, redacting their secrets
+// with the given redactor, and computes their stats.
+func (l *Log) bufferLines(lines []LogLine, redactor *logRedactor) (*bytes.Buffer, LogStats, error) {
 	lineBuffer := &bytes.Buffer{}
-	for _, line := range lines {
+	stats := LogStats{Priorities: map[string]int{}}
+	for i, line := range lines {
 		// unlikely scenario, but just in case priority is out of range.
 		if line.Priority > level.Emergency {
 			line.Priority = level.Emergency
@@ -157,35 +283,89 @@ func (l *Log) Append(ctx context.Context, lines []LogLine) error {
 			line.Priority = level.Trace
 		}
 
-		_, err := lineBuffer.WriteString(prependPriorityAndTimestamp(line.Priority, line.Timestamp, line.Data))
+		data, err := l.Info.Format.normalizeLine(line.Data)
 		if err != nil {
-			return errors.Wrap(err, "buffering lines")
+			return nil, LogStats{}, &LogLineInvalidError{LogID: l.ID, Line: i, Err: err}
 		}
-	}
+		data, redactions := redactor.redact(l.Info.Format, data)
+		stats.Redactions += redactions
+		stats.add(line.Priority, line.Timestamp, data)
 
-	conf := &CedarConfig{}
-	conf.Setup(l.env)
-	if err := conf.Find(); err != nil {
-		return errors.Wrap(err, "getting application configuration")
+		_, err = lineBuffer.WriteString(prependPriorityAndTimestamp(line.Priority, line.Timestamp, data))
+		if err != nil {
+			return nil, LogStats{}, errors.Wrap(err, "buffering lines")
+		}
 	}
-	bucket, err := l.Artifact.Type.Create(
-		ctx,
-		l.env,
-		conf.Bucket.BuildLogsBucket,
-		l.Artifact.Prefix,
-		string(pail.S3PermissionsPrivate),
-		true,
-	)
+
+	return lineBuffer, stats, nil
+}
+
+// uploadLines uploads the buffered lines as a new chunk of the log.
+func (l *Log) uploadLines(ctx context.Context, lineBuffer *bytes.Buffer, lines []LogLine) error {
+	bucket, err := l.getBucket(ctx, true)
 	if err != nil {
-		return errors.Wrap(err, "creating bucket")
+		return err
 	}
 
 	key := createBuildloggerChunkKey(lines[0].Timestamp, lines[len(lines)-1].Timestamp, len(lines))
-	if err := bucket.Put(ctx, key, lineBuffer); err != nil {
-		return errors.Wrap(err, "uploading log lines to bucket")
+	return errors.Wrap(bucket.Put(ctx, key, lineBuffer), "uploading log lines to bucket")
+}
+
+// discardLines removes the chunk uploaded by uploadLines for the given lines
+// once they cannot be recorded in the log's metadata, since version 1
+// readers list the chunks in the bucket and would otherwise read them. A
+// chunk already persisted in the log's chunk index by a concurrent close is
+// kept. Failures are only logged.
+func (l *Log) discardLines(ctx context.Context, lines []LogLine) {
+	key := createBuildloggerChunkKey(lines[0].Timestamp, lines[len(lines)-1].Timestamp, len(lines))
+	for _, chunk := range l.Artifact.Chunks {
+		if chunk.Key == key {
+			return
+		}
 	}
 
-	l.addToStatsCache(lines)
+	bucket, err := l.getBucket(ctx, true)
+	if err == nil {
+		err = errors.Wrap(bucket.Remove(ctx, key), "removing log lines from bucket")
+	}
+	grip.Warning(message.WrapError(err, message.Fields{
+		"message": "could not discard unrecorded log lines",
+		"id":      l.ID,
+		"key":     key,
+	}))
+}
+
+// updateStats adds the counters of newly appended lines to the log's stats.
+// The stats of a log closed concurrently are not updated and a
+// LogCompletedError is returned, since its chunk index no longer changes.
+// If the given log size limit is greater than 0, the stats of a log that
+// would exceed it are not updated either and the log is truncated, see
+// QuotaRule.MaxLogBytes.
+func (l *Log) updateStats(ctx context.Context, stats LogStats, maxLogBytes int64) error {
+	updateResult, err := l.env.GetDB().Collection(buildloggerCollection).UpdateOne(
+		ctx,
+		l.logSizeQuery(l.openQuery(), maxLogBytes, stats),
+		stats.update(),
+	)
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection":   buildloggerCollection,
+		"id":           l.ID,
+		"stats":        stats,
+		"updateResult": updateResult,
+		"op":           "update buildlogger log stats",
+	})
+	if err != nil {
+		return errors.Wrapf(err, "updating stats of log '%s'", l.ID)
+	}
+	if updateResult.MatchedCount == 0 {
+		if err = l.Find(ctx); err != nil {
+			return err
+		}
+		if !l.CompletedAt.IsZero() {
+			return &LogCompletedError{LogID: l.ID}
+		}
+		return l.rejectLogQuota(ctx, maxLogBytes, stats)
+	}
 
 	return nil
 }
@@ -214,26 +394,32 @@ func (l *Log) addToStatsCache(lines []LogLine) {
 }
 
 // Close "closes out" the log by populating the completed_at and info.exit_code
-// fields. The environment should not be nil.
+// fields. Closing a log that was already closed returns a LogCompletedError.
+// Version 1 logs are then upgraded to version 2 by persisting the index of
+// their chunks in the log's metadata. Since appends are rejected once the log
+// is closed, the listed chunks are final. Logs that fail to be upgraded are
+// upgraded later by the chunk index migration. The environment should not be
+// nil.
 func (l *Log) Close(ctx context.Context, exitCode int) error {
 	if l.env == nil {
 		return errors.New("cannot close log with a nil environment")
 	}
 
-	if l.ID == "" {
-		l.ID = l.Info.ID()
+	if err := l.Find(ctx); err != nil {
+		return err
+	}
+	if !l.CompletedAt.IsZero() {
+		return &LogCompletedError{LogID: l.ID}
 	}
 
 	completedAt := time.Now()
 	updateResult, err := l.env.GetDB().Collection(buildloggerCollection).UpdateOne(
 		ctx,
-		bson.M{"_id": l.ID},
-		bson.M{
-			"$set": bson.M{
-				logCompletedAtKey: completedAt,
-				bsonutil.GetDottedKeyName(logInfoKey, logInfoExitCodeKey): exitCode,
-			},
-		},
+		l.openQuery(),
+		bson.M{"$set": bson.M{
+			logCompletedAtKey: completedAt,
+			bsonutil.GetDottedKeyName(logInfoKey, logInfoExitCodeKey): exitCode,
+		}},
 	)
 	grip.DebugWhen(err == nil, message.Fields{
 		"collection":   buildloggerCollection,
@@ -243,15 +429,29 @@ func (l *Log) Close(ctx context.Context, exitCode int) error {
 		"updateResult": updateResult,
 		"op":           "close buildlogger log",
 	})
-	if err == nil && updateResult.MatchedCount == 0 {
-		return errors.Errorf("could not find log record '%s'", l.ID)
+	if err != nil {
+		return errors.Wrapf(err, "closing log '%s'", l.ID)
+	}
+	if updateResult.MatchedCount == 0 {
+		return &LogCompletedError{LogID: l.ID}
 	}
+	l.CompletedAt = completedAt
+	l.Info.ExitCode = exitCode
 
-	return errors.Wrapf(err, "closing log '%s'", l.ID)
+	if l.Artifact.Version == 1 {
+		// The log is already closed, so failing to upgrade it should
+		// not fail the close.
+		grip.Warning(message.WrapError(l.MigrateChunkIndex(ctx), message.Fields{
+			"message": "could not migrate chunk index of closed log, deferring to chunk index migration",
+			"id":      l.ID,
+		}))
+	}
 
+	return nil
 }
 
 // Download returns a LogIterator which iterates lines of the given log. The
+// chunks of completed logs are read through the log cache, see LogCache. The
 // environment should not be nil.
 func (l *Log) Download(ctx context.Context, timeRange TimeRange) (LogIterator, error) {
 	if l.env == nil {
@@ -262,30 +462,104 @@ func (l *Log) Download(ctx context.Context, timeRange TimeRange) (LogIterator, e
 		l.ID = l.Info.ID()
 	}
 
+	bucket, err := l.getReadBucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks, err := l.getChunks(ctx, bucket)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting chunks")
+	}
+
+	return NewBatchedLogIterator(bucket, chunks, 2, timeRange), nil
+}
+
+// DownloadFromLine returns a LineNumberedLogIterator which iterates lines of
+// the given log starting at the given zero-based line number. Chunks before
+// the line are located using their number of lines and are not downloaded.
+// The chunks of completed logs are read through the log cache, see LogCache.
+// The environment should not be nil.
+func (l *Log) DownloadFromLine(ctx context.Context, fromLine int) (LineNumberedLogIterator, error) {
+	if l.env == nil {
+		return nil, errors.New("cannot download log with a nil environment")
+	}
+	if fromLine < 0 {
+		return nil, errors.New("line number cannot be negative")
+	}
+
+	if l.ID == "" {
+		l.ID = l.Info.ID()
+	}
+
+	bucket, err := l.getReadBucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks, err := l.getChunks(ctx, bucket)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting chunks")
+	}
+
+	return NewLineOffsetLogIterator(bucket, chunks, fromLine), nil
+}
+
+// Follow returns a LogIterator which iterates lines of the given log starting
+// at the given time. Once the existing lines are exhausted, the iterator waits
+// for new chunks to be appended to the log, checking every poll interval,
+// until the log is closed. The log should be found before following it and
+// the environment should not be nil.
+func (l *Log) Follow(ctx context.Context, startAt time.Time, pollInterval time.Duration) (LogIterator, error) {
+	if l.env == nil {
+		return nil, errors.New("cannot follow log with a nil environment")
+	}
+	if pollInterval <= 0 {
+		return nil, errors.New("poll interval must be positive")
+	}
+
+	if l.ID == "" {
+		l.ID = l.Info.ID()
+	}
+
+	bucket, err := l.getBucket(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return newFollowingLogIterator(l, bucket, startAt, pollInterval), nil
+}
+
+// getConfig returns the application configuration.
+func (l *Log) getConfig() (*CedarConfig, error) {
 	conf := &CedarConfig{}
 	conf.Setup(l.env)
 	if err := conf.Find(); err != nil {
 		return nil, errors.Wrap(err, "getting application configuration")
 	}
 
+	return conf, nil
+}
+
+func (l *Log) getBucket(ctx context.Context, compress bool) (pail.Bucket, error) {
+	conf, err := l.getConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	bucket, err := l.Artifact.Type.Create(
 		ctx,
 		l.env,
 		conf.Bucket.BuildLogsBucket,
 		l.Artifact.Prefix,
 		string(pail.S3PermissionsPrivate),
-		false,
+		compress,
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating bucket")
 	}
 
-	chunks, err := l.getChunks(ctx, bucket)
-	if err != nil {
-		return nil, errors.Wrap(err, "getting chunks")
-	}
-
-	return NewBatchedLogIterator(bucket, chunks, 2, timeRange), nil
+	return bucket, nil
 }
 
 func (l *Log) getChunks(ctx context.Context, bucket pail.Bucket) ([]LogChunkInfo, error) {
@@ -298,29 +572,62 @@ func (l *Log) getChunks(ctx context.Context, bucket pail.Bucket) ([]LogChunkInfo
 	case 1:
 		// Version 1 uses the key of the chunk in the pail-backed
 		// offline storage to encode the chunk information.
-		it, err := bucket.List(ctx, "")
+		var err error
+		if cached, ok := bucket.(*cachedLogBucket); ok {
+			chunks, err = cached.getChunkListing(ctx)
+		} else {
+			chunks, err = l.listChunks(ctx, bucket, "")
+		}
 		if err != nil {
-			return nil, errors.Wrap(err, "listing chunks")
+			return nil, err
 		}
+	case 2:
+		// Version 2 stores the index of the chunks of closed logs in
+		// the DB, avoiding listing the pail-backed offline storage.
+		chunks = l.Artifact.Chunks
+	default:
+		return nil, errors.Errorf("invalid artifact version %d", l.Artifact.Version)
+	}
 
-		for it.Next(ctx) {
-			chunk, err := parseBuildloggerChunkKey(it.Item().Name())
-			if err != nil {
-				return nil, errors.Wrapf(err, "parsing chunk key '%s'", it.Item().Name())
-			}
-			chunks = append(chunks, chunk)
+	return chunks, nil
+}
+
+// listChunks lists the chunks of a version 1 log in the given bucket,
+// skipping the chunks ignored by readers, see
+// LogArtifactInfo.ignoredChunkKeys. If a key is given, only the chunks whose
+// key sorts after it are returned. Chunk keys sort by the chunks' start time,
+// so these are the chunks starting after the chunk with the given key.
+func (l *Log) listChunks(ctx context.Context, bucket pail.Bucket, after string) ([]LogChunkInfo, error) {
+	it, err := bucket.List(ctx, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "listing chunks")
+	}
+
+	var chunks []LogChunkInfo
+	ignore := l.Artifact.ignoredChunkKeys()
+	for it.Next(ctx) {
+		if ignore[it.Item().Name()] || (after != "" && it.Item().Name() <= after) {
+			continue
 		}
-		if err = it.Err(); err != nil {
-			return nil, errors.Wrap(err, "iterating chunks")
+		chunk, err := parseBuildloggerChunkKey(it.Item().Name())
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing chunk key '%s'", it.Item().Name())
 		}
-
-		sort.Slice(chunks, func(i, j int) bool {
-			return chunks[i].Start.Before(chunks[j].Start)
-		})
-	default:
-		return nil, errors.Errorf("invalid artifact version %d", l.Artifact.Version)
+		chunks = append(chunks, chunk)
+	}
+	if err = it.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterating chunks")
 	}
 
+	// Chunks starting at the same time are sorted by key so that line
+	// numbers are stable across reads.
+	sort.Slice(chunks, func(i, j int) bool {
+		if chunks[i].Start.Equal(chunks[j].Start) {
+			return chunks[i].Key < chunks[j].Key
+		}
+		return chunks[i].Start.Before(chunks[j].Start)
+	})
+
 	return chunks, nil
 }
 
@@ -401,6 +708,63 @@ func (id *LogInfo) ID() string {
 	return fmt.Sprintf("%x", hash.Sum(nil))
 }
 
+// LogStats describes the counters of a buildlogger log, maintained as lines
+// are appended.
+type LogStats struct {
+	NumLines int   `bson:"num_lines,omitempty"`
+	Size     int64 `bson:"size,omitempty"`
+	// Priorities maps the priorities of the log's lines, formatted as
+	// decimal numbers, to their number of lines.
+	Priorities  map[string]int `bson:"priorities,omitempty"`
+	FirstLineAt time.Time      `bson:"first_line_at,omitempty"`
+	LastLineAt  time.Time      `bson:"last_line_at,omitempty"`
+	// Redactions is the number of secrets redacted from the log's lines,
+	// see RedactionConfig.
+	Redactions int `bson:"redactions,omitempty"`
+}
+
+var (
+	logStatsNumLinesKey    = bsonutil.MustHaveTag(LogStats{}, "NumLines")
+	logStatsSizeKey        = bsonutil.MustHaveTag(LogStats{}, "Size")
+	logStatsPrioritiesKey  = bsonutil.MustHaveTag(LogStats{}, "Priorities")
+	logStatsFirstLineAtKey = bsonutil.MustHaveTag(LogStats{}, "FirstLineAt")
+	logStatsLastLineAtKey  = bsonutil.MustHaveTag(LogStats{}, "LastLineAt")
+	logStatsRedactionsKey  = bsonutil.MustHaveTag(LogStats{}, "Redactions")
+)
+
+func (s *LogStats) add(priority level.Priority, ts time.Time, data string) {
+	s.NumLines++
+	s.Size += int64(len(data))
+	s.Priorities[strconv.Itoa(int(priority))]++
+	if s.FirstLineAt.IsZero() || ts.Before(s.FirstLineAt) {
+		s.FirstLineAt = ts
+	}
+	if ts.After(s.LastLineAt) {
+		s.LastLineAt = ts
+	}
+}
+
+// update returns the DB update adding the stats to a log's stats.
+func (s *LogStats) update() bson.M {
+	inc := bson.M{
+		bsonutil.GetDottedKeyName(logStatsKey, logStatsNumLinesKey): s.NumLines,
+		bsonutil.GetDottedKeyName(logStatsKey, logStatsSizeKey):     s.Size,
+	}
+	if s.Redactions > 0 {
+		inc[bsonutil.GetDottedKeyName(logStatsKey, logStatsRedactionsKey)] = s.Redactions
+	}
+	for priority, count := range s.Priorities {
+		inc[bsonutil.GetDottedKeyName(logStatsKey, logStatsPrioritiesKey, priority)] = count
+	}
+	update := bson.M{"$inc": inc}
+	if !s.FirstLineAt.IsZero() {
+		update["$min"] = bson.M{bsonutil.GetDottedKeyName(logStatsKey, logStatsFirstLineAtKey): s.FirstLineAt}
+		update["$max"] = bson.M{bsonutil.GetDottedKeyName(logStatsKey, logStatsLastLineAtKey): s.LastLineAt}
+	}
+
+	return update
+}
+
 // LogLine describes a buildlogger log line. This is an intermediary type that
 // passes data from RPC calls to the upload phase and is used as the return
 // item for the LogIterator.
@@ -408,6 +772,39 @@ type LogLine struct {
 	Priority  level.Priority
 	Timestamp time.Time
 	Data      string
+	// Source is the log the line was read from. It is only set by
+	// iterators over multiple logs, see Logs.Merge.
+	Source *LogSource
+}
+
+// LogSource describes the buildlogger log a line was read from.
+type LogSource struct {
+	LogID string
+	Info  LogInfo
+}
+
+// Source returns the log as the source of its lines.
+func (l *Log) Source() LogSource {
+	return LogSource{
+		LogID: l.ID,
+		Info:  l.Info,
+	}
+}
+
+// name returns the process and test names of the source log, separated by a
+// slash, or the log's ID if it has neither.
+func (s *LogSource) name() string {
+	var names []string
+	for _, name := range []string{s.Info.ProcessName, s.Info.TestName} {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return s.LogID
+	}
+
+	return strings.Join(names, "/")
 }
 
 // Logs describes a set of buildlogger logs, typically related by some
@@ -557,8 +954,9 @@ func createFindQuery(opts LogFindOptions) map[string]interface{} {
 }
 
 // Merge merges the buildlogger logs, respecting the order of each line's
-// timestamp. The logs should be populated and the environment should not be
-// nil. When reverse is true, the log lines are returned in reverse order.
+// timestamp. Each line is attributed to the log it was read from, see
+// LogLine.Source. The logs should be populated and the environment should
+// not be nil.
 func (l *Logs) Merge(ctx context.Context) (LogIterator, error) {
 	if !l.populated {
 		return nil, errors.New("cannot merge unpopulated logs")
@@ -579,7 +977,7 @@ func (l *Logs) Merge(ctx context.Context) (LogIterator, error) {
 			catcher.Add(err)
 			return nil, errors.Wrap(catcher.Resolve(), "downloading log")
 		}
-		iterators = append(iterators, it)
+		iterators = append(iterators, NewSourceLogIterator(it, l.Logs[i].Source()))
 	}
 
 	return NewMergingIterator(iterators...), nil
@@ -0,0 +1,360 @@
+package model
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/pail"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const (
+	logCacheKey = "buildlogger-log-cache"
+
+	// LogCacheChunkListingTTL is the maximum amount of time a chunk
+	// listing of a completed buildlogger log is cached. Compaction
+	// replaces chunks of completed logs, so the TTL must be shorter than
+	// ReplacedLogChunkGracePeriod: a listing cached before a compaction
+	// expires before the chunks it references are removed.
+	LogCacheChunkListingTTL = 10 * time.Minute
+
+	// logCacheChunkInfoSize is the approximate size, in bytes, of the
+	// fields of a cached chunk listing entry other than its key.
+	logCacheChunkInfoSize = 64
+)
+
+// LogCache is an in-process cache of the chunk listings and chunk bodies of
+// completed buildlogger logs. Completed logs are immutable, so entries are
+// never updated, only evicted. Chunk listings are keyed by the log's artifact
+// prefix and chunk bodies by the log's artifact prefix and the chunk key.
+// Implementations must be safe for concurrent use and must not return chunk
+// listings put more than LogCacheChunkListingTTL ago.
+type LogCache interface {
+	// GetChunkListing returns the cached chunk listing of the log with
+	// the given artifact prefix and whether it was found.
+	GetChunkListing(string) ([]LogChunkInfo, bool)
+	// PutChunkListing caches the chunk listing of the log with the given
+	// artifact prefix.
+	PutChunkListing(string, []LogChunkInfo)
+	// GetChunk returns the cached body of the chunk with the given key of
+	// the log with the given artifact prefix and whether it was found.
+	GetChunk(string, string) ([]byte, bool)
+	// PutChunk caches the body of the chunk with the given key of the log
+	// with the given artifact prefix.
+	PutChunk(string, string, []byte)
+}
+
+// GetLogCache returns the log cache of the environment, creating it with
+// the application configuration's LogCacheConfig on first use. It returns
+// nil if the environment cache is disabled or the log cache is not
+// configured. The configuration is only read on first use, also when the log
+// cache is not configured.
+func GetLogCache(env cedar.Environment) (LogCache, error) {
+	if env == nil {
+		return nil, errors.New("cannot get log cache with a nil environment")
+	}
+
+	envCache, ok := env.GetCache()
+	if !ok {
+		return nil, nil
+	}
+	if cache, ok := getRegisteredLogCache(envCache); ok {
+		return cache, nil
+	}
+
+	conf := NewCedarConfig(env)
+	if err := conf.Find(); err != nil {
+		return nil, errors.Wrap(err, "getting application configuration")
+	}
+	if conf.LogCache.MaxSize <= 0 {
+		_ = envCache.PutNew(logCacheKey, disabledLogCache{})
+		return nil, nil
+	}
+	_ = envCache.PutNew(logCacheKey, NewLRULogCache(conf.LogCache.MaxSize, conf.LogCache.MaxChunkSize))
+	cache, _ := getRegisteredLogCache(envCache)
+
+	return cache, nil
+}
+
+// SetLogCache registers the given log cache with the environment, replacing
+// the LRU cache created from the application configuration. It returns
+// false if the environment cache is disabled or a log cache is already
+// registered, a log cache found to be unconfigured may be replaced.
+func SetLogCache(env cedar.Environment, cache LogCache) bool {
+	if env == nil || cache == nil {
+		return false
+	}
+
+	envCache, ok := env.GetCache()
+	if !ok {
+		return false
+	}
+	if value, ok := envCache.Get(logCacheKey); ok {
+		if _, disabled := value.(disabledLogCache); disabled {
+			envCache.Delete(logCacheKey)
+		}
+	}
+
+	return envCache.PutNew(logCacheKey, cache)
+}
+
+// disabledLogCache is registered with the environment cache once the log
+// cache is found to be unconfigured, so that reads do not look up the
+// application configuration every time.
+type disabledLogCache struct{}
+
+// getRegisteredLogCache returns the log cache registered with the given
+// environment cache, which is nil if the log cache is unconfigured, and
+// whether one was registered.
+func getRegisteredLogCache(envCache cedar.EnvironmentCache) (LogCache, bool) {
+	value, ok := envCache.Get(logCacheKey)
+	if !ok {
+		return nil, false
+	}
+	if _, disabled := value.(disabledLogCache); disabled {
+		return nil, true
+	}
+	cache, ok := value.(LogCache)
+
+	return cache, ok
+}
+
+// NewLRULogCache returns a LogCache that evicts the least recently used
+// entries once the total size of the cached entries exceeds maxSize bytes.
+// Chunk bodies larger than maxChunkSize bytes are not cached; a
+// non-positive maxChunkSize does not limit the size of a single chunk body.
+func NewLRULogCache(maxSize, maxChunkSize int64) LogCache {
+	return &lruLogCache{
+		maxSize:      maxSize,
+		maxChunkSize: maxChunkSize,
+		entries:      map[lruLogCacheKey]*list.Element{},
+		order:        list.New(),
+	}
+}
+
+type lruLogCache struct {
+	mu           sync.Mutex
+	maxSize      int64
+	maxChunkSize int64
+	size         int64
+	entries      map[lruLogCacheKey]*list.Element
+	order        *list.List
+}
+
+// lruLogCacheKey identifies a cache entry, either the chunk listing or a
+// chunk body of a log.
+type lruLogCacheKey struct {
+	prefix  string
+	chunk   string
+	listing bool
+}
+
+type lruLogCacheEntry struct {
+	key     lruLogCacheKey
+	size    int64
+	data    []byte
+	chunks  []LogChunkInfo
+	addedAt time.Time
+}
+
+func (c *lruLogCache) GetChunkListing(prefix string) ([]LogChunkInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.get(lruLogCacheKey{prefix: prefix, listing: true})
+	if !ok {
+		return nil, false
+	}
+	if time.Since(entry.addedAt) >= LogCacheChunkListingTTL {
+		c.remove(c.entries[entry.key])
+		return nil, false
+	}
+
+	return append([]LogChunkInfo{}, entry.chunks...), true
+}
+
+func (c *lruLogCache) PutChunkListing(prefix string, chunks []LogChunkInfo) {
+	size := int64(len(prefix))
+	for _, chunk := range chunks {
+		size += int64(len(chunk.Key)) + logCacheChunkInfoSize
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.put(&lruLogCacheEntry{
+		key:     lruLogCacheKey{prefix: prefix, listing: true},
+		size:    size,
+		chunks:  append([]LogChunkInfo{}, chunks...),
+		addedAt: time.Now(),
+	})
+}
+
+func (c *lruLogCache) GetChunk(prefix, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.get(lruLogCacheKey{prefix: prefix, chunk: key})
+	if !ok {
+		return nil, false
+	}
+
+	return entry.data, true
+}
+
+func (c *lruLogCache) PutChunk(prefix, key string, data []byte) {
+	if c.maxChunkSize > 0 && int64(len(data)) > c.maxChunkSize {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.put(&lruLogCacheEntry{
+		key:     lruLogCacheKey{prefix: prefix, chunk: key},
+		size:    int64(len(prefix) + len(key) + len(data)),
+		data:    data,
+		addedAt: time.Now(),
+	})
+}
+
+// get returns the entry with the given key, marking it as the most recently
+// used. The lock must be held.
+func (c *lruLogCache) get(key lruLogCacheKey) (*lruLogCacheEntry, bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+
+	return elem.Value.(*lruLogCacheEntry), true
+}
+
+// put adds the given entry, replacing any entry with the same key, and
+// evicts the least recently used entries until the cache fits its maximum
+// size. Entries larger than the maximum size are not added. The lock must be
+// held.
+func (c *lruLogCache) put(entry *lruLogCacheEntry) {
+	if entry.size > c.maxSize {
+		return
+	}
+	if elem, ok := c.entries[entry.key]; ok {
+		c.remove(elem)
+	}
+
+	c.entries[entry.key] = c.order.PushFront(entry)
+	c.size += entry.size
+	for c.size > c.maxSize {
+		c.remove(c.order.Back())
+	}
+}
+
+// remove removes the given element from the cache. The lock must be held.
+func (c *lruLogCache) remove(elem *list.Element) {
+	entry := c.order.Remove(elem).(*lruLogCacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+}
+
+// cachedLogBucket is a bucket that reads the chunk bodies of a completed
+// buildlogger log through the log cache.
+type cachedLogBucket struct {
+	pail.Bucket
+	cache LogCache
+	log   *Log
+}
+
+func (b *cachedLogBucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if data, ok := b.cache.GetChunk(b.log.Artifact.Prefix, key); ok {
+		b.log.addToCacheStats(true)
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+	b.log.addToCacheStats(false)
+
+	r, err := b.Bucket.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading chunk '%s'", key)
+	}
+	b.cache.PutChunk(b.log.Artifact.Prefix, key, data)
+
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+// getChunkListing returns the chunk listing of the log from the log cache,
+// listing the bucket on a miss. Listings are only cached when the log has no
+// pending or replaced chunks, since they change while a compaction is in
+// progress.
+func (b *cachedLogBucket) getChunkListing(ctx context.Context) ([]LogChunkInfo, error) {
+	cacheable := len(b.log.Artifact.ignoredChunkKeys()) == 0
+	if cacheable {
+		if chunks, ok := b.cache.GetChunkListing(b.log.Artifact.Prefix); ok {
+			b.log.addToCacheStats(true)
+			return chunks, nil
+		}
+		b.log.addToCacheStats(false)
+	}
+
+	chunks, err := b.log.listChunks(ctx, b.Bucket, "")
+	if err != nil {
+		return nil, err
+	}
+	if cacheable {
+		b.cache.PutChunkListing(b.log.Artifact.Prefix, chunks)
+	}
+
+	return chunks, nil
+}
+
+// getReadBucket returns the bucket used to read the log's chunks. The chunks
+// of completed logs are read through the log cache, if configured.
+func (l *Log) getReadBucket(ctx context.Context) (pail.Bucket, error) {
+	bucket, err := l.getBucket(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	if l.CompletedAt.IsZero() {
+		return bucket, nil
+	}
+
+	cache, err := GetLogCache(l.env)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting log cache")
+	}
+	if cache == nil {
+		return bucket, nil
+	}
+
+	return &cachedLogBucket{Bucket: bucket, cache: cache, log: l}, nil
+}
+
+func (l *Log) addToCacheStats(hit bool) {
+	name := cedar.StatsCacheBuildloggerCacheMisses
+	if hit {
+		name = cedar.StatsCacheBuildloggerCacheHits
+	}
+
+	if err := l.env.GetStatsCache(name).AddStat(cedar.Stat{
+		Count:   1,
+		Project: l.Info.Project,
+		Version: l.Info.Version,
+		TaskID:  l.Info.TaskID,
+	}); err != nil {
+		grip.Error(message.WrapError(err, message.Fields{
+			"message": "stats were dropped",
+			"cache":   name,
+		}))
+	}
+}
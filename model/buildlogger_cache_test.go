@@ -0,0 +1,207 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/pail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRULogCache(t *testing.T) {
+	t.Run("ChunkBodies", func(t *testing.T) {
+		cache := NewLRULogCache(30, 0)
+		_, ok := cache.GetChunk("prefix", "chunk0")
+		assert.False(t, ok)
+
+		cache.PutChunk("p", "c0", []byte("0123456789"))
+		cache.PutChunk("p", "c1", []byte("0123456789"))
+		data, ok := cache.GetChunk("p", "c0")
+		require.True(t, ok)
+		assert.Equal(t, []byte("0123456789"), data)
+
+		// Adding a third chunk evicts the least recently used one.
+		cache.PutChunk("p", "c2", []byte("0123456789"))
+		_, ok = cache.GetChunk("p", "c1")
+		assert.False(t, ok)
+		_, ok = cache.GetChunk("p", "c0")
+		assert.True(t, ok)
+		_, ok = cache.GetChunk("p", "c2")
+		assert.True(t, ok)
+
+		// Chunks are keyed by prefix.
+		_, ok = cache.GetChunk("q", "c0")
+		assert.False(t, ok)
+	})
+	t.Run("MaxSize", func(t *testing.T) {
+		cache := NewLRULogCache(10, 0)
+		cache.PutChunk("p", "c0", []byte("0123456789"))
+		_, ok := cache.GetChunk("p", "c0")
+		assert.False(t, ok)
+	})
+	t.Run("MaxChunkSize", func(t *testing.T) {
+		cache := NewLRULogCache(100, 5)
+		cache.PutChunk("p", "c0", []byte("012345"))
+		_, ok := cache.GetChunk("p", "c0")
+		assert.False(t, ok)
+		cache.PutChunk("p", "c1", []byte("01234"))
+		_, ok = cache.GetChunk("p", "c1")
+		assert.True(t, ok)
+	})
+	t.Run("ReplaceEntry", func(t *testing.T) {
+		cache := NewLRULogCache(100, 0).(*lruLogCache)
+		cache.PutChunk("p", "c0", []byte("0123456789"))
+		cache.PutChunk("p", "c0", []byte("01234"))
+		data, ok := cache.GetChunk("p", "c0")
+		require.True(t, ok)
+		assert.Equal(t, []byte("01234"), data)
+		assert.EqualValues(t, 8, cache.size)
+		assert.Equal(t, 1, cache.order.Len())
+	})
+	t.Run("ChunkListings", func(t *testing.T) {
+		cache := NewLRULogCache(1000, 0).(*lruLogCache)
+		_, ok := cache.GetChunkListing("p")
+		assert.False(t, ok)
+
+		chunks := []LogChunkInfo{{Key: "c0", NumLines: 1}, {Key: "c1", NumLines: 2}}
+		cache.PutChunkListing("p", chunks)
+		chunks[0].NumLines = 10
+		cached, ok := cache.GetChunkListing("p")
+		require.True(t, ok)
+		assert.Equal(t, []LogChunkInfo{{Key: "c0", NumLines: 1}, {Key: "c1", NumLines: 2}}, cached)
+		_, ok = cache.GetChunk("p", "")
+		assert.False(t, ok)
+
+		cache.entries[lruLogCacheKey{prefix: "p", listing: true}].Value.(*lruLogCacheEntry).addedAt = time.Now().Add(-LogCacheChunkListingTTL)
+		_, ok = cache.GetChunkListing("p")
+		assert.False(t, ok)
+		assert.Zero(t, cache.size)
+		assert.Empty(t, cache.entries)
+	})
+	t.Run("Concurrent", func(t *testing.T) {
+		cache := NewLRULogCache(100, 0).(*lruLogCache)
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					key := fmt.Sprintf("c%d", (i+j)%20)
+					if _, ok := cache.GetChunk("p", key); !ok {
+						cache.PutChunk("p", key, []byte("0123456789"))
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+		assert.True(t, cache.size <= 100)
+		assert.Equal(t, len(cache.entries), cache.order.Len())
+	})
+}
+
+func TestCachedLogBucket(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir, err := ioutil.TempDir(".", "cached-log-bucket-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: tmpDir})
+	require.NoError(t, err)
+
+	start := time.Now().Round(time.Millisecond).UTC()
+	chunk := LogChunkInfo{
+		Key:      createBuildloggerChunkKey(start, start.Add(time.Second), 1),
+		NumLines: 1,
+		Start:    start,
+		End:      start.Add(time.Second),
+	}
+	require.NoError(t, bucket.Put(ctx, chunk.Key, bytes.NewBufferString("line0\n")))
+
+	log := &Log{
+		ID:       "log",
+		Artifact: LogArtifactInfo{Prefix: "log", Version: 1},
+		env:      cedar.GetEnvironment(),
+	}
+	cached := &cachedLogBucket{Bucket: bucket, cache: NewLRULogCache(1000, 0), log: log}
+
+	chunks, err := log.getChunks(ctx, cached)
+	require.NoError(t, err)
+	assert.Equal(t, []LogChunkInfo{chunk}, chunks)
+	r, err := cached.Get(ctx, chunk.Key)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.NoError(t, r.Close())
+	assert.Equal(t, "line0\n", string(data))
+
+	// Once cached, neither the listing nor the body is read from the
+	// bucket.
+	require.NoError(t, bucket.Remove(ctx, chunk.Key))
+	chunks, err = log.getChunks(ctx, cached)
+	require.NoError(t, err)
+	assert.Equal(t, []LogChunkInfo{chunk}, chunks)
+	r, err = cached.Get(ctx, chunk.Key)
+	require.NoError(t, err)
+	data, err = ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.NoError(t, r.Close())
+	assert.Equal(t, "line0\n", string(data))
+
+	// Listings of logs being compacted are not cached.
+	log.Artifact.PendingChunks = []string{"pending"}
+	chunks, err = log.getChunks(ctx, cached)
+	require.NoError(t, err)
+	assert.Empty(t, chunks)
+
+	_, err = cached.Get(ctx, "missing")
+	assert.Error(t, err)
+}
+
+type mockEnvironmentCache map[string]interface{}
+
+func (c mockEnvironmentCache) PutNew(key string, value interface{}) bool {
+	if _, ok := c[key]; ok {
+		return false
+	}
+	c[key] = value
+	return true
+}
+
+func (c mockEnvironmentCache) RegisterUpdater(context.Context, context.CancelFunc, string, chan interface{}) bool {
+	return false
+}
+
+func (c mockEnvironmentCache) Get(key string) (interface{}, bool) {
+	value, ok := c[key]
+	return value, ok
+}
+
+func (c mockEnvironmentCache) Delete(key string) { delete(c, key) }
+
+func TestGetRegisteredLogCache(t *testing.T) {
+	envCache := mockEnvironmentCache{}
+	cache, ok := getRegisteredLogCache(envCache)
+	assert.False(t, ok)
+	assert.Nil(t, cache)
+
+	require.True(t, envCache.PutNew(logCacheKey, disabledLogCache{}))
+	cache, ok = getRegisteredLogCache(envCache)
+	assert.True(t, ok)
+	assert.Nil(t, cache)
+
+	envCache.Delete(logCacheKey)
+	require.True(t, envCache.PutNew(logCacheKey, NewLRULogCache(10, 0)))
+	cache, ok = getRegisteredLogCache(envCache)
+	assert.True(t, ok)
+	assert.NotNil(t, cache)
+}
@@ -0,0 +1,142 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// getChunkIndex lists the chunks of a version 1 log in offline storage, in
+// the format persisted by version 2 logs.
+func (l *Log) getChunkIndex(ctx context.Context) ([]LogChunkInfo, error) {
+	if l.Artifact.Version != 1 {
+		return nil, errors.Errorf("cannot index chunks of artifact version %d", l.Artifact.Version)
+	}
+
+	bucket, err := l.getBucket(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := l.getChunks(ctx, bucket)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting chunks of log '%s'", l.ID)
+	}
+	if chunks == nil {
+		chunks = []LogChunkInfo{}
+	}
+
+	return chunks, nil
+}
+
+// MigrateChunkIndex upgrades a closed version 1 log to version 2 by
+// persisting the index of its chunks in the log's metadata. The migration
+// fails, and may be retried, if the chunks of the log are compacted
+// concurrently. The environment should not be nil.
+func (l *Log) MigrateChunkIndex(ctx context.Context) error {
+	if l.env == nil {
+		return errors.New("cannot migrate chunk index with a nil environment")
+	}
+	if err := l.Find(ctx); err != nil {
+		return err
+	}
+	if l.CompletedAt.IsZero() {
+		return errors.Errorf("cannot migrate chunk index of open log '%s'", l.ID)
+	}
+
+	chunks, err := l.getChunkIndex(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The chunks replaced by compaction only ever change while chunks are
+	// pending, or when they are removed, so matching the number of
+	// replaced chunks ensures the listing is still current.
+	replacedChunksKey := bsonutil.GetDottedKeyName(logArtifactKey, logArtifactInfoReplacedChunksKey)
+	query := bson.M{
+		"_id": l.ID,
+		bsonutil.GetDottedKeyName(logArtifactKey, logArtifactInfoVersionKey):            1,
+		bsonutil.GetDottedKeyName(logArtifactKey, logArtifactInfoPendingChunksKey, "0"): bson.M{"$exists": false},
+		fmt.Sprintf("%s.%d", replacedChunksKey, len(l.Artifact.ReplacedChunks)):         bson.M{"$exists": false},
+	}
+	if n := len(l.Artifact.ReplacedChunks); n > 0 {
+		query[fmt.Sprintf("%s.%d", replacedChunksKey, n-1)] = bson.M{"$exists": true}
+	}
+	updateResult, err := l.env.GetDB().Collection(buildloggerCollection).UpdateOne(
+		ctx,
+		query,
+		bson.M{"$set": bson.M{
+			bsonutil.GetDottedKeyName(logArtifactKey, logArtifactInfoChunksKey):  chunks,
+			bsonutil.GetDottedKeyName(logArtifactKey, logArtifactInfoVersionKey): 2,
+		}},
+	)
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection":   buildloggerCollection,
+		"id":           l.ID,
+		"chunks":       len(chunks),
+		"updateResult": updateResult,
+		"op":           "migrate buildlogger chunk index",
+	})
+	if err == nil && updateResult.MatchedCount == 0 {
+		err = errors.Errorf("log record '%s' changed during migration", l.ID)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "migrating chunk index of log '%s'", l.ID)
+	}
+
+	l.Artifact.Chunks = chunks
+	l.Artifact.Version = 2
+
+	return nil
+}
+
+// MigratedLogs describes the buildlogger logs upgraded to artifact version 2.
+type MigratedLogs struct {
+	// Found is the number of closed version 1 logs found.
+	Found int
+	// Migrated are the IDs of the logs that were upgraded.
+	Migrated []string
+}
+
+// MigrateLogChunkIndexes upgrades at most limit of the closed version 1
+// buildlogger logs to version 2, see Log.MigrateChunkIndex. Logs that cannot
+// be upgraded are left unchanged so that the migration may be retried.
+func MigrateLogChunkIndexes(ctx context.Context, env cedar.Environment, limit int) (*MigratedLogs, error) {
+	if env == nil {
+		return nil, errors.New("cannot migrate chunk indexes with a nil environment")
+	}
+
+	query := bson.M{
+		bsonutil.GetDottedKeyName(logArtifactKey, logArtifactInfoVersionKey): 1,
+		logCompletedAtKey: bson.M{"$gt": time.Time{}},
+	}
+	var logs []Log
+	findOpts := options.Find().SetLimit(int64(limit))
+	cur, err := env.GetDB().Collection(buildloggerCollection).Find(ctx, query, findOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding closed version 1 logs")
+	}
+	if err = cur.All(ctx, &logs); err != nil {
+		return nil, errors.Wrap(err, "decoding closed version 1 logs")
+	}
+
+	migrated := &MigratedLogs{Found: len(logs)}
+	catcher := grip.NewBasicCatcher()
+	for i := range logs {
+		logs[i].Setup(env)
+		if err = logs[i].MigrateChunkIndex(ctx); err != nil {
+			catcher.Add(err)
+			continue
+		}
+		migrated.Migrated = append(migrated.Migrated, logs[i].ID)
+	}
+
+	return migrated, catcher.Resolve()
+}
@@ -0,0 +1,118 @@
+package model
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/pail"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestBuildloggerMigrateChunkIndex(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir, err := ioutil.TempDir(".", "migrate-chunk-index-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+		assert.NoError(t, db.Collection(buildloggerCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(env.GetConfig().DbConfigurationCollection).Drop(ctx))
+	}()
+	conf := &CedarConfig{
+		populated: true,
+		Bucket:    BucketConfig{BuildLogsBucket: tmpDir},
+	}
+	conf.Setup(env)
+	require.NoError(t, conf.Save())
+
+	createLog := func(closed bool) (*Log, []LogChunkInfo) {
+		log := CreateLog(LogInfo{Project: "project", TaskID: utility.RandomString()}, PailLocal)
+		if closed {
+			log.CompletedAt = time.Now()
+		}
+		log.Setup(env)
+		require.NoError(t, log.SaveNew(ctx))
+		bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: tmpDir, Prefix: log.Artifact.Prefix})
+		require.NoError(t, err)
+		chunks, _, err := GenerateTestLog(ctx, bucket, 20, 5)
+		require.NoError(t, err)
+		return log, chunks
+	}
+	closed1, chunks1 := createLog(true)
+	closed2, chunks2 := createLog(true)
+	openLog, _ := createLog(false)
+
+	t.Run("NilEnv", func(t *testing.T) {
+		l := &Log{ID: closed1.ID}
+		assert.Error(t, l.MigrateChunkIndex(ctx))
+		migrated, err := MigrateLogChunkIndexes(ctx, nil, 10)
+		assert.Error(t, err)
+		assert.Nil(t, migrated)
+	})
+	t.Run("OpenLog", func(t *testing.T) {
+		l := &Log{ID: openLog.ID}
+		l.Setup(env)
+		assert.Error(t, l.MigrateChunkIndex(ctx))
+		require.NoError(t, l.Find(ctx))
+		assert.Equal(t, 1, l.Artifact.Version)
+	})
+	t.Run("PendingChunks", func(t *testing.T) {
+		_, err := db.Collection(buildloggerCollection).UpdateOne(ctx, bson.M{"_id": closed1.ID}, bson.M{
+			"$set": bson.M{"artifact.pending_chunks": []string{"pending"}},
+		})
+		require.NoError(t, err)
+		l := &Log{ID: closed1.ID}
+		l.Setup(env)
+		assert.Error(t, l.MigrateChunkIndex(ctx))
+
+		_, err = db.Collection(buildloggerCollection).UpdateOne(ctx, bson.M{"_id": closed1.ID}, bson.M{
+			"$set": bson.M{"artifact.pending_chunks": []string{}},
+		})
+		require.NoError(t, err)
+	})
+	t.Run("Batch", func(t *testing.T) {
+		migrated, err := MigrateLogChunkIndexes(ctx, env, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, migrated.Found)
+		require.Len(t, migrated.Migrated, 1)
+
+		migrated, err = MigrateLogChunkIndexes(ctx, env, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, migrated.Found)
+		require.Len(t, migrated.Migrated, 1)
+
+		migrated, err = MigrateLogChunkIndexes(ctx, env, 10)
+		require.NoError(t, err)
+		assert.Zero(t, migrated.Found)
+	})
+	t.Run("MigratedLogs", func(t *testing.T) {
+		for _, test := range []struct {
+			log    *Log
+			chunks []LogChunkInfo
+		}{
+			{log: closed1, chunks: chunks1},
+			{log: closed2, chunks: chunks2},
+		} {
+			l := &Log{ID: test.log.ID}
+			l.Setup(env)
+			require.NoError(t, l.Find(ctx))
+			assert.Equal(t, 2, l.Artifact.Version)
+			assert.Equal(t, test.chunks, l.Artifact.Chunks)
+
+			bucket, err := l.getBucket(ctx, false)
+			require.NoError(t, err)
+			chunks, err := l.getChunks(ctx, bucket)
+			require.NoError(t, err)
+			assert.Equal(t, test.chunks, chunks)
+		}
+	})
+}
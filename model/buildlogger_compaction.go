@@ -0,0 +1,377 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/evergreen-ci/pail"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// DefaultCompactedLogChunkLines is the default target number of lines
+	// of compacted buildlogger log chunks.
+	DefaultCompactedLogChunkLines = 10000
+	// ReplacedLogChunkGracePeriod is the minimum amount of time chunks
+	// replaced by compaction are kept in offline storage so that reads
+	// started before the swap can complete.
+	ReplacedLogChunkGracePeriod = time.Hour
+)
+
+// ignoredChunkKeys returns the keys of the chunks in offline storage that
+// readers must ignore because they are either pending or replaced by
+// compaction.
+func (a LogArtifactInfo) ignoredChunkKeys() map[string]bool {
+	ignore := map[string]bool{}
+	for _, key := range a.PendingChunks {
+		ignore[key] = true
+	}
+	for _, chunk := range a.ReplacedChunks {
+		ignore[chunk.Key] = true
+	}
+
+	return ignore
+}
+
+// replacedChunkKeys returns the keys of the chunks merged into the given
+// compacted chunk.
+func (a LogArtifactInfo) replacedChunkKeys(key string) []string {
+	var keys []string
+	for _, chunk := range a.ReplacedChunks {
+		if chunk.ReplacedBy == key {
+			keys = append(keys, chunk.Key)
+		}
+	}
+
+	return keys
+}
+
+// CompactChunks merges runs of adjacent chunks with fewer than targetLines
+// lines into chunks with at most targetLines lines and returns the number of
+// chunks replaced. Only closed, version 2 logs are compacted: version 1 logs
+// are read by listing offline storage, where readers may see compacted chunks
+// before they are swapped in, and must first be upgraded with
+// MigrateChunkIndex.
+//
+// Compacted chunks are uploaded while marked as pending and then swapped in
+// with a single update of the log's metadata, which replaces the merged
+// chunks in the log's chunk index and marks them as replaced. Readers only
+// see the chunks in the index, so they never see missing or duplicated lines.
+// Replaced chunks remain in offline storage until removed by
+// RemoveReplacedChunks. The environment should not be nil.
+func (l *Log) CompactChunks(ctx context.Context, targetLines int) (int, error) {
+	if l.env == nil {
+		return 0, errors.New("cannot compact chunks with a nil environment")
+	}
+	if targetLines <= 0 {
+		return 0, errors.New("target number of lines must be positive")
+	}
+	if err := l.Find(ctx); err != nil {
+		return 0, err
+	}
+	if l.Artifact.Version != 2 {
+		return 0, errors.Errorf("cannot compact chunks of artifact version %d", l.Artifact.Version)
+	}
+	if l.CompletedAt.IsZero() {
+		return 0, errors.Errorf("cannot compact chunks of open log '%s'", l.ID)
+	}
+
+	bucket, err := l.getBucket(ctx, true)
+	if err != nil {
+		return 0, err
+	}
+	if err = l.removePendingChunks(ctx, l.Artifact.PendingChunks); err != nil {
+		return 0, errors.Wrap(err, "removing pending chunks of a previous compaction")
+	}
+
+	chunks, err := l.getChunks(ctx, bucket)
+	if err != nil {
+		return 0, errors.Wrap(err, "getting chunks")
+	}
+	existing := map[string]bool{}
+	for _, chunk := range chunks {
+		existing[chunk.Key] = true
+	}
+
+	var (
+		groups      [][]LogChunkInfo
+		pendingKeys []string
+	)
+	for _, group := range groupChunksForCompaction(chunks, targetLines) {
+		key := compactedChunk(group).Key
+		if existing[key] {
+			continue
+		}
+		existing[key] = true
+		groups = append(groups, group)
+		pendingKeys = append(pendingKeys, key)
+	}
+	if len(groups) == 0 {
+		return 0, nil
+	}
+
+	updateResult, err := l.env.GetDB().Collection(buildloggerCollection).UpdateOne(
+		ctx,
+		bson.M{"_id": l.ID},
+		bson.M{"$push": bson.M{
+			bsonutil.GetDottedKeyName(logArtifactKey, logArtifactInfoPendingChunksKey): bson.M{"$each": pendingKeys},
+		}},
+	)
+	if err == nil && updateResult.MatchedCount == 0 {
+		err = errors.Errorf("could not find log record '%s'", l.ID)
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "marking compacted chunks of log '%s' as pending", l.ID)
+	}
+
+	var replaced []ReplacedLogChunk
+	for i, group := range groups {
+		if err = l.uploadCompactedChunk(ctx, bucket, pendingKeys[i], group); err != nil {
+			catcher := grip.NewBasicCatcher()
+			catcher.Wrapf(err, "uploading compacted chunk '%s'", pendingKeys[i])
+			catcher.Wrap(l.removePendingChunks(ctx, pendingKeys), "removing pending chunks")
+			return 0, catcher.Resolve()
+		}
+		for _, chunk := range group {
+			replaced = append(replaced, ReplacedLogChunk{
+				Key:        chunk.Key,
+				ReplacedBy: pendingKeys[i],
+			})
+		}
+	}
+
+	replacedAt := time.Now()
+	for i := range replaced {
+		replaced[i].ReplacedAt = replacedAt
+	}
+	index := compactedChunkIndex(chunks, groups)
+	update := bson.M{
+		"$set": bson.M{
+			bsonutil.GetDottedKeyName(logArtifactKey, logArtifactInfoChunksKey): index,
+		},
+		"$pull": bson.M{
+			bsonutil.GetDottedKeyName(logArtifactKey, logArtifactInfoPendingChunksKey): bson.M{"$in": pendingKeys},
+		},
+		"$push": bson.M{
+			bsonutil.GetDottedKeyName(logArtifactKey, logArtifactInfoReplacedChunksKey): bson.M{"$each": replaced},
+		},
+	}
+	updateResult, err = l.env.GetDB().Collection(buildloggerCollection).UpdateOne(
+		ctx,
+		bson.M{
+			"_id": l.ID,
+			bsonutil.GetDottedKeyName(logArtifactKey, logArtifactInfoVersionKey): 2,
+		},
+		update,
+	)
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection":   buildloggerCollection,
+		"id":           l.ID,
+		"compacted":    len(pendingKeys),
+		"replaced":     len(replaced),
+		"updateResult": updateResult,
+		"op":           "swap compacted buildlogger chunks",
+	})
+	if err == nil && updateResult.MatchedCount == 0 {
+		err = errors.Errorf("could not find log record '%s'", l.ID)
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "swapping compacted chunks of log '%s'", l.ID)
+	}
+
+	l.Artifact.PendingChunks = nil
+	l.Artifact.ReplacedChunks = append(l.Artifact.ReplacedChunks, replaced...)
+	l.Artifact.Chunks = index
+
+	return len(replaced), nil
+}
+
+// RemoveReplacedChunks removes the chunks replaced by compaction more than
+// the grace period ago from offline storage, and returns the number of
+// replaced chunks that remain. The environment should not be nil.
+func (l *Log) RemoveReplacedChunks(ctx context.Context, gracePeriod time.Duration) (int, error) {
+	if l.env == nil {
+		return 0, errors.New("cannot remove replaced chunks with a nil environment")
+	}
+	if err := l.Find(ctx); err != nil {
+		return 0, err
+	}
+
+	var (
+		expired   []string
+		remaining int
+	)
+	cutoff := time.Now().Add(-gracePeriod)
+	for _, chunk := range l.Artifact.ReplacedChunks {
+		if chunk.ReplacedAt.Before(cutoff) {
+			expired = append(expired, chunk.Key)
+		} else {
+			remaining++
+		}
+	}
+	if len(expired) == 0 {
+		return remaining, nil
+	}
+
+	bucket, err := l.getBucket(ctx, true)
+	if err != nil {
+		return 0, err
+	}
+	if err = bucket.RemoveMany(ctx, expired...); err != nil {
+		return 0, errors.Wrapf(err, "removing replaced chunks of log '%s'", l.ID)
+	}
+
+	updateResult, err := l.env.GetDB().Collection(buildloggerCollection).UpdateOne(
+		ctx,
+		bson.M{"_id": l.ID},
+		bson.M{"$pull": bson.M{
+			bsonutil.GetDottedKeyName(logArtifactKey, logArtifactInfoReplacedChunksKey): bson.M{
+				replacedLogChunkKeyKey: bson.M{"$in": expired},
+			},
+		}},
+	)
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection":   buildloggerCollection,
+		"id":           l.ID,
+		"removed":      len(expired),
+		"updateResult": updateResult,
+		"op":           "remove replaced buildlogger chunks",
+	})
+
+	return remaining, errors.Wrapf(err, "removing replaced chunks from log record '%s'", l.ID)
+}
+
+// removePendingChunks removes the given pending chunks from offline storage
+// and then from the log's metadata.
+func (l *Log) removePendingChunks(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	bucket, err := l.getBucket(ctx, true)
+	if err != nil {
+		return err
+	}
+	if err = bucket.RemoveMany(ctx, keys...); err != nil {
+		return errors.Wrap(err, "removing pending chunks from offline storage")
+	}
+
+	_, err = l.env.GetDB().Collection(buildloggerCollection).UpdateOne(
+		ctx,
+		bson.M{"_id": l.ID},
+		bson.M{"$pull": bson.M{
+			bsonutil.GetDottedKeyName(logArtifactKey, logArtifactInfoPendingChunksKey): bson.M{"$in": keys},
+		}},
+	)
+	if err != nil {
+		return errors.Wrapf(err, "removing pending chunks from log record '%s'", l.ID)
+	}
+	l.Artifact.PendingChunks = nil
+
+	return nil
+}
+
+func (l *Log) uploadCompactedChunk(ctx context.Context, bucket pail.Bucket, key string, group []LogChunkInfo) error {
+	buffer := &bytes.Buffer{}
+	for _, chunk := range group {
+		r, err := bucket.Get(ctx, chunk.Key)
+		if err != nil {
+			return errors.Wrapf(err, "getting chunk '%s'", chunk.Key)
+		}
+		_, err = io.Copy(buffer, r)
+		catcher := grip.NewBasicCatcher()
+		catcher.Wrapf(err, "reading chunk '%s'", chunk.Key)
+		catcher.Wrapf(r.Close(), "closing chunk '%s'", chunk.Key)
+		if catcher.HasErrors() {
+			return catcher.Resolve()
+		}
+	}
+
+	return errors.Wrap(bucket.Put(ctx, key, buffer), "uploading compacted chunk")
+}
+
+// groupChunksForCompaction returns the runs of at least two adjacent chunks,
+// each with fewer than targetLines lines, whose combined number of lines does
+// not exceed targetLines. The chunks must be sorted.
+func groupChunksForCompaction(chunks []LogChunkInfo, targetLines int) [][]LogChunkInfo {
+	var (
+		groups   [][]LogChunkInfo
+		current  []LogChunkInfo
+		numLines int
+	)
+	flush := func() {
+		if len(current) > 1 {
+			groups = append(groups, current)
+		}
+		current = nil
+		numLines = 0
+	}
+	for _, chunk := range chunks {
+		if chunk.NumLines >= targetLines {
+			flush()
+			continue
+		}
+		if numLines+chunk.NumLines > targetLines {
+			flush()
+		}
+		current = append(current, chunk)
+		numLines += chunk.NumLines
+	}
+	flush()
+
+	return groups
+}
+
+// compactedChunkIndex returns the sorted chunk index resulting from replacing
+// each group of chunks with its compacted chunk.
+func compactedChunkIndex(chunks []LogChunkInfo, groups [][]LogChunkInfo) []LogChunkInfo {
+	replaced := map[string]bool{}
+	index := []LogChunkInfo{}
+	for _, group := range groups {
+		for _, chunk := range group {
+			replaced[chunk.Key] = true
+		}
+		index = append(index, compactedChunk(group))
+	}
+	for _, chunk := range chunks {
+		if !replaced[chunk.Key] {
+			index = append(index, chunk)
+		}
+	}
+	sort.SliceStable(index, func(i, j int) bool {
+		return index[i].Start.Before(index[j].Start)
+	})
+
+	return index
+}
+
+// compactedChunk returns the information of the chunk compacted from the
+// given group of chunks.
+func compactedChunk(group []LogChunkInfo) LogChunkInfo {
+	start := group[0].Start
+	end := group[0].End
+	var numLines int
+	for _, chunk := range group {
+		if chunk.Start.Before(start) {
+			start = chunk.Start
+		}
+		if chunk.End.After(end) {
+			end = chunk.End
+		}
+		numLines += chunk.NumLines
+	}
+
+	return LogChunkInfo{
+		Key:      createBuildloggerChunkKey(start, end, numLines),
+		NumLines: numLines,
+		Start:    start,
+		End:      end,
+	}
+}
@@ -0,0 +1,277 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/grip/level"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestGroupChunksForCompaction(t *testing.T) {
+	ts := time.Now().Round(time.Millisecond).UTC()
+	chunk := func(i, numLines int) LogChunkInfo {
+		start := ts.Add(time.Duration(i) * time.Second)
+		end := start.Add(500 * time.Millisecond)
+		return LogChunkInfo{
+			Key:      createBuildloggerChunkKey(start, end, numLines),
+			NumLines: numLines,
+			Start:    start,
+			End:      end,
+		}
+	}
+	chunks := []LogChunkInfo{
+		chunk(0, 2),
+		chunk(1, 3),
+		chunk(2, 4),
+		chunk(3, 10),
+		chunk(4, 1),
+		chunk(5, 9),
+		chunk(6, 5),
+		chunk(7, 5),
+		chunk(8, 5),
+	}
+
+	for _, test := range []struct {
+		name        string
+		chunks      []LogChunkInfo
+		targetLines int
+		expected    [][]LogChunkInfo
+	}{
+		{
+			name:        "NoChunks",
+			targetLines: 10,
+		},
+		{
+			name:        "SingleChunk",
+			chunks:      chunks[:1],
+			targetLines: 10,
+		},
+		{
+			name:        "Runs",
+			chunks:      chunks,
+			targetLines: 10,
+			expected: [][]LogChunkInfo{
+				chunks[0:3],
+				chunks[4:6],
+				chunks[6:8],
+			},
+		},
+		{
+			name:        "AllLarge",
+			chunks:      chunks,
+			targetLines: 1,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, groupChunksForCompaction(test.chunks, test.targetLines))
+		})
+	}
+	t.Run("CompactedChunk", func(t *testing.T) {
+		group := []LogChunkInfo{chunks[0], chunks[1], {Start: ts.Add(500 * time.Millisecond), End: ts.Add(10 * time.Second), NumLines: 1}}
+		assert.Equal(t, LogChunkInfo{
+			Key:      createBuildloggerChunkKey(ts, ts.Add(10*time.Second), 6),
+			NumLines: 6,
+			Start:    ts,
+			End:      ts.Add(10 * time.Second),
+		}, compactedChunk(group))
+	})
+	t.Run("CompactedChunkIndex", func(t *testing.T) {
+		groups := groupChunksForCompaction(chunks, 10)
+		index := compactedChunkIndex(chunks, groups)
+		require.Len(t, index, 5)
+		assert.Equal(t, compactedChunk(chunks[0:3]), index[0])
+		assert.Equal(t, chunks[3], index[1])
+		assert.Equal(t, compactedChunk(chunks[4:6]), index[2])
+		assert.Equal(t, compactedChunk(chunks[6:8]), index[3])
+		assert.Equal(t, chunks[8], index[4])
+	})
+}
+
+func TestBuildloggerCompactChunks(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir, err := ioutil.TempDir(".", "compact-chunks-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+		assert.NoError(t, db.Collection(buildloggerCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(env.GetConfig().DbConfigurationCollection).Drop(ctx))
+	}()
+	conf := &CedarConfig{
+		populated: true,
+		Bucket:    BucketConfig{BuildLogsBucket: tmpDir},
+	}
+	conf.Setup(env)
+	require.NoError(t, conf.Save())
+
+	start := time.Now().Add(-time.Hour).Round(time.Millisecond).UTC()
+	var lines []LogLine
+	for i := 0; i < 10; i++ {
+		lines = append(lines, LogLine{
+			Priority:  level.Info,
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+			Data:      fmt.Sprintf("line %d", i),
+		})
+	}
+	log := CreateLog(LogInfo{Project: "project", TaskID: "compact"}, PailLocal)
+	log.Setup(env)
+	require.NoError(t, log.SaveNew(ctx))
+	for i := 0; i < len(lines); i += 2 {
+		require.NoError(t, log.Append(ctx, lines[i:i+2]))
+	}
+	timeRange := TimeRange{StartAt: start, EndAt: start.Add(time.Minute)}
+	download := func(t *testing.T) []LogLine {
+		l := &Log{ID: log.ID}
+		l.Setup(env)
+		require.NoError(t, l.Find(ctx))
+		it, err := l.Download(ctx, timeRange)
+		require.NoError(t, err)
+		var downloaded []LogLine
+		for it.Next(ctx) {
+			downloaded = append(downloaded, it.Item())
+		}
+		require.NoError(t, it.Err())
+		require.NoError(t, it.Close())
+		return downloaded
+	}
+	expected := download(t)
+	require.Len(t, expected, len(lines))
+
+	t.Run("NoEnv", func(t *testing.T) {
+		l := &Log{ID: log.ID}
+		replaced, err := l.CompactChunks(ctx, 5)
+		assert.Error(t, err)
+		assert.Zero(t, replaced)
+	})
+	t.Run("OpenLog", func(t *testing.T) {
+		l := &Log{ID: log.ID}
+		l.Setup(env)
+		replaced, err := l.CompactChunks(ctx, 5)
+		assert.Error(t, err)
+		assert.Zero(t, replaced)
+	})
+	require.NoError(t, log.Close(ctx, 0))
+	t.Run("IgnoresPendingChunks", func(t *testing.T) {
+		l := &Log{ID: log.ID}
+		l.Setup(env)
+		require.NoError(t, l.Find(ctx))
+		key := createBuildloggerChunkKey(start, start.Add(time.Minute), 100)
+		l.Artifact.Version = 1
+		l.Artifact.PendingChunks = []string{key}
+		require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, log.Artifact.Prefix, key), []byte("garbage"), 0644))
+
+		bucket, err := l.getBucket(ctx, false)
+		require.NoError(t, err)
+		chunks, err := l.getChunks(ctx, bucket)
+		require.NoError(t, err)
+		assert.Len(t, chunks, 5)
+		require.NoError(t, os.Remove(filepath.Join(tmpDir, log.Artifact.Prefix, key)))
+	})
+	t.Run("Version1Log", func(t *testing.T) {
+		versionKey := bsonutil.GetDottedKeyName(logArtifactKey, logArtifactInfoVersionKey)
+		_, err := db.Collection(buildloggerCollection).UpdateOne(ctx, bson.M{"_id": log.ID}, bson.M{"$set": bson.M{versionKey: 1}})
+		require.NoError(t, err)
+		defer func() {
+			_, err := db.Collection(buildloggerCollection).UpdateOne(ctx, bson.M{"_id": log.ID}, bson.M{"$set": bson.M{versionKey: 2}})
+			require.NoError(t, err)
+		}()
+
+		l := &Log{ID: log.ID}
+		l.Setup(env)
+		replaced, err := l.CompactChunks(ctx, 5)
+		assert.Error(t, err)
+		assert.Zero(t, replaced)
+		assert.Equal(t, expected, download(t))
+	})
+	t.Run("Compacts", func(t *testing.T) {
+		l := &Log{ID: log.ID}
+		l.Setup(env)
+		replaced, err := l.CompactChunks(ctx, 5)
+		require.NoError(t, err)
+		assert.Equal(t, 4, replaced)
+		assert.Empty(t, l.Artifact.PendingChunks)
+		require.Len(t, l.Artifact.ReplacedChunks, 4)
+
+		bucket, err := l.getBucket(ctx, false)
+		require.NoError(t, err)
+		chunks, err := l.getChunks(ctx, bucket)
+		require.NoError(t, err)
+		require.Len(t, chunks, 3)
+		assert.Equal(t, 4, chunks[0].NumLines)
+		assert.Equal(t, 4, chunks[1].NumLines)
+		assert.Equal(t, 2, chunks[2].NumLines)
+		assert.Equal(t, []string{l.Artifact.ReplacedChunks[0].Key, l.Artifact.ReplacedChunks[1].Key}, l.Artifact.replacedChunkKeys(chunks[0].Key))
+
+		assert.Equal(t, expected, download(t))
+	})
+	t.Run("Idempotent", func(t *testing.T) {
+		l := &Log{ID: log.ID}
+		l.Setup(env)
+		replaced, err := l.CompactChunks(ctx, 5)
+		require.NoError(t, err)
+		assert.Zero(t, replaced)
+		assert.Equal(t, expected, download(t))
+	})
+	t.Run("FollowAfterCompaction", func(t *testing.T) {
+		l := &Log{ID: log.ID}
+		l.Setup(env)
+		it, err := l.Follow(ctx, start, time.Millisecond)
+		require.NoError(t, err)
+		var followed []LogLine
+		for it.Next(ctx) {
+			followed = append(followed, it.Item())
+		}
+		require.NoError(t, it.Err())
+		require.NoError(t, it.Close())
+		assert.Equal(t, expected, followed)
+	})
+	t.Run("RemoveReplacedChunks", func(t *testing.T) {
+		l := &Log{ID: log.ID}
+		l.Setup(env)
+		remaining, err := l.RemoveReplacedChunks(ctx, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, 4, remaining)
+		files, err := ioutil.ReadDir(filepath.Join(tmpDir, log.Artifact.Prefix))
+		require.NoError(t, err)
+		assert.Len(t, files, 7)
+
+		remaining, err = l.RemoveReplacedChunks(ctx, 0)
+		require.NoError(t, err)
+		assert.Zero(t, remaining)
+		files, err = ioutil.ReadDir(filepath.Join(tmpDir, log.Artifact.Prefix))
+		require.NoError(t, err)
+		assert.Len(t, files, 3)
+		require.NoError(t, l.Find(ctx))
+		assert.Empty(t, l.Artifact.ReplacedChunks)
+
+		assert.Equal(t, expected, download(t))
+	})
+}
+
+func TestLogArtifactInfoCompactedChunks(t *testing.T) {
+	ts := time.Now().Round(time.Millisecond).UTC()
+	artifact := LogArtifactInfo{ReplacedChunks: []ReplacedLogChunk{
+		{Key: createBuildloggerChunkKey(ts, ts, 1), ReplacedBy: "compacted"},
+		{Key: createBuildloggerChunkKey(ts.Add(time.Second), ts.Add(time.Second), 1), ReplacedBy: "compacted"},
+		{Key: "other", ReplacedBy: "other-compacted"},
+	}}
+	assert.Equal(t, []string{artifact.ReplacedChunks[0].Key, artifact.ReplacedChunks[1].Key}, artifact.replacedChunkKeys("compacted"))
+	assert.Empty(t, artifact.replacedChunkKeys(artifact.ReplacedChunks[0].Key))
+	assert.Equal(t, map[string]bool{
+		artifact.ReplacedChunks[0].Key: true,
+		artifact.ReplacedChunks[1].Key: true,
+		"other":                        true,
+	}, artifact.ignoredChunkKeys())
+}
@@ -0,0 +1,430 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MaxLogDiffContextLines is the maximum number of unchanged lines
+	// that may be returned before and after each changed line of a diff.
+	MaxLogDiffContextLines = 50
+	// DefaultLogDiffWindow is the number of lines of each log buffered
+	// while looking for the next common line when no window is specified.
+	DefaultLogDiffWindow = 1000
+	// MaxLogDiffWindow is the maximum number of lines of each log that may
+	// be buffered while looking for the next common line.
+	MaxLogDiffWindow = 10000
+
+	logDiffTimestampMask  = "<timestamp>"
+	logDiffNormalizedMask = "<normalized>"
+)
+
+// logDiffTimestampPatterns match the timestamps masked before comparing log
+// lines.
+var logDiffTimestampPatterns = []*regexp.Regexp{
+	// Dates with an optional time of day, such as RFC 3339 timestamps
+	// and the timestamps printed with log lines.
+	regexp.MustCompile(`\d{4}[-/]\d{2}[-/]\d{2}(?:[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?)?`),
+	// Times of day without a date.
+	regexp.MustCompile(`\b\d{2}:\d{2}:\d{2}(?:[.,]\d+)?\b`),
+}
+
+// LogDiffOptions describes the options for diffing the lines of two
+// buildlogger logs.
+type LogDiffOptions struct {
+	// Normalizers are regular expressions, using the Go syntax, whose
+	// matches are masked before comparing lines, in addition to the
+	// timestamps which are always masked.
+	Normalizers []string
+	// ContextLines is the number of unchanged lines to return before and
+	// after each changed line.
+	ContextLines int
+	// Window is the number of lines of each log buffered while looking
+	// for the next common line after a change. Changes spanning more
+	// lines are returned as the removal and addition of the entire
+	// window. If equal to 0, DefaultLogDiffWindow is used.
+	Window int
+}
+
+// Validate ensures that the diff options are valid.
+func (o LogDiffOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+
+	catcher.ErrorfWhen(o.ContextLines < 0 || o.ContextLines > MaxLogDiffContextLines, "context lines must be between 0 and %d", MaxLogDiffContextLines)
+	catcher.ErrorfWhen(o.Window < 0 || o.Window > MaxLogDiffWindow, "window must be between 0 and %d", MaxLogDiffWindow)
+	for _, normalizer := range o.Normalizers {
+		_, err := regexp.Compile(normalizer)
+		catcher.Wrapf(err, "compiling normalizer regex '%s'", normalizer)
+	}
+
+	return catcher.Resolve()
+}
+
+func (o LogDiffOptions) normalizer() (func(string) string, error) {
+	var normalizers []*regexp.Regexp
+	for _, normalizer := range o.Normalizers {
+		re, err := regexp.Compile(normalizer)
+		if err != nil {
+			return nil, errors.Wrapf(err, "compiling normalizer regex '%s'", normalizer)
+		}
+		normalizers = append(normalizers, re)
+	}
+
+	return func(data string) string {
+		data = strings.TrimRight(data, "\n")
+		for _, re := range logDiffTimestampPatterns {
+			data = re.ReplaceAllLiteralString(data, logDiffTimestampMask)
+		}
+		for _, re := range normalizers {
+			data = re.ReplaceAllLiteralString(data, logDiffNormalizedMask)
+		}
+
+		return data
+	}, nil
+}
+
+// LogDiffOp describes whether a line of a diff is unchanged, removed from the
+// base log, or added to the compared log.
+type LogDiffOp string
+
+const (
+	LogDiffEqual   LogDiffOp = " "
+	LogDiffRemoved LogDiffOp = "-"
+	LogDiffAdded   LogDiffOp = "+"
+)
+
+// LogDiffLine describes a single line of a diff of two logs.
+type LogDiffLine struct {
+	Op LogDiffOp
+	// Line is the line of the base log for unchanged and removed lines,
+	// and the line of the compared log for added lines.
+	Line LogLine
+	// BaseLineNumber and LineNumber are the 1-based numbers of the line
+	// in the base and compared log, respectively. For lines missing from
+	// one of the logs, they are the number of the next line of that log.
+	BaseLineNumber int
+	LineNumber     int
+	// HunkStart is true for the first line of each group of consecutive
+	// lines returned.
+	HunkStart bool
+}
+
+// NewLogDiffReader returns an io.Reader that reads the line-level diff of
+// the base and compared log iterators, in a unified diff-like format:
+//
+//	@@ -12 +14 @@
+//	 This line is in both logs.
+//	-This line is only in the base log.
+//	+This line is only in the compared log.
+//
+// Timestamps and the matches of the normalizers are masked before comparing
+// lines, but the original lines are read. Lines are diffed as they are
+// iterated, buffering at most the diff window of each log. Both iterators are
+// closed once the reader is exhausted.
+func NewLogDiffReader(ctx context.Context, base, compare LogIterator, opts LogDiffOptions) (io.Reader, error) {
+	it, err := newLogDiffIterator(base, compare, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logDiffReader{ctx: ctx, it: it}, nil
+}
+
+// logDiffIterator iterates over the diff of two log iterators. Lines of both
+// logs are compared until they differ, at which point up to the window of
+// lines of each log is buffered to find the closest pair of common lines,
+// which resynchronizes the logs.
+type logDiffIterator struct {
+	base         *logDiffSide
+	compare      *logDiffSide
+	window       int
+	contextLines int
+
+	before      []LogDiffLine
+	after       int
+	queue       []LogDiffLine
+	rawCount    int
+	lastQueued  int
+	currentItem LogDiffLine
+	exhausted   bool
+	catcher     grip.Catcher
+}
+
+func newLogDiffIterator(base, compare LogIterator, opts LogDiffOptions) (*logDiffIterator, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid diff options")
+	}
+	normalize, err := opts.normalizer()
+	if err != nil {
+		return nil, err
+	}
+
+	window := opts.Window
+	if window == 0 {
+		window = DefaultLogDiffWindow
+	}
+
+	return &logDiffIterator{
+		base:         &logDiffSide{it: base, normalize: normalize},
+		compare:      &logDiffSide{it: compare, normalize: normalize},
+		window:       window,
+		contextLines: opts.ContextLines,
+		catcher:      grip.NewBasicCatcher(),
+	}, nil
+}
+
+func (i *logDiffIterator) Next(ctx context.Context) bool {
+	for len(i.queue) == 0 {
+		if i.exhausted || i.catcher.HasErrors() {
+			return false
+		}
+		if err := ctx.Err(); err != nil {
+			i.catcher.Add(err)
+			return false
+		}
+
+		i.step(ctx)
+	}
+
+	i.currentItem = i.queue[0]
+	i.queue = i.queue[1:]
+
+	return true
+}
+
+// step diffs the next lines of the logs.
+func (i *logDiffIterator) step(ctx context.Context) {
+	i.fill(ctx, 1)
+	if i.catcher.HasErrors() {
+		return
+	}
+
+	a, b := i.base.buffer, i.compare.buffer
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		i.exhausted = true
+	case len(b) == 0:
+		i.emitRemoved(1)
+	case len(a) == 0:
+		i.emitAdded(1)
+	case a[0].key == b[0].key:
+		i.emit(LogDiffEqual, i.base.pop(), i.compare.pop())
+	default:
+		i.fill(ctx, i.window)
+		if i.catcher.HasErrors() {
+			return
+		}
+
+		removed, added, ok := i.resync()
+		if !ok {
+			removed, added = len(i.base.buffer), len(i.compare.buffer)
+		}
+		i.emitRemoved(removed)
+		i.emitAdded(added)
+	}
+}
+
+func (i *logDiffIterator) fill(ctx context.Context, n int) {
+	i.catcher.Add(i.base.fill(ctx, n))
+	i.catcher.Add(i.compare.fill(ctx, n))
+}
+
+// resync returns the number of buffered lines of the base and compared logs
+// preceding their closest pair of common lines, if any.
+func (i *logDiffIterator) resync() (int, int, bool) {
+	first := map[string]int{}
+	for j, entry := range i.compare.buffer {
+		if _, ok := first[entry.key]; !ok {
+			first[entry.key] = j
+		}
+	}
+
+	bestA, bestB := -1, -1
+	for a, entry := range i.base.buffer {
+		if bestA >= 0 && a >= bestA+bestB {
+			break
+		}
+		if b, ok := first[entry.key]; ok && (bestA < 0 || a+b < bestA+bestB) {
+			bestA, bestB = a, b
+		}
+	}
+
+	return bestA, bestB, bestA >= 0
+}
+
+func (i *logDiffIterator) emitRemoved(n int) {
+	for j := 0; j < n; j++ {
+		i.emit(LogDiffRemoved, i.base.pop(), logDiffEntry{number: i.compare.count - len(i.compare.buffer) + 1})
+	}
+}
+
+func (i *logDiffIterator) emitAdded(n int) {
+	for j := 0; j < n; j++ {
+		i.emit(LogDiffAdded, logDiffEntry{number: i.base.count - len(i.base.buffer) + 1}, i.compare.pop())
+	}
+}
+
+// emit queues the given line of the diff if it is a change or within the
+// context lines of a change.
+func (i *logDiffIterator) emit(op LogDiffOp, a, b logDiffEntry) {
+	line := LogDiffLine{
+		Op:             op,
+		Line:           a.line,
+		BaseLineNumber: a.number,
+		LineNumber:     b.number,
+	}
+	if op == LogDiffAdded {
+		line.Line = b.line
+	}
+	i.rawCount++
+
+	if op == LogDiffEqual {
+		if i.after > 0 {
+			i.after--
+			i.queueLine(line, i.rawCount)
+			return
+		}
+		if i.contextLines > 0 {
+			i.before = append(i.before, line)
+			if len(i.before) > i.contextLines {
+				i.before = i.before[1:]
+			}
+		}
+		return
+	}
+
+	for j, before := range i.before {
+		i.queueLine(before, i.rawCount-len(i.before)+j)
+	}
+	i.before = nil
+	i.queueLine(line, i.rawCount)
+	i.after = i.contextLines
+}
+
+func (i *logDiffIterator) queueLine(line LogDiffLine, rawNumber int) {
+	line.HunkStart = i.lastQueued == 0 || rawNumber != i.lastQueued+1
+	i.lastQueued = rawNumber
+	i.queue = append(i.queue, line)
+}
+
+func (i *logDiffIterator) Exhausted() bool { return i.exhausted && len(i.queue) == 0 }
+
+func (i *logDiffIterator) Err() error { return i.catcher.Resolve() }
+
+func (i *logDiffIterator) Item() LogDiffLine { return i.currentItem }
+
+func (i *logDiffIterator) Close() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.Add(i.base.it.Close())
+	catcher.Add(i.compare.it.Close())
+
+	return catcher.Resolve()
+}
+
+type logDiffEntry struct {
+	line   LogLine
+	number int
+	key    string
+}
+
+// logDiffSide buffers the lines of one of the diffed logs.
+type logDiffSide struct {
+	it        LogIterator
+	normalize func(string) string
+	buffer    []logDiffEntry
+	count     int
+	done      bool
+}
+
+// fill buffers lines until n lines are buffered or the log is exhausted.
+func (s *logDiffSide) fill(ctx context.Context, n int) error {
+	for !s.done && len(s.buffer) < n {
+		if !s.it.Next(ctx) {
+			s.done = true
+			return s.it.Err()
+		}
+
+		s.count++
+		item := s.it.Item()
+		s.buffer = append(s.buffer, logDiffEntry{
+			line:   item,
+			number: s.count,
+			key:    s.normalize(item.Data),
+		})
+	}
+
+	return nil
+}
+
+func (s *logDiffSide) pop() logDiffEntry {
+	entry := s.buffer[0]
+	s.buffer = s.buffer[1:]
+
+	return entry
+}
+
+type logDiffReader struct {
+	ctx      context.Context
+	it       *logDiffIterator
+	leftOver []byte
+}
+
+func (r *logDiffReader) Read(p []byte) (int, error) {
+	n := 0
+
+	if r.leftOver != nil {
+		data := r.leftOver
+		r.leftOver = nil
+		n = r.writeToBuffer(data, p, n)
+		if n == len(p) {
+			return n, nil
+		}
+	}
+
+	for r.it.Next(r.ctx) {
+		n = r.writeToBuffer([]byte(formatLogDiffLine(r.it.Item())), p, n)
+		if n == len(p) {
+			return n, nil
+		}
+	}
+
+	catcher := grip.NewBasicCatcher()
+	catcher.Add(r.it.Err())
+	catcher.Add(r.it.Close())
+	if catcher.HasErrors() {
+		return n, catcher.Resolve()
+	}
+
+	return n, io.EOF
+}
+
+func (r *logDiffReader) writeToBuffer(data, buffer []byte, n int) int {
+	if len(buffer) == 0 {
+		return 0
+	}
+
+	m := len(data)
+	if n+m > len(buffer) {
+		m = len(buffer) - n
+		r.leftOver = data[m:]
+	}
+	_ = copy(buffer[n:n+m], data[:m])
+
+	return n + m
+}
+
+func formatLogDiffLine(line LogDiffLine) string {
+	data := string(line.Op) + strings.TrimRight(line.Line.Data, "\n") + "\n"
+	if line.HunkStart {
+		data = fmt.Sprintf("@@ -%d +%d @@\n", line.BaseLineNumber, line.LineNumber) + data
+	}
+
+	return data
+}
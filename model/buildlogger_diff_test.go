@@ -0,0 +1,239 @@
+package model
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/evergreen-ci/pail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogDiffOptionsValidate(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		opts   LogDiffOptions
+		hasErr bool
+	}{
+		{
+			name: "Empty",
+		},
+		{
+			name: "Valid",
+			opts: LogDiffOptions{Normalizers: []string{`pid \d+`}, ContextLines: 3, Window: 100},
+		},
+		{
+			name:   "InvalidNormalizer",
+			opts:   LogDiffOptions{Normalizers: []string{`pid (`}},
+			hasErr: true,
+		},
+		{
+			name:   "NegativeContextLines",
+			opts:   LogDiffOptions{ContextLines: -1},
+			hasErr: true,
+		},
+		{
+			name:   "TooManyContextLines",
+			opts:   LogDiffOptions{ContextLines: MaxLogDiffContextLines + 1},
+			hasErr: true,
+		},
+		{
+			name:   "NegativeWindow",
+			opts:   LogDiffOptions{Window: -1},
+			hasErr: true,
+		},
+		{
+			name:   "TooLargeWindow",
+			opts:   LogDiffOptions{Window: MaxLogDiffWindow + 1},
+			hasErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.opts.Validate()
+			if test.hasErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLogDiffNormalizer(t *testing.T) {
+	normalize, err := LogDiffOptions{Normalizers: []string{`pid \d+`}}.normalizer()
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{
+			name:     "RFC3339",
+			data:     "started at 2021-01-02T15:04:05.123Z\n",
+			expected: "started at <timestamp>",
+		},
+		{
+			name:     "Offset",
+			data:     "started at 2021-01-02T15:04:05+05:00",
+			expected: "started at <timestamp>",
+		},
+		{
+			name:     "PrintedTimestamp",
+			data:     "[2021/01/02 15:04:05.000] started",
+			expected: "[<timestamp>] started",
+		},
+		{
+			name:     "TimeOfDay",
+			data:     "15:04:05,123 started",
+			expected: "<timestamp> started",
+		},
+		{
+			name:     "Normalizer",
+			data:     "started pid 1234",
+			expected: "started <normalized>",
+		},
+		{
+			name:     "Unchanged",
+			data:     "nothing to mask",
+			expected: "nothing to mask",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, normalize(test.data))
+		})
+	}
+}
+
+func TestNewLogDiffReader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir, err := ioutil.TempDir("", "diff-log-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	var n int
+	putLog := func(t *testing.T, data []string) LogIterator {
+		n++
+		path := filepath.Join(tmpDir, strconv.Itoa(n))
+		require.NoError(t, os.Mkdir(path, 0777))
+		bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: path})
+		require.NoError(t, err)
+		chunks, _ := putSearchTestLog(ctx, t, bucket, data)
+
+		return NewBatchedLogIterator(bucket, chunks, 2, TimeRange{StartAt: chunks[0].Start, EndAt: chunks[len(chunks)-1].End})
+	}
+
+	base := []string{
+		"starting test at 2021-01-02T15:04:05Z",
+		"pid 1234",
+		"setup",
+		"step 1",
+		"step 2",
+		"step 3",
+		"teardown",
+		"done",
+	}
+	for _, test := range []struct {
+		name     string
+		base     []string
+		compare  []string
+		opts     LogDiffOptions
+		expected string
+	}{
+		{
+			name:    "Identical",
+			base:    base,
+			compare: base,
+		},
+		{
+			name:    "MaskedTimestamps",
+			base:    base,
+			compare: append([]string{"starting test at 2022-11-12T01:02:03Z"}, base[1:]...),
+		},
+		{
+			name:     "Changed",
+			base:     base,
+			compare:  []string{base[0], base[1], base[2], base[3], "step two", base[5], base[6], base[7]},
+			opts:     LogDiffOptions{ContextLines: 1},
+			expected: "@@ -4 +4 @@\n step 1\n-step 2\n+step two\n step 3\n",
+		},
+		{
+			name:     "InsertedAndRemoved",
+			base:     base,
+			compare:  []string{base[0], base[1], "retrying setup", base[2], base[3], base[4], base[6], base[7]},
+			expected: "@@ -3 +3 @@\n+retrying setup\n@@ -6 +7 @@\n-step 3\n",
+		},
+		{
+			name:     "OverlappingContext",
+			base:     base,
+			compare:  []string{base[0], base[1], base[2], "step one", base[4], "step three", base[6], base[7]},
+			opts:     LogDiffOptions{ContextLines: 1},
+			expected: "@@ -3 +3 @@\n setup\n-step 1\n+step one\n step 2\n-step 3\n+step three\n teardown\n",
+		},
+		{
+			name:     "Normalizers",
+			base:     base,
+			compare:  []string{base[0], "pid 5678", base[2], base[3], base[4], base[5], base[6], "done!"},
+			opts:     LogDiffOptions{Normalizers: []string{`\d{4}`}},
+			expected: "@@ -8 +8 @@\n-done\n+done!\n",
+		},
+		{
+			name:     "Appended",
+			base:     base,
+			compare:  append(append([]string{}, base...), "extra"),
+			opts:     LogDiffOptions{ContextLines: 2},
+			expected: "@@ -7 +7 @@\n teardown\n done\n+extra\n",
+		},
+		{
+			name:     "OutsideWindow",
+			base:     []string{"a", "b", "c", "d"},
+			compare:  []string{"x", "y", "c", "d"},
+			opts:     LogDiffOptions{Window: 2},
+			expected: "@@ -1 +1 @@\n-a\n-b\n+x\n+y\n",
+		},
+		{
+			name:     "Resync",
+			base:     []string{"a", "b", "c", "d"},
+			compare:  []string{"x", "y", "c", "d"},
+			opts:     LogDiffOptions{Window: 3},
+			expected: "@@ -1 +1 @@\n-a\n-b\n+x\n+y\n",
+		},
+		{
+			name:     "NoResync",
+			base:     []string{"a", "b", "c"},
+			compare:  []string{"x", "c", "a"},
+			opts:     LogDiffOptions{Window: 1},
+			expected: "@@ -1 +1 @@\n-a\n+x\n-b\n+c\n-c\n+a\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := NewLogDiffReader(ctx, putLog(t, test.base), putLog(t, test.compare), test.opts)
+			require.NoError(t, err)
+
+			data, err := ioutil.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, string(data))
+		})
+	}
+	t.Run("InvalidOptions", func(t *testing.T) {
+		r, err := NewLogDiffReader(ctx, putLog(t, base), putLog(t, base), LogDiffOptions{Normalizers: []string{"("}})
+		assert.Error(t, err)
+		assert.Nil(t, r)
+	})
+	t.Run("ContextError", func(t *testing.T) {
+		errCtx, errCancel := context.WithCancel(context.Background())
+		errCancel()
+
+		r, err := NewLogDiffReader(errCtx, putLog(t, base), putLog(t, base), LogDiffOptions{})
+		require.NoError(t, err)
+		_, err = ioutil.ReadAll(r)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,375 @@
+package model
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// MaxLogFailureSignatures is the maximum number of distinct failure
+	// signatures stored with a log. Once reached, only the occurrences of
+	// the signatures already found are counted.
+	MaxLogFailureSignatures = 20
+
+	// maxLogFailureSignatureLength is the maximum length of a signature
+	// and of the sample line stored with it.
+	maxLogFailureSignatureLength = 256
+	// maxLogFailureLookahead is the maximum number of lines following the
+	// start of a panic or traceback searched for the rest of its
+	// signature.
+	maxLogFailureLookahead = 50
+)
+
+// LogFailureKind describes the kind of error line a failure signature was
+// extracted from.
+type LogFailureKind string
+
+const (
+	LogFailurePanic       LogFailureKind = "panic"
+	LogFailureException   LogFailureKind = "exception"
+	LogFailureTestFailure LogFailureKind = "test_failure"
+	LogFailureAssertion   LogFailureKind = "assertion"
+)
+
+// LogFailureSignature describes an error line of a failed log, normalized so
+// that the same failure yields the same signature across logs.
+type LogFailureSignature struct {
+	// ID is a hash of the kind and signature, used to find the logs
+	// sharing the signature.
+	ID        string         `bson:"id"`
+	Kind      LogFailureKind `bson:"kind"`
+	Signature string         `bson:"signature"`
+	// Line and LineNumber are the first line matching the signature,
+	// before normalization, and its 1-based number in the log.
+	Line       string `bson:"line"`
+	LineNumber int    `bson:"line_number"`
+	// Count is the number of lines of the log matching the signature.
+	Count int `bson:"count"`
+}
+
+var (
+	logFailureSignatureIDKey = bsonutil.MustHaveTag(LogFailureSignature{}, "ID")
+)
+
+var (
+	goPanicPattern      = regexp.MustCompile(`^(?:panic|fatal error): (.*)$`)
+	goStackFramePattern = regexp.MustCompile(`^(\S+)\([^()]*\)$`)
+	tracebackPattern    = regexp.MustCompile(`^Traceback \(most recent call last\):$`)
+	pythonErrorPattern  = regexp.MustCompile(`^([\w.]+(?:Error|Exception|Exit|Interrupt)\b:?.*)$`)
+	javaErrorPattern    = regexp.MustCompile(`^Exception in thread "[^"]*" (\S+(?::.*)?)$`)
+	goTestFailPattern   = regexp.MustCompile(`^\s*--- FAIL: (\S+)`)
+	goPkgFailPattern    = regexp.MustCompile(`^FAIL\s+(\S+)`)
+	pytestFailPattern   = regexp.MustCompile(`^FAILED (\S+)`)
+	assertionPattern    = regexp.MustCompile(`(?i)\bassert(?:ion)?s?\b.*\bfail(?:s|ed|ure)?\b.*`)
+	testifyTracePattern = regexp.MustCompile(`^\s*Error Trace:`)
+	testifyErrorPattern = regexp.MustCompile(`^\s*Error:\s+(.*)$`)
+
+	// goStackFrameSkipPrefixes are the prefixes of the functions skipped
+	// when looking for the frame of a panic within the code under test.
+	goStackFrameSkipPrefixes = []string{"panic", "runtime.", "testing.", "reflect.", "sync."}
+
+	// logFailureNormalizers mask the parts of failure signatures that vary
+	// between occurrences of the same failure, in order.
+	logFailureNormalizers = []struct {
+		re   *regexp.Regexp
+		mask string
+	}{
+		{re: regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), mask: "<uuid>"},
+		{re: regexp.MustCompile(`0x[0-9a-fA-F]+|\b[0-9a-fA-F]{12,}\b`), mask: "<hex>"},
+		{re: regexp.MustCompile(`\d+`), mask: "<n>"},
+		{re: regexp.MustCompile(`\s+`), mask: " "},
+	}
+)
+
+// ExtractLogFailureSignatures returns the failure signatures of the lines of
+// the given iterator: Go panics and the first frame of their stack trace
+// outside of the runtime, the exceptions ending Python tracebacks and Java
+// stack traces, test failures, and assertion failures. Signatures, other than
+// the names of failed tests, are normalized by masking timestamps, numbers,
+// hexadecimal values, and UUIDs.
+// The iterator is closed once the extraction completes.
+func ExtractLogFailureSignatures(ctx context.Context, it LogIterator) ([]LogFailureSignature, error) {
+	e := &failureSignatureExtractor{index: map[string]int{}}
+	for it.Next(ctx) {
+		e.add(strings.TrimRight(it.Item().Data, "\n"))
+	}
+	e.flushPanic()
+
+	catcher := grip.NewBasicCatcher()
+	catcher.Add(it.Err())
+	catcher.Add(it.Close())
+	if catcher.HasErrors() {
+		return nil, catcher.Resolve()
+	}
+
+	return e.signatures, nil
+}
+
+type failureSignatureExtractor struct {
+	signatures []LogFailureSignature
+	index      map[string]int
+	lineNumber int
+
+	panicMessage    string
+	panicLine       string
+	panicLineNumber int
+	panicRemaining  int
+
+	tracebackRemaining    int
+	testifyTraceRemaining int
+}
+
+func (e *failureSignatureExtractor) add(line string) {
+	e.lineNumber++
+
+	if e.panicRemaining > 0 {
+		e.panicRemaining--
+		if match := goStackFramePattern.FindStringSubmatch(line); match != nil && !skipGoStackFrame(match[1]) {
+			e.panicMessage = fmt.Sprintf("%s @ %s", e.panicMessage, match[1])
+			e.flushPanic()
+		} else if e.panicRemaining == 0 {
+			e.flushPanic()
+		}
+		return
+	}
+	if match := goPanicPattern.FindStringSubmatch(line); match != nil {
+		e.panicMessage = "panic: " + match[1]
+		e.panicLine = line
+		e.panicLineNumber = e.lineNumber
+		e.panicRemaining = maxLogFailureLookahead
+		return
+	}
+
+	if e.tracebackRemaining > 0 {
+		e.tracebackRemaining--
+		if match := pythonErrorPattern.FindStringSubmatch(line); match != nil {
+			e.record(LogFailureException, match[1], line, e.lineNumber)
+			e.tracebackRemaining = 0
+		}
+		return
+	}
+	if tracebackPattern.MatchString(line) {
+		e.tracebackRemaining = maxLogFailureLookahead
+		return
+	}
+	if match := javaErrorPattern.FindStringSubmatch(line); match != nil {
+		e.record(LogFailureException, match[1], line, e.lineNumber)
+		return
+	}
+
+	if e.testifyTraceRemaining > 0 {
+		e.testifyTraceRemaining--
+		if match := testifyErrorPattern.FindStringSubmatch(line); match != nil {
+			e.record(LogFailureAssertion, "Error: "+match[1], line, e.lineNumber)
+			e.testifyTraceRemaining = 0
+			return
+		}
+	}
+	if testifyTracePattern.MatchString(line) {
+		e.testifyTraceRemaining = maxLogFailureLookahead
+		return
+	}
+
+	for _, re := range []*regexp.Regexp{goTestFailPattern, goPkgFailPattern, pytestFailPattern} {
+		if match := re.FindStringSubmatch(line); match != nil {
+			e.record(LogFailureTestFailure, "FAIL "+match[1], line, e.lineNumber)
+			return
+		}
+	}
+	if match := assertionPattern.FindString(line); match != "" {
+		e.record(LogFailureAssertion, match, line, e.lineNumber)
+	}
+}
+
+// flushPanic records the pending panic, if any.
+func (e *failureSignatureExtractor) flushPanic() {
+	if e.panicMessage == "" {
+		return
+	}
+
+	e.record(LogFailurePanic, e.panicMessage, e.panicLine, e.panicLineNumber)
+	e.panicMessage = ""
+	e.panicRemaining = 0
+}
+
+func (e *failureSignatureExtractor) record(kind LogFailureKind, signature, line string, lineNumber int) {
+	// Test names identify test failures, so they are not normalized.
+	if kind == LogFailureTestFailure {
+		signature = truncateLogFailureSignature(signature)
+	} else {
+		signature = normalizeLogFailureSignature(signature)
+	}
+	id := logFailureSignatureID(kind, signature)
+	if idx, ok := e.index[id]; ok {
+		e.signatures[idx].Count++
+		return
+	}
+	if len(e.signatures) >= MaxLogFailureSignatures {
+		return
+	}
+
+	e.index[id] = len(e.signatures)
+	e.signatures = append(e.signatures, LogFailureSignature{
+		ID:         id,
+		Kind:       kind,
+		Signature:  signature,
+		Line:       truncateLogFailureSignature(line),
+		LineNumber: lineNumber,
+		Count:      1,
+	})
+}
+
+func skipGoStackFrame(function string) bool {
+	for _, prefix := range goStackFrameSkipPrefixes {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalizeLogFailureSignature(signature string) string {
+	for _, re := range logDiffTimestampPatterns {
+		signature = re.ReplaceAllLiteralString(signature, logDiffTimestampMask)
+	}
+	for _, normalizer := range logFailureNormalizers {
+		signature = normalizer.re.ReplaceAllLiteralString(signature, normalizer.mask)
+	}
+
+	return truncateLogFailureSignature(strings.TrimSpace(signature))
+}
+
+func truncateLogFailureSignature(data string) string {
+	if len(data) <= maxLogFailureSignatureLength {
+		return data
+	}
+
+	return data[:maxLogFailureSignatureLength]
+}
+
+func logFailureSignatureID(kind LogFailureKind, signature string) string {
+	hash := sha1.New()
+	_, _ = io.WriteString(hash, string(kind))
+	_, _ = io.WriteString(hash, signature)
+
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// ExtractFailureSignatures extracts the failure signatures of all of the lines
+// of the log and stores them with the log, replacing any previously extracted
+// signatures. The environment should not be nil.
+func (l *Log) ExtractFailureSignatures(ctx context.Context) error {
+	if l.env == nil {
+		return errors.New("cannot extract failure signatures with a nil environment")
+	}
+
+	it, err := l.Download(ctx, TimeRange{EndAt: utility.MaxTime})
+	if err != nil {
+		return errors.Wrapf(err, "downloading log '%s'", l.ID)
+	}
+	signatures, err := ExtractLogFailureSignatures(ctx, it)
+	if err != nil {
+		return errors.Wrapf(err, "extracting failure signatures of log '%s'", l.ID)
+	}
+	if signatures == nil {
+		signatures = []LogFailureSignature{}
+	}
+
+	updateResult, err := l.env.GetDB().Collection(buildloggerCollection).UpdateOne(
+		ctx,
+		bson.M{"_id": l.ID},
+		bson.M{"$set": bson.M{logFailureSignaturesKey: signatures}},
+	)
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection":   buildloggerCollection,
+		"id":           l.ID,
+		"signatures":   len(signatures),
+		"updateResult": updateResult,
+		"op":           "set buildlogger log failure signatures",
+	})
+	if err == nil && updateResult.MatchedCount == 0 {
+		err = errors.Errorf("could not find log record '%s'", l.ID)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "setting failure signatures of log '%s'", l.ID)
+	}
+	l.FailureSignatures = signatures
+
+	return nil
+}
+
+// LogFailureSignatureFindOptions describes the options for finding the logs
+// with a failure signature.
+type LogFailureSignatureFindOptions struct {
+	Project     string
+	SignatureID string
+	// Since is the earliest creation time of the logs returned.
+	Since time.Time
+	// ExcludeTaskID, when set, excludes the logs of the given task.
+	ExcludeTaskID string
+	Limit         int64
+}
+
+// Validate ensures that the find options are valid.
+func (o LogFailureSignatureFindOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+
+	catcher.NewWhen(o.Project == "", "must specify a project")
+	catcher.NewWhen(o.SignatureID == "", "must specify a failure signature ID")
+	catcher.NewWhen(o.Limit < 0, "limit cannot be negative")
+
+	return catcher.Resolve()
+}
+
+// FindLogsByFailureSignature returns the logs of the given project with the
+// given failure signature, sorted by descending creation time. The artifact
+// of the logs returned is not populated.
+func FindLogsByFailureSignature(ctx context.Context, env cedar.Environment, opts LogFailureSignatureFindOptions) ([]Log, error) {
+	if env == nil {
+		return nil, errors.New("cannot find logs with a nil environment")
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid find options")
+	}
+
+	search := bson.M{
+		bsonutil.GetDottedKeyName(logInfoKey, logInfoProjectKey):                     opts.Project,
+		bsonutil.GetDottedKeyName(logFailureSignaturesKey, logFailureSignatureIDKey): opts.SignatureID,
+		logCreatedAtKey: bson.M{"$gte": opts.Since},
+	}
+	if opts.ExcludeTaskID != "" {
+		search[bsonutil.GetDottedKeyName(logInfoKey, logInfoTaskIDKey)] = bson.M{"$ne": opts.ExcludeTaskID}
+	}
+	findOpts := options.Find().
+		SetSort(bson.D{{Key: logCreatedAtKey, Value: -1}}).
+		SetProjection(bson.M{logArtifactKey: 0})
+	if opts.Limit > 0 {
+		findOpts.SetLimit(opts.Limit)
+	}
+
+	cur, err := env.GetDB().Collection(buildloggerCollection).Find(ctx, search, findOpts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding logs with failure signature '%s'", opts.SignatureID)
+	}
+	logs := []Log{}
+	if err = cur.All(ctx, &logs); err != nil {
+		return nil, errors.Wrapf(err, "decoding logs with failure signature '%s'", opts.SignatureID)
+	}
+
+	return logs, nil
+}
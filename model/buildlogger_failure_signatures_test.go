@@ -0,0 +1,329 @@
+package model
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/pail"
+	"github.com/mongodb/grip/level"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractLogFailureSignatures(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir, err := ioutil.TempDir("", "failure-signatures-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	for _, test := range []struct {
+		name     string
+		lines    []string
+		expected []LogFailureSignature
+	}{
+		{
+			name:  "NoFailures",
+			lines: []string{"starting", "PASS", "ok  \tgithub.com/evergreen-ci/cedar\t0.01s"},
+		},
+		{
+			name: "GoPanic",
+			lines: []string{
+				"starting",
+				"panic: runtime error: index out of range [5] with length 3",
+				"",
+				"goroutine 1 [running]:",
+				"runtime.gopanic(0xc000010000)",
+				"\t/usr/local/go/src/runtime/panic.go:1038 +0x215",
+				"github.com/evergreen-ci/cedar.(*Log).Append(0xc00001a0c0, {0x0, 0x0})",
+				"\t/cedar/buildlogger.go:123 +0x1d",
+				"main.main()",
+			},
+			expected: []LogFailureSignature{
+				{
+					Kind:       LogFailurePanic,
+					Signature:  "panic: runtime error: index out of range [<n>] with length <n> @ github.com/evergreen-ci/cedar.(*Log).Append",
+					Line:       "panic: runtime error: index out of range [5] with length 3",
+					LineNumber: 2,
+					Count:      1,
+				},
+			},
+		},
+		{
+			name: "GoPanicWithoutStackTrace",
+			lines: []string{
+				"fatal error: concurrent map writes",
+				"done",
+			},
+			expected: []LogFailureSignature{
+				{
+					Kind:       LogFailurePanic,
+					Signature:  "panic: concurrent map writes",
+					Line:       "fatal error: concurrent map writes",
+					LineNumber: 1,
+					Count:      1,
+				},
+			},
+		},
+		{
+			name: "PythonTraceback",
+			lines: []string{
+				"Traceback (most recent call last):",
+				`  File "test.py", line 10, in <module>`,
+				"    main()",
+				"ConnectionError: could not connect to 10.1.2.3:27017 after 0x1f retries",
+			},
+			expected: []LogFailureSignature{
+				{
+					Kind:       LogFailureException,
+					Signature:  "ConnectionError: could not connect to <n>.<n>.<n>.<n>:<n> after <hex> retries",
+					Line:       "ConnectionError: could not connect to 10.1.2.3:27017 after 0x1f retries",
+					LineNumber: 4,
+					Count:      1,
+				},
+			},
+		},
+		{
+			name: "JavaException",
+			lines: []string{
+				`Exception in thread "main" java.lang.IllegalStateException: request 123e4567-e89b-12d3-a456-426614174000 failed`,
+				"\tat Main.main(Main.java:5)",
+			},
+			expected: []LogFailureSignature{
+				{
+					Kind:       LogFailureException,
+					Signature:  "java.lang.IllegalStateException: request <uuid> failed",
+					Line:       `Exception in thread "main" java.lang.IllegalStateException: request 123e4567-e89b-12d3-a456-426614174000 failed`,
+					LineNumber: 1,
+					Count:      1,
+				},
+			},
+		},
+		{
+			name: "TestFailures",
+			lines: []string{
+				"=== RUN   TestFoo2",
+				"--- FAIL: TestFoo2 (0.01s)",
+				"    --- FAIL: TestFoo2/Sub (0.00s)",
+				"FAILED tests/test_foo.py::test_bar - AssertionError",
+				"FAIL\tgithub.com/evergreen-ci/cedar\t0.02s",
+				"--- FAIL: TestFoo2 (0.03s)",
+			},
+			expected: []LogFailureSignature{
+				{
+					Kind:       LogFailureTestFailure,
+					Signature:  "FAIL TestFoo2",
+					Line:       "--- FAIL: TestFoo2 (0.01s)",
+					LineNumber: 2,
+					Count:      2,
+				},
+				{
+					Kind:       LogFailureTestFailure,
+					Signature:  "FAIL TestFoo2/Sub",
+					Line:       "    --- FAIL: TestFoo2/Sub (0.00s)",
+					LineNumber: 3,
+					Count:      1,
+				},
+				{
+					Kind:       LogFailureTestFailure,
+					Signature:  "FAIL tests/test_foo.py::test_bar",
+					Line:       "FAILED tests/test_foo.py::test_bar - AssertionError",
+					LineNumber: 4,
+					Count:      1,
+				},
+				{
+					Kind:       LogFailureTestFailure,
+					Signature:  "FAIL github.com/evergreen-ci/cedar",
+					Line:       "FAIL\tgithub.com/evergreen-ci/cedar\t0.02s",
+					LineNumber: 5,
+					Count:      1,
+				},
+			},
+		},
+		{
+			name: "Assertions",
+			lines: []string{
+				"[2021/01/02 15:04:05.000] assertion failed: 1 != 2",
+				"[2021/01/02 15:04:06.000] assertion failed: 3 != 4",
+				"    foo_test.go:10:",
+				"        \tError Trace:\tfoo_test.go:10",
+				"        \tError:      \tNot equal: ",
+			},
+			expected: []LogFailureSignature{
+				{
+					Kind:       LogFailureAssertion,
+					Signature:  "assertion failed: <n> != <n>",
+					Line:       "[2021/01/02 15:04:05.000] assertion failed: 1 != 2",
+					LineNumber: 1,
+					Count:      2,
+				},
+				{
+					Kind:       LogFailureAssertion,
+					Signature:  "Error: Not equal:",
+					Line:       "        \tError:      \tNot equal: ",
+					LineNumber: 5,
+					Count:      1,
+				},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: tmpDir, Prefix: test.name})
+			require.NoError(t, err)
+			chunks, _ := putSearchTestLog(ctx, t, bucket, test.lines)
+
+			signatures, err := ExtractLogFailureSignatures(ctx, NewBatchedLogIterator(bucket, chunks, 2, TimeRange{EndAt: time.Now().Add(time.Hour)}))
+			require.NoError(t, err)
+			require.Len(t, signatures, len(test.expected))
+			for i, signature := range signatures {
+				test.expected[i].ID = logFailureSignatureID(test.expected[i].Kind, test.expected[i].Signature)
+				assert.Equal(t, test.expected[i], signature)
+			}
+		})
+	}
+	t.Run("MaxSignatures", func(t *testing.T) {
+		bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: tmpDir, Prefix: t.Name()})
+		require.NoError(t, err)
+		var lines []string
+		for i := 0; i < MaxLogFailureSignatures+5; i++ {
+			lines = append(lines, "--- FAIL: Test"+strings.Repeat("A", i+1))
+		}
+		lines = append(lines, "--- FAIL: TestA")
+		chunks, _ := putSearchTestLog(ctx, t, bucket, lines)
+
+		signatures, err := ExtractLogFailureSignatures(ctx, NewBatchedLogIterator(bucket, chunks, 2, TimeRange{EndAt: time.Now().Add(time.Hour)}))
+		require.NoError(t, err)
+		require.Len(t, signatures, MaxLogFailureSignatures)
+		assert.Equal(t, 2, signatures[0].Count)
+	})
+	t.Run("ContextError", func(t *testing.T) {
+		bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: tmpDir, Prefix: t.Name()})
+		require.NoError(t, err)
+		chunks, _ := putSearchTestLog(ctx, t, bucket, []string{"--- FAIL: TestFoo"})
+		errCtx, errCancel := context.WithCancel(context.Background())
+		errCancel()
+
+		signatures, err := ExtractLogFailureSignatures(errCtx, NewBatchedLogIterator(bucket, chunks, 2, TimeRange{EndAt: time.Now().Add(time.Hour)}))
+		assert.Error(t, err)
+		assert.Nil(t, signatures)
+	})
+}
+
+func TestLogFailureSignatureFindOptionsValidate(t *testing.T) {
+	assert.NoError(t, LogFailureSignatureFindOptions{Project: "project", SignatureID: "id"}.Validate())
+	assert.Error(t, LogFailureSignatureFindOptions{SignatureID: "id"}.Validate())
+	assert.Error(t, LogFailureSignatureFindOptions{Project: "project"}.Validate())
+	assert.Error(t, LogFailureSignatureFindOptions{Project: "project", SignatureID: "id", Limit: -1}.Validate())
+}
+
+func TestLogFailureSignatures(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir, err := ioutil.TempDir(".", "failure-signatures-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+		assert.NoError(t, db.Collection(buildloggerCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(env.GetConfig().DbConfigurationCollection).Drop(ctx))
+	}()
+	conf := &CedarConfig{
+		populated: true,
+		Bucket:    BucketConfig{BuildLogsBucket: tmpDir},
+	}
+	conf.Setup(env)
+	require.NoError(t, conf.Save())
+
+	ts := time.Now().Add(-time.Hour).Round(time.Millisecond).UTC()
+	var numLogs int
+	createLog := func(t *testing.T, info LogInfo, data ...string) *Log {
+		log := CreateLog(info, PailLocal)
+		log.CreatedAt = ts.Add(time.Duration(numLogs) * time.Minute)
+		numLogs++
+		log.Setup(env)
+		require.NoError(t, log.SaveNew(ctx))
+		var lines []LogLine
+		for i, d := range data {
+			lines = append(lines, LogLine{Priority: level.Info, Timestamp: ts.Add(time.Duration(i) * time.Second), Data: d})
+		}
+		require.NoError(t, log.Append(ctx, lines))
+		require.NoError(t, log.Close(ctx, 1))
+		require.NoError(t, log.ExtractFailureSignatures(ctx))
+		return log
+	}
+
+	log1 := createLog(t, LogInfo{Project: "project", TaskID: "task1"}, "starting", "--- FAIL: TestFoo (0.01s)")
+	log2 := createLog(t, LogInfo{Project: "project", TaskID: "task2"}, "--- FAIL: TestFoo (0.02s)", "assertion failed: 1 != 2")
+	_ = createLog(t, LogInfo{Project: "other", TaskID: "task3"}, "--- FAIL: TestFoo (0.03s)")
+	log4 := createLog(t, LogInfo{Project: "project", TaskID: "task4"}, "all good")
+
+	t.Run("Extract", func(t *testing.T) {
+		l := &Log{ID: log1.ID}
+		l.Setup(env)
+		require.NoError(t, l.Find(ctx))
+		require.Len(t, l.FailureSignatures, 1)
+		assert.Equal(t, log1.FailureSignatures, l.FailureSignatures)
+		assert.Equal(t, "FAIL TestFoo", l.FailureSignatures[0].Signature)
+		assert.Equal(t, 2, l.FailureSignatures[0].LineNumber)
+
+		l = &Log{ID: log4.ID}
+		l.Setup(env)
+		require.NoError(t, l.Find(ctx))
+		assert.Empty(t, l.FailureSignatures)
+	})
+	t.Run("ExtractDNE", func(t *testing.T) {
+		l := &Log{ID: "DNE"}
+		l.Setup(env)
+		assert.Error(t, l.ExtractFailureSignatures(ctx))
+	})
+	t.Run("FindBySignature", func(t *testing.T) {
+		opts := LogFailureSignatureFindOptions{
+			Project:     "project",
+			SignatureID: log1.FailureSignatures[0].ID,
+			Since:       time.Now().Add(-24 * time.Hour),
+		}
+		logs, err := FindLogsByFailureSignature(ctx, env, opts)
+		require.NoError(t, err)
+		require.Len(t, logs, 2)
+		assert.Equal(t, log2.ID, logs[0].ID)
+		assert.Equal(t, log1.ID, logs[1].ID)
+		assert.Empty(t, logs[0].Artifact.Prefix)
+
+		opts.ExcludeTaskID = "task1"
+		logs, err = FindLogsByFailureSignature(ctx, env, opts)
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+		assert.Equal(t, log2.ID, logs[0].ID)
+
+		opts.ExcludeTaskID = ""
+		opts.Limit = 1
+		logs, err = FindLogsByFailureSignature(ctx, env, opts)
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+		assert.Equal(t, log2.ID, logs[0].ID)
+
+		opts.Limit = 0
+		opts.Since = time.Now().Add(time.Hour)
+		logs, err = FindLogsByFailureSignature(ctx, env, opts)
+		require.NoError(t, err)
+		assert.Empty(t, logs)
+	})
+	t.Run("InvalidOptions", func(t *testing.T) {
+		logs, err := FindLogsByFailureSignature(ctx, env, LogFailureSignatureFindOptions{Project: "project"})
+		assert.Error(t, err)
+		assert.Nil(t, logs)
+	})
+}
+
+func TestNormalizeLogFailureSignature(t *testing.T) {
+	assert.Equal(t, "error at <timestamp>: object <hex> not found", normalizeLogFailureSignature("error at 2021-01-02T15:04:05Z:  object 5f8d0d55b54764421b7156c3\tnot found "))
+	assert.Len(t, normalizeLogFailureSignature(strings.Repeat("z", 2*maxLogFailureSignatureLength)), maxLogFailureSignatureLength)
+}
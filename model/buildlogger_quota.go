@@ -0,0 +1,398 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/anser/db"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/level"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	logUsageCollection = "buildlogger.usage"
+
+	// LogUsageDateFormat is the format of the UTC day identifying the log
+	// usage of a project.
+	LogUsageDateFormat = "2006-01-02"
+)
+
+// Validate ensures that the quota rules are valid.
+func (c QuotaConfig) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	seen := map[string]bool{}
+	for _, rule := range c.Rules {
+		catcher.ErrorfWhen(seen[rule.Project], "duplicate quota rule for project '%s'", rule.Project)
+		catcher.ErrorfWhen(rule.MaxAppendBytes < 0 || rule.MaxLogBytes < 0 || rule.MaxDailyBytes < 0, "quota rule for project '%s' cannot have a negative limit", rule.Project)
+		seen[rule.Project] = true
+	}
+
+	return catcher.Resolve()
+}
+
+// GetRule returns the quota rule applying to the given project, falling back
+// to the default rule. A zero rule, without any limits, is returned if no rule
+// applies.
+func (c QuotaConfig) GetRule(project string) QuotaRule {
+	var defaultRule QuotaRule
+	for _, rule := range c.Rules {
+		if rule.Project == project {
+			return rule
+		}
+		if rule.Project == "" {
+			defaultRule = rule
+		}
+	}
+
+	return defaultRule
+}
+
+// LogQuotaExceededError is returned when appending log lines would exceed a
+// quota of the log's project.
+type LogQuotaExceededError struct {
+	LogID string
+	// Quota is the name of the exceeded quota.
+	Quota string
+	Limit int64
+}
+
+func (e *LogQuotaExceededError) Error() string {
+	return fmt.Sprintf("appending to log '%s' exceeds the %s quota of %d bytes", e.LogID, e.Quota, e.Limit)
+}
+
+// IsLogQuotaExceeded returns whether the cause of the given error is an
+// exceeded log quota.
+func IsLogQuotaExceeded(err error) bool {
+	_, ok := errors.Cause(err).(*LogQuotaExceededError)
+	return ok
+}
+
+// checkQuota returns a LogQuotaExceededError if appending lines with the given
+// stats exceeds a quota of the log's project. The first time the log exceeds
+// its size quota, it is truncated by appending a marker line and no further
+// lines are accepted. Since the log's stats may be stale, the size quota is
+// enforced again when the stats are updated, see logSizeQuery. If the lines
+// are accepted, their size is reserved in the usage of the log's project,
+// callers failing to append the lines afterwards must release it with
+// releaseUsage.
+func (l *Log) checkQuota(ctx context.Context, quota QuotaConfig, stats LogStats) error {
+	rule := quota.GetRule(l.Info.Project)
+
+	if l.Truncated {
+		l.recordUsage(ctx, LogUsage{RejectedBytes: stats.Size})
+		return &LogQuotaExceededError{LogID: l.ID, Quota: "log", Limit: rule.MaxLogBytes}
+	}
+	if rule.MaxAppendBytes > 0 && stats.Size > rule.MaxAppendBytes {
+		l.recordUsage(ctx, LogUsage{RejectedBytes: stats.Size})
+		return &LogQuotaExceededError{LogID: l.ID, Quota: "append", Limit: rule.MaxAppendBytes}
+	}
+	if rule.MaxLogBytes > 0 && l.Stats.Size+stats.Size > rule.MaxLogBytes {
+		return l.rejectLogQuota(ctx, rule.MaxLogBytes, stats)
+	}
+	if rule.MaxDailyBytes <= 0 {
+		l.recordUsage(ctx, LogUsage{Bytes: stats.Size, NumLines: stats.NumLines})
+		return nil
+	}
+
+	reserved, err := l.reserveUsage(ctx, rule.MaxDailyBytes, stats)
+	if err != nil {
+		return err
+	}
+	if !reserved {
+		l.recordUsage(ctx, LogUsage{RejectedBytes: stats.Size})
+		return &LogQuotaExceededError{LogID: l.ID, Quota: "daily", Limit: rule.MaxDailyBytes}
+	}
+
+	return nil
+}
+
+// reserveUsage atomically adds the given stats to the usage of the log's
+// project for the current day, unless this exceeds the given daily limit.
+// Returns whether the usage was reserved.
+func (l *Log) reserveUsage(ctx context.Context, limit int64, stats LogStats) (bool, error) {
+	if stats.Size > limit {
+		return false, nil
+	}
+
+	date := logUsageDate(time.Now())
+	id := logUsageID(l.Info.Project, date)
+	filter := bson.M{
+		logUsageIDKey:    id,
+		logUsageBytesKey: bson.M{"$lte": limit - stats.Size},
+	}
+	update := bson.M{
+		"$setOnInsert": bson.M{
+			logUsageProjectKey: l.Info.Project,
+			logUsageDateKey:    date,
+		},
+		"$inc": bson.M{
+			logUsageBytesKey:    stats.Size,
+			logUsageNumLinesKey: stats.NumLines,
+		},
+	}
+	updateResult, err := l.env.GetDB().Collection(logUsageCollection).UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// The filter does not match an existing usage document that
+		// would exceed the limit, or one concurrently inserted, so the
+		// upsert fails on its ID. Retrying without upserting tells
+		// these cases apart.
+		updateResult, err = l.env.GetDB().Collection(logUsageCollection).UpdateOne(ctx, filter, update)
+	}
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection":   logUsageCollection,
+		"id":           id,
+		"log_id":       l.ID,
+		"limit":        limit,
+		"stats":        stats,
+		"updateResult": updateResult,
+		"op":           "reserve buildlogger usage",
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "reserving log usage '%s'", id)
+	}
+
+	return updateResult.MatchedCount > 0 || updateResult.UpsertedCount > 0, nil
+}
+
+// releaseUsage subtracts the given stats, reserved by checkQuota, from the
+// usage of the log's project for the current day.
+func (l *Log) releaseUsage(ctx context.Context, stats LogStats) {
+	l.recordUsage(ctx, LogUsage{Bytes: -stats.Size, NumLines: -stats.NumLines})
+}
+
+// logSizeQuery restricts the given query of the log to match only while the
+// log is not truncated and has room for lines with the given stats within the
+// given log size limit. The query is not restricted if the limit is not
+// greater than 0.
+func (l *Log) logSizeQuery(query bson.M, limit int64, stats LogStats) bson.M {
+	if limit <= 0 {
+		return query
+	}
+
+	sizeKey := bsonutil.GetDottedKeyName(logStatsKey, logStatsSizeKey)
+	query[logTruncatedKey] = bson.M{"$ne": true}
+	query["$or"] = []bson.M{
+		{sizeKey: bson.M{"$exists": false}},
+		{sizeKey: bson.M{"$lte": limit - stats.Size}},
+	}
+
+	return query
+}
+
+// rejectLogQuota truncates the log for exceeding the given log size limit
+// and returns the LogQuotaExceededError rejecting lines with the given stats.
+func (l *Log) rejectLogQuota(ctx context.Context, limit int64, stats LogStats) error {
+	truncated, err := l.truncate(ctx, limit)
+	if err != nil {
+		return err
+	}
+	usage := LogUsage{RejectedBytes: stats.Size}
+	if truncated {
+		usage.TruncatedLogs = 1
+	}
+	l.recordUsage(ctx, usage)
+
+	return &LogQuotaExceededError{LogID: l.ID, Quota: "log", Limit: limit}
+}
+
+// truncate marks the log as truncated and appends a marker line noting the
+// truncation. Only the first of concurrent truncations appends the marker and
+// returns true.
+func (l *Log) truncate(ctx context.Context, limit int64) (bool, error) {
+	updateResult, err := l.env.GetDB().Collection(buildloggerCollection).UpdateOne(
+		ctx,
+		bson.M{
+			"_id":           l.ID,
+			logTruncatedKey: bson.M{"$ne": true},
+		},
+		bson.M{"$set": bson.M{logTruncatedKey: true}},
+	)
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection":   buildloggerCollection,
+		"id":           l.ID,
+		"limit":        limit,
+		"updateResult": updateResult,
+		"op":           "truncate buildlogger log",
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "truncating log '%s'", l.ID)
+	}
+	l.Truncated = true
+	if updateResult.MatchedCount == 0 {
+		return false, nil
+	}
+
+	marker, err := l.truncationMarker(limit)
+	if err != nil {
+		return true, errors.Wrapf(err, "creating truncation marker of log '%s'", l.ID)
+	}
+	lines := []LogLine{marker}
+	lineBuffer, stats, err := l.bufferLines(lines, nil)
+	if err != nil {
+		return true, errors.Wrapf(err, "creating truncation marker of log '%s'", l.ID)
+	}
+	if err = l.uploadLines(ctx, lineBuffer, lines); err != nil {
+		return true, err
+	}
+
+	// The marker itself may exceed the limit.
+	return true, l.updateStats(ctx, stats, 0)
+}
+
+// truncationMarker returns the line appended to a truncated log, formatted
+// according to the log's format.
+func (l *Log) truncationMarker(limit int64) (LogLine, error) {
+	msg := fmt.Sprintf("log truncated by cedar after exceeding the quota of %d bytes", limit)
+	line := LogLine{
+		Priority:  level.Warning,
+		Timestamp: time.Now(),
+		Data:      msg,
+	}
+
+	marker := map[string]interface{}{
+		"message":   msg,
+		"truncated": true,
+	}
+	switch l.Info.Format {
+	case LogFormatJSON:
+		data, err := json.Marshal(marker)
+		if err != nil {
+			return LogLine{}, errors.Wrap(err, "marshalling JSON marker")
+		}
+		line.Data = string(data)
+	case LogFormatBSON:
+		data, err := bson.Marshal(marker)
+		if err != nil {
+			return LogLine{}, errors.Wrap(err, "marshalling BSON marker")
+		}
+		line.Data = string(data)
+	}
+
+	return line, nil
+}
+
+// LogUsage describes the size of the buildlogger log lines ingested for a
+// project in a UTC day.
+type LogUsage struct {
+	ID      string    `bson:"_id"`
+	Project string    `bson:"project"`
+	Date    time.Time `bson:"date"`
+	// Bytes is the size of the appended log lines.
+	Bytes    int64 `bson:"bytes"`
+	NumLines int   `bson:"num_lines"`
+	// RejectedBytes is the size of the log lines that were rejected for
+	// exceeding a quota.
+	RejectedBytes int64 `bson:"rejected_bytes"`
+	// TruncatedLogs is the number of logs that were truncated for
+	// exceeding their size quota.
+	TruncatedLogs int `bson:"truncated_logs"`
+}
+
+var (
+	logUsageIDKey            = bsonutil.MustHaveTag(LogUsage{}, "ID")
+	logUsageProjectKey       = bsonutil.MustHaveTag(LogUsage{}, "Project")
+	logUsageDateKey          = bsonutil.MustHaveTag(LogUsage{}, "Date")
+	logUsageBytesKey         = bsonutil.MustHaveTag(LogUsage{}, "Bytes")
+	logUsageNumLinesKey      = bsonutil.MustHaveTag(LogUsage{}, "NumLines")
+	logUsageRejectedBytesKey = bsonutil.MustHaveTag(LogUsage{}, "RejectedBytes")
+	logUsageTruncatedLogsKey = bsonutil.MustHaveTag(LogUsage{}, "TruncatedLogs")
+)
+
+func logUsageDate(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+func logUsageID(project string, date time.Time) string {
+	return fmt.Sprintf("%s@%s", project, logUsageDate(date).Format(LogUsageDateFormat))
+}
+
+// recordUsage adds the given counters to the usage of the log's project for
+// the current day. Failures are only logged since usage is informational
+// and should not fail appends.
+func (l *Log) recordUsage(ctx context.Context, usage LogUsage) {
+	date := logUsageDate(time.Now())
+	id := logUsageID(l.Info.Project, date)
+	updateResult, err := l.env.GetDB().Collection(logUsageCollection).UpdateOne(
+		ctx,
+		bson.M{logUsageIDKey: id},
+		bson.M{
+			"$setOnInsert": bson.M{
+				logUsageProjectKey: l.Info.Project,
+				logUsageDateKey:    date,
+			},
+			"$inc": bson.M{
+				logUsageBytesKey:         usage.Bytes,
+				logUsageNumLinesKey:      usage.NumLines,
+				logUsageRejectedBytesKey: usage.RejectedBytes,
+				logUsageTruncatedLogsKey: usage.TruncatedLogs,
+			},
+		},
+		options.Update().SetUpsert(true),
+	)
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection":   logUsageCollection,
+		"id":           id,
+		"log_id":       l.ID,
+		"usage":        usage,
+		"updateResult": updateResult,
+		"op":           "update buildlogger usage",
+	})
+	grip.Warning(message.WrapError(err, message.Fields{
+		"message":    "could not record buildlogger usage",
+		"collection": logUsageCollection,
+		"id":         id,
+		"log_id":     l.ID,
+	}))
+}
+
+// FindLogUsage returns the usage of the given project on the UTC day of the
+// given time. Projects without usage that day have zero usage.
+func FindLogUsage(ctx context.Context, env cedar.Environment, project string, date time.Time) (*LogUsage, error) {
+	if env == nil {
+		return nil, errors.New("cannot find log usage with a nil environment")
+	}
+
+	usage := &LogUsage{}
+	id := logUsageID(project, date)
+	err := env.GetDB().Collection(logUsageCollection).FindOne(ctx, bson.M{logUsageIDKey: id}).Decode(usage)
+	if db.ResultsNotFound(err) {
+		return &LogUsage{ID: id, Project: project, Date: logUsageDate(date)}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding log usage '%s'", id)
+	}
+
+	return usage, nil
+}
+
+// FindLogUsageByDate returns the usage of every project with usage on the UTC
+// day of the given time, sorted by descending size.
+func FindLogUsageByDate(ctx context.Context, env cedar.Environment, date time.Time) ([]LogUsage, error) {
+	if env == nil {
+		return nil, errors.New("cannot find log usage with a nil environment")
+	}
+
+	findOpts := options.Find().SetSort(bson.D{
+		{Key: logUsageBytesKey, Value: -1},
+		{Key: logUsageProjectKey, Value: 1},
+	})
+	cur, err := env.GetDB().Collection(logUsageCollection).Find(ctx, bson.M{logUsageDateKey: logUsageDate(date)}, findOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding log usage")
+	}
+	usage := []LogUsage{}
+	if err = cur.All(ctx, &usage); err != nil {
+		return nil, errors.Wrap(err, "decoding log usage")
+	}
+
+	return usage, nil
+}
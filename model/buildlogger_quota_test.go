@@ -0,0 +1,337 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/mongodb/grip/level"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaConfigValidate(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		conf   QuotaConfig
+		hasErr bool
+	}{
+		{
+			name: "Empty",
+		},
+		{
+			name: "Valid",
+			conf: QuotaConfig{Rules: []QuotaRule{
+				{MaxLogBytes: 1024},
+				{Project: "project", MaxAppendBytes: 64, MaxDailyBytes: 4096},
+			}},
+		},
+		{
+			name: "DuplicateProject",
+			conf: QuotaConfig{Rules: []QuotaRule{
+				{Project: "project", MaxLogBytes: 1024},
+				{Project: "project", MaxLogBytes: 2048},
+			}},
+			hasErr: true,
+		},
+		{
+			name:   "NegativeLimit",
+			conf:   QuotaConfig{Rules: []QuotaRule{{Project: "project", MaxDailyBytes: -1}}},
+			hasErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.conf.Validate()
+			if test.hasErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestQuotaConfigGetRule(t *testing.T) {
+	t.Run("NoRules", func(t *testing.T) {
+		assert.Zero(t, QuotaConfig{}.GetRule("project"))
+	})
+	t.Run("ProjectRule", func(t *testing.T) {
+		conf := QuotaConfig{Rules: []QuotaRule{{MaxLogBytes: 1}, {Project: "project", MaxLogBytes: 2}}}
+		assert.Equal(t, conf.Rules[1], conf.GetRule("project"))
+	})
+	t.Run("DefaultRule", func(t *testing.T) {
+		conf := QuotaConfig{Rules: []QuotaRule{{Project: "project", MaxLogBytes: 2}, {MaxLogBytes: 1}}}
+		assert.Equal(t, conf.Rules[1], conf.GetRule("other"))
+	})
+	t.Run("NoDefaultRule", func(t *testing.T) {
+		conf := QuotaConfig{Rules: []QuotaRule{{Project: "project", MaxLogBytes: 2}}}
+		assert.Zero(t, conf.GetRule("other"))
+	})
+}
+
+func TestIsLogQuotaExceeded(t *testing.T) {
+	err := &LogQuotaExceededError{LogID: "log", Quota: "log", Limit: 1024}
+	assert.True(t, IsLogQuotaExceeded(err))
+	assert.True(t, IsLogQuotaExceeded(errors.Wrap(err, "appending")))
+	assert.False(t, IsLogQuotaExceeded(errors.New("appending")))
+	assert.False(t, IsLogQuotaExceeded(nil))
+}
+
+func TestLogTruncationMarker(t *testing.T) {
+	for _, format := range []LogFormat{LogFormatUnknown, LogFormatText, LogFormatJSON, LogFormatBSON} {
+		t.Run(string(format), func(t *testing.T) {
+			l := &Log{Info: LogInfo{Format: format}}
+			marker, err := l.truncationMarker(1024)
+			require.NoError(t, err)
+			assert.Equal(t, level.Warning, marker.Priority)
+			require.NoError(t, format.ValidateLine(marker.Data))
+
+			data, err := format.normalizeLine(marker.Data)
+			require.NoError(t, err)
+			assert.Contains(t, data, "1024 bytes")
+			if format.IsStructured() {
+				doc := map[string]interface{}{}
+				require.NoError(t, json.Unmarshal([]byte(data), &doc))
+				assert.Equal(t, true, doc["truncated"])
+			}
+		})
+	}
+}
+
+func TestBuildloggerAppendQuota(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir, err := ioutil.TempDir(".", "append-quota-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+		assert.NoError(t, db.Collection(buildloggerCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(logUsageCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(env.GetConfig().DbConfigurationCollection).Drop(ctx))
+	}()
+	conf := &CedarConfig{
+		populated: true,
+		Bucket:    BucketConfig{BuildLogsBucket: tmpDir},
+		Quota: QuotaConfig{Rules: []QuotaRule{
+			{MaxAppendBytes: 10, MaxLogBytes: 20},
+			{Project: "daily", MaxDailyBytes: 15},
+			{Project: "concurrent", MaxDailyBytes: 20},
+		}},
+	}
+	conf.Setup(env)
+	require.NoError(t, conf.Save())
+
+	ts := time.Now().Add(-time.Hour).Round(time.Millisecond).UTC()
+	lines := func(n int) []LogLine {
+		var lines []LogLine
+		for i := 0; i < n; i++ {
+			lines = append(lines, LogLine{Priority: level.Info, Timestamp: ts.Add(time.Duration(i) * time.Second), Data: "line"})
+		}
+		return lines
+	}
+	createLog := func(t *testing.T, info LogInfo) *Log {
+		log := CreateLog(info, PailLocal)
+		log.Setup(env)
+		require.NoError(t, log.SaveNew(ctx))
+		return log
+	}
+	find := func(t *testing.T, id string) *Log {
+		l := &Log{ID: id}
+		l.Setup(env)
+		require.NoError(t, l.Find(ctx))
+		return l
+	}
+
+	t.Run("WithinQuota", func(t *testing.T) {
+		log := createLog(t, LogInfo{Project: "project", TaskID: "within"})
+		require.NoError(t, log.Append(ctx, lines(2)))
+
+		l := find(t, log.ID)
+		assert.False(t, l.Truncated)
+		assert.EqualValues(t, 8, l.Stats.Size)
+	})
+	t.Run("ExceedsAppendQuota", func(t *testing.T) {
+		log := createLog(t, LogInfo{Project: "project", TaskID: "append"})
+		err := log.Append(ctx, lines(3))
+		assert.True(t, IsLogQuotaExceeded(err))
+
+		l := find(t, log.ID)
+		assert.False(t, l.Truncated)
+		assert.Zero(t, l.Stats.NumLines)
+	})
+	t.Run("ExceedsLogQuota", func(t *testing.T) {
+		log := createLog(t, LogInfo{Project: "project", TaskID: "log", Format: LogFormatJSON})
+		jsonLines := func(i int) []LogLine {
+			return []LogLine{{Priority: level.Info, Timestamp: ts.Add(time.Duration(i) * time.Second), Data: `{"a":1}`}}
+		}
+		for i := 0; i < 2; i++ {
+			l := find(t, log.ID)
+			require.NoError(t, l.Append(ctx, jsonLines(i)))
+		}
+		l := find(t, log.ID)
+		assert.True(t, IsLogQuotaExceeded(l.Append(ctx, jsonLines(2))))
+
+		l = find(t, log.ID)
+		assert.True(t, l.Truncated)
+		assert.Equal(t, 3, l.Stats.NumLines)
+		assert.Equal(t, 1, l.Stats.Priorities[strconv.Itoa(int(level.Warning))])
+
+		// Truncated logs reject every append, even those within
+		// quota, and are only marked once.
+		assert.True(t, IsLogQuotaExceeded(l.Append(ctx, lines(1))))
+		l = find(t, log.ID)
+		assert.Equal(t, 3, l.Stats.NumLines)
+	})
+	t.Run("ReadTruncatedLog", func(t *testing.T) {
+		log := createLog(t, LogInfo{Project: "project", TaskID: "read"})
+		appended := lines(4)
+		require.NoError(t, log.Append(ctx, appended[:2]))
+		require.NoError(t, log.Append(ctx, appended[2:]))
+		assert.True(t, IsLogQuotaExceeded(log.Append(ctx, lines(2))))
+
+		l := find(t, log.ID)
+		require.True(t, l.Truncated)
+		it, err := l.Download(ctx, TimeRange{EndAt: time.Now().Add(time.Hour)})
+		require.NoError(t, err)
+		var downloaded []LogLine
+		for it.Next(ctx) {
+			downloaded = append(downloaded, it.Item())
+		}
+		require.NoError(t, it.Err())
+		require.NoError(t, it.Close())
+		require.Len(t, downloaded, 5)
+		for _, line := range downloaded[:4] {
+			assert.Equal(t, "line\n", line.Data)
+		}
+		assert.Equal(t, level.Warning, downloaded[4].Priority)
+		assert.Contains(t, downloaded[4].Data, "20 bytes")
+	})
+	t.Run("ExceedsDailyQuota", func(t *testing.T) {
+		log := createLog(t, LogInfo{Project: "daily", TaskID: "daily"})
+		require.NoError(t, log.Append(ctx, lines(2)))
+		log2 := createLog(t, LogInfo{Project: "daily", TaskID: "daily2"})
+		assert.True(t, IsLogQuotaExceeded(log2.Append(ctx, lines(2))))
+		require.NoError(t, log2.Append(ctx, lines(1)))
+	})
+	t.Run("AppendSequence", func(t *testing.T) {
+		log := createLog(t, LogInfo{Project: "project", TaskID: "sequence"})
+		appended, err := log.AppendSequence(ctx, 1, lines(3))
+		assert.True(t, IsLogQuotaExceeded(err))
+		assert.False(t, appended)
+
+		l := find(t, log.ID)
+		assert.Zero(t, l.Sequences.Last)
+	})
+	t.Run("Usage", func(t *testing.T) {
+		usage, err := FindLogUsage(ctx, env, "daily", time.Now())
+		require.NoError(t, err)
+		assert.EqualValues(t, 12, usage.Bytes)
+		assert.Equal(t, 3, usage.NumLines)
+		assert.EqualValues(t, 8, usage.RejectedBytes)
+		assert.Zero(t, usage.TruncatedLogs)
+
+		usage, err = FindLogUsage(ctx, env, "project", time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 1, usage.TruncatedLogs)
+
+		usage, err = FindLogUsage(ctx, env, "DNE", time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, usage.Bytes)
+
+		allUsage, err := FindLogUsageByDate(ctx, env, time.Now())
+		require.NoError(t, err)
+		require.Len(t, allUsage, 2)
+		assert.Equal(t, "project", allUsage[0].Project)
+		assert.Equal(t, "daily", allUsage[1].Project)
+
+		allUsage, err = FindLogUsageByDate(ctx, env, time.Now().Add(-48*time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, allUsage)
+	})
+	t.Run("ConcurrentAppendsWithinDailyQuota", func(t *testing.T) {
+		var logs []*Log
+		for i := 0; i < 10; i++ {
+			logs = append(logs, createLog(t, LogInfo{Project: "concurrent", TaskID: "concurrent" + strconv.Itoa(i)}))
+		}
+		errs := make(chan error, len(logs))
+		var wg sync.WaitGroup
+		for _, log := range logs {
+			wg.Add(1)
+			go func(log *Log) {
+				defer wg.Done()
+				errs <- log.Append(ctx, lines(1))
+			}(log)
+		}
+		wg.Wait()
+		close(errs)
+
+		var appended int
+		for err := range errs {
+			if err == nil {
+				appended++
+				continue
+			}
+			assert.True(t, IsLogQuotaExceeded(err))
+		}
+		assert.Equal(t, 5, appended)
+
+		usage, err := FindLogUsage(ctx, env, "concurrent", time.Now())
+		require.NoError(t, err)
+		assert.EqualValues(t, 20, usage.Bytes)
+		assert.EqualValues(t, 20, usage.RejectedBytes)
+	})
+	t.Run("ConcurrentAppendsWithinLogQuota", func(t *testing.T) {
+		log := createLog(t, LogInfo{Project: "project", TaskID: "concurrent_log"})
+		var logs []*Log
+		for i := 0; i < 10; i++ {
+			logs = append(logs, find(t, log.ID))
+		}
+		appended := lines(len(logs))
+		errs := make(chan error, len(logs))
+		var wg sync.WaitGroup
+		for i, l := range logs {
+			wg.Add(1)
+			go func(l *Log, lines []LogLine) {
+				defer wg.Done()
+				errs <- l.Append(ctx, lines)
+			}(l, appended[i:i+1])
+		}
+		wg.Wait()
+		close(errs)
+
+		var numAppended int
+		for err := range errs {
+			if err == nil {
+				numAppended++
+				continue
+			}
+			assert.True(t, IsLogQuotaExceeded(err))
+		}
+		assert.Equal(t, 5, numAppended)
+
+		l := find(t, log.ID)
+		assert.True(t, l.Truncated)
+		assert.Equal(t, 6, l.Stats.NumLines)
+		assert.Equal(t, 1, l.Stats.Priorities[strconv.Itoa(int(level.Warning))])
+		// The chunks of the rejected appends are discarded.
+		files, err := ioutil.ReadDir(filepath.Join(tmpDir, log.Artifact.Prefix))
+		require.NoError(t, err)
+		assert.Len(t, files, 6)
+	})
+}
+
+func TestLogUsageID(t *testing.T) {
+	date := time.Date(2021, time.January, 2, 23, 30, 0, 0, time.FixedZone("", -2*60*60))
+	assert.Equal(t, "project@2021-01-03", logUsageID("project", date))
+	assert.Equal(t, time.Date(2021, time.January, 3, 0, 0, 0, 0, time.UTC), logUsageDate(date))
+}
@@ -0,0 +1,171 @@
+package model
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+const (
+	// logRedactionReplacement replaces the secrets redacted from log lines.
+	logRedactionReplacement = "[REDACTED]"
+	// logRedactionSecretGroup is the name of the capture group of a
+	// redaction pattern holding the secret.
+	logRedactionSecretGroup = "secret"
+)
+
+// logRedactionDetectors are the built-in redaction patterns.
+var logRedactionDetectors = []*regexp.Regexp{
+	// AWS access key IDs.
+	regexp.MustCompile(`\b(?:AKIA|ASIA|AIDA|AROA|AGPA|ANPA|ANVA|AIPA)[0-9A-Z]{16}\b`),
+	// AWS secret access keys, which are only recognizable by the name
+	// they are assigned to.
+	regexp.MustCompile(`(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9/+=]{40})`),
+	// Bearer tokens, typically from authorization headers.
+	regexp.MustCompile(`(?i)\bbearer\s+(?P<secret>[A-Za-z0-9\-._~+/]+=*)`),
+}
+
+// logRedactionPatterns caches the compiled redaction patterns of the
+// application configuration.
+var logRedactionPatterns sync.Map
+
+func compileRedactionPattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := logRedactionPatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.Wrapf(err, "compiling redaction pattern '%s'", pattern)
+	}
+	logRedactionPatterns.Store(pattern, re)
+
+	return re, nil
+}
+
+// Validate ensures that the redaction rules are valid.
+func (c RedactionConfig) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	seen := map[string]bool{}
+	for _, rule := range c.Rules {
+		catcher.ErrorfWhen(seen[rule.Project], "duplicate redaction rule for project '%s'", rule.Project)
+		seen[rule.Project] = true
+		for _, pattern := range rule.Patterns {
+			_, err := compileRedactionPattern(pattern)
+			catcher.Wrapf(err, "invalid redaction rule for project '%s'", rule.Project)
+		}
+	}
+
+	return catcher.Resolve()
+}
+
+// redactor returns the redactor of the log lines of the given project.
+func (c RedactionConfig) redactor(project string) (*logRedactor, error) {
+	var (
+		defaultRule, projectRule *RedactionRule
+		patterns                 []string
+	)
+	for i, rule := range c.Rules {
+		switch rule.Project {
+		case "":
+			defaultRule = &c.Rules[i]
+		case project:
+			projectRule = &c.Rules[i]
+		default:
+			continue
+		}
+		patterns = append(patterns, rule.Patterns...)
+	}
+
+	r := &logRedactor{}
+	switch {
+	case projectRule != nil:
+		r.patterns = append(r.patterns, projectDetectors(projectRule)...)
+	case defaultRule != nil:
+		r.patterns = append(r.patterns, projectDetectors(defaultRule)...)
+	default:
+		r.patterns = append(r.patterns, logRedactionDetectors...)
+	}
+	for _, pattern := range patterns {
+		re, err := compileRedactionPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	return r, nil
+}
+
+func projectDetectors(rule *RedactionRule) []*regexp.Regexp {
+	if rule.DisableDetectors {
+		return nil
+	}
+	return logRedactionDetectors
+}
+
+// logRedactor replaces the secrets matched by its patterns in log lines.
+type logRedactor struct {
+	patterns []*regexp.Regexp
+}
+
+// redact returns the given line of the given format with its secrets
+// replaced, along with the number of secrets replaced. A nil redactor does not
+// replace anything.
+func (r *logRedactor) redact(format LogFormat, data string) (string, int) {
+	if r == nil {
+		return data, 0
+	}
+
+	var count int
+	redacted := data
+	for _, re := range r.patterns {
+		var n int
+		redacted, n = redactPattern(re, redacted)
+		count += n
+	}
+
+	// Structured lines are stored as JSON. Patterns that match across
+	// JSON syntax, rather than within a string, can leave the line
+	// invalid, in which case the entire line is redacted.
+	if count > 0 && format.IsStructured() && !json.Valid([]byte(redacted)) {
+		data, _ := json.Marshal(map[string]string{"redacted": logRedactionReplacement})
+		return string(data), count
+	}
+
+	return redacted, count
+}
+
+func redactPattern(re *regexp.Regexp, data string) (string, int) {
+	matches := re.FindAllStringSubmatchIndex(data, -1)
+	if len(matches) == 0 {
+		return data, 0
+	}
+
+	group := re.SubexpIndex(logRedactionSecretGroup)
+	var (
+		b     strings.Builder
+		last  int
+		count int
+	)
+	for _, match := range matches {
+		start, end := match[0], match[1]
+		if group >= 0 {
+			start, end = match[2*group], match[2*group+1]
+		}
+		if start < 0 || end <= start {
+			continue
+		}
+		b.WriteString(data[last:start])
+		b.WriteString(logRedactionReplacement)
+		last = end
+		count++
+	}
+	b.WriteString(data[last:])
+
+	return b.String(), count
+}
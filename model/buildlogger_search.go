@@ -0,0 +1,211 @@
+package model
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/level"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MaxLogSearchContextLines is the maximum number of context lines that
+	// may be returned before and after each search match.
+	MaxLogSearchContextLines = 50
+	// MaxLogSearchMatches is the maximum number of matches a single
+	// search may return.
+	MaxLogSearchMatches = 1000
+)
+
+// LogSearchOptions describes the options for searching the lines of
+// buildlogger logs.
+type LogSearchOptions struct {
+	// Query is the string to search for. If Regex is true, it is
+	// interpreted as a regular expression.
+	Query string
+	// Regex, when true, interprets the query as a regular expression
+	// using the Go syntax.
+	Regex bool
+	// ContextLines is the number of lines to return before and after
+	// each matching line.
+	ContextLines int
+	// MaxMatches is the match budget for the search. Once another line
+	// matches after the budget is reached, the search stops and the
+	// results are marked as truncated.
+	MaxMatches int
+	// MinPriority and MaxPriority, when greater than 0, are the inclusive
+	// bounds of the priority of the lines searched, including context
+	// lines.
+	MinPriority level.Priority
+	MaxPriority level.Priority
+}
+
+// Validate ensures that the search options are valid.
+func (o LogSearchOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+
+	catcher.NewWhen(o.Query == "", "must specify a search query")
+	catcher.ErrorfWhen(o.ContextLines < 0 || o.ContextLines > MaxLogSearchContextLines, "context lines must be between 0 and %d", MaxLogSearchContextLines)
+	catcher.ErrorfWhen(o.MaxMatches <= 0 || o.MaxMatches > MaxLogSearchMatches, "max matches must be between 1 and %d", MaxLogSearchMatches)
+	catcher.NewWhen(o.MinPriority > 0 && o.MaxPriority > 0 && o.MinPriority > o.MaxPriority, "min priority cannot be greater than max priority")
+	if o.Regex {
+		_, err := regexp.Compile(o.Query)
+		catcher.Wrap(err, "compiling search regex")
+	}
+
+	return catcher.Resolve()
+}
+
+func (o LogSearchOptions) matcher() (func(string) bool, error) {
+	if !o.Regex {
+		return func(data string) bool { return strings.Contains(data, o.Query) }, nil
+	}
+
+	re, err := regexp.Compile(o.Query)
+	if err != nil {
+		return nil, errors.Wrap(err, "compiling search regex")
+	}
+
+	return re.MatchString, nil
+}
+
+// LogSearchMatch describes a single log line matching a search along with
+// its surrounding context lines.
+type LogSearchMatch struct {
+	LogID       string
+	ProcessName string
+	Line        LogLine
+	Before      []LogLine
+	After       []LogLine
+}
+
+// LogSearchResults describes the results of a buildlogger log search.
+type LogSearchResults struct {
+	Matches []LogSearchMatch
+	// Truncated is true when more lines match than the match budget
+	// allows, in which case only the matches within the budget are
+	// returned.
+	Truncated bool
+}
+
+// Search searches the lines of each log, within the time range used to find
+// the logs, for the given query. The match budget applies to the whole
+// search, so each log is searched with the budget left by the previous logs.
+// Matches are sorted by timestamp. The logs should be populated and the
+// environment should not be nil.
+func (l *Logs) Search(ctx context.Context, opts LogSearchOptions) (*LogSearchResults, error) {
+	if !l.populated {
+		return nil, errors.New("cannot search unpopulated logs")
+	}
+	if l.env == nil {
+		return nil, errors.New("cannot search with a nil environment")
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid search options")
+	}
+
+	results := &LogSearchResults{}
+	for i := range l.Logs {
+		if results.Truncated {
+			break
+		}
+
+		l.Logs[i].Setup(l.env)
+		it, err := l.Logs[i].Download(ctx, l.timeRange)
+		if err != nil {
+			return nil, errors.Wrapf(err, "downloading log '%s'", l.Logs[i].ID)
+		}
+
+		logOpts := opts
+		logOpts.MaxMatches = opts.MaxMatches - len(results.Matches)
+		matches, truncated, err := SearchLogIterator(ctx, it, logOpts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "searching log '%s'", l.Logs[i].ID)
+		}
+		for j := range matches {
+			matches[j].LogID = l.Logs[i].ID
+			matches[j].ProcessName = l.Logs[i].Info.ProcessName
+		}
+		results.Matches = append(results.Matches, matches...)
+		results.Truncated = results.Truncated || truncated
+	}
+
+	results.sort()
+
+	return results, nil
+}
+
+// SearchLogIterator searches the lines of the given iterator for the given
+// query, returning the matching lines along with whether more lines matched
+// than the match budget allows. The iterator is closed once the search
+// completes. The log ID and process name of the matches are not set.
+func SearchLogIterator(ctx context.Context, it LogIterator, opts LogSearchOptions) ([]LogSearchMatch, bool, error) {
+	match, err := opts.matcher()
+	if err != nil {
+		return nil, false, err
+	}
+	if opts.MinPriority > 0 || opts.MaxPriority > 0 {
+		it = NewPriorityFilteringLogIterator(it, opts.MinPriority, opts.MaxPriority)
+	}
+
+	var (
+		matches   []LogSearchMatch
+		before    []LogLine
+		pending   []int
+		truncated bool
+	)
+	for it.Next(ctx) {
+		item := it.Item()
+
+		remaining := pending[:0]
+		for _, idx := range pending {
+			matches[idx].After = append(matches[idx].After, item)
+			if len(matches[idx].After) < opts.ContextLines {
+				remaining = append(remaining, idx)
+			}
+		}
+		pending = remaining
+
+		if !truncated && match(item.Data) {
+			if len(matches) < opts.MaxMatches {
+				matches = append(matches, LogSearchMatch{
+					Line:   item,
+					Before: append([]LogLine{}, before...),
+				})
+				if opts.ContextLines > 0 {
+					pending = append(pending, len(matches)-1)
+				}
+			} else {
+				truncated = true
+			}
+		}
+		if truncated && len(pending) == 0 {
+			break
+		}
+
+		if opts.ContextLines > 0 {
+			before = append(before, item)
+			if len(before) > opts.ContextLines {
+				before = before[1:]
+			}
+		}
+	}
+
+	catcher := grip.NewBasicCatcher()
+	catcher.Add(it.Err())
+	catcher.Add(it.Close())
+	if catcher.HasErrors() {
+		return nil, false, catcher.Resolve()
+	}
+
+	return matches, truncated, nil
+}
+
+func (r *LogSearchResults) sort() {
+	sort.SliceStable(r.Matches, func(i, j int) bool {
+		return r.Matches[i].Line.Timestamp.Before(r.Matches[j].Line.Timestamp)
+	})
+}
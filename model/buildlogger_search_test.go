@@ -0,0 +1,309 @@
+package model
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/pail"
+	"github.com/mongodb/grip/level"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogSearchOptionsValidate(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		opts   LogSearchOptions
+		hasErr bool
+	}{
+		{
+			name: "Valid",
+			opts: LogSearchOptions{Query: "failed", MaxMatches: 10},
+		},
+		{
+			name: "ValidRegex",
+			opts: LogSearchOptions{Query: "assert.*failed", Regex: true, ContextLines: 2, MaxMatches: 10},
+		},
+		{
+			name:   "EmptyQuery",
+			opts:   LogSearchOptions{MaxMatches: 10},
+			hasErr: true,
+		},
+		{
+			name:   "InvalidRegex",
+			opts:   LogSearchOptions{Query: "assert(", Regex: true, MaxMatches: 10},
+			hasErr: true,
+		},
+		{
+			name:   "NegativeContextLines",
+			opts:   LogSearchOptions{Query: "failed", ContextLines: -1, MaxMatches: 10},
+			hasErr: true,
+		},
+		{
+			name:   "TooManyContextLines",
+			opts:   LogSearchOptions{Query: "failed", ContextLines: MaxLogSearchContextLines + 1, MaxMatches: 10},
+			hasErr: true,
+		},
+		{
+			name:   "ZeroMaxMatches",
+			opts:   LogSearchOptions{Query: "failed"},
+			hasErr: true,
+		},
+		{
+			name:   "TooManyMaxMatches",
+			opts:   LogSearchOptions{Query: "failed", MaxMatches: MaxLogSearchMatches + 1},
+			hasErr: true,
+		},
+		{
+			name: "PriorityRange",
+			opts: LogSearchOptions{Query: "failed", MaxMatches: 10, MinPriority: level.Info, MaxPriority: level.Error},
+		},
+		{
+			name:   "InvertedPriorityRange",
+			opts:   LogSearchOptions{Query: "failed", MaxMatches: 10, MinPriority: level.Error, MaxPriority: level.Info},
+			hasErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.opts.Validate()
+			if test.hasErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSearchLogIterator(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir, err := ioutil.TempDir("", "search-log-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: tmpDir})
+	require.NoError(t, err)
+
+	chunks, lines := putSearchTestLog(ctx, t, bucket, []string{
+		"starting test",
+		"assertion failed: x != y",
+		"cleaning up",
+		"retrying",
+		"ASSERTION FAILED: a != b",
+		"assertion failed: c != d",
+		"done",
+	})
+	timeRange := TimeRange{StartAt: chunks[0].Start, EndAt: chunks[len(chunks)-1].End}
+
+	for _, test := range []struct {
+		name              string
+		opts              LogSearchOptions
+		expectedMatches   []int
+		expectedBefore    [][]int
+		expectedAfter     [][]int
+		expectedTruncated bool
+	}{
+		{
+			name:            "Substring",
+			opts:            LogSearchOptions{Query: "assertion failed", MaxMatches: 10},
+			expectedMatches: []int{1, 5},
+		},
+		{
+			name:            "Regex",
+			opts:            LogSearchOptions{Query: "(?i)assertion failed: [a-z] != [a-z]", Regex: true, MaxMatches: 10},
+			expectedMatches: []int{1, 4, 5},
+		},
+		{
+			name:            "ContextLines",
+			opts:            LogSearchOptions{Query: "assertion failed", ContextLines: 2, MaxMatches: 10},
+			expectedMatches: []int{1, 5},
+			expectedBefore:  [][]int{{0}, {3, 4}},
+			expectedAfter:   [][]int{{2, 3}, {6}},
+		},
+		{
+			name:              "MatchBudget",
+			opts:              LogSearchOptions{Query: "(?i)failed", Regex: true, ContextLines: 1, MaxMatches: 2},
+			expectedMatches:   []int{1, 4},
+			expectedBefore:    [][]int{{0}, {3}},
+			expectedAfter:     [][]int{{2}, {5}},
+			expectedTruncated: true,
+		},
+		{
+			name:            "ExactMatchBudget",
+			opts:            LogSearchOptions{Query: "assertion failed", MaxMatches: 2},
+			expectedMatches: []int{1, 5},
+		},
+		{
+			name: "NoMatches",
+			opts: LogSearchOptions{Query: "segfault", MaxMatches: 10},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			matches, truncated, err := SearchLogIterator(ctx, NewSerializedLogIterator(bucket, chunks, timeRange), test.opts)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedTruncated, truncated)
+			require.Len(t, matches, len(test.expectedMatches))
+			for i, match := range matches {
+				assert.Equal(t, lines[test.expectedMatches[i]], match.Line)
+				if test.expectedBefore != nil {
+					assert.Equal(t, getSearchTestLines(lines, test.expectedBefore[i]), match.Before)
+					assert.Equal(t, getSearchTestLines(lines, test.expectedAfter[i]), match.After)
+				} else {
+					assert.Empty(t, match.Before)
+					assert.Empty(t, match.After)
+				}
+			}
+		})
+	}
+	t.Run("InvalidRegex", func(t *testing.T) {
+		matches, truncated, err := SearchLogIterator(ctx, NewSerializedLogIterator(bucket, chunks, timeRange), LogSearchOptions{Query: "(", Regex: true, MaxMatches: 1})
+		assert.Error(t, err)
+		assert.False(t, truncated)
+		assert.Nil(t, matches)
+	})
+	t.Run("ContextError", func(t *testing.T) {
+		errCtx, errCancel := context.WithCancel(context.Background())
+		errCancel()
+
+		matches, _, err := SearchLogIterator(errCtx, NewBatchedLogIterator(bucket, chunks, 2, timeRange), LogSearchOptions{Query: "failed", MaxMatches: 1})
+		assert.Error(t, err)
+		assert.Nil(t, matches)
+	})
+}
+
+func TestLogsSearch(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir, err := ioutil.TempDir(".", "logs-search-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+		assert.NoError(t, db.Collection(buildloggerCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(env.GetConfig().DbConfigurationCollection).Drop(ctx))
+	}()
+	conf := &CedarConfig{
+		populated: true,
+		Bucket:    BucketConfig{BuildLogsBucket: tmpDir},
+	}
+	conf.Setup(env)
+	require.NoError(t, conf.Save())
+
+	ts := time.Now().Add(-time.Hour).Round(time.Millisecond).UTC()
+	log1 := CreateLog(LogInfo{Project: "project", TaskID: "task", ProcessName: "mongod"}, PailLocal)
+	log1.Setup(env)
+	require.NoError(t, log1.SaveNew(ctx))
+	require.NoError(t, log1.Append(ctx, []LogLine{
+		{Priority: level.Info, Timestamp: ts, Data: "starting"},
+		{Priority: level.Error, Timestamp: ts.Add(2 * time.Second), Data: "assertion failed"},
+	}))
+	log2 := CreateLog(LogInfo{Project: "project", TaskID: "task", ProcessName: "mongos"}, PailLocal)
+	log2.Setup(env)
+	require.NoError(t, log2.SaveNew(ctx))
+	require.NoError(t, log2.Append(ctx, []LogLine{
+		{Priority: level.Error, Timestamp: ts.Add(time.Second), Data: "assertion failed"},
+		{Priority: level.Info, Timestamp: ts.Add(3 * time.Second), Data: "done"},
+	}))
+
+	findOpts := LogFindOptions{
+		TimeRange: TimeRange{EndAt: time.Now()},
+		Info:      LogInfo{TaskID: "task"},
+	}
+
+	t.Run("Unpopulated", func(t *testing.T) {
+		logs := Logs{}
+		logs.Setup(env)
+		results, err := logs.Search(ctx, LogSearchOptions{Query: "failed", MaxMatches: 10})
+		assert.Error(t, err)
+		assert.Nil(t, results)
+	})
+	t.Run("InvalidOptions", func(t *testing.T) {
+		logs := Logs{}
+		logs.Setup(env)
+		require.NoError(t, logs.Find(ctx, findOpts))
+		results, err := logs.Search(ctx, LogSearchOptions{Query: "failed"})
+		assert.Error(t, err)
+		assert.Nil(t, results)
+	})
+	t.Run("SortedAcrossLogs", func(t *testing.T) {
+		logs := Logs{}
+		logs.Setup(env)
+		require.NoError(t, logs.Find(ctx, findOpts))
+		results, err := logs.Search(ctx, LogSearchOptions{Query: "failed", ContextLines: 1, MaxMatches: 10})
+		require.NoError(t, err)
+		assert.False(t, results.Truncated)
+		require.Len(t, results.Matches, 2)
+		assert.Equal(t, log2.ID, results.Matches[0].LogID)
+		assert.Equal(t, "mongos", results.Matches[0].ProcessName)
+		assert.Equal(t, ts.Add(time.Second), results.Matches[0].Line.Timestamp)
+		assert.Empty(t, results.Matches[0].Before)
+		require.Len(t, results.Matches[0].After, 1)
+		assert.Equal(t, "done\n", results.Matches[0].After[0].Data)
+		assert.Equal(t, log1.ID, results.Matches[1].LogID)
+		assert.Equal(t, "mongod", results.Matches[1].ProcessName)
+		assert.Equal(t, ts.Add(2*time.Second), results.Matches[1].Line.Timestamp)
+		require.Len(t, results.Matches[1].Before, 1)
+		assert.Equal(t, "starting\n", results.Matches[1].Before[0].Data)
+		assert.Empty(t, results.Matches[1].After)
+	})
+	t.Run("MatchBudget", func(t *testing.T) {
+		logs := Logs{}
+		logs.Setup(env)
+		require.NoError(t, logs.Find(ctx, findOpts))
+		results, err := logs.Search(ctx, LogSearchOptions{Query: "failed", MaxMatches: 1})
+		require.NoError(t, err)
+		assert.True(t, results.Truncated)
+		require.Len(t, results.Matches, 1)
+		assert.Equal(t, log2.ID, results.Matches[0].LogID)
+	})
+	t.Run("ExactMatchBudget", func(t *testing.T) {
+		logs := Logs{}
+		logs.Setup(env)
+		require.NoError(t, logs.Find(ctx, findOpts))
+		results, err := logs.Search(ctx, LogSearchOptions{Query: "failed", MaxMatches: 2})
+		require.NoError(t, err)
+		assert.False(t, results.Truncated)
+		assert.Len(t, results.Matches, 2)
+	})
+}
+
+func putSearchTestLog(ctx context.Context, t *testing.T, bucket pail.Bucket, data []string) ([]LogChunkInfo, []LogLine) {
+	ts := time.Now().Round(time.Millisecond).UTC()
+	var (
+		chunks []LogChunkInfo
+		lines  []LogLine
+	)
+	for i := 0; i < len(data); i += 3 {
+		var rawLines strings.Builder
+		chunk := LogChunkInfo{Start: ts}
+		for j := i; j < i+3 && j < len(data); j++ {
+			lines = append(lines, LogLine{Priority: level.Info, Timestamp: ts, Data: data[j] + "\n"})
+			rawLines.WriteString(prependPriorityAndTimestamp(level.Info, ts, data[j]))
+			chunk.End = ts
+			chunk.NumLines++
+			ts = ts.Add(time.Millisecond)
+		}
+		chunk.Key = createBuildloggerChunkKey(chunk.Start, chunk.End, chunk.NumLines)
+		require.NoError(t, bucket.Put(ctx, chunk.Key, strings.NewReader(rawLines.String())))
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks, lines
+}
+
+func getSearchTestLines(lines []LogLine, idxs []int) []LogLine {
+	out := []LogLine{}
+	for _, idx := range idxs {
+		out = append(out, lines[idx])
+	}
+
+	return out
+}
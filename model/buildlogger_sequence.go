@@ -0,0 +1,212 @@
+package model
+
+import (
+	"context"
+
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// maxSequenceUpdateAttempts is the number of times a sequenced append is
+// attempted when the log is concurrently appended to.
+const maxSequenceUpdateAttempts = 5
+
+// LogSequences describes the sequence numbers of the chunks appended to a
+// buildlogger log by clients that number their appends. All sequence numbers
+// up to Last were appended, except for those in Gaps.
+type LogSequences struct {
+	Last int64              `bson:"last,omitempty"`
+	Gaps []LogSequenceRange `bson:"gaps,omitempty"`
+}
+
+var (
+	logSequencesLastKey = bsonutil.MustHaveTag(LogSequences{}, "Last")
+	logSequencesGapsKey = bsonutil.MustHaveTag(LogSequences{}, "Gaps")
+)
+
+// LogSequenceRange describes an inclusive range of sequence numbers.
+type LogSequenceRange struct {
+	Start int64 `bson:"start"`
+	End   int64 `bson:"end"`
+}
+
+// contains returns whether the given sequence number was already appended.
+func (s LogSequences) contains(sequence int64) bool {
+	if sequence > s.Last {
+		return false
+	}
+	for _, gap := range s.Gaps {
+		if sequence >= gap.Start && sequence <= gap.End {
+			return false
+		}
+	}
+
+	return true
+}
+
+// add returns the sequences with the given sequence number appended, which
+// should not already be contained in the sequences.
+func (s LogSequences) add(sequence int64) LogSequences {
+	if sequence > s.Last {
+		added := LogSequences{Last: sequence}
+		added.Gaps = append(added.Gaps, s.Gaps...)
+		if sequence > s.Last+1 {
+			added.Gaps = append(added.Gaps, LogSequenceRange{Start: s.Last + 1, End: sequence - 1})
+		}
+		return added
+	}
+
+	added := LogSequences{Last: s.Last}
+	for _, gap := range s.Gaps {
+		if sequence < gap.Start || sequence > gap.End {
+			added.Gaps = append(added.Gaps, gap)
+			continue
+		}
+		if gap.Start < sequence {
+			added.Gaps = append(added.Gaps, LogSequenceRange{Start: gap.Start, End: sequence - 1})
+		}
+		if sequence < gap.End {
+			added.Gaps = append(added.Gaps, LogSequenceRange{Start: sequence + 1, End: gap.End})
+		}
+	}
+
+	return added
+}
+
+// AppendSequence appends the lines to the log like Append, identified by the
+// given client assigned sequence number, starting at 1. Appending a sequence
+// number that was already appended, for example when a client retries an
+// append that timed out, is a no-op and returns false. Skipped sequence
+// numbers are recorded in the log's metadata as gaps until they are appended.
+// Appending to a closed log is rejected with a LogCompletedError. The log
+// should be found before appending and the environment should not be nil.
+func (l *Log) AppendSequence(ctx context.Context, sequence int64, lines []LogLine) (bool, error) {
+	if l.env == nil {
+		return false, errors.New("cannot not append log lines with a nil environment")
+	}
+	if sequence <= 0 {
+		return false, errors.Errorf("invalid sequence number %d", sequence)
+	}
+	if l.Sequences.contains(sequence) {
+		return false, nil
+	}
+	if !l.CompletedAt.IsZero() {
+		return false, &LogCompletedError{LogID: l.ID}
+	}
+
+	conf, err := l.getConfig()
+	if err != nil {
+		return false, err
+	}
+	redactor, err := conf.Redaction.redactor(l.Info.Project)
+	if err != nil {
+		return false, errors.Wrapf(err, "getting redaction rules of log '%s'", l.ID)
+	}
+	lineBuffer, stats, err := l.bufferLines(lines, redactor)
+	if err != nil {
+		return false, err
+	}
+	if err = l.checkQuota(ctx, conf.Quota, stats); err != nil {
+		return false, err
+	}
+	// Unless the sequence is appended by this call, the reserved usage is
+	// released and the uploaded chunk is discarded. Chunk keys are derived
+	// from the lines, so a replayed append that races with the original
+	// overwrites the same chunk, which is kept once the original records
+	// the sequence.
+	var uploaded, appended bool
+	defer func() {
+		if appended {
+			return
+		}
+		l.releaseUsage(ctx, stats)
+		if uploaded && !l.Sequences.contains(sequence) {
+			l.discardLines(ctx, lines)
+		}
+	}()
+	if len(lines) > 0 {
+		if err = l.uploadLines(ctx, lineBuffer, lines); err != nil {
+			return false, err
+		}
+		uploaded = true
+	}
+
+	maxLogBytes := conf.Quota.GetRule(l.Info.Project).MaxLogBytes
+	for attempt := 1; ; attempt++ {
+		recorded, err := l.updateSequences(ctx, sequence, stats, maxLogBytes)
+		if err != nil {
+			return false, err
+		}
+		if recorded {
+			break
+		}
+		if attempt >= maxSequenceUpdateAttempts {
+			return false, errors.Errorf("log '%s' was concurrently appended to %d times", l.ID, attempt)
+		}
+
+		if err = l.Find(ctx); err != nil {
+			return false, err
+		}
+		if l.Sequences.contains(sequence) {
+			return false, nil
+		}
+		if !l.CompletedAt.IsZero() {
+			return false, &LogCompletedError{LogID: l.ID}
+		}
+		if maxLogBytes > 0 && (l.Truncated || l.Stats.Size+stats.Size > maxLogBytes) {
+			return false, l.rejectLogQuota(ctx, maxLogBytes, stats)
+		}
+	}
+
+	appended = true
+	l.addToStatsCache(lines)
+
+	return true, nil
+}
+
+// updateSequences records the sequence number as appended along with the
+// stats of its lines. No update is made, and false is returned, if the
+// sequences of the log changed since it was found, the log was closed, or
+// the log would exceed the given log size limit, see logSizeQuery.
+func (l *Log) updateSequences(ctx context.Context, sequence int64, stats LogStats, maxLogBytes int64) (bool, error) {
+	lastKey := bsonutil.GetDottedKeyName(logSequencesKey, logSequencesLastKey)
+	gapsKey := bsonutil.GetDottedKeyName(logSequencesKey, logSequencesGapsKey)
+	query := l.logSizeQuery(l.openQuery(), maxLogBytes, stats)
+	if l.Sequences.Last == 0 {
+		query[lastKey] = bson.M{"$exists": false}
+	} else {
+		query[lastKey] = l.Sequences.Last
+	}
+	if len(l.Sequences.Gaps) == 0 {
+		query[bsonutil.GetDottedKeyName(gapsKey, "0")] = bson.M{"$exists": false}
+	} else {
+		query[gapsKey] = l.Sequences.Gaps
+	}
+
+	sequences := l.Sequences.add(sequence)
+	update := stats.update()
+	update["$set"] = bson.M{logSequencesKey: sequences}
+
+	updateResult, err := l.env.GetDB().Collection(buildloggerCollection).UpdateOne(ctx, query, update)
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection":   buildloggerCollection,
+		"id":           l.ID,
+		"sequence":     sequence,
+		"stats":        stats,
+		"updateResult": updateResult,
+		"op":           "update buildlogger log sequences",
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "updating sequences of log '%s'", l.ID)
+	}
+	if updateResult.MatchedCount == 0 {
+		return false, nil
+	}
+
+	l.Sequences = sequences
+
+	return true, nil
+}
@@ -0,0 +1,167 @@
+package model
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/mongodb/grip/level"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogSequences(t *testing.T) {
+	var sequences LogSequences
+	assert.False(t, sequences.contains(1))
+
+	sequences = sequences.add(1)
+	assert.Equal(t, LogSequences{Last: 1}, sequences)
+	assert.True(t, sequences.contains(1))
+
+	sequences = sequences.add(2)
+	assert.Equal(t, LogSequences{Last: 2}, sequences)
+
+	sequences = sequences.add(7)
+	assert.Equal(t, LogSequences{Last: 7, Gaps: []LogSequenceRange{{Start: 3, End: 6}}}, sequences)
+	for _, sequence := range []int64{3, 4, 5, 6, 8} {
+		assert.False(t, sequences.contains(sequence), "%d", sequence)
+	}
+	for _, sequence := range []int64{1, 2, 7} {
+		assert.True(t, sequences.contains(sequence), "%d", sequence)
+	}
+
+	sequences = sequences.add(10)
+	assert.Equal(t, LogSequences{Last: 10, Gaps: []LogSequenceRange{{Start: 3, End: 6}, {Start: 8, End: 9}}}, sequences)
+
+	sequences = sequences.add(4)
+	assert.Equal(t, LogSequences{Last: 10, Gaps: []LogSequenceRange{{Start: 3, End: 3}, {Start: 5, End: 6}, {Start: 8, End: 9}}}, sequences)
+
+	for _, sequence := range []int64{3, 5, 6, 8, 9} {
+		sequences = sequences.add(sequence)
+	}
+	assert.Equal(t, LogSequences{Last: 10}, sequences)
+}
+
+func TestBuildloggerAppendSequence(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir, err := ioutil.TempDir(".", "append-sequence-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+		assert.NoError(t, db.Collection(buildloggerCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(env.GetConfig().DbConfigurationCollection).Drop(ctx))
+	}()
+	conf := &CedarConfig{
+		populated: true,
+		Bucket:    BucketConfig{BuildLogsBucket: tmpDir},
+	}
+	conf.Setup(env)
+	require.NoError(t, conf.Save())
+
+	log := CreateLog(LogInfo{Project: "project", TaskID: "sequence"}, PailLocal)
+	log.Setup(env)
+	require.NoError(t, log.SaveNew(ctx))
+	ts := time.Now().Add(-time.Hour).Round(time.Millisecond).UTC()
+	lines := func(i int) []LogLine {
+		return []LogLine{{Priority: level.Info, Timestamp: ts.Add(time.Duration(i) * time.Second), Data: "line\n"}}
+	}
+	find := func(t *testing.T) *Log {
+		l := &Log{ID: log.ID}
+		l.Setup(env)
+		require.NoError(t, l.Find(ctx))
+		return l
+	}
+
+	t.Run("NoEnv", func(t *testing.T) {
+		l := &Log{ID: log.ID}
+		appended, err := l.AppendSequence(ctx, 1, lines(1))
+		assert.Error(t, err)
+		assert.False(t, appended)
+	})
+	t.Run("InvalidSequence", func(t *testing.T) {
+		l := find(t)
+		appended, err := l.AppendSequence(ctx, 0, lines(1))
+		assert.Error(t, err)
+		assert.False(t, appended)
+	})
+	t.Run("Appends", func(t *testing.T) {
+		l := find(t)
+		appended, err := l.AppendSequence(ctx, 1, lines(1))
+		require.NoError(t, err)
+		assert.True(t, appended)
+
+		l = find(t)
+		assert.Equal(t, LogSequences{Last: 1}, l.Sequences)
+		assert.Equal(t, 1, l.Stats.NumLines)
+	})
+	t.Run("SkipsReplays", func(t *testing.T) {
+		l := find(t)
+		appended, err := l.AppendSequence(ctx, 1, lines(1))
+		require.NoError(t, err)
+		assert.False(t, appended)
+
+		l = find(t)
+		assert.Equal(t, LogSequences{Last: 1}, l.Sequences)
+		assert.Equal(t, 1, l.Stats.NumLines)
+	})
+	t.Run("RecordsGaps", func(t *testing.T) {
+		l := find(t)
+		appended, err := l.AppendSequence(ctx, 4, lines(4))
+		require.NoError(t, err)
+		assert.True(t, appended)
+
+		l = find(t)
+		assert.Equal(t, LogSequences{Last: 4, Gaps: []LogSequenceRange{{Start: 2, End: 3}}}, l.Sequences)
+		assert.Equal(t, 2, l.Stats.NumLines)
+	})
+	t.Run("StaleLog", func(t *testing.T) {
+		stale := find(t)
+		l := find(t)
+		appended, err := l.AppendSequence(ctx, 3, lines(3))
+		require.NoError(t, err)
+		assert.True(t, appended)
+
+		appended, err = stale.AppendSequence(ctx, 3, lines(3))
+		require.NoError(t, err)
+		assert.False(t, appended)
+		appended, err = stale.AppendSequence(ctx, 2, lines(2))
+		require.NoError(t, err)
+		assert.True(t, appended)
+
+		l = find(t)
+		assert.Equal(t, LogSequences{Last: 4}, l.Sequences)
+		assert.Equal(t, 4, l.Stats.NumLines)
+		files, err := ioutil.ReadDir(filepath.Join(tmpDir, log.Artifact.Prefix))
+		require.NoError(t, err)
+		assert.Len(t, files, 4)
+	})
+	t.Run("ClosedConcurrently", func(t *testing.T) {
+		closed := CreateLog(LogInfo{Project: "project", TaskID: "closed"}, PailLocal)
+		closed.Setup(env)
+		require.NoError(t, closed.SaveNew(ctx))
+		stale := &Log{ID: closed.ID}
+		stale.Setup(env)
+		require.NoError(t, stale.Find(ctx))
+		l := &Log{ID: closed.ID}
+		l.Setup(env)
+		require.NoError(t, l.Close(ctx, 0))
+
+		appended, err := stale.AppendSequence(ctx, 1, lines(1))
+		assert.True(t, IsLogCompleted(err))
+		assert.False(t, appended)
+
+		// The chunk of the unrecorded sequence is removed.
+		files, err := ioutil.ReadDir(filepath.Join(tmpDir, closed.Artifact.Prefix))
+		if !os.IsNotExist(err) {
+			require.NoError(t, err)
+			assert.Empty(t, files)
+		}
+	})
+}
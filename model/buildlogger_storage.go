@@ -40,16 +40,38 @@ type LogArtifactInfo struct {
 	Type    PailType `bson:"type"`
 	Prefix  string   `bson:"prefix"`
 	Version int      `bson:"version"`
-	// This field is part of the version 0 LogArtifactInfo model, we are
-	// keeping it for backwards compatibility.
+	// Chunks stores the chunk information of version 0 logs, kept for
+	// backwards compatibility, and the chunk index of version 2 logs,
+	// which is persisted when the log is closed.
 	Chunks []LogChunkInfo `bson:"chunks,omitempty"`
+	// PendingChunks are the keys of compacted chunks that are being
+	// uploaded, readers ignore them until they are swapped in.
+	PendingChunks []string `bson:"pending_chunks,omitempty"`
+	// ReplacedChunks are the chunks merged into compacted chunks, readers
+	// ignore them until they are removed from offline storage.
+	ReplacedChunks []ReplacedLogChunk `bson:"replaced_chunks,omitempty"`
 }
 
 var (
-	logArtifactInfoTypeKey    = bsonutil.MustHaveTag(LogArtifactInfo{}, "Type")
-	logArtifactInfoPrefixKey  = bsonutil.MustHaveTag(LogArtifactInfo{}, "Prefix")
-	logArtifactInfoVersionKey = bsonutil.MustHaveTag(LogArtifactInfo{}, "Version")
-	logArtifactInfoChunksKey  = bsonutil.MustHaveTag(LogArtifactInfo{}, "Chunks")
+	logArtifactInfoTypeKey           = bsonutil.MustHaveTag(LogArtifactInfo{}, "Type")
+	logArtifactInfoPrefixKey         = bsonutil.MustHaveTag(LogArtifactInfo{}, "Prefix")
+	logArtifactInfoVersionKey        = bsonutil.MustHaveTag(LogArtifactInfo{}, "Version")
+	logArtifactInfoChunksKey         = bsonutil.MustHaveTag(LogArtifactInfo{}, "Chunks")
+	logArtifactInfoPendingChunksKey  = bsonutil.MustHaveTag(LogArtifactInfo{}, "PendingChunks")
+	logArtifactInfoReplacedChunksKey = bsonutil.MustHaveTag(LogArtifactInfo{}, "ReplacedChunks")
+)
+
+// ReplacedLogChunk describes a chunk that was merged into a compacted chunk.
+type ReplacedLogChunk struct {
+	Key        string    `bson:"key"`
+	ReplacedBy string    `bson:"replaced_by"`
+	ReplacedAt time.Time `bson:"replaced_at"`
+}
+
+var (
+	replacedLogChunkKeyKey        = bsonutil.MustHaveTag(ReplacedLogChunk{}, "Key")
+	replacedLogChunkReplacedByKey = bsonutil.MustHaveTag(ReplacedLogChunk{}, "ReplacedBy")
+	replacedLogChunkReplacedAtKey = bsonutil.MustHaveTag(ReplacedLogChunk{}, "ReplacedAt")
 )
 
 // LogChunkInfo describes a chunk of log lines stored in pail-backed offline
@@ -0,0 +1,174 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// IsStructured returns whether lines of the log format are structured
+// documents rather than opaque text.
+func (lf LogFormat) IsStructured() bool {
+	return lf == LogFormatJSON || lf == LogFormatBSON
+}
+
+// ValidateLine returns an error if the given line data is malformed for the
+// log format. Lines of unstructured formats are always valid.
+func (lf LogFormat) ValidateLine(data string) error {
+	_, err := lf.normalizeLine(data)
+	return err
+}
+
+// normalizeLine converts a line of the log format into the representation
+// stored in offline storage. Structured lines are stored as single-line JSON
+// documents: JSON lines are compacted and BSON lines are converted to
+// canonical extended JSON so that the type of each field is preserved.
+func (lf LogFormat) normalizeLine(data string) (string, error) {
+	switch lf {
+	case LogFormatJSON:
+		trimmed := bytes.TrimSpace([]byte(data))
+		if len(trimmed) == 0 || trimmed[0] != '{' {
+			return "", errors.New("JSON log line must be an object")
+		}
+		out := &bytes.Buffer{}
+		if err := json.Compact(out, trimmed); err != nil {
+			return "", errors.Wrap(err, "invalid JSON log line")
+		}
+		return out.String(), nil
+	case LogFormatBSON:
+		raw := bson.Raw(data)
+		if err := raw.Validate(); err != nil {
+			return "", errors.Wrap(err, "invalid BSON log line")
+		}
+		out, err := bson.MarshalExtJSON(raw, true, false)
+		if err != nil {
+			return "", errors.Wrap(err, "converting BSON log line to extended JSON")
+		}
+		return string(out), nil
+	default:
+		return data, nil
+	}
+}
+
+// LogFieldFilter describes a predicate on a field of a structured log line.
+type LogFieldFilter struct {
+	// Key is the name of the field. Nested fields are addressed using dot
+	// notation.
+	Key string
+	// Value is the expected value of the field, compared to the string
+	// representation of the field's value.
+	Value string
+}
+
+// ParseLogFieldFilter parses a field filter from a predicate of the form
+// "key=value".
+func ParseLogFieldFilter(predicate string) (LogFieldFilter, error) {
+	parts := strings.SplitN(predicate, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return LogFieldFilter{}, errors.Errorf("invalid field filter '%s', must be of the form 'key=value'", predicate)
+	}
+
+	return LogFieldFilter{Key: parts[0], Value: parts[1]}, nil
+}
+
+// MatchLogFieldFilters returns whether the given log line is a structured
+// document that satisfies all of the filters. Unstructured lines never match
+// a non-empty set of filters.
+func MatchLogFieldFilters(line LogLine, filters []LogFieldFilter) bool {
+	if len(filters) == 0 {
+		return true
+	}
+
+	doc, ok := parseStructuredLogLine(line.Data)
+	if !ok {
+		return false
+	}
+	for _, filter := range filters {
+		val, err := doc.LookupErr(strings.Split(filter.Key, ".")...)
+		if err != nil || rawValueString(val) != filter.Value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseStructuredLogLine parses the stored representation of a structured
+// log line, returning false if the line is not a structured document.
+func parseStructuredLogLine(data string) (bson.Raw, bool) {
+	trimmed := strings.TrimSpace(data)
+	if !strings.HasPrefix(trimmed, "{") {
+		return nil, false
+	}
+
+	var doc bson.Raw
+	if err := bson.UnmarshalExtJSON([]byte(trimmed), false, &doc); err != nil {
+		return nil, false
+	}
+
+	return doc, true
+}
+
+func rawValueString(val bson.RawValue) string {
+	switch val.Type {
+	case bsontype.String:
+		return val.StringValue()
+	case bsontype.Int32:
+		return strconv.FormatInt(int64(val.Int32()), 10)
+	case bsontype.Int64:
+		return strconv.FormatInt(val.Int64(), 10)
+	case bsontype.Double:
+		return strconv.FormatFloat(val.Double(), 'f', -1, 64)
+	case bsontype.Boolean:
+		return strconv.FormatBool(val.Boolean())
+	case bsontype.DateTime:
+		return val.Time().UTC().Format(time.RFC3339Nano)
+	case bsontype.ObjectID:
+		return val.ObjectID().Hex()
+	case bsontype.Null:
+		return "null"
+	default:
+		return val.String()
+	}
+}
+
+// formatNDJSONLogLine formats the log line as a single line JSON document.
+// Structured lines are embedded as documents, all other lines are embedded
+// as strings. When printSource is true, the ID and process name of the
+// line's source log, if any, are added to the document.
+func formatNDJSONLogLine(item LogLine, printSource bool) string {
+	out := struct {
+		LogID       string      `json:"log_id,omitempty"`
+		ProcessName string      `json:"proc_name,omitempty"`
+		Timestamp   string      `json:"ts"`
+		Priority    int         `json:"priority"`
+		Data        interface{} `json:"data"`
+	}{
+		Timestamp: item.Timestamp.UTC().Format(time.RFC3339Nano),
+		Priority:  int(item.Priority),
+		Data:      strings.TrimSuffix(item.Data, "\n"),
+	}
+	if printSource && item.Source != nil {
+		out.LogID = item.Source.LogID
+		out.ProcessName = item.Source.Info.ProcessName
+	}
+	if _, ok := parseStructuredLogLine(item.Data); ok {
+		out.Data = json.RawMessage(strings.TrimSpace(item.Data))
+	}
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		// This should never happen since the embedded document was
+		// already validated, fall back to embedding it as a string.
+		out.Data = strings.TrimSuffix(item.Data, "\n")
+		data, _ = json.Marshal(out)
+	}
+
+	return string(data) + "\n"
+}
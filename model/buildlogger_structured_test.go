@@ -0,0 +1,243 @@
+package model
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/pail"
+	"github.com/mongodb/grip/level"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestLogFormatNormalizeLine(t *testing.T) {
+	bsonLine, err := bson.Marshal(bson.D{{Key: "msg", Value: "hello"}, {Key: "n", Value: int64(5)}})
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		name     string
+		format   LogFormat
+		data     string
+		expected string
+		hasErr   bool
+	}{
+		{
+			name:     "Text",
+			format:   LogFormatText,
+			data:     "{not json",
+			expected: "{not json",
+		},
+		{
+			name:     "Unknown",
+			format:   LogFormatUnknown,
+			data:     "anything",
+			expected: "anything",
+		},
+		{
+			name:     "JSON",
+			format:   LogFormatJSON,
+			data:     "{\"level\": \"error\",\n \"msg\": \"failed\"}\n",
+			expected: `{"level":"error","msg":"failed"}`,
+		},
+		{
+			name:   "MalformedJSON",
+			format: LogFormatJSON,
+			data:   `{"level": "error"`,
+			hasErr: true,
+		},
+		{
+			name:   "JSONArray",
+			format: LogFormatJSON,
+			data:   `["error"]`,
+			hasErr: true,
+		},
+		{
+			name:   "EmptyJSON",
+			format: LogFormatJSON,
+			data:   "",
+			hasErr: true,
+		},
+		{
+			name:     "BSON",
+			format:   LogFormatBSON,
+			data:     string(bsonLine),
+			expected: `{"msg":"hello","n":{"$numberLong":"5"}}`,
+		},
+		{
+			name:   "MalformedBSON",
+			format: LogFormatBSON,
+			data:   string(bsonLine[:len(bsonLine)-2]),
+			hasErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			normalized, err := test.format.normalizeLine(test.data)
+			if test.hasErr {
+				assert.Error(t, err)
+				assert.Error(t, test.format.ValidateLine(test.data))
+				return
+			}
+			require.NoError(t, err)
+			assert.NoError(t, test.format.ValidateLine(test.data))
+			assert.Equal(t, test.expected, normalized)
+			assert.NotContains(t, normalized, "\n")
+		})
+	}
+}
+
+func TestBufferInvalidLogLine(t *testing.T) {
+	l := &Log{ID: "log", Info: LogInfo{Format: LogFormatJSON}}
+	_, _, err := l.bufferLines([]LogLine{
+		{Priority: level.Info, Timestamp: time.Now(), Data: `{"msg":"first"}`},
+		{Priority: level.Info, Timestamp: time.Now(), Data: "second\n"},
+	}, nil)
+	require.Error(t, err)
+	assert.True(t, IsLogLineInvalid(err))
+	assert.Equal(t, 1, err.(*LogLineInvalidError).Line)
+	assert.False(t, IsLogLineInvalid(&LogCompletedError{LogID: l.ID}))
+}
+
+func TestParseLogFieldFilter(t *testing.T) {
+	filter, err := ParseLogFieldFilter("level=error")
+	require.NoError(t, err)
+	assert.Equal(t, LogFieldFilter{Key: "level", Value: "error"}, filter)
+
+	filter, err = ParseLogFieldFilter("attr.query=a=b")
+	require.NoError(t, err)
+	assert.Equal(t, LogFieldFilter{Key: "attr.query", Value: "a=b"}, filter)
+
+	filter, err = ParseLogFieldFilter("component=")
+	require.NoError(t, err)
+	assert.Equal(t, LogFieldFilter{Key: "component"}, filter)
+
+	for _, predicate := range []string{"", "level", "=error"} {
+		_, err = ParseLogFieldFilter(predicate)
+		assert.Error(t, err)
+	}
+}
+
+func TestMatchLogFieldFilters(t *testing.T) {
+	line := LogLine{Data: `{"level":"error","component":"replication","attr":{"attempt":3,"retry":true,"n":{"$numberLong":"7"}}}` + "\n"}
+
+	for _, test := range []struct {
+		name     string
+		line     LogLine
+		filters  []LogFieldFilter
+		expected bool
+	}{
+		{
+			name:     "NoFilters",
+			line:     LogLine{Data: "text line\n"},
+			expected: true,
+		},
+		{
+			name:     "String",
+			line:     line,
+			filters:  []LogFieldFilter{{Key: "level", Value: "error"}},
+			expected: true,
+		},
+		{
+			name:     "MultipleFilters",
+			line:     line,
+			filters:  []LogFieldFilter{{Key: "level", Value: "error"}, {Key: "component", Value: "replication"}},
+			expected: true,
+		},
+		{
+			name:     "NestedFields",
+			line:     line,
+			filters:  []LogFieldFilter{{Key: "attr.attempt", Value: "3"}, {Key: "attr.retry", Value: "true"}, {Key: "attr.n", Value: "7"}},
+			expected: true,
+		},
+		{
+			name:    "Mismatch",
+			line:    line,
+			filters: []LogFieldFilter{{Key: "level", Value: "error"}, {Key: "component", Value: "sharding"}},
+		},
+		{
+			name:    "MissingField",
+			line:    line,
+			filters: []LogFieldFilter{{Key: "attr.missing", Value: "3"}},
+		},
+		{
+			name:    "TextLine",
+			line:    LogLine{Data: "level=error\n"},
+			filters: []LogFieldFilter{{Key: "level", Value: "error"}},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, MatchLogFieldFilters(test.line, test.filters))
+		})
+	}
+}
+
+func TestFormatNDJSONLogLine(t *testing.T) {
+	ts := time.Date(2020, time.January, 2, 3, 4, 5, 6000000, time.UTC)
+	assert.Equal(t,
+		`{"ts":"2020-01-02T03:04:05.006Z","priority":70,"data":{"level":"error","n":1}}`+"\n",
+		formatNDJSONLogLine(LogLine{Priority: level.Error, Timestamp: ts, Data: `{"level":"error","n":1}` + "\n"}, false),
+	)
+	assert.Equal(t,
+		`{"ts":"2020-01-02T03:04:05.006Z","priority":40,"data":"this is \"text\""}`+"\n",
+		formatNDJSONLogLine(LogLine{Priority: level.Info, Timestamp: ts, Data: `this is "text"` + "\n"}, false),
+	)
+}
+
+func TestFieldFilteringLogIterator(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir, err := ioutil.TempDir("", "field-filtering-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: tmpDir})
+	require.NoError(t, err)
+
+	chunks, lines := putSearchTestLog(ctx, t, bucket, []string{
+		`{"level":"info","component":"replication"}`,
+		`{"level":"error","component":"replication"}`,
+		"plain text",
+		`{"level":"error","component":"sharding"}`,
+		`{"level":"error","component":"replication","attempt":2}`,
+	})
+	timeRange := TimeRange{StartAt: chunks[0].Start, EndAt: chunks[len(chunks)-1].End}
+	filters := []LogFieldFilter{{Key: "level", Value: "error"}, {Key: "component", Value: "replication"}}
+
+	t.Run("Forward", func(t *testing.T) {
+		it := NewFieldFilteringLogIterator(NewSerializedLogIterator(bucket, chunks, timeRange), filters)
+		assert.False(t, it.IsReversed())
+		var filtered []LogLine
+		for it.Next(ctx) {
+			filtered = append(filtered, it.Item())
+		}
+		assert.NoError(t, it.Err())
+		assert.True(t, it.Exhausted())
+		assert.NoError(t, it.Close())
+		assert.Equal(t, []LogLine{lines[1], lines[4]}, filtered)
+	})
+	t.Run("Reverse", func(t *testing.T) {
+		it := NewFieldFilteringLogIterator(NewSerializedLogIterator(bucket, chunks, timeRange), filters).Reverse()
+		assert.True(t, it.IsReversed())
+		var filtered []LogLine
+		for it.Next(ctx) {
+			filtered = append(filtered, it.Item())
+		}
+		assert.NoError(t, it.Err())
+		assert.NoError(t, it.Close())
+		assert.Equal(t, []LogLine{lines[4], lines[1]}, filtered)
+	})
+	t.Run("NDJSONReader", func(t *testing.T) {
+		it := NewFieldFilteringLogIterator(NewSerializedLogIterator(bucket, chunks, timeRange), filters)
+		data, err := ioutil.ReadAll(NewLogIteratorReader(ctx, it, LogIteratorReaderOptions{NDJSON: true, PrintTime: true}))
+		require.NoError(t, err)
+		readLines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		require.Len(t, readLines, 2)
+		assert.Equal(t, formatNDJSONLogLine(lines[1], false), readLines[0]+"\n")
+		assert.Equal(t, formatNDJSONLogLine(lines[4], false), readLines[1]+"\n")
+	})
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -196,6 +197,26 @@ func TestBuildloggerRemove(t *testing.T) {
 	})
 }
 
+func TestBuildloggerRemoveArtifacts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.Run("Unpopulated", func(t *testing.T) {
+		l := &Log{ID: "log", Artifact: LogArtifactInfo{Prefix: "log"}}
+		l.Setup(cedar.GetEnvironment())
+		assert.Error(t, l.RemoveArtifacts(ctx))
+	})
+	t.Run("NoEnv", func(t *testing.T) {
+		l := &Log{ID: "log", Artifact: LogArtifactInfo{Prefix: "log"}, populated: true}
+		assert.Error(t, l.RemoveArtifacts(ctx))
+	})
+	t.Run("NoPrefix", func(t *testing.T) {
+		l := &Log{ID: "log", populated: true}
+		l.Setup(cedar.GetEnvironment())
+		assert.Error(t, l.RemoveArtifacts(ctx))
+	})
+}
+
 func TestBuildloggerAppend(t *testing.T) {
 	env := cedar.GetEnvironment()
 	db := env.GetDB()
@@ -309,6 +330,50 @@ func TestBuildloggerAppend(t *testing.T) {
 		assert.True(t, filenames[createBuildloggerChunkKey(chunk1[0].Timestamp, chunk1[len(chunk1)-1].Timestamp, len(chunk1))])
 		assert.True(t, filenames[createBuildloggerChunkKey(chunk2[0].Timestamp, chunk2[len(chunk2)-1].Timestamp, len(chunk2))])
 	})
+	t.Run("Stats", func(t *testing.T) {
+		l := &Log{ID: log.ID}
+		l.Setup(env)
+		require.NoError(t, l.Find(ctx))
+
+		var size int64
+		for _, line := range append(chunk1, chunk2...) {
+			size += int64(len(line.Data))
+		}
+		assert.Equal(t, len(chunk1)+len(chunk2), l.Stats.NumLines)
+		assert.Equal(t, size, l.Stats.Size)
+		assert.Equal(t, map[string]int{"30": 1, "40": 3, "100": 1}, l.Stats.Priorities)
+		assert.True(t, chunk1[0].Timestamp.Equal(l.Stats.FirstLineAt))
+		assert.True(t, chunk2[len(chunk2)-1].Timestamp.Equal(l.Stats.LastLineAt))
+	})
+	t.Run("AppendStructured", func(t *testing.T) {
+		jsonLog := Log{Info: LogInfo{Project: "structured", Format: LogFormatJSON}, populated: true}
+		jsonLog.ID = jsonLog.Info.ID()
+		jsonLog.Artifact = LogArtifactInfo{
+			Type:   PailLocal,
+			Prefix: jsonLog.ID,
+		}
+		_, err = db.Collection(buildloggerCollection).InsertOne(ctx, jsonLog)
+		require.NoError(t, err)
+		jsonLog.Setup(env)
+
+		ts := time.Now().Add(-time.Hour).Round(time.Millisecond).UTC()
+		assert.Error(t, jsonLog.Append(ctx, []LogLine{
+			{Priority: level.Info, Timestamp: ts, Data: `{"msg": "valid"}`},
+			{Priority: level.Info, Timestamp: ts, Data: "not json"},
+		}))
+		require.NoError(t, jsonLog.Append(ctx, []LogLine{
+			{Priority: level.Info, Timestamp: ts, Data: "{\"msg\": \"valid\",\n \"n\": 1}"},
+		}))
+
+		r, err := testBucket.Get(ctx, filepath.Join(jsonLog.ID, createBuildloggerChunkKey(ts, ts, 1)))
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, r.Close())
+		}()
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, prependPriorityAndTimestamp(level.Info, ts, `{"msg":"valid","n":1}`), string(data))
+	})
 }
 
 func TestBuildloggerDownload(t *testing.T) {
@@ -430,6 +495,115 @@ func TestBuildloggerDownload(t *testing.T) {
 	})
 }
 
+func TestBuildloggerFollow(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir, err := ioutil.TempDir(".", "follow-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+		assert.NoError(t, db.Collection(buildloggerCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(env.GetConfig().DbConfigurationCollection).Drop(ctx))
+	}()
+
+	t.Run("NoEnv", func(t *testing.T) {
+		l := Log{ID: "id", populated: true}
+		it, err := l.Follow(ctx, time.Time{}, time.Millisecond)
+		assert.Error(t, err)
+		assert.Nil(t, it)
+	})
+	t.Run("InvalidPollInterval", func(t *testing.T) {
+		l := Log{ID: "id", populated: true}
+		l.Setup(env)
+		it, err := l.Follow(ctx, time.Time{}, 0)
+		assert.Error(t, err)
+		assert.Nil(t, it)
+	})
+	conf := &CedarConfig{
+		populated: true,
+		Bucket:    BucketConfig{BuildLogsBucket: tmpDir},
+	}
+	conf.Setup(env)
+	require.NoError(t, conf.Save())
+	t.Run("StreamsUntilClosed", func(t *testing.T) {
+		log := CreateLog(LogInfo{Project: "project", TaskID: "follow"}, PailLocal)
+		log.Setup(env)
+		require.NoError(t, log.SaveNew(ctx))
+
+		start := time.Now().Add(-time.Hour).Round(time.Millisecond).UTC()
+		var expected []LogLine
+		for i := 0; i < 10; i++ {
+			expected = append(expected, LogLine{
+				Priority:  level.Info,
+				Timestamp: start.Add(time.Duration(i) * time.Second),
+				Data:      fmt.Sprintf("line %d", i),
+			})
+		}
+		require.NoError(t, log.Append(ctx, expected[:5]))
+
+		it, err := log.Follow(ctx, start, 10*time.Millisecond)
+		require.NoError(t, err)
+		for i := 0; i < 5; i++ {
+			require.True(t, it.Next(ctx))
+			assert.Equal(t, expected[i].Data+"\n", it.Item().Data)
+		}
+
+		require.NoError(t, log.Append(ctx, expected[5:]))
+		for i := 5; i < 10; i++ {
+			require.True(t, it.Next(ctx))
+			assert.Equal(t, expected[i].Data+"\n", it.Item().Data)
+		}
+
+		require.NoError(t, log.Close(ctx, 0))
+		assert.False(t, it.Next(ctx))
+		assert.True(t, it.Exhausted())
+		assert.NoError(t, it.Err())
+		assert.NoError(t, it.Close())
+	})
+	t.Run("OutOfOrderChunksStreamedOnClose", func(t *testing.T) {
+		log := CreateLog(LogInfo{Project: "project", TaskID: "follow-out-of-order"}, PailLocal)
+		log.Setup(env)
+		require.NoError(t, log.SaveNew(ctx))
+
+		start := time.Now().Add(-time.Hour).Round(time.Millisecond).UTC()
+		late := []LogLine{{Priority: level.Info, Timestamp: start.Add(time.Minute), Data: "late"}}
+		early := []LogLine{{Priority: level.Info, Timestamp: start, Data: "early"}}
+		require.NoError(t, log.Append(ctx, late))
+
+		it, err := log.Follow(ctx, start, 10*time.Millisecond)
+		require.NoError(t, err)
+		require.True(t, it.Next(ctx))
+		assert.Equal(t, "late\n", it.Item().Data)
+
+		// Chunks starting before the last iterated chunk are not
+		// listed while the log is open, but are in the chunk index
+		// of the closed log.
+		require.NoError(t, log.Append(ctx, early))
+		require.NoError(t, log.Close(ctx, 0))
+		require.True(t, it.Next(ctx))
+		assert.Equal(t, "early\n", it.Item().Data)
+		assert.False(t, it.Next(ctx))
+		assert.NoError(t, it.Err())
+		assert.NoError(t, it.Close())
+	})
+	t.Run("ContextCanceled", func(t *testing.T) {
+		log := CreateLog(LogInfo{Project: "project", TaskID: "follow-canceled"}, PailLocal)
+		log.Setup(env)
+		require.NoError(t, log.SaveNew(ctx))
+
+		tctx, tcancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer tcancel()
+		it, err := log.Follow(tctx, time.Time{}, 10*time.Millisecond)
+		require.NoError(t, err)
+		assert.False(t, it.Next(tctx))
+		assert.False(t, it.Exhausted())
+		assert.Error(t, it.Err())
+		assert.NoError(t, it.Close())
+	})
+}
+
 func TestBuildloggerGetChunks(t *testing.T) {
 	env := cedar.GetEnvironment()
 	db := env.GetDB()
@@ -467,6 +641,13 @@ func TestBuildloggerGetChunks(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, chunks, bucketChunks)
 	})
+	t.Run("ArtifactVersion2", func(t *testing.T) {
+		_, log := getTestLogs(time.Now())
+		log.Artifact.Version = 2
+		chunks, err := log.getChunks(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, log.Artifact.Chunks, chunks)
+	})
 }
 
 func TestBuildloggerClose(t *testing.T) {
@@ -474,12 +655,28 @@ func TestBuildloggerClose(t *testing.T) {
 	db := env.GetDB()
 	ctx, cancel := env.Context()
 	defer cancel()
+	tmpDir, err := ioutil.TempDir(".", "close-test")
+	require.NoError(t, err)
 	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
 		assert.NoError(t, db.Collection(buildloggerCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(env.GetConfig().DbConfigurationCollection).Drop(ctx))
 	}()
+	conf := &CedarConfig{
+		populated: true,
+		Bucket:    BucketConfig{BuildLogsBucket: tmpDir},
+	}
+	conf.Setup(env)
+	require.NoError(t, conf.Save())
 	log1, log2 := getTestLogs(time.Now())
+	log1.CompletedAt = time.Time{}
+	log2.CompletedAt = time.Time{}
+	bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: tmpDir, Prefix: log1.Artifact.Prefix})
+	require.NoError(t, err)
+	chunks, _, err := GenerateTestLog(ctx, bucket, 100, 10)
+	require.NoError(t, err)
 
-	_, err := db.Collection(buildloggerCollection).InsertOne(ctx, log1)
+	_, err = db.Collection(buildloggerCollection).InsertOne(ctx, log1)
 	require.NoError(t, err)
 	_, err = db.Collection(buildloggerCollection).InsertOne(ctx, log2)
 	require.NoError(t, err)
@@ -508,7 +705,10 @@ func TestBuildloggerClose(t *testing.T) {
 		assert.Equal(t, e1, updatedLog.Info.ExitCode)
 		assert.Equal(t, log1.Info.Mainline, updatedLog.Info.Mainline)
 		assert.Equal(t, log1.Info.Schema, updatedLog.Info.Schema)
-		assert.Equal(t, log1.Artifact, updatedLog.Artifact)
+		assert.Equal(t, log1.Artifact.Type, updatedLog.Artifact.Type)
+		assert.Equal(t, log1.Artifact.Prefix, updatedLog.Artifact.Prefix)
+		assert.Equal(t, 2, updatedLog.Artifact.Version)
+		assert.Equal(t, chunks, updatedLog.Artifact.Chunks)
 	})
 	t.Run("WithoutID", func(t *testing.T) {
 		e2 := 9
@@ -527,6 +727,40 @@ func TestBuildloggerClose(t *testing.T) {
 		assert.Equal(t, log2.Info.Schema, updatedLog.Info.Schema)
 		assert.Equal(t, log2.Artifact, updatedLog.Artifact)
 	})
+	t.Run("AlreadyClosed", func(t *testing.T) {
+		l := &Log{ID: log1.ID, populated: true}
+		l.Setup(env)
+		err := l.Close(ctx, 1)
+		assert.True(t, IsLogCompleted(err))
+
+		updatedLog := &Log{}
+		require.NoError(t, db.Collection(buildloggerCollection).FindOne(ctx, bson.M{"_id": log1.ID}).Decode(updatedLog))
+		assert.Equal(t, 0, updatedLog.Info.ExitCode)
+	})
+	t.Run("AppendAfterClose", func(t *testing.T) {
+		l := &Log{ID: log1.ID, populated: true}
+		l.Setup(env)
+		require.NoError(t, l.Find(ctx))
+		err := l.Append(ctx, []LogLine{{Priority: level.Info, Timestamp: time.Now(), Data: "late line"}})
+		assert.True(t, IsLogCompleted(err))
+		_, err = l.AppendSequence(ctx, 1, []LogLine{{Priority: level.Info, Timestamp: time.Now(), Data: "late line"}})
+		assert.True(t, IsLogCompleted(err))
+	})
+	t.Run("AppendRacingClose", func(t *testing.T) {
+		// The log was found while open, but closed before the
+		// append's stats were updated.
+		l := &Log{ID: log1.ID, populated: true}
+		l.Setup(env)
+		require.NoError(t, l.Find(ctx))
+		l.CompletedAt = time.Time{}
+		err := l.Append(ctx, []LogLine{{Priority: level.Info, Timestamp: time.Now(), Data: "late line"}})
+		assert.True(t, IsLogCompleted(err))
+
+		updatedLog := &Log{}
+		require.NoError(t, db.Collection(buildloggerCollection).FindOne(ctx, bson.M{"_id": log1.ID}).Decode(updatedLog))
+		assert.Equal(t, chunks, updatedLog.Artifact.Chunks)
+		assert.Zero(t, updatedLog.Stats.NumLines)
+	})
 }
 
 func TestBuildloggerFindLogs(t *testing.T) {
@@ -918,7 +1152,7 @@ func TestBuildloggerMerge(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, it)
 
-		assert.Equal(t, NewMergingIterator(it1, it2), it)
+		assert.Equal(t, NewMergingIterator(NewSourceLogIterator(it1, log1.Source()), NewSourceLogIterator(it2, log2.Source())), it)
 		assert.NoError(t, it.Close())
 	})
 }
@@ -2,6 +2,7 @@ package model
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/evergreen-ci/cedar"
@@ -36,6 +37,11 @@ type CedarConfig struct {
 	Flags          OperationalFlags          `bson:"flags" json:"flags" yaml:"flags"`
 	Service        ServiceConfig             `bson:"service" json:"service" yaml:"service"`
 	ChangeDetector ChangeDetectorConfig      `bson:"change_detector" json:"change_detector" yaml:"change_detector"`
+	Retention      RetentionConfig           `bson:"retention" json:"retention" yaml:"retention"`
+	Quota          QuotaConfig               `bson:"quota" json:"quota" yaml:"quota"`
+	Redaction      RedactionConfig           `bson:"redaction" json:"redaction" yaml:"redaction"`
+	LogCache       LogCacheConfig            `bson:"log_cache" json:"log_cache" yaml:"log_cache"`
+	FlakyTests     FlakyTestsConfig          `bson:"flaky_tests" json:"flaky_tests" yaml:"flaky_tests"`
 
 	populated bool
 	env       cedar.Environment
@@ -64,6 +70,11 @@ var (
 	cedarConfigurationFlagsKey          = bsonutil.MustHaveTag(CedarConfig{}, "Flags")
 	cedarConfigurationServiceKey        = bsonutil.MustHaveTag(CedarConfig{}, "Service")
 	cedarConfigurationChangeDetectorKey = bsonutil.MustHaveTag(CedarConfig{}, "ChangeDetector")
+	cedarConfigurationRetentionKey      = bsonutil.MustHaveTag(CedarConfig{}, "Retention")
+	cedarConfigurationQuotaKey          = bsonutil.MustHaveTag(CedarConfig{}, "Quota")
+	cedarConfigurationRedactionKey      = bsonutil.MustHaveTag(CedarConfig{}, "Redaction")
+	cedarConfigurationLogCacheKey       = bsonutil.MustHaveTag(CedarConfig{}, "LogCache")
+	cedarConfigurationFlakyTestsKey     = bsonutil.MustHaveTag(CedarConfig{}, "FlakyTests")
 )
 
 type EvergreenConfig struct {
@@ -197,6 +208,9 @@ type BucketConfig struct {
 	BuildLogsBucket       string   `bson:"build_logs_bucket" json:"build_logs_bucket" yaml:"build_logs_bucket"`
 	TestResultsBucket     string   `bson:"test_results_bucket" json:"test_results_bucket" yaml:"test_results_bucket"`
 	TestResultsBucketType PailType `bson:"test_results_bucket_type" json:"test_results_bucket_type" yaml:"test_results_bucket_type"`
+	// BuildLogsBucketType is the bucket type of the buildlogger logs
+	// created by Cedar rather than by clients, such as simple logs.
+	BuildLogsBucketType PailType `bson:"build_logs_bucket_type" json:"build_logs_bucket_type" yaml:"build_logs_bucket_type"`
 
 	PrestoRoleARN           string `bson:"presto_role_arn" json:"presto_role_arn" yaml:"presto_role_arn"`
 	PrestoBucket            string `bson:"presto_bucket" json:"presto_bucket" yaml:"presto_bucket"`
@@ -209,11 +223,161 @@ var (
 	cedarS3BucketConfigBuildLogsBucketKey = bsonutil.MustHaveTag(BucketConfig{}, "BuildLogsBucket")
 )
 
+// RetentionConfig describes how long buildlogger logs and test results are
+// kept before they expire and are deleted.
+type RetentionConfig struct {
+	Rules []RetentionRule `bson:"rules" json:"rules" yaml:"rules"`
+}
+
+var (
+	cedarRetentionConfigRulesKey = bsonutil.MustHaveTag(RetentionConfig{}, "Rules")
+)
+
+// RetentionRule describes the time to live of the data of a project. A rule
+// with no project is the default rule and applies to every project without
+// its own rule. A zero TTL means that the data never expires.
+type RetentionRule struct {
+	Project     string        `bson:"project,omitempty" json:"project,omitempty" yaml:"project,omitempty"`
+	MainlineTTL time.Duration `bson:"mainline_ttl" json:"mainline_ttl" yaml:"mainline_ttl"`
+	PatchTTL    time.Duration `bson:"patch_ttl" json:"patch_ttl" yaml:"patch_ttl"`
+}
+
+var (
+	cedarRetentionRuleProjectKey     = bsonutil.MustHaveTag(RetentionRule{}, "Project")
+	cedarRetentionRuleMainlineTTLKey = bsonutil.MustHaveTag(RetentionRule{}, "MainlineTTL")
+	cedarRetentionRulePatchTTLKey    = bsonutil.MustHaveTag(RetentionRule{}, "PatchTTL")
+)
+
+// QuotaConfig describes the limits on the size of the buildlogger log lines
+// ingested per project.
+type QuotaConfig struct {
+	Rules []QuotaRule `bson:"rules" json:"rules" yaml:"rules"`
+}
+
+var (
+	cedarQuotaConfigRulesKey = bsonutil.MustHaveTag(QuotaConfig{}, "Rules")
+)
+
+// QuotaRule describes the byte limits of the log lines of a project. A rule
+// with no project is the default rule and applies to every project without
+// its own rule. A zero limit means that there is no limit.
+type QuotaRule struct {
+	Project string `bson:"project,omitempty" json:"project,omitempty" yaml:"project,omitempty"`
+	// MaxAppendBytes is the maximum size of the lines of a single append.
+	MaxAppendBytes int64 `bson:"max_append_bytes" json:"max_append_bytes" yaml:"max_append_bytes"`
+	// MaxLogBytes is the maximum size of the lines of a single log, after
+	// which the log is truncated.
+	MaxLogBytes int64 `bson:"max_log_bytes" json:"max_log_bytes" yaml:"max_log_bytes"`
+	// MaxDailyBytes is the maximum size of the lines appended to all of
+	// the project's logs per UTC day.
+	MaxDailyBytes int64 `bson:"max_daily_bytes" json:"max_daily_bytes" yaml:"max_daily_bytes"`
+}
+
+var (
+	cedarQuotaRuleProjectKey        = bsonutil.MustHaveTag(QuotaRule{}, "Project")
+	cedarQuotaRuleMaxAppendBytesKey = bsonutil.MustHaveTag(QuotaRule{}, "MaxAppendBytes")
+	cedarQuotaRuleMaxLogBytesKey    = bsonutil.MustHaveTag(QuotaRule{}, "MaxLogBytes")
+	cedarQuotaRuleMaxDailyBytesKey  = bsonutil.MustHaveTag(QuotaRule{}, "MaxDailyBytes")
+)
+
+// RedactionConfig describes the secrets redacted from buildlogger log lines
+// before they are stored.
+type RedactionConfig struct {
+	Rules []RedactionRule `bson:"rules" json:"rules" yaml:"rules"`
+}
+
+var (
+	cedarRedactionConfigRulesKey = bsonutil.MustHaveTag(RedactionConfig{}, "Rules")
+)
+
+// RedactionRule describes the redaction patterns of a project. The patterns
+// of the rule with no project apply to every project, in addition to the
+// patterns of the project's own rule. The built-in detectors for AWS keys and
+// bearer tokens apply to every project unless disabled by its rule, or by the
+// rule with no project if the project has no rule.
+type RedactionRule struct {
+	Project string `bson:"project,omitempty" json:"project,omitempty" yaml:"project,omitempty"`
+	// Patterns are regular expressions matching secrets. If a pattern has
+	// a capture group named "secret", only the group is redacted rather
+	// than the whole match.
+	Patterns         []string `bson:"patterns" json:"patterns" yaml:"patterns"`
+	DisableDetectors bool     `bson:"disable_detectors" json:"disable_detectors" yaml:"disable_detectors"`
+}
+
+var (
+	cedarRedactionRuleProjectKey          = bsonutil.MustHaveTag(RedactionRule{}, "Project")
+	cedarRedactionRulePatternsKey         = bsonutil.MustHaveTag(RedactionRule{}, "Patterns")
+	cedarRedactionRuleDisableDetectorsKey = bsonutil.MustHaveTag(RedactionRule{}, "DisableDetectors")
+)
+
+// LogCacheConfig describes the in-process cache of the chunk listings and
+// chunk bodies of completed buildlogger logs. MaxSize is the total size, in
+// bytes, of the cached chunk bodies and a zero MaxSize disables the cache.
+// Chunk bodies larger than MaxChunkSize bytes are not cached; a zero
+// MaxChunkSize does not limit the size of a single chunk body. The cache is
+// created with the configuration at the time of its first use.
+type LogCacheConfig struct {
+	MaxSize      int64 `bson:"max_size" json:"max_size" yaml:"max_size"`
+	MaxChunkSize int64 `bson:"max_chunk_size" json:"max_chunk_size" yaml:"max_chunk_size"`
+}
+
+var (
+	cedarLogCacheConfigMaxSizeKey      = bsonutil.MustHaveTag(LogCacheConfig{}, "MaxSize")
+	cedarLogCacheConfigMaxChunkSizeKey = bsonutil.MustHaveTag(LogCacheConfig{}, "MaxChunkSize")
+)
+
+// FlakyTestsConfig describes the detection of flaky tests. Tests are scored
+// with the mainline test history of the last Window and a zero Window
+// disables the detection. Tests with fewer than MinRuns versions in the
+// window are not scored; a zero MinRuns scores every test.
+type FlakyTestsConfig struct {
+	Window  time.Duration `bson:"window" json:"window" yaml:"window"`
+	MinRuns int           `bson:"min_runs" json:"min_runs" yaml:"min_runs"`
+}
+
+var (
+	cedarFlakyTestsConfigWindowKey  = bsonutil.MustHaveTag(FlakyTestsConfig{}, "Window")
+	cedarFlakyTestsConfigMinRunsKey = bsonutil.MustHaveTag(FlakyTestsConfig{}, "MinRuns")
+)
+
 type ServiceConfig struct {
 	AppServers  []string `bson:"app_servers" json:"app_servers" yaml:"app_servers"`
 	CORSOrigins []string `bson:"cors_origins" json:"cors_origins" yaml:"cors_origins"`
 }
 
+// Validate ensures that the buildlogger quota, redaction, and retention rules
+// are valid.
+func (c *CedarConfig) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.Wrap(c.Quota.Validate(), "invalid quota configuration")
+	catcher.Wrap(c.Redaction.Validate(), "invalid redaction configuration")
+	catcher.Wrap(c.Retention.Validate(), "invalid retention configuration")
+
+	return catcher.Resolve()
+}
+
+// invalidCedarConfigWarnings are the validation errors of the loaded
+// configurations that were already logged.
+var invalidCedarConfigWarnings sync.Map
+
+// warnIfInvalid logs the validation errors of a loaded configuration, once per
+// distinct error, since the configuration is loaded on every use when the
+// environment cache is disabled.
+func (c *CedarConfig) warnIfInvalid() {
+	err := c.Validate()
+	if err == nil {
+		return
+	}
+	if _, warned := invalidCedarConfigWarnings.LoadOrStore(err.Error(), true); warned {
+		return
+	}
+
+	grip.Warning(message.WrapError(err, message.Fields{
+		"message": "invalid application configuration",
+		"id":      cedarConfigurationID,
+	}))
+}
+
 func (c *CedarConfig) Setup(e cedar.Environment) { c.env = e }
 func (c *CedarConfig) IsNil() bool               { return !c.populated }
 func (c *CedarConfig) Find() error {
@@ -270,6 +434,7 @@ func (c *CedarConfig) find(ctx context.Context) error {
 
 	c.populated = true
 	c.Flags.env = c.env
+	c.warnIfInvalid()
 
 	return nil
 }
@@ -319,6 +484,7 @@ func (c *CedarConfig) createConfigWatcher(ctx context.Context) (chan interface{}
 				return
 			}
 
+			out.FullDocument.warnIfInvalid()
 			select {
 			case updates <- *out.FullDocument:
 			case <-ctx.Done():
@@ -343,6 +509,9 @@ func (c *CedarConfig) Save() error {
 	if c.env == nil {
 		return errors.New("cannot save Cedar configuration with a nil environment")
 	}
+	if err := c.Validate(); err != nil {
+		return errors.Wrap(err, "validating Cedar configuration")
+	}
 
 	ctx, cancel := c.env.Context()
 	defer cancel()
@@ -374,7 +543,9 @@ func LoadCedarConfig(file string) (*CedarConfig, error) {
 		return nil, errors.WithStack(err)
 	}
 
-	// TODO: validate here (?)
+	if err := newConfig.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "validating Cedar configuration file '%s'", file)
+	}
 
 	newConfig.populated = true
 
@@ -2,6 +2,8 @@ package model
 
 import (
 	"context"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -95,6 +97,15 @@ func TestCedarConfig(t *testing.T) {
 			assert.Error(t, err)
 			assert.Contains(t, err.Error(), "saving application")
 		},
+		"SaveErrorsWithInvalidConfig": func(ctx context.Context, t *testing.T, env cedar.Environment, conf *CedarConfig) {
+			conf.Redaction.Rules = []RedactionRule{{Project: "project", Patterns: []string{`(`}}}
+			conf.populated = true
+			conf.Setup(env)
+			err := conf.Save()
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "validating")
+			assert.True(t, db.ResultsNotFound(conf.Find()))
+		},
 		"SaveErrorsWithNoEnvConfigured": func(ctx context.Context, t *testing.T, env cedar.Environment, conf *CedarConfig) {
 			conf.populated = true
 			err := conf.Save()
@@ -136,6 +147,36 @@ func TestCedarConfig(t *testing.T) {
 	require.NoError(t, session.DB(conf.DatabaseName).DropDatabase())
 }
 
+func TestCedarConfigValidate(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		conf := &CedarConfig{
+			Quota:     QuotaConfig{Rules: []QuotaRule{{Project: "project", MaxLogBytes: 1024}}},
+			Redaction: RedactionConfig{Rules: []RedactionRule{{Project: "project", Patterns: []string{`token=\S+`}}}},
+			Retention: RetentionConfig{Rules: []RetentionRule{{Project: "project", PatchTTL: time.Hour}}},
+		}
+		assert.NoError(t, conf.Validate())
+	})
+	t.Run("InvalidQuota", func(t *testing.T) {
+		conf := &CedarConfig{Quota: QuotaConfig{Rules: []QuotaRule{{Project: "project", MaxLogBytes: -1}}}}
+		assert.Error(t, conf.Validate())
+	})
+	t.Run("InvalidRedaction", func(t *testing.T) {
+		conf := &CedarConfig{Redaction: RedactionConfig{Rules: []RedactionRule{{Project: "project", Patterns: []string{`(`}}}}}
+		assert.Error(t, conf.Validate())
+	})
+	t.Run("InvalidRetention", func(t *testing.T) {
+		conf := &CedarConfig{Retention: RetentionConfig{Rules: []RetentionRule{{Project: "project"}, {Project: "project"}}}}
+		assert.Error(t, conf.Validate())
+	})
+	t.Run("LoadInvalidFile", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "cedar.yaml")
+		require.NoError(t, ioutil.WriteFile(file, []byte("redaction:\n  rules:\n    - patterns: [\"(\"]\n"), 0644))
+		conf, err := LoadCedarConfig(file)
+		assert.Error(t, err)
+		assert.Nil(t, conf)
+	})
+}
+
 func TestCachedConfig(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
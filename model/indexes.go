@@ -78,6 +78,30 @@ func GetRequiredIndexes() []SystemIndexes {
 			},
 			Collection: buildloggerCollection,
 		},
+		{
+			Keys: bson.D{
+				{Key: bsonutil.GetDottedKeyName(logInfoKey, logInfoProjectKey), Value: 1},
+				{Key: bsonutil.GetDottedKeyName(logInfoKey, logInfoMainlineKey), Value: 1},
+				{Key: logCreatedAtKey, Value: 1},
+			},
+			Collection: buildloggerCollection,
+		},
+		{
+			Keys: bson.D{
+				{Key: bsonutil.GetDottedKeyName(logInfoKey, logInfoProjectKey), Value: 1},
+				{Key: bsonutil.GetDottedKeyName(logFailureSignaturesKey, logFailureSignatureIDKey), Value: 1},
+				{Key: logCreatedAtKey, Value: -1},
+			},
+			Options: bson.D{
+				{
+					Key: "partialFilterExpression",
+					Value: bson.M{
+						logFailureSignaturesKey: bson.M{"$exists": true},
+					},
+				},
+			},
+			Collection: buildloggerCollection,
+		},
 		{
 			Keys: bson.D{
 				{Key: bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoTaskIDKey), Value: 1},
@@ -100,6 +124,50 @@ func GetRequiredIndexes() []SystemIndexes {
 			},
 			Collection: testResultsCollection,
 		},
+		{
+			Keys: bson.D{
+				{Key: bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoProjectKey), Value: 1},
+				{Key: bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoMainlineKey), Value: 1},
+				{Key: testResultsCreatedAtKey, Value: 1},
+			},
+			Collection: testResultsCollection,
+		},
+		{
+			Keys: bson.D{
+				{Key: testHistoryEntryProjectKey, Value: 1},
+				{Key: testHistoryEntryTestNameKey, Value: 1},
+				{Key: testHistoryEntryVariantKey, Value: 1},
+				{Key: testHistoryEntryTaskNameKey, Value: 1},
+				{Key: testHistoryEntryCreatedAtKey, Value: -1},
+			},
+			Collection: testHistoryCollection,
+		},
+		{
+			Keys: bson.D{
+				{Key: testHistoryEntryProjectKey, Value: 1},
+				{Key: testHistoryEntryTestNameKey, Value: 1},
+				{Key: testHistoryEntryCreatedAtKey, Value: -1},
+			},
+			Collection: testHistoryCollection,
+		},
+		{
+			Keys:       bson.D{{Key: testHistoryEntryTestResultsIDKey, Value: 1}},
+			Collection: testHistoryCollection,
+		},
+		{
+			Keys: bson.D{
+				{Key: testHistoryEntryMainlineKey, Value: 1},
+				{Key: testHistoryEntryCreatedAtKey, Value: 1},
+			},
+			Collection: testHistoryCollection,
+		},
+		{
+			Keys: bson.D{
+				{Key: flakyTestProjectKey, Value: 1},
+				{Key: flakyTestScoreKey, Value: -1},
+			},
+			Collection: flakyTestsCollection,
+		},
 		{
 			Keys:       bson.D{{Key: dbUserAPIKeyKey, Value: 1}},
 			Collection: userCollection,
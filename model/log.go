@@ -20,6 +20,9 @@ type LogRecord struct {
 	Bucket      string `bson:"bucket"`
 	KeyName     string `bson:"key"`
 	Metadata    `bson:"metadata"`
+	// Migrated indicates that the merged segments were copied into the
+	// buildlogger log storing the simple log, see MigrateSimpleLog.
+	Migrated bool `bson:"migrated,omitempty"`
 
 	populated bool
 	env       cedar.Environment
@@ -31,6 +34,7 @@ var (
 	logRecordKeyNameKey      = bsonutil.MustHaveTag(LogRecord{}, "KeyName")
 	logRecordLastSegementKey = bsonutil.MustHaveTag(LogRecord{}, "LastSegment")
 	logRecordMetadataKey     = bsonutil.MustHaveTag(LogRecord{}, "Metadata")
+	logRecordMigratedKey     = bsonutil.MustHaveTag(LogRecord{}, "Migrated")
 )
 
 func (l *LogRecord) Setup(e cedar.Environment) { l.env = e }
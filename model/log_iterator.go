@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -501,6 +502,407 @@ func (i *mergingIterator) Close() error {
 	return catcher.Resolve()
 }
 
+///////////////////
+// Following Iterator
+///////////////////
+
+type followingIterator struct {
+	log          *Log
+	bucket       pail.Bucket
+	startAt      time.Time
+	pollInterval time.Duration
+	seenChunks   map[string]bool
+	lastKey      string
+	current      LogIterator
+	currentItem  LogLine
+	catcher      grip.Catcher
+	completed    bool
+	exhausted    bool
+	closed       bool
+}
+
+func newFollowingLogIterator(log *Log, bucket pail.Bucket, startAt time.Time, pollInterval time.Duration) LogIterator {
+	return &followingIterator{
+		log:          log,
+		bucket:       bucket,
+		startAt:      startAt,
+		pollInterval: pollInterval,
+		seenChunks:   map[string]bool{},
+		catcher:      grip.NewBasicCatcher(),
+	}
+}
+
+// Reverse is a no-op for following iterators since the end of the log is not
+// known until the log is closed, the iterator itself is returned.
+func (i *followingIterator) Reverse() LogIterator { return i }
+
+func (i *followingIterator) IsReversed() bool { return false }
+
+func (i *followingIterator) Next(ctx context.Context) bool {
+	if i.closed || i.exhausted {
+		return false
+	}
+
+	for {
+		if i.current != nil {
+			if i.current.Next(ctx) {
+				i.currentItem = i.current.Item()
+				return true
+			}
+
+			i.catcher.Add(i.current.Err())
+			i.catcher.Add(i.current.Close())
+			i.current = nil
+			if i.catcher.HasErrors() {
+				return false
+			}
+		}
+
+		if i.completed {
+			i.exhausted = true
+			return false
+		}
+
+		found, err := i.refresh(ctx)
+		if err != nil {
+			i.catcher.Add(err)
+			return false
+		}
+		if found || i.completed {
+			continue
+		}
+
+		timer := time.NewTimer(i.pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			i.catcher.Add(ctx.Err())
+			return false
+		case <-timer.C:
+		}
+	}
+}
+
+// refresh looks for chunks that have not yet been iterated. While the log is
+// open, only the chunks listed after the last iterated chunk are considered
+// and the log is only found again, to check whether it has been closed, once
+// there are no such chunks. The chunk index of a closed log is complete, so
+// any chunks not yet iterated are found then.
+func (i *followingIterator) refresh(ctx context.Context) (bool, error) {
+	if i.log.Artifact.Version == 1 {
+		chunks, err := i.log.listChunks(ctx, i.bucket, i.lastKey)
+		if err != nil {
+			return false, errors.Wrap(err, "listing chunks")
+		}
+		if i.setCurrent(i.unseenChunks(chunks)) {
+			return true, nil
+		}
+	}
+
+	log := &Log{ID: i.log.ID}
+	log.Setup(i.log.env)
+	if err := log.Find(ctx); err != nil {
+		return false, errors.Wrap(err, "refreshing log")
+	}
+	i.completed = !log.CompletedAt.IsZero()
+	if !i.completed && log.Artifact.Version == 1 {
+		return false, nil
+	}
+
+	chunks, err := log.getChunks(ctx, i.bucket)
+	if err != nil {
+		return false, errors.Wrap(err, "getting chunks")
+	}
+
+	var newChunks []LogChunkInfo
+	for _, chunk := range chunks {
+		if i.seenChunks[chunk.Key] {
+			continue
+		}
+		i.seenChunks[chunk.Key] = true
+
+		// If some of the chunks merged into a compacted chunk were
+		// already iterated, iterate the remaining merged chunks
+		// instead, they are kept in offline storage for a grace
+		// period after the swap.
+		replacedKeys := log.Artifact.replacedChunkKeys(chunk.Key)
+		var seenReplaced bool
+		for _, key := range replacedKeys {
+			seenReplaced = seenReplaced || i.seenChunks[key]
+		}
+		if !seenReplaced {
+			for _, key := range replacedKeys {
+				i.seenChunks[key] = true
+			}
+			newChunks = append(newChunks, chunk)
+			continue
+		}
+		for _, key := range replacedKeys {
+			if i.seenChunks[key] {
+				continue
+			}
+			i.seenChunks[key] = true
+			replaced, err := parseBuildloggerChunkKey(key)
+			if err != nil {
+				return false, errors.Wrapf(err, "parsing chunk key '%s'", key)
+			}
+			newChunks = append(newChunks, replaced)
+		}
+	}
+
+	return i.setCurrent(newChunks), nil
+}
+
+// unseenChunks returns the given chunks that have not yet been iterated,
+// marking them as seen.
+func (i *followingIterator) unseenChunks(chunks []LogChunkInfo) []LogChunkInfo {
+	var newChunks []LogChunkInfo
+	for _, chunk := range chunks {
+		if i.seenChunks[chunk.Key] {
+			continue
+		}
+		i.seenChunks[chunk.Key] = true
+		newChunks = append(newChunks, chunk)
+	}
+
+	return newChunks
+}
+
+// setCurrent sets the current iterator to iterate the given chunks, sorted by
+// start time. Returns false if there are no chunks.
+func (i *followingIterator) setCurrent(chunks []LogChunkInfo) bool {
+	if len(chunks) == 0 {
+		return false
+	}
+	sort.SliceStable(chunks, func(i, j int) bool {
+		return chunks[i].Start.Before(chunks[j].Start)
+	})
+	for _, chunk := range chunks {
+		if chunk.Key > i.lastKey {
+			i.lastKey = chunk.Key
+		}
+	}
+	i.current = NewSerializedLogIterator(i.bucket, chunks, TimeRange{StartAt: i.startAt, EndAt: utility.MaxTime})
+
+	return true
+}
+
+func (i *followingIterator) Exhausted() bool { return i.exhausted }
+
+func (i *followingIterator) Err() error { return i.catcher.Resolve() }
+
+func (i *followingIterator) Item() LogLine { return i.currentItem }
+
+func (i *followingIterator) Close() error {
+	i.closed = true
+	if i.current != nil {
+		return i.current.Close()
+	}
+
+	return nil
+}
+
+///////////////////
+// Line Offset Iterator
+///////////////////
+
+// LineNumberedLogIterator is a LogIterator that tracks the line number of its
+// current item.
+type LineNumberedLogIterator interface {
+	LogIterator
+	// LineNumber returns the zero-based line number, within the log, of
+	// the current LogLine item held by the iterator.
+	LineNumber() int
+}
+
+type lineOffsetIterator struct {
+	it         LogIterator
+	skip       int
+	lineNumber int
+}
+
+// NewLineOffsetLogIterator returns a LineNumberedLogIterator over the lines of
+// the given chunks starting at the given zero-based line number. The chunks
+// must be sorted, chunks entirely before the line are skipped without being
+// downloaded.
+func NewLineOffsetLogIterator(bucket pail.Bucket, chunks []LogChunkInfo, fromLine int) LineNumberedLogIterator {
+	skip := fromLine
+	for len(chunks) > 0 && chunks[0].NumLines <= skip {
+		skip -= chunks[0].NumLines
+		chunks = chunks[1:]
+	}
+
+	return &lineOffsetIterator{
+		it:         NewBatchedLogIterator(bucket, chunks, 2, TimeRange{EndAt: utility.MaxTime}),
+		skip:       skip,
+		lineNumber: fromLine - 1,
+	}
+}
+
+// Reverse is a no-op for line offset iterators since lines are addressed from
+// the start of the log, the iterator itself is returned.
+func (i *lineOffsetIterator) Reverse() LogIterator { return i }
+
+func (i *lineOffsetIterator) IsReversed() bool { return false }
+
+func (i *lineOffsetIterator) Next(ctx context.Context) bool {
+	for ; i.skip > 0; i.skip-- {
+		if !i.it.Next(ctx) {
+			return false
+		}
+	}
+
+	if !i.it.Next(ctx) {
+		return false
+	}
+	i.lineNumber++
+
+	return true
+}
+
+func (i *lineOffsetIterator) LineNumber() int { return i.lineNumber }
+
+func (i *lineOffsetIterator) Exhausted() bool { return i.it.Exhausted() }
+
+func (i *lineOffsetIterator) Err() error { return i.it.Err() }
+
+func (i *lineOffsetIterator) Item() LogLine { return i.it.Item() }
+
+func (i *lineOffsetIterator) Close() error { return i.it.Close() }
+
+///////////////////
+// Filtering Iterator
+///////////////////
+
+type fieldFilteringIterator struct {
+	it      LogIterator
+	filters []LogFieldFilter
+}
+
+// NewFieldFilteringLogIterator returns a LogIterator that only iterates over
+// the structured lines of the given iterator that match all of the given
+// field filters.
+func NewFieldFilteringLogIterator(it LogIterator, filters []LogFieldFilter) LogIterator {
+	return &fieldFilteringIterator{
+		it:      it,
+		filters: filters,
+	}
+}
+
+func (i *fieldFilteringIterator) Reverse() LogIterator {
+	return &fieldFilteringIterator{
+		it:      i.it.Reverse(),
+		filters: i.filters,
+	}
+}
+
+func (i *fieldFilteringIterator) IsReversed() bool { return i.it.IsReversed() }
+
+func (i *fieldFilteringIterator) Next(ctx context.Context) bool {
+	for i.it.Next(ctx) {
+		if MatchLogFieldFilters(i.it.Item(), i.filters) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (i *fieldFilteringIterator) Exhausted() bool { return i.it.Exhausted() }
+
+func (i *fieldFilteringIterator) Err() error { return i.it.Err() }
+
+func (i *fieldFilteringIterator) Item() LogLine { return i.it.Item() }
+
+func (i *fieldFilteringIterator) Close() error { return i.it.Close() }
+
+type priorityFilteringIterator struct {
+	it          LogIterator
+	minPriority level.Priority
+	maxPriority level.Priority
+}
+
+// NewPriorityFilteringLogIterator returns a LogIterator that only iterates
+// over the lines of the given iterator with a priority within the given
+// inclusive bounds. A zero bound is ignored.
+func NewPriorityFilteringLogIterator(it LogIterator, minPriority, maxPriority level.Priority) LogIterator {
+	return &priorityFilteringIterator{
+		it:          it,
+		minPriority: minPriority,
+		maxPriority: maxPriority,
+	}
+}
+
+func (i *priorityFilteringIterator) Reverse() LogIterator {
+	return &priorityFilteringIterator{
+		it:          i.it.Reverse(),
+		minPriority: i.minPriority,
+		maxPriority: i.maxPriority,
+	}
+}
+
+func (i *priorityFilteringIterator) IsReversed() bool { return i.it.IsReversed() }
+
+func (i *priorityFilteringIterator) Next(ctx context.Context) bool {
+	for i.it.Next(ctx) {
+		if matchPriority(i.it.Item().Priority, i.minPriority, i.maxPriority) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (i *priorityFilteringIterator) Exhausted() bool { return i.it.Exhausted() }
+
+func (i *priorityFilteringIterator) Err() error { return i.it.Err() }
+
+func (i *priorityFilteringIterator) Item() LogLine { return i.it.Item() }
+
+func (i *priorityFilteringIterator) Close() error { return i.it.Close() }
+
+////////////////////
+// Source Iterator
+////////////////////
+
+type sourceIterator struct {
+	it     LogIterator
+	source *LogSource
+}
+
+// NewSourceLogIterator returns a LogIterator that attributes each line of
+// the given iterator to the given source log, see LogLine.Source.
+func NewSourceLogIterator(it LogIterator, source LogSource) LogIterator {
+	return &sourceIterator{
+		it:     it,
+		source: &source,
+	}
+}
+
+func (i *sourceIterator) Reverse() LogIterator {
+	return &sourceIterator{
+		it:     i.it.Reverse(),
+		source: i.source,
+	}
+}
+
+func (i *sourceIterator) IsReversed() bool { return i.it.IsReversed() }
+
+func (i *sourceIterator) Next(ctx context.Context) bool { return i.it.Next(ctx) }
+
+func (i *sourceIterator) Exhausted() bool { return i.it.Exhausted() }
+
+func (i *sourceIterator) Err() error { return i.it.Err() }
+
+func (i *sourceIterator) Item() LogLine {
+	item := i.it.Item()
+	item.Source = i.source
+
+	return item
+}
+
+func (i *sourceIterator) Close() error { return i.it.Close() }
+
 ///////////////////
 // Helper functions
 ///////////////////
@@ -526,6 +928,10 @@ func prependPriorityAndTimestamp(p level.Priority, t time.Time, data string) str
 	return fmt.Sprintf("%3d%20d%s\n", p, utility.UnixMilli(t), data)
 }
 
+func matchPriority(p, minPriority, maxPriority level.Priority) bool {
+	return (minPriority <= 0 || p >= minPriority) && (maxPriority <= 0 || p <= maxPriority)
+}
+
 func filterChunks(timeRange TimeRange, chunks []LogChunkInfo) []LogChunkInfo {
 	filteredChunks := []LogChunkInfo{}
 	for i := 0; i < len(chunks); i++ {
@@ -634,11 +1040,55 @@ type LogIteratorReaderOptions struct {
 	// also reading every line for each timestamp reached. If TailN is set,
 	// this will be ignored.
 	SoftSizeLimit int
+	// NDJSON, when true, prints each log line as a newline delimited JSON
+	// document with the timestamp, priority, and data of the line:
+	//		{"ts":"2006-01-02T15:04:05.000Z","priority":30,"data":{"msg":"This is a log line."}}
+	// Structured lines are embedded as documents and all other lines as
+	// strings. If set, PrintTime and PrintPriority are ignored.
+	NDJSON bool
+	// PrintSource, when true, prints the source log of each log line, see
+	// LogLine.Source, along with the line in the following format:
+	//		[proc_name/test_name] This is a log line.
+	// The source is printed before the priority and timestamp. When
+	// NDJSON is set, the ID and process name of the source log are added
+	// to each document instead:
+	//		{"log_id":"abc","proc_name":"mongod","ts":"2006-01-02T15:04:05.000Z","priority":30,"data":"This is a log line."}
+	// Lines without a source are printed as is.
+	PrintSource bool
+	// SplitTimestamps, when true, allows SoftSizeLimit to split the lines
+	// sharing a timestamp across pages. This should only be used when
+	// pages are addressed by line number rather than by timestamp.
+	SplitTimestamps bool
+	// MinPriority and MaxPriority, when greater than 0, are the inclusive
+	// bounds of the priority of the lines read from the log. Lines are
+	// filtered before Limit, TailN, and SoftSizeLimit are applied.
+	MinPriority level.Priority
+	MaxPriority level.Priority
+	// Follow, when true, returns a reader that makes each log line
+	// available as soon as it is iterated rather than waiting to fill the
+	// read buffer. This should be used with iterators returned by
+	// Log.Follow. If set, TailN, Limit, and SoftSizeLimit are ignored.
+	Follow bool
 }
 
 // NewLogIteratorReader returns an io.Reader that reads the log lines from the
 // log iterator.
 func NewLogIteratorReader(ctx context.Context, it LogIterator, opts LogIteratorReaderOptions) io.Reader {
+	if opts.MinPriority > 0 || opts.MaxPriority > 0 {
+		it = NewPriorityFilteringLogIterator(it, opts.MinPriority, opts.MaxPriority)
+	}
+
+	if opts.Follow {
+		return &logIteratorFollowReader{
+			ctx:           ctx,
+			it:            it,
+			printTime:     opts.PrintTime,
+			printPriority: opts.PrintPriority,
+			printSource:   opts.PrintSource,
+			ndjson:        opts.NDJSON,
+		}
+	}
+
 	if opts.TailN > 0 {
 		if !it.IsReversed() {
 			it = it.Reverse()
@@ -650,30 +1100,38 @@ func NewLogIteratorReader(ctx context.Context, it LogIterator, opts LogIteratorR
 			n:             opts.TailN,
 			printTime:     opts.PrintTime,
 			printPriority: opts.PrintPriority,
+			printSource:   opts.PrintSource,
+			ndjson:        opts.NDJSON,
 		}
 	}
 
 	return &logIteratorReader{
-		ctx:           ctx,
-		it:            it,
-		limit:         opts.Limit,
-		printTime:     opts.PrintTime,
-		printPriority: opts.PrintPriority,
-		softSizeLimit: opts.SoftSizeLimit,
+		ctx:             ctx,
+		it:              it,
+		limit:           opts.Limit,
+		printTime:       opts.PrintTime,
+		printPriority:   opts.PrintPriority,
+		printSource:     opts.PrintSource,
+		ndjson:          opts.NDJSON,
+		softSizeLimit:   opts.SoftSizeLimit,
+		splitTimestamps: opts.SplitTimestamps,
 	}
 }
 
 type logIteratorReader struct {
-	ctx            context.Context
-	it             LogIterator
-	lineCount      int
-	limit          int
-	leftOver       []byte
-	printTime      bool
-	printPriority  bool
-	softSizeLimit  int
-	totalBytesRead int
-	lastItem       LogLine
+	ctx             context.Context
+	it              LogIterator
+	lineCount       int
+	limit           int
+	leftOver        []byte
+	printTime       bool
+	printPriority   bool
+	printSource     bool
+	ndjson          bool
+	softSizeLimit   int
+	splitTimestamps bool
+	totalBytesRead  int
+	lastItem        LogLine
 }
 
 func (r *logIteratorReader) Read(p []byte) (int, error) {
@@ -693,19 +1151,12 @@ func (r *logIteratorReader) Read(p []byte) (int, error) {
 		if r.limit > 0 && r.lineCount > r.limit {
 			break
 		}
-		if r.softSizeLimit > 0 && r.totalBytesRead >= r.softSizeLimit && !r.lastItem.Timestamp.Equal(r.it.Item().Timestamp) {
+		if r.softSizeLimit > 0 && r.totalBytesRead >= r.softSizeLimit && (r.splitTimestamps || !r.lastItem.Timestamp.Equal(r.it.Item().Timestamp)) {
 			break
 		}
 
 		r.lastItem = r.it.Item()
-		data := r.it.Item().Data
-		if r.printTime {
-			data = fmt.Sprintf("[%s] %s",
-				r.it.Item().Timestamp.Format("2006/01/02 15:04:05.000"), data)
-		}
-		if r.printPriority {
-			data = fmt.Sprintf("[P:%3d] %s", r.it.Item().Priority, data)
-		}
+		data := formatLogLine(r.it.Item(), r.printTime, r.printPriority, r.printSource, r.ndjson)
 		n = r.writeToBuffer([]byte(data), p, n)
 		if n == len(p) {
 			return n, nil
@@ -745,6 +1196,8 @@ type logIteratorTailReader struct {
 	n             int
 	printTime     bool
 	printPriority bool
+	printSource   bool
+	ndjson        bool
 	r             io.Reader
 }
 
@@ -765,14 +1218,7 @@ func (r *logIteratorTailReader) Read(p []byte) (int, error) {
 func (r *logIteratorTailReader) getReader() error {
 	var lines string
 	for i := 0; i < r.n && r.it.Next(r.ctx); i++ {
-		data := r.it.Item().Data
-		if r.printTime {
-			data = fmt.Sprintf("[%s] %s",
-				r.it.Item().Timestamp.Format("2006/01/02 15:04:05.000"), data)
-		}
-		if r.printPriority {
-			data = fmt.Sprintf("[P:%3d] %s", r.it.Item().Priority, data)
-		}
+		data := formatLogLine(r.it.Item(), r.printTime, r.printPriority, r.printSource, r.ndjson)
 		lines = data + lines
 	}
 
@@ -785,6 +1231,104 @@ func (r *logIteratorTailReader) getReader() error {
 	return catcher.Resolve()
 }
 
+type logIteratorFollowReader struct {
+	ctx           context.Context
+	it            LogIterator
+	printTime     bool
+	printPriority bool
+	printSource   bool
+	ndjson        bool
+	leftOver      []byte
+	done          bool
+}
+
+func (r *logIteratorFollowReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if r.leftOver == nil {
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.leftOver)
+	r.leftOver = r.leftOver[n:]
+	if len(r.leftOver) == 0 {
+		r.leftOver = nil
+	}
+
+	return n, nil
+}
+
+// WriteTo writes each log line to the given writer as soon as it is
+// iterated, flushing the writer after each line if it supports flushing.
+func (r *logIteratorFollowReader) WriteTo(w io.Writer) (int64, error) {
+	flusher, canFlush := w.(interface{ Flush() })
+
+	var total int64
+	for {
+		if r.leftOver == nil {
+			if err := r.next(); err == io.EOF {
+				return total, nil
+			} else if err != nil {
+				return total, err
+			}
+		}
+
+		n, err := w.Write(r.leftOver)
+		total += int64(n)
+		r.leftOver = nil
+		if err != nil {
+			return total, errors.Wrap(err, "writing log line")
+		}
+		if canFlush {
+			flusher.Flush()
+		}
+	}
+}
+
+func (r *logIteratorFollowReader) next() error {
+	if r.done {
+		return io.EOF
+	}
+
+	if r.it.Next(r.ctx) {
+		r.leftOver = []byte(formatLogLine(r.it.Item(), r.printTime, r.printPriority, r.printSource, r.ndjson))
+		return nil
+	}
+
+	r.done = true
+	catcher := grip.NewBasicCatcher()
+	catcher.Add(r.it.Err())
+	catcher.Add(r.it.Close())
+	if catcher.HasErrors() {
+		return catcher.Resolve()
+	}
+
+	return io.EOF
+}
+
+func formatLogLine(item LogLine, printTime, printPriority, printSource, ndjson bool) string {
+	if ndjson {
+		return formatNDJSONLogLine(item, printSource)
+	}
+
+	data := item.Data
+	if printTime {
+		data = fmt.Sprintf("[%s] %s", item.Timestamp.Format("2006/01/02 15:04:05.000"), data)
+	}
+	if printPriority {
+		data = fmt.Sprintf("[P:%3d] %s", item.Priority, data)
+	}
+	if printSource && item.Source != nil {
+		data = fmt.Sprintf("[%s] %s", item.Source.name(), data)
+	}
+
+	return data
+}
+
 type reverseLineReader struct {
 	r     *bufio.Reader
 	lines []string
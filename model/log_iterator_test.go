@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/evergreen-ci/pail"
+	"github.com/mongodb/grip/level"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -320,6 +321,266 @@ func TestMergeLogIterator(t *testing.T) {
 	})
 }
 
+func TestLineOffsetLogIterator(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tmpDir, err := ioutil.TempDir(".", "line-offset-iterator-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: tmpDir})
+	require.NoError(t, err)
+	chunks, lines, err := GenerateTestLog(ctx, bucket, 95, 10)
+	require.NoError(t, err)
+
+	for _, fromLine := range []int{0, 7, 10, 54, 94} {
+		t.Run(fmt.Sprintf("FromLine%d", fromLine), func(t *testing.T) {
+			it := NewLineOffsetLogIterator(bucket, chunks, fromLine)
+			assert.False(t, it.IsReversed())
+			expectedLine := fromLine
+			for it.Next(ctx) {
+				require.True(t, expectedLine < len(lines))
+				assert.Equal(t, expectedLine, it.LineNumber())
+				assert.Equal(t, lines[expectedLine], it.Item())
+				expectedLine++
+			}
+			assert.Equal(t, len(lines), expectedLine)
+			assert.True(t, it.Exhausted())
+			assert.NoError(t, it.Err())
+			assert.NoError(t, it.Close())
+		})
+	}
+	t.Run("PastEnd", func(t *testing.T) {
+		it := NewLineOffsetLogIterator(bucket, chunks, len(lines))
+		assert.False(t, it.Next(ctx))
+		assert.NoError(t, it.Err())
+		assert.NoError(t, it.Close())
+	})
+	t.Run("SkipsChunks", func(t *testing.T) {
+		missing := append([]LogChunkInfo{{Key: "DNE", NumLines: 5}}, chunks...)
+		it := NewLineOffsetLogIterator(bucket, missing, 5)
+		require.True(t, it.Next(ctx))
+		assert.Equal(t, 5, it.LineNumber())
+		assert.Equal(t, lines[0], it.Item())
+		assert.NoError(t, it.Close())
+	})
+}
+
+func TestPriorityFilteringLogIterator(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tmpDir, err := ioutil.TempDir(".", "priority-filtering-iterator-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: tmpDir})
+	require.NoError(t, err)
+
+	ts := time.Now().Round(time.Millisecond).UTC()
+	priorities := []level.Priority{level.Debug, level.Info, level.Error, level.Warning, level.Info, level.Critical, level.Trace}
+	var (
+		lines    []LogLine
+		rawLines string
+	)
+	for i, p := range priorities {
+		line := LogLine{
+			Priority:  p,
+			Timestamp: ts.Add(time.Duration(i) * time.Millisecond),
+			Data:      fmt.Sprintf("line %d\n", i),
+		}
+		lines = append(lines, line)
+		rawLines += prependPriorityAndTimestamp(line.Priority, line.Timestamp, fmt.Sprintf("line %d", i))
+	}
+	chunk := LogChunkInfo{
+		Key:      createBuildloggerChunkKey(lines[0].Timestamp, lines[len(lines)-1].Timestamp, len(lines)),
+		NumLines: len(lines),
+		Start:    lines[0].Timestamp,
+		End:      lines[len(lines)-1].Timestamp,
+	}
+	require.NoError(t, bucket.Put(ctx, chunk.Key, strings.NewReader(rawLines)))
+	timeRange := TimeRange{StartAt: chunk.Start, EndAt: chunk.End}
+
+	for _, test := range []struct {
+		name     string
+		min      level.Priority
+		max      level.Priority
+		expected []LogLine
+	}{
+		{
+			name:     "NoBounds",
+			expected: lines,
+		},
+		{
+			name:     "MinPriority",
+			min:      level.Warning,
+			expected: []LogLine{lines[2], lines[3], lines[5]},
+		},
+		{
+			name:     "MaxPriority",
+			max:      level.Debug,
+			expected: []LogLine{lines[0], lines[6]},
+		},
+		{
+			name:     "MinAndMaxPriority",
+			min:      level.Info,
+			max:      level.Warning,
+			expected: []LogLine{lines[1], lines[3], lines[4]},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			it := NewPriorityFilteringLogIterator(NewBatchedLogIterator(bucket, []LogChunkInfo{chunk}, 2, timeRange), test.min, test.max)
+			var actual []LogLine
+			for it.Next(ctx) {
+				actual = append(actual, it.Item())
+			}
+			assert.NoError(t, it.Err())
+			assert.True(t, it.Exhausted())
+			assert.NoError(t, it.Close())
+			assert.Equal(t, test.expected, actual)
+
+			it = NewPriorityFilteringLogIterator(NewBatchedLogIterator(bucket, []LogChunkInfo{chunk}, 2, timeRange), test.min, test.max).Reverse()
+			assert.True(t, it.IsReversed())
+			actual = nil
+			for it.Next(ctx) {
+				actual = append([]LogLine{it.Item()}, actual...)
+			}
+			assert.NoError(t, it.Err())
+			assert.NoError(t, it.Close())
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+	t.Run("ReaderTail", func(t *testing.T) {
+		r := NewLogIteratorReader(ctx, NewBatchedLogIterator(bucket, []LogChunkInfo{chunk}, 2, timeRange), LogIteratorReaderOptions{
+			TailN:       2,
+			MinPriority: level.Warning,
+		})
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, lines[3].Data+lines[5].Data, string(data))
+	})
+	t.Run("ReaderLimit", func(t *testing.T) {
+		it := NewBatchedLogIterator(bucket, []LogChunkInfo{chunk}, 2, timeRange)
+		r := NewLogIteratorReader(ctx, it, LogIteratorReaderOptions{
+			Limit:       2,
+			MinPriority: level.Warning,
+		})
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, lines[2].Data+lines[3].Data, string(data))
+		assert.Equal(t, lines[5], it.Item())
+	})
+}
+
+func TestSourceLogIterator(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tmpDir, err := ioutil.TempDir(".", "source-iterator-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	ts := time.Now().Round(time.Millisecond).UTC()
+	sources := []LogSource{
+		{LogID: "log0", Info: LogInfo{ProcessName: "mongod", TestName: "test0"}},
+		{LogID: "log1", Info: LogInfo{ProcessName: "mongos"}},
+		{LogID: "log2"},
+	}
+	var (
+		lines   []LogLine
+		chunks  []LogChunkInfo
+		buckets []pail.Bucket
+	)
+	for i := range sources {
+		bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: tmpDir, Prefix: sources[i].LogID})
+		require.NoError(t, err)
+		line := LogLine{
+			Priority:  level.Info,
+			Timestamp: ts.Add(time.Duration(i) * time.Millisecond),
+			Data:      fmt.Sprintf("line %d\n", i),
+			Source:    &sources[i],
+		}
+		chunk := LogChunkInfo{
+			Key:      createBuildloggerChunkKey(line.Timestamp, line.Timestamp, 1),
+			NumLines: 1,
+			Start:    line.Timestamp,
+			End:      line.Timestamp,
+		}
+		require.NoError(t, bucket.Put(ctx, chunk.Key, strings.NewReader(prependPriorityAndTimestamp(line.Priority, line.Timestamp, fmt.Sprintf("line %d", i)))))
+		lines = append(lines, line)
+		chunks = append(chunks, chunk)
+		buckets = append(buckets, bucket)
+	}
+	iterators := func() []LogIterator {
+		var its []LogIterator
+		for i := range sources {
+			timeRange := TimeRange{StartAt: chunks[i].Start, EndAt: chunks[i].End}
+			its = append(its, NewSourceLogIterator(NewBatchedLogIterator(buckets[i], chunks[i:i+1], 2, timeRange), sources[i]))
+		}
+		return its
+	}
+
+	t.Run("Merge", func(t *testing.T) {
+		it := NewMergingIterator(iterators()...)
+		var actual []LogLine
+		for it.Next(ctx) {
+			actual = append(actual, it.Item())
+		}
+		assert.NoError(t, it.Err())
+		assert.NoError(t, it.Close())
+		assert.Equal(t, lines, actual)
+	})
+	t.Run("MergeReverse", func(t *testing.T) {
+		it := NewMergingIterator(iterators()...).Reverse()
+		var actual []LogLine
+		for it.Next(ctx) {
+			actual = append([]LogLine{it.Item()}, actual...)
+		}
+		assert.NoError(t, it.Err())
+		assert.NoError(t, it.Close())
+		assert.Equal(t, lines, actual)
+	})
+	t.Run("ReaderWithSource", func(t *testing.T) {
+		r := NewLogIteratorReader(ctx, NewMergingIterator(iterators()...), LogIteratorReaderOptions{
+			PrintSource:   true,
+			PrintPriority: true,
+		})
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, "[mongod/test0] [P: 40] line 0\n[mongos] [P: 40] line 1\n[log2] [P: 40] line 2\n", string(data))
+	})
+	t.Run("ReaderWithoutSource", func(t *testing.T) {
+		r := NewLogIteratorReader(ctx, NewMergingIterator(iterators()...), LogIteratorReaderOptions{})
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, "line 0\nline 1\nline 2\n", string(data))
+	})
+	t.Run("ReaderNDJSONWithSource", func(t *testing.T) {
+		r := NewLogIteratorReader(ctx, NewMergingIterator(iterators()...), LogIteratorReaderOptions{
+			PrintSource: true,
+			NDJSON:      true,
+		})
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		expected := fmt.Sprintf(`{"log_id":"log0","proc_name":"mongod","ts":"%s","priority":40,"data":"line 0"}`+"\n", lines[0].Timestamp.Format(time.RFC3339Nano)) +
+			fmt.Sprintf(`{"log_id":"log1","proc_name":"mongos","ts":"%s","priority":40,"data":"line 1"}`+"\n", lines[1].Timestamp.Format(time.RFC3339Nano)) +
+			fmt.Sprintf(`{"log_id":"log2","ts":"%s","priority":40,"data":"line 2"}`+"\n", lines[2].Timestamp.Format(time.RFC3339Nano))
+		assert.Equal(t, expected, string(data))
+	})
+	t.Run("ReaderNDJSONWithoutSource", func(t *testing.T) {
+		r := NewLogIteratorReader(ctx, NewMergingIterator(iterators()...), LogIteratorReaderOptions{NDJSON: true})
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "log_id")
+		assert.NotContains(t, string(data), "proc_name")
+	})
+}
+
 func TestLogIteratorReader(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -568,6 +829,19 @@ func TestLogIteratorReader(t *testing.T) {
 		assert.Zero(t, n)
 		assert.Error(t, err)
 	})
+	t.Run("WithSoftSizeLimitSplitTimestamps", func(t *testing.T) {
+		opts := LogIteratorReaderOptions{SoftSizeLimit: 5000, SplitTimestamps: true}
+		it := NewMergingIterator(
+			NewBatchedLogIterator(bucket, chunks, 2, timeRange),
+			NewBatchedLogIterator(bucket, chunks, 2, timeRange),
+			NewBatchedLogIterator(bucket, chunks, 2, timeRange),
+		)
+		readData, err := ioutil.ReadAll(NewLogIteratorReader(ctx, it, opts))
+		require.NoError(t, err)
+		assert.Len(t, readData, 5000+50) // 50 lines each 100 characters long + newline
+		assert.Equal(t, lines[16].Timestamp, it.Item().Timestamp)
+		assert.Equal(t, lines[16].Data, it.Item().Data)
+	})
 	t.Run("EmptyBuffer", func(t *testing.T) {
 		opts := LogIteratorReaderOptions{}
 		r := NewLogIteratorReader(ctx, NewBatchedLogIterator(bucket, chunks, 2, timeRange), opts)
@@ -598,6 +872,104 @@ func TestLogIteratorReader(t *testing.T) {
 	})
 }
 
+type flushCountingWriter struct {
+	strings.Builder
+	flushes int
+}
+
+func (w *flushCountingWriter) Flush() { w.flushes++ }
+
+func TestLogIteratorFollowReader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir, err := ioutil.TempDir("", "follow-reader-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: tmpDir})
+	require.NoError(t, err)
+
+	chunks, lines, err := GenerateTestLog(ctx, bucket, 100, 10)
+	require.NoError(t, err)
+	timeRange := TimeRange{
+		StartAt: chunks[0].Start,
+		EndAt:   chunks[len(chunks)-1].End,
+	}
+
+	t.Run("ReadsOneLineAtATime", func(t *testing.T) {
+		opts := LogIteratorReaderOptions{Follow: true, Limit: 10, SoftSizeLimit: 1}
+		r := NewLogIteratorReader(ctx, NewBatchedLogIterator(bucket, chunks, 2, timeRange), opts)
+		p := make([]byte, 4096)
+		current := 0
+		for {
+			n, err := r.Read(p)
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			require.True(t, current < len(lines))
+			require.Equal(t, lines[current].Data, string(p[:n]))
+			current++
+		}
+		assert.Equal(t, len(lines), current)
+
+		n, err := r.Read(p)
+		assert.Zero(t, n)
+		assert.Equal(t, io.EOF, err)
+	})
+	t.Run("LeftOver", func(t *testing.T) {
+		opts := LogIteratorReaderOptions{Follow: true}
+		r := NewLogIteratorReader(ctx, NewBatchedLogIterator(bucket, chunks, 2, timeRange), opts)
+		readData := []byte{}
+		p := make([]byte, 3)
+		for {
+			n, err := r.Read(p)
+			readData = append(readData, p[:n]...)
+			require.True(t, n <= len(p))
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+		}
+
+		readLines := strings.Split(string(readData), "\n")
+		require.Len(t, readLines, len(lines)+1)
+		for i, line := range readLines[:len(readLines)-1] {
+			assert.Equal(t, lines[i].Data, line+"\n")
+		}
+	})
+	t.Run("WriteToFlushesEachLine", func(t *testing.T) {
+		opts := LogIteratorReaderOptions{Follow: true, PrintTime: true, PrintPriority: true}
+		r := NewLogIteratorReader(ctx, NewBatchedLogIterator(bucket, chunks, 2, timeRange), opts)
+		w := &flushCountingWriter{}
+		n, err := io.Copy(w, r)
+		require.NoError(t, err)
+		assert.Equal(t, int64(w.Len()), n)
+		assert.Equal(t, len(lines), w.flushes)
+
+		readLines := strings.Split(w.String(), "\n")
+		require.Len(t, readLines, len(lines)+1)
+		for i, line := range readLines[:len(readLines)-1] {
+			formattedTime := lines[i].Timestamp.Format("2006/01/02 15:04:05.000")
+			expectedLine := fmt.Sprintf("[P:%3d] [%s] %s", lines[i].Priority, formattedTime, lines[i].Data)
+			assert.Equal(t, expectedLine, line+"\n")
+		}
+	})
+	t.Run("ContextError", func(t *testing.T) {
+		errCtx, errCancel := context.WithCancel(context.Background())
+		errCancel()
+
+		opts := LogIteratorReaderOptions{Follow: true}
+		r := NewLogIteratorReader(errCtx, NewBatchedLogIterator(bucket, chunks, 2, timeRange), opts)
+		p := make([]byte, 101)
+		n, err := r.Read(p)
+		assert.Zero(t, n)
+		assert.Error(t, err)
+		assert.NotEqual(t, io.EOF, err)
+	})
+}
+
 func TestLogIteratorTailReader(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
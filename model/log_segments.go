@@ -23,6 +23,10 @@ type LogSegment struct {
 
 	Metadata `bson:"metadata"`
 
+	// Migrated indicates that the segment was copied into the
+	// buildlogger log storing the simple log, see MigrateSimpleLog.
+	Migrated bool `bson:"migrated,omitempty"`
+
 	// internal fields used by methods:
 	populated bool
 	env       cedar.Environment
@@ -36,6 +40,7 @@ var (
 	logSegmentSegmentIDKey  = bsonutil.MustHaveTag(LogSegment{}, "Segment")
 	logSegmentMetricsKey    = bsonutil.MustHaveTag(LogSegment{}, "Metrics")
 	logSegmentMetadataKey   = bsonutil.MustHaveTag(LogSegment{}, "Metadata")
+	logSegmentMigratedKey   = bsonutil.MustHaveTag(LogSegment{}, "Migrated")
 )
 
 type LogMetrics struct {
@@ -143,7 +148,7 @@ func (l *LogSegments) Find(logID string, sorted bool) error {
 	}
 
 	l.populated = false
-	if err = query.All(l.logs); err != nil {
+	if err = query.All(&l.logs); err != nil {
 		return errors.Wrapf(err, "finding log segment '%s'", logID)
 	}
 
@@ -0,0 +1,195 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Validate ensures that the retention rules are valid.
+func (c RetentionConfig) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	seen := map[string]bool{}
+	for _, rule := range c.Rules {
+		catcher.ErrorfWhen(seen[rule.Project], "duplicate retention rule for project '%s'", rule.Project)
+		catcher.ErrorfWhen(rule.MainlineTTL < 0 || rule.PatchTTL < 0, "retention rule for project '%s' cannot have a negative TTL", rule.Project)
+		seen[rule.Project] = true
+	}
+
+	return catcher.Resolve()
+}
+
+// expiredQuery returns the query for records created before the TTL of the
+// retention rule applying to them. The given keys are the dotted keys of the
+// project, mainline, and creation time fields of the records. A nil query is
+// returned if no records can expire.
+func (c RetentionConfig) expiredQuery(now time.Time, projectKey, mainlineKey, createdAtKey string) bson.M {
+	var (
+		defaultRule *RetentionRule
+		clauses     []bson.M
+	)
+	projects := []string{}
+	for i, rule := range c.Rules {
+		if rule.Project == "" {
+			defaultRule = &c.Rules[i]
+			continue
+		}
+		projects = append(projects, rule.Project)
+		clauses = append(clauses, rule.expiredClauses(now, rule.Project, projectKey, mainlineKey, createdAtKey)...)
+	}
+	if defaultRule != nil {
+		clauses = append(clauses, defaultRule.expiredClauses(now, bson.M{"$nin": projects}, projectKey, mainlineKey, createdAtKey)...)
+	}
+
+	switch len(clauses) {
+	case 0:
+		return nil
+	case 1:
+		return clauses[0]
+	default:
+		return bson.M{"$or": clauses}
+	}
+}
+
+func (r RetentionRule) expiredClauses(now time.Time, project interface{}, projectKey, mainlineKey, createdAtKey string) []bson.M {
+	var clauses []bson.M
+	for _, ttl := range []struct {
+		mainline bool
+		ttl      time.Duration
+	}{
+		{mainline: true, ttl: r.MainlineTTL},
+		{mainline: false, ttl: r.PatchTTL},
+	} {
+		if ttl.ttl <= 0 {
+			continue
+		}
+		clauses = append(clauses, bson.M{
+			projectKey:   project,
+			mainlineKey:  ttl.mainline,
+			createdAtKey: bson.M{"$lt": now.Add(-ttl.ttl)},
+		})
+	}
+
+	return clauses
+}
+
+// ExpiredRecords describes the expired records removed from a collection.
+type ExpiredRecords struct {
+	// Found is the number of expired records found.
+	Found int
+	// Removed are the IDs of the records that were removed along with
+	// their data in offline blob storage.
+	Removed []string
+	// Projects is the number of removed records per project.
+	Projects map[string]int
+}
+
+func (r *ExpiredRecords) add(id, project string) {
+	r.Removed = append(r.Removed, id)
+	if r.Projects == nil {
+		r.Projects = map[string]int{}
+	}
+	r.Projects[project]++
+}
+
+// RemoveExpiredLogs removes at most limit of the oldest buildlogger logs that
+// expired according to the given retention rules, along with their chunks in
+// offline blob storage. Logs whose chunks cannot be removed are kept so that
+// removal may be retried.
+func RemoveExpiredLogs(ctx context.Context, env cedar.Environment, retention RetentionConfig, limit int) (*ExpiredRecords, error) {
+	if env == nil {
+		return nil, errors.New("cannot remove expired logs with a nil environment")
+	}
+
+	query := retention.expiredQuery(
+		time.Now(),
+		bsonutil.GetDottedKeyName(logInfoKey, logInfoProjectKey),
+		bsonutil.GetDottedKeyName(logInfoKey, logInfoMainlineKey),
+		logCreatedAtKey,
+	)
+	if query == nil {
+		return &ExpiredRecords{}, nil
+	}
+
+	var logs []Log
+	findOpts := options.Find().SetSort(bson.M{logCreatedAtKey: 1}).SetLimit(int64(limit))
+	cur, err := env.GetDB().Collection(buildloggerCollection).Find(ctx, query, findOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding expired logs")
+	}
+	if err = cur.All(ctx, &logs); err != nil {
+		return nil, errors.Wrap(err, "decoding expired logs")
+	}
+
+	expired := &ExpiredRecords{Found: len(logs)}
+	catcher := grip.NewBasicCatcher()
+	for i := range logs {
+		logs[i].populated = true
+		logs[i].Setup(env)
+		if err = logs[i].RemoveArtifacts(ctx); err != nil {
+			catcher.Add(err)
+			continue
+		}
+		if err = logs[i].Remove(ctx); err != nil {
+			catcher.Add(err)
+			continue
+		}
+		expired.add(logs[i].ID, logs[i].Info.Project)
+	}
+
+	return expired, catcher.Resolve()
+}
+
+// RemoveExpiredTestResults removes at most limit of the oldest test results
+// records that expired according to the given retention rules, along with
+// their data in offline blob storage. Records whose data cannot be removed are
+// kept so that removal may be retried.
+func RemoveExpiredTestResults(ctx context.Context, env cedar.Environment, retention RetentionConfig, limit int) (*ExpiredRecords, error) {
+	if env == nil {
+		return nil, errors.New("cannot remove expired test results with a nil environment")
+	}
+
+	query := retention.expiredQuery(
+		time.Now(),
+		bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoProjectKey),
+		bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoMainlineKey),
+		testResultsCreatedAtKey,
+	)
+	if query == nil {
+		return &ExpiredRecords{}, nil
+	}
+
+	var records []TestResults
+	findOpts := options.Find().SetSort(bson.M{testResultsCreatedAtKey: 1}).SetLimit(int64(limit))
+	cur, err := env.GetDB().Collection(testResultsCollection).Find(ctx, query, findOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding expired test results")
+	}
+	if err = cur.All(ctx, &records); err != nil {
+		return nil, errors.Wrap(err, "decoding expired test results")
+	}
+
+	expired := &ExpiredRecords{Found: len(records)}
+	catcher := grip.NewBasicCatcher()
+	for i := range records {
+		records[i].populated = true
+		records[i].Setup(env)
+		if err = records[i].RemoveArtifacts(ctx); err != nil {
+			catcher.Add(err)
+			continue
+		}
+		if err = records[i].Remove(ctx); err != nil {
+			catcher.Add(err)
+			continue
+		}
+		expired.add(records[i].ID, records[i].Info.Project)
+	}
+
+	return expired, catcher.Resolve()
+}
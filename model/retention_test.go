@@ -0,0 +1,251 @@
+package model
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/pail"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestRetentionConfigValidate(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		conf   RetentionConfig
+		hasErr bool
+	}{
+		{
+			name: "Empty",
+		},
+		{
+			name: "Valid",
+			conf: RetentionConfig{Rules: []RetentionRule{
+				{PatchTTL: 30 * 24 * time.Hour, MainlineTTL: 365 * 24 * time.Hour},
+				{Project: "project", PatchTTL: 7 * 24 * time.Hour},
+			}},
+		},
+		{
+			name: "DuplicateDefault",
+			conf: RetentionConfig{Rules: []RetentionRule{
+				{PatchTTL: time.Hour},
+				{MainlineTTL: time.Hour},
+			}},
+			hasErr: true,
+		},
+		{
+			name: "DuplicateProject",
+			conf: RetentionConfig{Rules: []RetentionRule{
+				{Project: "project", PatchTTL: time.Hour},
+				{Project: "project", MainlineTTL: time.Hour},
+			}},
+			hasErr: true,
+		},
+		{
+			name:   "NegativeTTL",
+			conf:   RetentionConfig{Rules: []RetentionRule{{Project: "project", PatchTTL: -time.Hour}}},
+			hasErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.conf.Validate()
+			if test.hasErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRetentionConfigExpiredQuery(t *testing.T) {
+	now := time.Now()
+	t.Run("NoRules", func(t *testing.T) {
+		assert.Nil(t, RetentionConfig{}.expiredQuery(now, "project", "mainline", "created_at"))
+	})
+	t.Run("NoTTLs", func(t *testing.T) {
+		conf := RetentionConfig{Rules: []RetentionRule{{}, {Project: "project"}}}
+		assert.Nil(t, conf.expiredQuery(now, "project", "mainline", "created_at"))
+	})
+	t.Run("SingleClause", func(t *testing.T) {
+		conf := RetentionConfig{Rules: []RetentionRule{{Project: "project", PatchTTL: time.Hour}}}
+		assert.Equal(t, bson.M{
+			"project":    "project",
+			"mainline":   false,
+			"created_at": bson.M{"$lt": now.Add(-time.Hour)},
+		}, conf.expiredQuery(now, "project", "mainline", "created_at"))
+	})
+	t.Run("DefaultExcludesProjectRules", func(t *testing.T) {
+		conf := RetentionConfig{Rules: []RetentionRule{
+			{PatchTTL: time.Hour, MainlineTTL: 2 * time.Hour},
+			{Project: "forever"},
+			{Project: "project", MainlineTTL: 3 * time.Hour},
+		}}
+		assert.Equal(t, bson.M{"$or": []bson.M{
+			{
+				"project":    "project",
+				"mainline":   true,
+				"created_at": bson.M{"$lt": now.Add(-3 * time.Hour)},
+			},
+			{
+				"project":    bson.M{"$nin": []string{"forever", "project"}},
+				"mainline":   true,
+				"created_at": bson.M{"$lt": now.Add(-2 * time.Hour)},
+			},
+			{
+				"project":    bson.M{"$nin": []string{"forever", "project"}},
+				"mainline":   false,
+				"created_at": bson.M{"$lt": now.Add(-time.Hour)},
+			},
+		}}, conf.expiredQuery(now, "project", "mainline", "created_at"))
+	})
+}
+
+func TestRemoveExpiredLogs(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir, err := ioutil.TempDir(".", "remove-expired-logs-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+		assert.NoError(t, db.Collection(buildloggerCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(env.GetConfig().DbConfigurationCollection).Drop(ctx))
+	}()
+	conf := &CedarConfig{
+		populated: true,
+		Bucket:    BucketConfig{BuildLogsBucket: tmpDir},
+	}
+	conf.Setup(env)
+	require.NoError(t, conf.Save())
+
+	retention := RetentionConfig{Rules: []RetentionRule{
+		{PatchTTL: 24 * time.Hour, MainlineTTL: 48 * time.Hour},
+		{Project: "forever"},
+	}}
+	createLog := func(project string, mainline bool, age time.Duration) *Log {
+		log := CreateLog(LogInfo{Project: project, TaskID: utility.RandomString(), Mainline: mainline}, PailLocal)
+		log.CreatedAt = time.Now().Add(-age)
+		log.Setup(env)
+		require.NoError(t, log.SaveNew(ctx))
+		require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, log.Artifact.Prefix), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, log.Artifact.Prefix, "chunk"), []byte("data"), 0644))
+		return log
+	}
+	expiredPatch := createLog("project", false, 25*time.Hour)
+	expiredMainline := createLog("project", true, 49*time.Hour)
+	unexpiredMainline := createLog("project", true, 25*time.Hour)
+	unexpiredPatch := createLog("project", false, time.Hour)
+	kept := createLog("forever", false, 100*time.Hour)
+
+	t.Run("NilEnv", func(t *testing.T) {
+		expired, err := RemoveExpiredLogs(ctx, nil, retention, 10)
+		assert.Error(t, err)
+		assert.Nil(t, expired)
+	})
+	t.Run("NoRules", func(t *testing.T) {
+		expired, err := RemoveExpiredLogs(ctx, env, RetentionConfig{}, 10)
+		require.NoError(t, err)
+		assert.Zero(t, expired.Found)
+		assert.Empty(t, expired.Removed)
+	})
+	t.Run("Batch", func(t *testing.T) {
+		expired, err := RemoveExpiredLogs(ctx, env, retention, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, expired.Found)
+		assert.Equal(t, []string{expiredMainline.ID}, expired.Removed)
+		assert.Equal(t, map[string]int{"project": 1}, expired.Projects)
+
+		expired, err = RemoveExpiredLogs(ctx, env, retention, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, expired.Found)
+		assert.Equal(t, []string{expiredPatch.ID}, expired.Removed)
+
+		expired, err = RemoveExpiredLogs(ctx, env, retention, 10)
+		require.NoError(t, err)
+		assert.Zero(t, expired.Found)
+	})
+	t.Run("RemovedRecordsAndChunks", func(t *testing.T) {
+		for _, log := range []*Log{expiredPatch, expiredMainline} {
+			l := &Log{ID: log.ID}
+			l.Setup(env)
+			assert.Error(t, l.Find(ctx))
+			_, err := os.Stat(filepath.Join(tmpDir, log.Artifact.Prefix, "chunk"))
+			assert.True(t, os.IsNotExist(err))
+		}
+		for _, log := range []*Log{unexpiredMainline, unexpiredPatch, kept} {
+			l := &Log{ID: log.ID}
+			l.Setup(env)
+			assert.NoError(t, l.Find(ctx))
+			_, err := os.Stat(filepath.Join(tmpDir, log.Artifact.Prefix, "chunk"))
+			assert.NoError(t, err)
+		}
+	})
+}
+
+func TestRemoveExpiredTestResults(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir, err := ioutil.TempDir(".", "remove-expired-test-results-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+		assert.NoError(t, db.Collection(testResultsCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(env.GetConfig().DbConfigurationCollection).Drop(ctx))
+	}()
+	conf := &CedarConfig{
+		populated: true,
+		Bucket:    BucketConfig{PrestoBucket: tmpDir},
+	}
+	conf.Setup(env)
+	require.NoError(t, conf.Save())
+	bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: tmpDir})
+	require.NoError(t, err)
+
+	retention := RetentionConfig{Rules: []RetentionRule{{Project: "project", MainlineTTL: time.Hour}}}
+	createTestResults := func(project string, age time.Duration) *TestResults {
+		record := getTestResults()
+		record.Info.Project = project
+		record.Info.Mainline = true
+		record.ID = record.Info.ID()
+		record.CreatedAt = time.Now().Add(-age)
+		record.populated = true
+		record.Setup(env)
+		require.NoError(t, record.SaveNew(ctx))
+		require.NoError(t, bucket.Put(ctx, record.PrestoPartitionKey(), strings.NewReader("parquet")))
+		return record
+	}
+	expired := createTestResults("project", 2*time.Hour)
+	unexpired := createTestResults("project", time.Minute)
+	kept := createTestResults("other", 2*time.Hour)
+
+	removed, err := RemoveExpiredTestResults(ctx, env, retention, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed.Found)
+	assert.Equal(t, []string{expired.ID}, removed.Removed)
+	assert.Equal(t, map[string]int{"project": 1}, removed.Projects)
+
+	record := &TestResults{ID: expired.ID}
+	record.Setup(env)
+	assert.Error(t, record.Find(ctx))
+	_, err = os.Stat(filepath.Join(tmpDir, expired.PrestoPartitionKey()))
+	assert.True(t, os.IsNotExist(err))
+	for _, r := range []*TestResults{unexpired, kept} {
+		record = &TestResults{ID: r.ID}
+		record.Setup(env)
+		assert.NoError(t, record.Find(ctx))
+		_, err = os.Stat(filepath.Join(tmpDir, r.PrestoPartitionKey()))
+		assert.NoError(t, err)
+	}
+}
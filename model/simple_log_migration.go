@@ -0,0 +1,309 @@
+package model
+
+import (
+	"context"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/pail"
+	"github.com/mongodb/anser/db"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/level"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SimpleLogProject is the project of the buildlogger logs storing simple
+// logs.
+const SimpleLogProject = "simple_log"
+
+// SimpleLogInfo returns the info of the buildlogger log storing the simple log
+// with the given ID. The simple log ID is the log's task ID.
+func SimpleLogInfo(logID string) LogInfo {
+	return LogInfo{
+		Project: SimpleLogProject,
+		TaskID:  logID,
+		Format:  LogFormatText,
+	}
+}
+
+// FindSimpleLog returns the buildlogger log storing the simple log with the
+// given ID.
+func FindSimpleLog(ctx context.Context, env cedar.Environment, logID string) (*Log, error) {
+	info := SimpleLogInfo(logID)
+	log := &Log{ID: info.ID()}
+	log.Setup(env)
+	if err := log.Find(ctx); err != nil {
+		return nil, err
+	}
+
+	return log, nil
+}
+
+// AppendSimpleLog appends the given content to the buildlogger log storing
+// the simple log with the given ID, creating the log if it does not exist.
+// Increments are the zero-based numbers of the simple log's segments;
+// appending an increment that was already appended is a no-op.
+func AppendSimpleLog(ctx context.Context, env cedar.Environment, logID string, increment int, content string) error {
+	if increment < 0 {
+		return errors.Errorf("invalid increment %d", increment)
+	}
+
+	log, err := findOrCreateSimpleLog(ctx, env, logID)
+	if err != nil {
+		return err
+	}
+	_, err = log.AppendSequence(ctx, simpleLogSequence(increment), simpleLogLines(log, simpleLogSequence(increment), content))
+
+	return errors.Wrapf(err, "appending increment %d to simple log '%s'", increment, logID)
+}
+
+func findOrCreateSimpleLog(ctx context.Context, env cedar.Environment, logID string) (*Log, error) {
+	if env == nil {
+		return nil, errors.New("cannot find simple log with a nil environment")
+	}
+
+	log, err := FindSimpleLog(ctx, env, logID)
+	if err == nil {
+		return log, nil
+	}
+	if !db.ResultsNotFound(errors.Cause(err)) {
+		return nil, errors.Wrapf(err, "finding simple log '%s'", logID)
+	}
+
+	conf := NewCedarConfig(env)
+	if err = conf.Find(); err != nil {
+		return nil, errors.Wrap(err, "getting application configuration")
+	}
+
+	log = CreateLog(SimpleLogInfo(logID), simpleLogBucketType(conf))
+	// Line timestamps are derived from the creation time, which is
+	// stored with millisecond precision.
+	log.CreatedAt = log.CreatedAt.Round(time.Millisecond)
+	log.Setup(env)
+	if saveErr := log.SaveNew(ctx); saveErr != nil {
+		// The log may have been concurrently created.
+		if log, err = FindSimpleLog(ctx, env, logID); err != nil {
+			return nil, errors.Wrapf(saveErr, "creating simple log '%s'", logID)
+		}
+	}
+
+	return log, nil
+}
+
+// simpleLogBucketType returns the storage type of simple logs: the storage
+// type of buildlogger logs, defaulting to S3 where simple logs were always
+// stored.
+func simpleLogBucketType(conf *CedarConfig) PailType {
+	if conf.Bucket.BuildLogsBucketType == "" {
+		return PailS3
+	}
+	return conf.Bucket.BuildLogsBucketType
+}
+
+// simpleLogSequence returns the sequence number of the buildlogger log chunk
+// storing the given increment of a simple log. Sequence number 1 is reserved
+// for the merged segments of a migrated simple log.
+func simpleLogSequence(increment int) int64 { return int64(increment) + 2 }
+
+// simpleLogLines splits the given simple log content into lines. Simple logs
+// do not have line timestamps, so the lines are timestamped with the log's
+// creation time offset by the sequence number, which orders the log's chunks
+// and keeps them deterministic across retries.
+func simpleLogLines(log *Log, sequence int64, content string) []LogLine {
+	content = strings.TrimSuffix(content, "\n")
+	if content == "" {
+		return nil
+	}
+
+	ts := log.CreatedAt.Add(time.Duration(sequence) * time.Millisecond)
+	var lines []LogLine
+	for _, data := range strings.Split(content, "\n") {
+		lines = append(lines, LogLine{
+			Priority:  level.Info,
+			Timestamp: ts,
+			Data:      data,
+		})
+	}
+
+	return lines
+}
+
+// MigrateSimpleLog copies the segments of the simple log with the given ID,
+// and its merged segments if any, into the buildlogger log storing the simple
+// log and marks them as migrated. Segments already copied are skipped, so the
+// migration may be retried. The segments are left in place.
+func MigrateSimpleLog(ctx context.Context, env cedar.Environment, logID string) error {
+	log, err := findOrCreateSimpleLog(ctx, env, logID)
+	if err != nil {
+		return err
+	}
+	conf := NewCedarConfig(env)
+	if err = conf.Find(); err != nil {
+		return errors.Wrap(err, "getting application configuration")
+	}
+	migrator := &simpleLogObjectMigrator{
+		env:        env,
+		log:        log,
+		bucketType: simpleLogBucketType(conf),
+		buckets:    map[string]pail.Bucket{},
+	}
+
+	dbConn := env.GetDB()
+	record := LogRecord{}
+	err = dbConn.Collection(logRecordCollection).FindOne(ctx, bson.M{logRecordIDKey: logID}).Decode(&record)
+	if err != nil && !db.ResultsNotFound(err) {
+		return errors.Wrapf(err, "finding record of simple log '%s'", logID)
+	}
+	if err == nil && record.KeyName != "" {
+		if err = migrator.migrate(ctx, 1, record.Bucket, record.KeyName); err != nil {
+			return errors.Wrapf(err, "migrating merged segments of simple log '%s'", logID)
+		}
+	}
+
+	var segments []LogSegment
+	cur, err := dbConn.Collection(logSegmentsCollection).Find(ctx, bson.M{logSegmentLogIDKey: logID})
+	if err != nil {
+		return errors.Wrapf(err, "finding segments of simple log '%s'", logID)
+	}
+	if err = cur.All(ctx, &segments); err != nil {
+		return errors.Wrapf(err, "decoding segments of simple log '%s'", logID)
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].Segment < segments[j].Segment })
+	for _, segment := range segments {
+		if err = migrator.migrate(ctx, simpleLogSequence(segment.Segment), segment.Bucket, segment.KeyName); err != nil {
+			return errors.Wrapf(err, "migrating segment %d of simple log '%s'", segment.Segment, logID)
+		}
+	}
+
+	set := bson.M{"$set": bson.M{logSegmentMigratedKey: true}}
+	segmentsResult, err := dbConn.Collection(logSegmentsCollection).UpdateMany(ctx, bson.M{logSegmentLogIDKey: logID}, set)
+	if err != nil {
+		return errors.Wrapf(err, "marking segments of simple log '%s' as migrated", logID)
+	}
+	recordResult, err := dbConn.Collection(logRecordCollection).UpdateOne(ctx, bson.M{logRecordIDKey: logID}, bson.M{"$set": bson.M{logRecordMigratedKey: true}})
+	if err != nil {
+		return errors.Wrapf(err, "marking record of simple log '%s' as migrated", logID)
+	}
+	grip.Debug(message.Fields{
+		"collection":     buildloggerCollection,
+		"id":             log.ID,
+		"simple_log_id":  logID,
+		"segments":       len(segments),
+		"segmentsResult": segmentsResult,
+		"recordResult":   recordResult,
+		"op":             "migrate simple log",
+	})
+
+	return nil
+}
+
+// simpleLogObjectMigrator copies the bucket objects storing the segments of a
+// simple log into the buildlogger log storing the simple log. The buckets are
+// of the storage type of simple logs, see simpleLogBucketType.
+type simpleLogObjectMigrator struct {
+	env        cedar.Environment
+	log        *Log
+	bucketType PailType
+	buckets    map[string]pail.Bucket
+}
+
+// migrate appends the content of the given bucket object to the buildlogger
+// log with the given sequence number, unless it was already appended.
+func (m *simpleLogObjectMigrator) migrate(ctx context.Context, sequence int64, bucketName, key string) error {
+	if m.log.Sequences.contains(sequence) {
+		return nil
+	}
+
+	bucket, ok := m.buckets[bucketName]
+	if !ok {
+		var err error
+		bucket, err = m.bucketType.Create(ctx, m.env, bucketName, "", string(pail.S3PermissionsPrivate), false)
+		if err != nil {
+			return errors.Wrapf(err, "getting bucket '%s'", bucketName)
+		}
+		m.buckets[bucketName] = bucket
+	}
+	r, err := bucket.Reader(ctx, key)
+	if err != nil {
+		return errors.Wrapf(err, "getting reader for '%s'", key)
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return errors.Wrapf(err, "reading '%s'", key)
+	}
+
+	_, err = m.log.AppendSequence(ctx, sequence, simpleLogLines(m.log, sequence, string(data)))
+	return err
+}
+
+// MigrateSimpleLogs migrates at most limit of the simple logs with segments,
+// or merged segments, not yet migrated, see MigrateSimpleLog. Simple logs that
+// cannot be migrated are left unmigrated so that the migration may be
+// retried.
+func MigrateSimpleLogs(ctx context.Context, env cedar.Environment, limit int) (*MigratedLogs, error) {
+	if env == nil {
+		return nil, errors.New("cannot migrate simple logs with a nil environment")
+	}
+
+	dbConn := env.GetDB()
+	pipeline := []bson.M{
+		{"$match": bson.M{logSegmentMigratedKey: bson.M{"$ne": true}}},
+		{"$group": bson.M{"_id": "$" + logSegmentLogIDKey}},
+		{"$limit": limit},
+	}
+	cur, err := dbConn.Collection(logSegmentsCollection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding unmigrated simple log segments")
+	}
+	var segmentLogIDs []struct {
+		ID string `bson:"_id"`
+	}
+	if err = cur.All(ctx, &segmentLogIDs); err != nil {
+		return nil, errors.Wrap(err, "decoding unmigrated simple log segments")
+	}
+	ids := map[string]bool{}
+	for _, logID := range segmentLogIDs {
+		ids[logID.ID] = true
+	}
+	if len(ids) < limit {
+		var records []LogRecord
+		findOpts := options.Find().SetLimit(int64(limit))
+		cur, err = dbConn.Collection(logRecordCollection).Find(ctx, bson.M{logRecordMigratedKey: bson.M{"$ne": true}}, findOpts)
+		if err != nil {
+			return nil, errors.Wrap(err, "finding unmigrated simple log records")
+		}
+		if err = cur.All(ctx, &records); err != nil {
+			return nil, errors.Wrap(err, "decoding unmigrated simple log records")
+		}
+		for _, record := range records {
+			if len(ids) < limit {
+				ids[record.LogID] = true
+			}
+		}
+	}
+
+	sortedIDs := make([]string, 0, len(ids))
+	for id := range ids {
+		sortedIDs = append(sortedIDs, id)
+	}
+	sort.Strings(sortedIDs)
+
+	migrated := &MigratedLogs{Found: len(sortedIDs)}
+	catcher := grip.NewBasicCatcher()
+	for _, id := range sortedIDs {
+		if err = MigrateSimpleLog(ctx, env, id); err != nil {
+			catcher.Add(err)
+			continue
+		}
+		migrated.Migrated = append(migrated.Migrated, id)
+	}
+
+	return migrated, catcher.Resolve()
+}
@@ -0,0 +1,223 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/pail"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip/level"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSimpleLogLines(t *testing.T) {
+	log := &Log{CreatedAt: time.Now().Round(time.Millisecond)}
+	ts := log.CreatedAt.Add(3 * time.Millisecond)
+
+	assert.Equal(t, []LogLine{
+		{Priority: level.Info, Timestamp: ts, Data: "line0"},
+		{Priority: level.Info, Timestamp: ts, Data: ""},
+		{Priority: level.Info, Timestamp: ts, Data: "line2"},
+	}, simpleLogLines(log, 3, "line0\n\nline2\n"))
+	assert.Empty(t, simpleLogLines(log, 3, ""))
+	assert.Empty(t, simpleLogLines(log, 3, "\n"))
+}
+
+func TestAppendSimpleLog(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir, err := ioutil.TempDir(".", "simple-log-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+		assert.NoError(t, db.Collection(buildloggerCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(env.GetConfig().DbConfigurationCollection).Drop(ctx))
+	}()
+	conf := &CedarConfig{
+		populated: true,
+		Bucket:    BucketConfig{BuildLogsBucket: tmpDir},
+	}
+	conf.Setup(env)
+	require.NoError(t, conf.Save())
+
+	t.Run("DefaultBucketType", func(t *testing.T) {
+		assert.Equal(t, PailS3, simpleLogBucketType(conf))
+	})
+	conf.Bucket.BuildLogsBucketType = PailLocal
+	require.NoError(t, conf.Save())
+	t.Run("BucketType", func(t *testing.T) {
+		assert.Equal(t, PailLocal, simpleLogBucketType(conf))
+	})
+	t.Run("InvalidIncrement", func(t *testing.T) {
+		assert.Error(t, AppendSimpleLog(ctx, env, "simple0", -1, "line0"))
+	})
+	t.Run("Append", func(t *testing.T) {
+		require.NoError(t, AppendSimpleLog(ctx, env, "simple1", 1, "line2\nline3"))
+		require.NoError(t, AppendSimpleLog(ctx, env, "simple1", 0, "line0\nline1"))
+		// Replayed increments are skipped.
+		require.NoError(t, AppendSimpleLog(ctx, env, "simple1", 1, "line2\nline3"))
+
+		log, err := FindSimpleLog(ctx, env, "simple1")
+		require.NoError(t, err)
+		assert.Equal(t, SimpleLogInfo("simple1"), log.Info)
+		assert.Equal(t, PailLocal, log.Artifact.Type)
+		assert.Equal(t, 4, log.Stats.NumLines)
+
+		it, err := log.Download(ctx, TimeRange{EndAt: utility.MaxTime})
+		require.NoError(t, err)
+		var lines []string
+		for it.Next(ctx) {
+			lines = append(lines, it.Item().Data)
+		}
+		require.NoError(t, it.Err())
+		require.NoError(t, it.Close())
+		assert.Equal(t, []string{"line0\n", "line1\n", "line2\n", "line3\n"}, lines)
+	})
+}
+
+func TestMigrateSimpleLog(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir, err := ioutil.TempDir(".", "simple-log-migration-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+		assert.NoError(t, db.Collection(buildloggerCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(logRecordCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(logSegmentsCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(env.GetConfig().DbConfigurationCollection).Drop(ctx))
+	}()
+	conf := &CedarConfig{
+		populated: true,
+		Bucket: BucketConfig{
+			BuildLogsBucket:     tmpDir,
+			BuildLogsBucketType: PailLocal,
+		},
+	}
+	conf.Setup(env)
+	require.NoError(t, conf.Save())
+
+	// Legacy simple logs are stored in a bucket of the same storage type
+	// as buildlogger logs.
+	legacyBucketName := filepath.Join(tmpDir, "legacy")
+	require.NoError(t, os.MkdirAll(legacyBucketName, 0755))
+	legacyBucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: legacyBucketName})
+	require.NoError(t, err)
+	putLegacyLog := func(t *testing.T, key, content string) {
+		require.NoError(t, legacyBucket.Put(ctx, key, strings.NewReader(content)))
+	}
+	insertSegment := func(t *testing.T, logID string, segment int, key string) {
+		_, err := db.Collection(logSegmentsCollection).InsertOne(ctx, LogSegment{
+			ID:      fmt.Sprintf("%s.%d", logID, segment),
+			LogID:   logID,
+			Segment: segment,
+			Bucket:  legacyBucketName,
+			KeyName: key,
+		})
+		require.NoError(t, err)
+	}
+	downloadLines := func(t *testing.T, logID string) []string {
+		log, err := FindSimpleLog(ctx, env, logID)
+		require.NoError(t, err)
+		it, err := log.Download(ctx, TimeRange{EndAt: utility.MaxTime})
+		require.NoError(t, err)
+		var lines []string
+		for it.Next(ctx) {
+			lines = append(lines, it.Item().Data)
+		}
+		require.NoError(t, it.Err())
+		require.NoError(t, it.Close())
+		return lines
+	}
+
+	putLegacyLog(t, "simple-log/simple1", "merged0\nmerged1\n")
+	_, err = db.Collection(logRecordCollection).InsertOne(ctx, LogRecord{
+		LogID:   "simple1",
+		Bucket:  legacyBucketName,
+		KeyName: "simple-log/simple1",
+	})
+	require.NoError(t, err)
+	putLegacyLog(t, "simple-log/simple1.0", "seg0")
+	insertSegment(t, "simple1", 0, "simple-log/simple1.0")
+	// The segment's object is missing, so migrating it fails unless it
+	// is skipped.
+	insertSegment(t, "simple1", 1, "simple-log/simple1.1")
+	require.NoError(t, AppendSimpleLog(ctx, env, "simple1", 1, "seg1"))
+
+	t.Run("Migrate", func(t *testing.T) {
+		require.NoError(t, MigrateSimpleLog(ctx, env, "simple1"))
+		assert.Equal(t, []string{"merged0\n", "merged1\n", "seg0\n", "seg1\n"}, downloadLines(t, "simple1"))
+
+		record := LogRecord{}
+		require.NoError(t, db.Collection(logRecordCollection).FindOne(ctx, bson.M{logRecordIDKey: "simple1"}).Decode(&record))
+		assert.True(t, record.Migrated)
+		count, err := db.Collection(logSegmentsCollection).CountDocuments(ctx, bson.M{
+			logSegmentLogIDKey:    "simple1",
+			logSegmentMigratedKey: true,
+		})
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, count)
+	})
+	t.Run("Idempotent", func(t *testing.T) {
+		require.NoError(t, MigrateSimpleLog(ctx, env, "simple1"))
+		assert.Equal(t, []string{"merged0\n", "merged1\n", "seg0\n", "seg1\n"}, downloadLines(t, "simple1"))
+
+		log, err := FindSimpleLog(ctx, env, "simple1")
+		require.NoError(t, err)
+		assert.Equal(t, 4, log.Stats.NumLines)
+	})
+	t.Run("MissingSegment", func(t *testing.T) {
+		insertSegment(t, "simple2", 0, "simple-log/simple2.0")
+		assert.Error(t, MigrateSimpleLog(ctx, env, "simple2"))
+
+		count, err := db.Collection(logSegmentsCollection).CountDocuments(ctx, bson.M{
+			logSegmentLogIDKey:    "simple2",
+			logSegmentMigratedKey: true,
+		})
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+	t.Run("MigrateSimpleLogs", func(t *testing.T) {
+		putLegacyLog(t, "simple-log/simple2.0", "seg0")
+		putLegacyLog(t, "simple-log/simple3.0", "seg0")
+		insertSegment(t, "simple3", 0, "simple-log/simple3.0")
+
+		migrated, err := MigrateSimpleLogs(ctx, env, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 2, migrated.Found)
+		assert.Equal(t, []string{"simple2", "simple3"}, migrated.Migrated)
+		assert.Equal(t, []string{"seg0\n"}, downloadLines(t, "simple3"))
+
+		migrated, err = MigrateSimpleLogs(ctx, env, 10)
+		require.NoError(t, err)
+		assert.Zero(t, migrated.Found)
+	})
+}
+
+func TestMigrateSimpleLogs(t *testing.T) {
+	env := cedar.GetEnvironment()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	migrated, err := MigrateSimpleLogs(ctx, nil, 10)
+	assert.Error(t, err)
+	assert.Nil(t, migrated)
+
+	migrated, err = MigrateSimpleLogs(ctx, env, 10)
+	require.NoError(t, err)
+	assert.Zero(t, migrated.Found)
+	assert.Empty(t, migrated.Migrated)
+}
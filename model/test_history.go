@@ -0,0 +1,330 @@
+package model
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/anser/db"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	testHistoryCollection = "test_history"
+
+	// DefaultTestHistoryLimit is the default maximum number of test history
+	// entries returned by FindTestHistory.
+	DefaultTestHistoryLimit = 100
+	// MaxTestHistoryLimit is the maximum number of test history entries
+	// returned by FindTestHistory.
+	MaxTestHistoryLimit = 1000
+)
+
+// TestHistoryEntry is the rollup of the results of a single test in a single
+// task execution. Entries are maintained as test results are appended, so the
+// history of a test can be read without downloading the test results of
+// every task execution.
+type TestHistoryEntry struct {
+	ID              string    `bson:"_id"`
+	TestResultsID   string    `bson:"test_results_id"`
+	Project         string    `bson:"project"`
+	Version         string    `bson:"version"`
+	Variant         string    `bson:"variant"`
+	TaskName        string    `bson:"task_name"`
+	DisplayTaskName string    `bson:"display_task_name,omitempty"`
+	TaskID          string    `bson:"task_id"`
+	Execution       int       `bson:"execution"`
+	RequestType     string    `bson:"request_type"`
+	Mainline        bool      `bson:"mainline"`
+	CreatedAt       time.Time `bson:"created_at"`
+	TestName        string    `bson:"test_name"`
+	// Status and Duration are those of the last appended result of the
+	// test. Tests may have more than one result in a task execution, e.g.
+	// when retried, so the number of results and of passed and failed
+	// results are kept too. Results that neither passed nor failed, e.g.
+	// skipped results, are only counted in the number of results.
+	Status      string        `bson:"status"`
+	Duration    time.Duration `bson:"duration"`
+	NumResults  int           `bson:"num_results"`
+	NumPassed   int           `bson:"num_passed"`
+	NumFailed   int           `bson:"num_failed"`
+	TestEndTime time.Time     `bson:"test_end_time"`
+}
+
+var (
+	testHistoryEntryIDKey              = bsonutil.MustHaveTag(TestHistoryEntry{}, "ID")
+	testHistoryEntryTestResultsIDKey   = bsonutil.MustHaveTag(TestHistoryEntry{}, "TestResultsID")
+	testHistoryEntryProjectKey         = bsonutil.MustHaveTag(TestHistoryEntry{}, "Project")
+	testHistoryEntryVersionKey         = bsonutil.MustHaveTag(TestHistoryEntry{}, "Version")
+	testHistoryEntryVariantKey         = bsonutil.MustHaveTag(TestHistoryEntry{}, "Variant")
+	testHistoryEntryTaskNameKey        = bsonutil.MustHaveTag(TestHistoryEntry{}, "TaskName")
+	testHistoryEntryDisplayTaskNameKey = bsonutil.MustHaveTag(TestHistoryEntry{}, "DisplayTaskName")
+	testHistoryEntryTaskIDKey          = bsonutil.MustHaveTag(TestHistoryEntry{}, "TaskID")
+	testHistoryEntryExecutionKey       = bsonutil.MustHaveTag(TestHistoryEntry{}, "Execution")
+	testHistoryEntryRequestTypeKey     = bsonutil.MustHaveTag(TestHistoryEntry{}, "RequestType")
+	testHistoryEntryMainlineKey        = bsonutil.MustHaveTag(TestHistoryEntry{}, "Mainline")
+	testHistoryEntryCreatedAtKey       = bsonutil.MustHaveTag(TestHistoryEntry{}, "CreatedAt")
+	testHistoryEntryTestNameKey        = bsonutil.MustHaveTag(TestHistoryEntry{}, "TestName")
+	testHistoryEntryStatusKey          = bsonutil.MustHaveTag(TestHistoryEntry{}, "Status")
+	testHistoryEntryDurationKey        = bsonutil.MustHaveTag(TestHistoryEntry{}, "Duration")
+	testHistoryEntryNumResultsKey      = bsonutil.MustHaveTag(TestHistoryEntry{}, "NumResults")
+	testHistoryEntryNumPassedKey       = bsonutil.MustHaveTag(TestHistoryEntry{}, "NumPassed")
+	testHistoryEntryNumFailedKey       = bsonutil.MustHaveTag(TestHistoryEntry{}, "NumFailed")
+	testHistoryEntryTestEndTimeKey     = bsonutil.MustHaveTag(TestHistoryEntry{}, "TestEndTime")
+)
+
+// testHistoryEntryID returns the ID of the test history entry of the given
+// test in the given test results record.
+func testHistoryEntryID(testResultsID, testName string) string {
+	hash := sha1.New()
+	_, _ = io.WriteString(hash, testResultsID)
+	_, _ = io.WriteString(hash, testName)
+
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// isFailedTestStatus returns whether the given test result status is a
+// failure.
+func isFailedTestStatus(status string) bool {
+	return strings.Contains(strings.ToLower(status), "fail")
+}
+
+// isPassedTestStatus returns whether the given test result status is a pass.
+func isPassedTestStatus(status string) bool {
+	return strings.Contains(strings.ToLower(status), "pass")
+}
+
+// updateTestHistory upserts the test history entries of the given results.
+// The counts are incremented, so concurrent appends are rolled up correctly.
+func (t *TestResults) updateTestHistory(ctx context.Context, results []TestResult) error {
+	models := make([]mongo.WriteModel, 0, len(results))
+	for _, result := range results {
+		testName := result.GetDisplayName()
+		var numPassed, numFailed int
+		switch {
+		case isFailedTestStatus(result.Status):
+			numFailed = 1
+		case isPassedTestStatus(result.Status):
+			numPassed = 1
+		}
+
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{testHistoryEntryIDKey: testHistoryEntryID(t.ID, testName)}).
+			SetUpdate(bson.M{
+				"$setOnInsert": bson.M{
+					testHistoryEntryTestResultsIDKey:   t.ID,
+					testHistoryEntryProjectKey:         t.Info.Project,
+					testHistoryEntryVersionKey:         t.Info.Version,
+					testHistoryEntryVariantKey:         t.Info.Variant,
+					testHistoryEntryTaskNameKey:        t.Info.TaskName,
+					testHistoryEntryDisplayTaskNameKey: t.Info.DisplayTaskName,
+					testHistoryEntryTaskIDKey:          t.Info.TaskID,
+					testHistoryEntryExecutionKey:       t.Info.Execution,
+					testHistoryEntryRequestTypeKey:     t.Info.RequestType,
+					testHistoryEntryMainlineKey:        t.Info.Mainline,
+					testHistoryEntryCreatedAtKey:       t.CreatedAt,
+					testHistoryEntryTestNameKey:        testName,
+				},
+				"$set": bson.M{
+					testHistoryEntryStatusKey:      result.Status,
+					testHistoryEntryDurationKey:    result.TestEndTime.Sub(result.TestStartTime),
+					testHistoryEntryTestEndTimeKey: result.TestEndTime,
+				},
+				"$inc": bson.M{
+					testHistoryEntryNumResultsKey: 1,
+					testHistoryEntryNumPassedKey:  numPassed,
+					testHistoryEntryNumFailedKey:  numFailed,
+				},
+			}).
+			SetUpsert(true))
+	}
+	if len(models) == 0 {
+		return nil
+	}
+
+	writeResult, err := t.env.GetDB().Collection(testHistoryCollection).BulkWrite(ctx, models)
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection":   testHistoryCollection,
+		"id":           t.ID,
+		"results":      len(results),
+		"write_result": writeResult,
+		"op":           "update test history",
+	})
+
+	return errors.Wrapf(err, "updating test history of test results record '%s'", t.ID)
+}
+
+// removeTestHistory removes the test history entries of the test results
+// record.
+func (t *TestResults) removeTestHistory(ctx context.Context) error {
+	deleteResult, err := t.env.GetDB().Collection(testHistoryCollection).DeleteMany(ctx, bson.M{testHistoryEntryTestResultsIDKey: t.ID})
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection":   testHistoryCollection,
+		"id":           t.ID,
+		"deleteResult": deleteResult,
+		"op":           "remove test history",
+	})
+
+	return errors.Wrapf(err, "removing test history of test results record '%s'", t.ID)
+}
+
+// TestHistoryOptions specify the arguments for finding the history of a test.
+// The project and test name are required.
+type TestHistoryOptions struct {
+	Project  string
+	Variant  string
+	TaskName string
+	TestName string
+	// Mainline restricts the history to mainline task executions.
+	Mainline bool
+	// TimeRange restricts the history to task executions of test results
+	// records created within the range. Versions are ordered by creation
+	// time, so this also restricts the history to a range of versions.
+	TimeRange TimeRange
+	// StartVersion and EndVersion restrict the history to the task
+	// executions created from the first entry of the start version to the
+	// last entry of the end version of the test, inclusive. Either may be
+	// omitted to leave that end of the range open. They cannot be combined
+	// with a time range.
+	StartVersion string
+	EndVersion   string
+	// Limit is the maximum number of entries returned. It defaults to
+	// DefaultTestHistoryLimit and may not exceed MaxTestHistoryLimit.
+	Limit int
+}
+
+// Validate ensures TestHistoryOptions is configured correctly.
+func (o *TestHistoryOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+
+	catcher.NewWhen(o.Project == "", "must specify a project")
+	catcher.NewWhen(o.TestName == "", "must specify a test name")
+	catcher.NewWhen(!o.TimeRange.IsZero() && !o.TimeRange.IsValid(), "invalid time range")
+	catcher.NewWhen(!o.TimeRange.IsZero() && o.hasVersionRange(), "cannot specify both a time range and a version range")
+	catcher.NewWhen(o.Limit < 0, "limit cannot be negative")
+	catcher.ErrorfWhen(o.Limit > MaxTestHistoryLimit, "limit cannot exceed %d", MaxTestHistoryLimit)
+	if o.Limit == 0 {
+		o.Limit = DefaultTestHistoryLimit
+	}
+
+	return catcher.Resolve()
+}
+
+func (o *TestHistoryOptions) hasVersionRange() bool {
+	return o.StartVersion != "" || o.EndVersion != ""
+}
+
+// resolveVersionRange sets the time range to the creation times of the
+// test's history entries of the start and end versions. Returns false if
+// either version has no entries of the test, or the versions are out of
+// order, since no entries fall in the range.
+func (o *TestHistoryOptions) resolveVersionRange(ctx context.Context, env cedar.Environment) (bool, error) {
+	for _, bound := range []struct {
+		version string
+		sort    int
+		at      *time.Time
+	}{
+		{version: o.StartVersion, sort: 1, at: &o.TimeRange.StartAt},
+		{version: o.EndVersion, sort: -1, at: &o.TimeRange.EndAt},
+	} {
+		if bound.version == "" {
+			continue
+		}
+
+		entry := TestHistoryEntry{}
+		err := env.GetDB().Collection(testHistoryCollection).FindOne(
+			ctx,
+			bson.M{
+				testHistoryEntryProjectKey:  o.Project,
+				testHistoryEntryTestNameKey: o.TestName,
+				testHistoryEntryVersionKey:  bound.version,
+			},
+			options.FindOne().SetSort(bson.D{{Key: testHistoryEntryCreatedAtKey, Value: bound.sort}}),
+		).Decode(&entry)
+		if db.ResultsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, errors.Wrapf(err, "finding test history of version '%s'", bound.version)
+		}
+		*bound.at = entry.CreatedAt
+	}
+
+	return o.TimeRange.StartAt.IsZero() || o.TimeRange.EndAt.IsZero() || !o.TimeRange.EndAt.Before(o.TimeRange.StartAt), nil
+}
+
+func (o *TestHistoryOptions) createFindQuery() bson.M {
+	query := bson.M{
+		testHistoryEntryProjectKey:  o.Project,
+		testHistoryEntryTestNameKey: o.TestName,
+	}
+	if o.Variant != "" {
+		query[testHistoryEntryVariantKey] = o.Variant
+	}
+	if o.TaskName != "" {
+		query[testHistoryEntryTaskNameKey] = o.TaskName
+	}
+	if o.Mainline {
+		query[testHistoryEntryMainlineKey] = true
+	}
+	createdAt := bson.M{}
+	if !o.TimeRange.StartAt.IsZero() {
+		createdAt["$gte"] = o.TimeRange.StartAt
+	}
+	if !o.TimeRange.EndAt.IsZero() {
+		createdAt["$lte"] = o.TimeRange.EndAt
+	}
+	if len(createdAt) > 0 {
+		query[testHistoryEntryCreatedAtKey] = createdAt
+	}
+
+	return query
+}
+
+// FindTestHistory returns the history entries of the test matching the given
+// options, most recent first. The environment should not be nil.
+func FindTestHistory(ctx context.Context, env cedar.Environment, opts TestHistoryOptions) ([]TestHistoryEntry, error) {
+	if env == nil {
+		return nil, errors.New("cannot find test history with a nil environment")
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid test history options")
+	}
+	if opts.hasVersionRange() {
+		found, err := opts.resolveVersionRange(ctx, env)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return []TestHistoryEntry{}, nil
+		}
+	}
+
+	findOpts := options.Find().
+		SetSort(bson.D{
+			{Key: testHistoryEntryCreatedAtKey, Value: -1},
+			{Key: testHistoryEntryExecutionKey, Value: -1},
+		}).
+		SetLimit(int64(opts.Limit))
+	cur, err := env.GetDB().Collection(testHistoryCollection).Find(ctx, opts.createFindQuery(), findOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding test history")
+	}
+
+	entries := []TestHistoryEntry{}
+	if err = cur.All(ctx, &entries); err != nil {
+		return nil, errors.Wrap(err, "decoding test history")
+	}
+
+	return entries, nil
+}
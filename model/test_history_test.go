@@ -0,0 +1,205 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestHistoryOptionsValidate(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		opts   TestHistoryOptions
+		hasErr bool
+	}{
+		{
+			name:   "NoProject",
+			opts:   TestHistoryOptions{TestName: "test"},
+			hasErr: true,
+		},
+		{
+			name:   "NoTestName",
+			opts:   TestHistoryOptions{Project: "project"},
+			hasErr: true,
+		},
+		{
+			name: "InvalidTimeRange",
+			opts: TestHistoryOptions{
+				Project:   "project",
+				TestName:  "test",
+				TimeRange: TimeRange{StartAt: time.Now(), EndAt: time.Now().Add(-time.Hour)},
+			},
+			hasErr: true,
+		},
+		{
+			name: "TimeAndVersionRange",
+			opts: TestHistoryOptions{
+				Project:      "project",
+				TestName:     "test",
+				TimeRange:    TimeRange{StartAt: time.Now().Add(-time.Hour), EndAt: time.Now()},
+				StartVersion: "a",
+			},
+			hasErr: true,
+		},
+		{
+			name:   "NegativeLimit",
+			opts:   TestHistoryOptions{Project: "project", TestName: "test", Limit: -1},
+			hasErr: true,
+		},
+		{
+			name:   "LimitTooLarge",
+			opts:   TestHistoryOptions{Project: "project", TestName: "test", Limit: MaxTestHistoryLimit + 1},
+			hasErr: true,
+		},
+		{
+			name: "Valid",
+			opts: TestHistoryOptions{Project: "project", TestName: "test"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.opts.Validate()
+			if test.hasErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, DefaultTestHistoryLimit, test.opts.Limit)
+			}
+		})
+	}
+}
+
+func TestFindTestHistory(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir := t.TempDir()
+	defer func() {
+		assert.NoError(t, db.Collection(env.GetConfig().DbConfigurationCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(testResultsCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(testHistoryCollection).Drop(ctx))
+	}()
+
+	conf := &CedarConfig{populated: true}
+	conf.Setup(env)
+	conf.Bucket.PrestoBucket = tmpDir
+	conf.Bucket.PrestoTestResultsPrefix = "presto-test-results"
+	require.NoError(t, conf.Save())
+
+	_, err := FindTestHistory(ctx, nil, TestHistoryOptions{Project: "project", TestName: "test0"})
+	assert.Error(t, err)
+	_, err = FindTestHistory(ctx, env, TestHistoryOptions{Project: "project"})
+	assert.Error(t, err)
+
+	var records []*TestResults
+	for i := 0; i < 3; i++ {
+		for _, variant := range []string{"linux", "windows"} {
+			record := CreateTestResults(TestResultsInfo{
+				Project:   "project",
+				Version:   string(rune('a' + i)),
+				Variant:   variant,
+				TaskName:  "task",
+				TaskID:    variant + string(rune('a'+i)),
+				Execution: 0,
+				Mainline:  i != 1,
+			}, PailLocal)
+			record.CreatedAt = time.Now().Add(time.Duration(i-3) * time.Hour).Round(time.Millisecond).UTC()
+			record.Setup(env)
+			require.NoError(t, record.SaveNew(ctx))
+			records = append(records, record)
+
+			start := time.Now().Round(time.Millisecond).UTC()
+			require.NoError(t, record.Append(ctx, []TestResult{
+				{TestName: "test0", Status: "fail", TestStartTime: start, TestEndTime: start.Add(time.Second)},
+				{TestName: "test1", Status: "pass", TestStartTime: start, TestEndTime: start.Add(time.Second)},
+			}))
+			require.NoError(t, record.Append(ctx, []TestResult{
+				{TestName: "test0", Status: "pass", Trial: 1, TestStartTime: start, TestEndTime: start.Add(2 * time.Second)},
+			}))
+		}
+	}
+
+	t.Run("AllVariants", func(t *testing.T) {
+		history, err := FindTestHistory(ctx, env, TestHistoryOptions{Project: "project", TestName: "test0"})
+		require.NoError(t, err)
+		require.Len(t, history, 6)
+		for i := 1; i < len(history); i++ {
+			assert.False(t, history[i].CreatedAt.After(history[i-1].CreatedAt))
+		}
+		assert.Equal(t, "pass", history[0].Status)
+		assert.Equal(t, 2*time.Second, history[0].Duration)
+		assert.Equal(t, 2, history[0].NumResults)
+		assert.Equal(t, 1, history[0].NumPassed)
+		assert.Equal(t, 1, history[0].NumFailed)
+		assert.Equal(t, "c", history[0].Version)
+	})
+	t.Run("Filtered", func(t *testing.T) {
+		history, err := FindTestHistory(ctx, env, TestHistoryOptions{
+			Project:  "project",
+			Variant:  "linux",
+			TaskName: "task",
+			TestName: "test1",
+			Mainline: true,
+			Limit:    1,
+		})
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.Equal(t, "linux", history[0].Variant)
+		assert.Equal(t, "c", history[0].Version)
+		assert.Equal(t, 1, history[0].NumResults)
+		assert.Equal(t, 1, history[0].NumPassed)
+		assert.Zero(t, history[0].NumFailed)
+	})
+	t.Run("TimeRange", func(t *testing.T) {
+		history, err := FindTestHistory(ctx, env, TestHistoryOptions{
+			Project:  "project",
+			Variant:  "windows",
+			TestName: "test1",
+			TimeRange: TimeRange{
+				StartAt: records[1].CreatedAt,
+				EndAt:   records[3].CreatedAt,
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Equal(t, "b", history[0].Version)
+		assert.Equal(t, "a", history[1].Version)
+	})
+	t.Run("VersionRange", func(t *testing.T) {
+		history, err := FindTestHistory(ctx, env, TestHistoryOptions{
+			Project:      "project",
+			Variant:      "windows",
+			TestName:     "test1",
+			StartVersion: "b",
+			EndVersion:   "c",
+		})
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Equal(t, "c", history[0].Version)
+		assert.Equal(t, "b", history[1].Version)
+
+		history, err = FindTestHistory(ctx, env, TestHistoryOptions{Project: "project", TestName: "test1", EndVersion: "a"})
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Equal(t, "a", history[0].Version)
+
+		history, err = FindTestHistory(ctx, env, TestHistoryOptions{Project: "project", TestName: "test1", StartVersion: "c", EndVersion: "a"})
+		require.NoError(t, err)
+		assert.Empty(t, history)
+
+		history, err = FindTestHistory(ctx, env, TestHistoryOptions{Project: "project", TestName: "test1", StartVersion: "DNE"})
+		require.NoError(t, err)
+		assert.Empty(t, history)
+	})
+	t.Run("RemovedWithRecord", func(t *testing.T) {
+		require.NoError(t, records[5].Remove(ctx))
+		history, err := FindTestHistory(ctx, env, TestHistoryOptions{Project: "project", Variant: "windows", TestName: "test0"})
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Equal(t, "b", history[0].Version)
+	})
+}
@@ -12,7 +12,6 @@ import (
 	"regexp"
 	"runtime"
 	"sort"
-	"strings"
 	"sync"
 	"time"
 
@@ -91,7 +90,7 @@ func CreateTestResults(info TestResultsInfo, artifactStorageType PailType) *Test
 		Artifact: TestResultsArtifactInfo{
 			Type:    artifactStorageType,
 			Prefix:  info.ID(),
-			Version: 1,
+			Version: 2,
 		},
 		populated: true,
 	}
@@ -106,7 +105,11 @@ func (t *TestResults) IsNil() bool { return !t.populated }
 
 // PrestoPartitionKey returns the partition key for the S3 bucket in Presto.
 func (t *TestResults) PrestoPartitionKey() string {
-	return fmt.Sprintf("task_create_iso=%s/project=%s/%s", t.CreatedAt.UTC().Format(parquetDateFormat), t.Info.Project, t.Artifact.Prefix)
+	return fmt.Sprintf("%s/%s", t.prestoPartition(), t.Artifact.Prefix)
+}
+
+func (t *TestResults) prestoPartition() string {
+	return fmt.Sprintf("task_create_iso=%s/project=%s", t.CreatedAt.UTC().Format(parquetDateFormat), t.Info.Project)
 }
 
 // Find searches the DB for the TestResults record. The environment should not
@@ -155,8 +158,8 @@ func (t *TestResults) SaveNew(ctx context.Context) error {
 	return errors.Wrapf(err, "saving new test results record '%s'", t.ID)
 }
 
-// Remove removes the TestResults record from the DB. The environment should
-// not be nil.
+// Remove removes the TestResults record, along with its test history entries,
+// from the DB. The environment should not be nil.
 func (t *TestResults) Remove(ctx context.Context) error {
 	if t.env == nil {
 		return errors.New("cannot remove with a nil environment")
@@ -166,6 +169,10 @@ func (t *TestResults) Remove(ctx context.Context) error {
 		t.ID = t.Info.ID()
 	}
 
+	if err := t.removeTestHistory(ctx); err != nil {
+		return err
+	}
+
 	deleteResult, err := t.env.GetDB().Collection(testResultsCollection).DeleteOne(ctx, bson.M{"_id": t.ID})
 	grip.DebugWhen(err == nil, message.Fields{
 		"collection":   testResultsCollection,
@@ -177,9 +184,61 @@ func (t *TestResults) Remove(ctx context.Context) error {
 	return errors.Wrapf(err, "removing test results record '%s'", t.ID)
 }
 
+// RemoveArtifacts removes the test results from the offline blob storage
+// bucket configured for the task execution. The TestResults record should be
+// populated and the environment should not be nil.
+func (t *TestResults) RemoveArtifacts(ctx context.Context) error {
+	if !t.populated {
+		return errors.New("cannot remove artifacts of unpopulated test results")
+	}
+	if t.env == nil {
+		return errors.New("cannot remove artifacts with a nil environment")
+	}
+	// Every artifact of the record is stored under its prefix, removing
+	// the artifacts of a record without one would remove those of other
+	// records.
+	if t.Artifact.Prefix == "" {
+		return errors.Errorf("cannot remove artifacts of record '%s' without an artifact prefix", t.ID)
+	}
+
+	switch t.Artifact.Version {
+	case 0:
+		bucket, err := t.GetBucket(ctx)
+		if err != nil {
+			return err
+		}
+
+		return errors.Wrapf(bucket.RemovePrefix(ctx, ""), "removing test results of record '%s'", t.ID)
+	case 1:
+		bucket, err := t.GetPrestoBucket(ctx)
+		if err != nil {
+			return err
+		}
+
+		return errors.Wrapf(bucket.Remove(ctx, t.PrestoPartitionKey()), "removing Parquet test results of record '%s'", t.ID)
+	case 2:
+		bucket, err := t.GetPrestoBucket(ctx)
+		if err != nil {
+			return err
+		}
+
+		if err = bucket.Remove(ctx, t.PrestoPartitionKey()); err != nil && !pail.IsKeyNotFoundError(err) {
+			return errors.Wrapf(err, "removing Parquet test results of record '%s'", t.ID)
+		}
+
+		return errors.Wrapf(bucket.RemovePrefix(ctx, t.partsPrefix()), "removing Parquet test results parts of record '%s'", t.ID)
+	default:
+		return errors.Errorf("unsupported test results artifact version '%d'", t.Artifact.Version)
+	}
+}
+
 // Append uploads test results to the offline blob storage bucket configured
-// for the task execution. The TestResults record should be populated and the
-// environment should not be nil.
+// for the task execution. Version 2 test results are uploaded as a new Parquet
+// part file, so concurrent appends never overwrite each other; earlier
+// versions rewrite the record's single Parquet file. The stats, failed tests
+// sample, and test history entries of the record are updated too. The
+// TestResults record should be populated and the environment should not be
+// nil.
 func (t *TestResults) Append(ctx context.Context, results []TestResult) error {
 	if !t.populated {
 		return errors.New("cannot append without populated test results")
@@ -196,17 +255,23 @@ func (t *TestResults) Append(ctx context.Context, results []TestResult) error {
 		return nil
 	}
 
-	allResults, err := t.downloadParquet(ctx)
-	if err != nil && !pail.IsKeyNotFoundError(err) {
-		return errors.Wrap(err, "getting uploaded test results")
-	}
-	allResults = append(allResults, results...)
+	if t.Artifact.Version == 2 {
+		if err := t.uploadParquet(ctx, t.newPartKey(), t.convertToParquet(results)); err != nil {
+			return errors.Wrap(err, "uploading Parquet test results part")
+		}
+	} else {
+		allResults, err := t.downloadParquet(ctx, t.PrestoPartitionKey())
+		if err != nil && !pail.IsKeyNotFoundError(err) {
+			return errors.Wrap(err, "getting uploaded test results")
+		}
+		allResults = append(allResults, results...)
 
-	if err = t.uploadParquet(ctx, t.convertToParquet(allResults)); err != nil {
-		return errors.Wrap(err, "appending Parquet test results")
+		if err = t.uploadParquet(ctx, t.PrestoPartitionKey(), t.convertToParquet(allResults)); err != nil {
+			return errors.Wrap(err, "appending Parquet test results")
+		}
 	}
 
-	if err = t.env.GetStatsCache(cedar.StatsCacheTestResults).AddStat(cedar.Stat{
+	if err := t.env.GetStatsCache(cedar.StatsCacheTestResults).AddStat(cedar.Stat{
 		Count:   len(results),
 		Project: t.Info.Project,
 		Version: t.Info.Version,
@@ -218,10 +283,14 @@ func (t *TestResults) Append(ctx context.Context, results []TestResult) error {
 		}))
 	}
 
-	return t.updateStatsAndFailedSample(ctx, results)
+	if err := t.updateStatsAndFailedSample(ctx, results); err != nil {
+		return err
+	}
+
+	return t.updateTestHistory(ctx, results)
 }
 
-func (t *TestResults) uploadParquet(ctx context.Context, results *ParquetTestResults) error {
+func (t *TestResults) uploadParquet(ctx context.Context, key string, results *ParquetTestResults) error {
 	conf := &CedarConfig{}
 	conf.Setup(t.env)
 	if err := conf.Find(); err != nil {
@@ -232,7 +301,7 @@ func (t *TestResults) uploadParquet(ctx context.Context, results *ParquetTestRes
 	if err != nil {
 		return err
 	}
-	w, err := bucket.Writer(ctx, t.PrestoPartitionKey())
+	w, err := bucket.Writer(ctx, key)
 	if err != nil {
 		return errors.Wrap(err, "creating Presto bucket writer")
 	}
@@ -254,45 +323,67 @@ func (t *TestResults) uploadParquet(ctx context.Context, results *ParquetTestRes
 	return errors.Wrap(pw.Write(results), "writing Parquet test results")
 }
 
+// updateStatsAndFailedSample atomically increments the stats and appends to
+// the failed tests sample of the record in the DB, so that concurrent appends
+// do not overwrite each other's updates.
 func (t *TestResults) updateStatsAndFailedSample(ctx context.Context, results []TestResult) error {
-	var failedCount int
+	var failed []string
 	for i := 0; i < len(results); i++ {
-		if strings.Contains(strings.ToLower(results[i].Status), "fail") {
-			if len(t.FailedTestsSample) < FailedTestsSampleSize {
-				t.FailedTestsSample = append(t.FailedTestsSample, results[i].GetDisplayName())
-			}
-			failedCount++
+		if isFailedTestStatus(results[i].Status) {
+			failed = append(failed, results[i].GetDisplayName())
 		}
 	}
+	sample := failed
+	if len(sample) > FailedTestsSampleSize {
+		sample = sample[:FailedTestsSampleSize]
+	}
 
-	updateResult, err := t.env.GetDB().Collection(testResultsCollection).UpdateOne(
-		ctx,
-		bson.M{testResultsIDKey: t.ID},
-		bson.M{
-			"$inc": bson.M{
-				bsonutil.GetDottedKeyName(testResultsStatsKey, testResultsStatsTotalCountKey):  len(results),
-				bsonutil.GetDottedKeyName(testResultsStatsKey, testResultsStatsFailedCountKey): failedCount,
-			},
-			"$set": bson.M{
-				testResultsFailedTestsSampleKey: t.FailedTestsSample,
-			},
+	update := bson.M{
+		"$inc": bson.M{
+			bsonutil.GetDottedKeyName(testResultsStatsKey, testResultsStatsTotalCountKey):  len(results),
+			bsonutil.GetDottedKeyName(testResultsStatsKey, testResultsStatsFailedCountKey): len(failed),
 		},
-	)
+	}
+	if len(sample) > 0 {
+		// Records saved without failed tests have a null sample, which
+		// cannot be pushed to.
+		_, err := t.env.GetDB().Collection(testResultsCollection).UpdateOne(
+			ctx,
+			bson.M{testResultsIDKey: t.ID, testResultsFailedTestsSampleKey: nil},
+			bson.M{"$set": bson.M{testResultsFailedTestsSampleKey: []string{}}},
+		)
+		if err != nil {
+			return errors.Wrapf(err, "initializing failing tests sample for test result record '%s'", t.ID)
+		}
+
+		update["$push"] = bson.M{
+			testResultsFailedTestsSampleKey: bson.M{
+				"$each":  sample,
+				"$slice": FailedTestsSampleSize,
+			},
+		}
+	}
+
+	updateResult, err := t.env.GetDB().Collection(testResultsCollection).UpdateOne(ctx, bson.M{testResultsIDKey: t.ID}, update)
 	grip.DebugWhen(err == nil, message.Fields{
-		"collection":          testResultsCollection,
-		"id":                  t.ID,
-		"inc_total_count":     len(results),
-		"inc_failed_count":    failedCount,
-		"failed_tests_sample": t.FailedTestsSample,
-		"update_result":       updateResult,
-		"op":                  "updating stats and failing tests sample",
+		"collection":       testResultsCollection,
+		"id":               t.ID,
+		"inc_total_count":  len(results),
+		"inc_failed_count": len(failed),
+		"push_failed":      sample,
+		"update_result":    updateResult,
+		"op":               "updating stats and failing tests sample",
 	})
 	if err == nil && updateResult.MatchedCount == 0 {
 		err = errors.Errorf("could not find test results record '%s'", t.ID)
 	}
 
 	t.Stats.TotalCount += len(results)
-	t.Stats.FailedCount += failedCount
+	t.Stats.FailedCount += len(failed)
+	t.FailedTestsSample = append(t.FailedTestsSample, sample...)
+	if len(t.FailedTestsSample) > FailedTestsSampleSize {
+		t.FailedTestsSample = t.FailedTestsSample[:FailedTestsSampleSize]
+	}
 
 	return errors.Wrapf(err, "appending to failing tests sample for test result record '%s'", t.ID)
 }
@@ -331,19 +422,21 @@ func (t *TestResults) Download(ctx context.Context) ([]TestResult, error) {
 
 		return results, catcher.Resolve()
 	case 1:
-		return t.downloadParquet(ctx)
+		return t.downloadParquet(ctx, t.PrestoPartitionKey())
+	case 2:
+		return t.downloadParts(ctx)
 	default:
 		return nil, errors.Errorf("unsupported test results artifact version '%d'", t.Artifact.Version)
 	}
 }
 
-func (t *TestResults) downloadParquet(ctx context.Context) ([]TestResult, error) {
+func (t *TestResults) downloadParquet(ctx context.Context, key string) ([]TestResult, error) {
 	prestoBucket, err := t.GetPrestoBucket(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	r, err := prestoBucket.Get(ctx, t.PrestoPartitionKey())
+	r, err := prestoBucket.Get(ctx, key)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting Parquet test results")
 	}
@@ -913,6 +1006,48 @@ func FindAndDownloadTestResults(ctx context.Context, env cedar.Environment, task
 	return stats, filteredResults, nil
 }
 
+// ExportTestResults fetches the TestResults records for the given tasks and
+// passes the test results of each record, filtered and sorted, to the given
+// export function in order by (task ID, execution). Records are downloaded
+// one at a time as they are exported, so the test results are sorted within
+// each record and cannot be paginated. The environment should not be nil.
+func ExportTestResults(ctx context.Context, env cedar.Environment, taskOpts []TestResultsTaskOptions, filterOpts *TestResultsFilterAndSortOptions, export func([]TestResult) error) error {
+	if filterOpts != nil && filterOpts.Limit > 0 {
+		return errors.New("cannot paginate exported test results")
+	}
+
+	testResults, err := FindTestResults(ctx, env, taskOpts)
+	if err != nil {
+		return errors.Wrap(err, "finding test results")
+	}
+	if filterOpts != nil {
+		if err = filterOpts.setup(ctx, env); err != nil {
+			return err
+		}
+	}
+
+	sort.SliceStable(testResults, func(i, j int) bool {
+		if testResults[i].Info.TaskID == testResults[j].Info.TaskID {
+			return testResults[i].Info.Execution < testResults[j].Info.Execution
+		}
+		return testResults[i].Info.TaskID < testResults[j].Info.TaskID
+	})
+	for i := range testResults {
+		results, err := testResults[i].Download(ctx)
+		if err != nil {
+			return errors.Wrapf(err, "downloading test results record '%s'", testResults[i].ID)
+		}
+		if filterOpts != nil {
+			results, _ = filterOpts.apply(results)
+		}
+		if err = export(results); err != nil {
+			return errors.Wrapf(err, "exporting test results record '%s'", testResults[i].ID)
+		}
+	}
+
+	return nil
+}
+
 // filterAndSortCedarTestResults takes a slice of test results and returns a
 // filtered sorted and paginated version of that slice.
 func filterAndSortTestResults(ctx context.Context, env cedar.Environment, results []TestResult, opts *TestResultsFilterAndSortOptions) ([]TestResult, int, error) {
@@ -920,27 +1055,44 @@ func filterAndSortTestResults(ctx context.Context, env cedar.Environment, result
 		return results, len(results), nil
 	}
 
-	if err := opts.Validate(); err != nil {
-		return nil, 0, errors.Wrap(err, "validating filter and sort test results options")
+	if err := opts.setup(ctx, env); err != nil {
+		return nil, 0, err
 	}
+	results, totalCount := opts.apply(results)
+
+	return results, totalCount, nil
+}
 
-	if opts.BaseTasks != nil {
-		_, baseResults, err := FindAndDownloadTestResults(ctx, env, opts.BaseTasks, nil)
+// setup validates the options and fetches the statuses of the base tasks'
+// test results, if any.
+func (o *TestResultsFilterAndSortOptions) setup(ctx context.Context, env cedar.Environment) error {
+	if err := o.Validate(); err != nil {
+		return errors.Wrap(err, "validating filter and sort test results options")
+	}
+
+	if o.BaseTasks != nil {
+		_, baseResults, err := FindAndDownloadTestResults(ctx, env, o.BaseTasks, nil)
 		if err != nil {
-			return nil, 0, errors.Wrap(err, "getting base test results")
+			return errors.Wrap(err, "getting base test results")
 		}
 		for _, result := range baseResults {
-			opts.baseStatusMap[result.GetDisplayName()] = result.Status
+			o.baseStatusMap[result.GetDisplayName()] = result.Status
 		}
 	}
 
-	results = filterTestResults(results, opts)
-	sortTestResults(results, opts)
+	return nil
+}
+
+// apply returns the filtered, sorted, and paginated test results and the
+// number of test results before pagination. The options must be set up.
+func (o *TestResultsFilterAndSortOptions) apply(results []TestResult) ([]TestResult, int) {
+	results = filterTestResults(results, o)
+	sortTestResults(results, o)
 
 	totalCount := len(results)
-	if opts.Limit > 0 {
-		offset := opts.Limit * opts.Page
-		end := offset + opts.Limit
+	if o.Limit > 0 {
+		offset := o.Limit * o.Page
+		end := offset + o.Limit
 		if offset > totalCount {
 			offset = totalCount
 		}
@@ -950,13 +1102,13 @@ func filterAndSortTestResults(ctx context.Context, env cedar.Environment, result
 		results = results[offset:end]
 	}
 
-	if len(opts.baseStatusMap) > 0 {
+	if len(o.baseStatusMap) > 0 {
 		for i := range results {
-			results[i].BaseStatus = opts.baseStatusMap[results[i].GetDisplayName()]
+			results[i].BaseStatus = o.baseStatusMap[results[i].GetDisplayName()]
 		}
 	}
 
-	return results, totalCount, nil
+	return results, totalCount
 }
 
 func filterTestResults(results []TestResult, opts *TestResultsFilterAndSortOptions) []TestResult {
@@ -0,0 +1,279 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/pail"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CompactedTestResultsPartGracePeriod is the minimum amount of time Parquet
+// test results part files merged by compaction are kept in offline storage so
+// that reads started before the swap can complete.
+const CompactedTestResultsPartGracePeriod = time.Hour
+
+// partsPrefix returns the prefix of the record's Parquet part files in the
+// Presto bucket. The parts are stored under the record's partition in a
+// directory prefixed with an underscore, which Presto ignores, so the
+// record's test results are only visible to Presto once it is compacted.
+func (t *TestResults) partsPrefix() string {
+	return fmt.Sprintf("%s/_parts/%s/", t.prestoPartition(), t.Artifact.Prefix)
+}
+
+// newPartKey returns a new, unique key for a Parquet part file of the record.
+// Part keys sort in the order the parts were created.
+func (t *TestResults) newPartKey() string {
+	return fmt.Sprintf("%s%020d_%s", t.partsPrefix(), time.Now().UnixNano(), utility.RandomString())
+}
+
+// listParts returns the sorted keys of the record's Parquet part files.
+func (t *TestResults) listParts(ctx context.Context, bucket pail.Bucket) ([]string, error) {
+	it, err := bucket.List(ctx, t.partsPrefix())
+	if err != nil {
+		return nil, errors.Wrap(err, "listing Parquet test results parts")
+	}
+
+	var keys []string
+	for it.Next(ctx) {
+		keys = append(keys, it.Item().Name())
+	}
+	if err = it.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterating Parquet test results parts")
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// downloadParts returns the test results of a version 2 record. Once the
+// record is compacted, the compacted Parquet file is read along with any
+// parts appended after the compaction; the compacted parts are ignored.
+func (t *TestResults) downloadParts(ctx context.Context) ([]TestResult, error) {
+	bucket, err := t.GetPrestoBucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := t.listParts(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []TestResult
+	compacted := map[string]bool{}
+	if !t.Artifact.CompactedAt.IsZero() {
+		if results, err = t.downloadParquet(ctx, t.PrestoPartitionKey()); err != nil {
+			return nil, errors.Wrap(err, "getting compacted test results")
+		}
+		for _, key := range t.Artifact.CompactedParts {
+			compacted[key] = true
+		}
+	}
+	for _, key := range keys {
+		if compacted[key] {
+			continue
+		}
+		partResults, err := t.downloadParquet(ctx, key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting test results part '%s'", key)
+		}
+		results = append(results, partResults...)
+	}
+
+	return results, nil
+}
+
+// CompactParts merges the Parquet part files of a closed, version 2 record
+// into the single Parquet file at the record's Presto partition key and
+// returns the number of parts merged. Records are compacted at most once.
+//
+// The compacted file is uploaded before it is swapped in with a single update
+// of the record's metadata, which also marks the merged parts as compacted.
+// Readers ignore the compacted file until the swap and the compacted parts
+// after it, so they never see missing or duplicated results. Parts appended
+// after the parts are listed remain readable but are not part of the
+// compacted file. Compacted parts remain in offline storage until removed by
+// RemoveCompactedParts. CompactParts must not be called concurrently for the
+// same record. The environment should not be nil.
+func (t *TestResults) CompactParts(ctx context.Context) (int, error) {
+	if t.env == nil {
+		return 0, errors.New("cannot compact parts with a nil environment")
+	}
+	if err := t.Find(ctx); err != nil {
+		return 0, err
+	}
+	if t.Artifact.Version != 2 {
+		return 0, errors.Errorf("cannot compact parts of artifact version %d", t.Artifact.Version)
+	}
+	if t.CompletedAt.IsZero() {
+		return 0, errors.Errorf("cannot compact parts of open test results record '%s'", t.ID)
+	}
+	if !t.Artifact.CompactedAt.IsZero() {
+		return 0, nil
+	}
+
+	bucket, err := t.GetPrestoBucket(ctx)
+	if err != nil {
+		return 0, err
+	}
+	keys, err := t.listParts(ctx, bucket)
+	if err != nil {
+		return 0, err
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	var results []TestResult
+	for _, key := range keys {
+		partResults, err := t.downloadParquet(ctx, key)
+		if err != nil {
+			return 0, errors.Wrapf(err, "getting test results part '%s'", key)
+		}
+		results = append(results, partResults...)
+	}
+	if err = t.uploadParquet(ctx, t.PrestoPartitionKey(), t.convertToParquet(results)); err != nil {
+		return 0, errors.Wrap(err, "uploading compacted Parquet test results")
+	}
+
+	compactedAt := time.Now()
+	updateResult, err := t.env.GetDB().Collection(testResultsCollection).UpdateOne(
+		ctx,
+		bson.M{
+			testResultsIDKey: t.ID,
+			bsonutil.GetDottedKeyName(testResultsArtifactKey, testResultsArtifactInfoCompactedAtKey): bson.M{"$exists": false},
+		},
+		bson.M{"$set": bson.M{
+			bsonutil.GetDottedKeyName(testResultsArtifactKey, testResultsArtifactInfoCompactedAtKey):    compactedAt,
+			bsonutil.GetDottedKeyName(testResultsArtifactKey, testResultsArtifactInfoCompactedPartsKey): keys,
+		}},
+	)
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection":    testResultsCollection,
+		"id":            t.ID,
+		"compacted":     len(keys),
+		"update_result": updateResult,
+		"op":            "swap compacted test results parts",
+	})
+	if err == nil && updateResult.MatchedCount == 0 {
+		err = errors.Errorf("could not find uncompacted test results record '%s'", t.ID)
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "swapping compacted parts of test results record '%s'", t.ID)
+	}
+
+	t.Artifact.CompactedAt = compactedAt
+	t.Artifact.CompactedParts = keys
+
+	return len(keys), nil
+}
+
+// RemoveCompactedParts removes the parts compacted more than the grace period
+// ago from offline storage. Parts compacted within the grace period are kept
+// and remain listed in the record's compacted parts. The environment should
+// not be nil.
+func (t *TestResults) RemoveCompactedParts(ctx context.Context, gracePeriod time.Duration) error {
+	if t.env == nil {
+		return errors.New("cannot remove compacted parts with a nil environment")
+	}
+	if err := t.Find(ctx); err != nil {
+		return err
+	}
+
+	keys := t.Artifact.CompactedParts
+	if len(keys) == 0 {
+		return nil
+	}
+	if t.Artifact.CompactedAt.After(time.Now().Add(-gracePeriod)) {
+		return nil
+	}
+
+	bucket, err := t.GetPrestoBucket(ctx)
+	if err != nil {
+		return err
+	}
+	if err = bucket.RemoveMany(ctx, keys...); err != nil {
+		return errors.Wrapf(err, "removing compacted parts of test results record '%s'", t.ID)
+	}
+
+	updateResult, err := t.env.GetDB().Collection(testResultsCollection).UpdateOne(
+		ctx,
+		bson.M{testResultsIDKey: t.ID},
+		bson.M{"$pull": bson.M{
+			bsonutil.GetDottedKeyName(testResultsArtifactKey, testResultsArtifactInfoCompactedPartsKey): bson.M{"$in": keys},
+		}},
+	)
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection":    testResultsCollection,
+		"id":            t.ID,
+		"removed":       len(keys),
+		"update_result": updateResult,
+		"op":            "remove compacted test results parts",
+	})
+	if err != nil {
+		return errors.Wrapf(err, "removing compacted parts from test results record '%s'", t.ID)
+	}
+	t.Artifact.CompactedParts = nil
+
+	return nil
+}
+
+// CompactedTestResults describes the test results records whose Parquet part
+// files were compacted.
+type CompactedTestResults struct {
+	// Found is the number of uncompacted records found.
+	Found int
+	// Compacted are the IDs of the records that were compacted.
+	Compacted []string
+}
+
+// CompactTestResultsParts compacts the Parquet part files of at most limit of
+// the version 2 test results records closed before the given time and not yet
+// compacted, see TestResults.CompactParts. Records without test results have
+// no parts and are skipped. Records that cannot be compacted are left
+// uncompacted so that the compaction may be retried.
+func CompactTestResultsParts(ctx context.Context, env cedar.Environment, completedBefore time.Time, limit int) (*CompactedTestResults, error) {
+	if env == nil {
+		return nil, errors.New("cannot compact test results parts with a nil environment")
+	}
+
+	query := bson.M{
+		bsonutil.GetDottedKeyName(testResultsArtifactKey, testResultsArtifactInfoVersionKey):     2,
+		bsonutil.GetDottedKeyName(testResultsArtifactKey, testResultsArtifactInfoCompactedAtKey): bson.M{"$exists": false},
+		bsonutil.GetDottedKeyName(testResultsStatsKey, testResultsStatsTotalCountKey):            bson.M{"$gt": 0},
+		testResultsCompletedAtKey: bson.M{"$gt": time.Time{}, "$lt": completedBefore},
+	}
+	var records []TestResults
+	findOpts := options.Find().SetLimit(int64(limit))
+	cur, err := env.GetDB().Collection(testResultsCollection).Find(ctx, query, findOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding uncompacted test results records")
+	}
+	if err = cur.All(ctx, &records); err != nil {
+		return nil, errors.Wrap(err, "decoding uncompacted test results records")
+	}
+
+	compacted := &CompactedTestResults{Found: len(records)}
+	catcher := grip.NewBasicCatcher()
+	for i := range records {
+		records[i].Setup(env)
+		if _, err = records[i].CompactParts(ctx); err != nil {
+			catcher.Add(err)
+			continue
+		}
+		if !records[i].Artifact.CompactedAt.IsZero() {
+			compacted.Compacted = append(compacted.Compacted, records[i].ID)
+		}
+	}
+
+	return compacted, catcher.Resolve()
+}
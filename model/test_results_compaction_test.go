@@ -0,0 +1,244 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/pail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestTestResultsPartKeys(t *testing.T) {
+	tr := getTestResults()
+	prefix := tr.partsPrefix()
+	assert.True(t, strings.HasPrefix(prefix, tr.prestoPartition()+"/_parts/"))
+	assert.False(t, strings.HasPrefix(prefix, tr.PrestoPartitionKey()))
+
+	first := tr.newPartKey()
+	second := tr.newPartKey()
+	assert.True(t, strings.HasPrefix(first, prefix))
+	assert.NotEqual(t, first, second)
+	assert.True(t, first < second)
+}
+
+func TestTestResultsCompactParts(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir := t.TempDir()
+	defer func() {
+		assert.NoError(t, db.Collection(env.GetConfig().DbConfigurationCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(testResultsCollection).Drop(ctx))
+	}()
+
+	conf := &CedarConfig{populated: true}
+	conf.Setup(env)
+	conf.Bucket.TestResultsBucket = tmpDir
+	conf.Bucket.PrestoBucket = tmpDir
+	conf.Bucket.PrestoTestResultsPrefix = "presto-test-results"
+	require.NoError(t, conf.Save())
+	testBucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: tmpDir})
+	require.NoError(t, err)
+
+	tr := getTestResults()
+	tr.CompletedAt = time.Time{}
+	tr.Artifact.Version = 2
+	tr.populated = true
+	tr.Setup(env)
+	require.NoError(t, tr.SaveNew(ctx))
+
+	var results []TestResult
+	for i := 0; i < 20; i++ {
+		result := getTestResult()
+		result.TaskID = tr.Info.TaskID
+		result.Execution = tr.Info.Execution
+		if i%4 == 0 {
+			result.Status = "Fail"
+		}
+		results = append(results, result)
+	}
+	testNames := func(results []TestResult) []string {
+		var names []string
+		for _, result := range results {
+			names = append(names, result.TestName)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	t.Run("ConcurrentAppends", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				record := &TestResults{ID: tr.ID}
+				record.Setup(env)
+				require.NoError(t, record.Find(ctx))
+				assert.NoError(t, record.Append(ctx, results[i*4:(i+1)*4]))
+			}(i)
+		}
+		wg.Wait()
+
+		require.NoError(t, tr.Find(ctx))
+		assert.Equal(t, 16, tr.Stats.TotalCount)
+		assert.Equal(t, 4, tr.Stats.FailedCount)
+		assert.Len(t, tr.FailedTestsSample, 4)
+		downloaded, err := tr.Download(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, testNames(results[:16]), testNames(downloaded))
+
+		// Nothing is written to the Presto partition key before
+		// compaction.
+		_, err = testBucket.Get(ctx, fmt.Sprintf("%s/%s", conf.Bucket.PrestoTestResultsPrefix, tr.PrestoPartitionKey()))
+		assert.True(t, pail.IsKeyNotFoundError(err))
+	})
+	t.Run("OpenRecord", func(t *testing.T) {
+		_, err := tr.CompactParts(ctx)
+		assert.Error(t, err)
+	})
+	require.NoError(t, tr.Close(ctx))
+	t.Run("Compact", func(t *testing.T) {
+		compacted, err := tr.CompactParts(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 4, compacted)
+		assert.False(t, tr.Artifact.CompactedAt.IsZero())
+		assert.Len(t, tr.Artifact.CompactedParts, 4)
+
+		compactedResults, err := tr.downloadParquet(ctx, tr.PrestoPartitionKey())
+		require.NoError(t, err)
+		assert.Equal(t, testNames(results[:16]), testNames(compactedResults))
+		downloaded, err := tr.Download(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, testNames(results[:16]), testNames(downloaded))
+
+		// Records are only compacted once.
+		compacted, err = tr.CompactParts(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, compacted)
+	})
+	t.Run("AppendAfterCompaction", func(t *testing.T) {
+		require.NoError(t, tr.Append(ctx, results[16:]))
+		require.NoError(t, tr.Find(ctx))
+		downloaded, err := tr.Download(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, testNames(results), testNames(downloaded))
+	})
+	t.Run("RemoveCompactedParts", func(t *testing.T) {
+		require.NoError(t, tr.RemoveCompactedParts(ctx, time.Hour))
+		assert.Len(t, tr.Artifact.CompactedParts, 4)
+
+		require.NoError(t, tr.RemoveCompactedParts(ctx, 0))
+		assert.Empty(t, tr.Artifact.CompactedParts)
+		var saved TestResults
+		require.NoError(t, db.Collection(testResultsCollection).FindOne(ctx, bson.M{"_id": tr.ID}).Decode(&saved))
+		assert.False(t, saved.Artifact.CompactedAt.IsZero())
+		assert.Empty(t, saved.Artifact.CompactedParts)
+
+		parts, err := tr.listParts(ctx, mustGetPrestoBucket(ctx, t, tr))
+		require.NoError(t, err)
+		assert.Len(t, parts, 1)
+		downloaded, err := tr.Download(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, testNames(results), testNames(downloaded))
+	})
+	t.Run("RemoveArtifacts", func(t *testing.T) {
+		require.NoError(t, tr.RemoveArtifacts(ctx))
+		parts, err := tr.listParts(ctx, mustGetPrestoBucket(ctx, t, tr))
+		require.NoError(t, err)
+		assert.Empty(t, parts)
+		_, err = testBucket.Get(ctx, fmt.Sprintf("%s/%s", conf.Bucket.PrestoTestResultsPrefix, tr.PrestoPartitionKey()))
+		assert.True(t, pail.IsKeyNotFoundError(err))
+	})
+}
+
+func mustGetPrestoBucket(ctx context.Context, t *testing.T, tr *TestResults) pail.Bucket {
+	bucket, err := tr.GetPrestoBucket(ctx)
+	require.NoError(t, err)
+	return bucket
+}
+
+func TestCompactTestResultsParts(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir := t.TempDir()
+	defer func() {
+		assert.NoError(t, db.Collection(env.GetConfig().DbConfigurationCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(testResultsCollection).Drop(ctx))
+	}()
+
+	conf := &CedarConfig{populated: true}
+	conf.Setup(env)
+	conf.Bucket.TestResultsBucket = tmpDir
+	conf.Bucket.PrestoBucket = tmpDir
+	conf.Bucket.PrestoTestResultsPrefix = "presto-test-results"
+	require.NoError(t, conf.Save())
+
+	createRecord := func(t *testing.T, numResults int, closed bool) *TestResults {
+		tr := getTestResults()
+		tr.CompletedAt = time.Time{}
+		tr.Artifact.Version = 2
+		tr.populated = true
+		tr.Setup(env)
+		require.NoError(t, tr.SaveNew(ctx))
+		var results []TestResult
+		for i := 0; i < numResults; i++ {
+			result := getTestResult()
+			result.TaskID = tr.Info.TaskID
+			result.Execution = tr.Info.Execution
+			results = append(results, result)
+		}
+		if numResults > 0 {
+			require.NoError(t, tr.Append(ctx, results))
+		}
+		if closed {
+			require.NoError(t, tr.Close(ctx))
+		}
+		return tr
+	}
+	closed := createRecord(t, 5, true)
+	open := createRecord(t, 5, false)
+	empty := createRecord(t, 0, true)
+
+	t.Run("NilEnv", func(t *testing.T) {
+		compacted, err := CompactTestResultsParts(ctx, nil, time.Now(), 10)
+		assert.Error(t, err)
+		assert.Nil(t, compacted)
+	})
+	t.Run("RecentlyClosed", func(t *testing.T) {
+		compacted, err := CompactTestResultsParts(ctx, env, closed.CompletedAt.Add(-time.Minute), 10)
+		require.NoError(t, err)
+		assert.Zero(t, compacted.Found)
+		assert.Empty(t, compacted.Compacted)
+	})
+	t.Run("Compact", func(t *testing.T) {
+		compacted, err := CompactTestResultsParts(ctx, env, time.Now().Add(time.Minute), 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, compacted.Found)
+		assert.Equal(t, []string{closed.ID}, compacted.Compacted)
+
+		require.NoError(t, closed.Find(ctx))
+		assert.False(t, closed.Artifact.CompactedAt.IsZero())
+		assert.Len(t, closed.Artifact.CompactedParts, 1)
+		for _, tr := range []*TestResults{open, empty} {
+			require.NoError(t, tr.Find(ctx))
+			assert.True(t, tr.Artifact.CompactedAt.IsZero())
+		}
+
+		// Compacted records are not found again.
+		compacted, err = CompactTestResultsParts(ctx, env, time.Now().Add(time.Minute), 10)
+		require.NoError(t, err)
+		assert.Zero(t, compacted.Found)
+	})
+}
@@ -0,0 +1,306 @@
+package model
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	flakyTestsCollection = "flaky_tests"
+
+	// DefaultFlakyTestsLimit is the default maximum number of flaky tests
+	// returned by FindFlakyTests.
+	DefaultFlakyTestsLimit = 100
+	// MaxFlakyTestsLimit is the maximum number of flaky tests returned by
+	// FindFlakyTests.
+	MaxFlakyTestsLimit = 1000
+
+	// flakyTestsBatchSize is the maximum number of flaky tests saved in a
+	// single bulk write by DetectFlakyTests.
+	flakyTestsBatchSize = 1000
+)
+
+// FlakyTest describes a test that both passed and failed on the same mainline
+// version of a task, either across executions or across trials of a single
+// execution. The score is the fraction of the versions the test ran on within
+// the detection window that were flaky.
+type FlakyTest struct {
+	ID               string    `bson:"_id"`
+	Project          string    `bson:"project"`
+	Variant          string    `bson:"variant"`
+	TaskName         string    `bson:"task_name"`
+	TestName         string    `bson:"test_name"`
+	NumRuns          int       `bson:"num_runs"`
+	NumFlakyRuns     int       `bson:"num_flaky_runs"`
+	NumFailedRuns    int       `bson:"num_failed_runs"`
+	Score            float64   `bson:"score"`
+	LastFlakyVersion string    `bson:"last_flaky_version"`
+	LastFlakyAt      time.Time `bson:"last_flaky_at"`
+	DetectedAt       time.Time `bson:"detected_at"`
+}
+
+var (
+	flakyTestIDKey               = bsonutil.MustHaveTag(FlakyTest{}, "ID")
+	flakyTestProjectKey          = bsonutil.MustHaveTag(FlakyTest{}, "Project")
+	flakyTestVariantKey          = bsonutil.MustHaveTag(FlakyTest{}, "Variant")
+	flakyTestTaskNameKey         = bsonutil.MustHaveTag(FlakyTest{}, "TaskName")
+	flakyTestTestNameKey         = bsonutil.MustHaveTag(FlakyTest{}, "TestName")
+	flakyTestNumRunsKey          = bsonutil.MustHaveTag(FlakyTest{}, "NumRuns")
+	flakyTestNumFlakyRunsKey     = bsonutil.MustHaveTag(FlakyTest{}, "NumFlakyRuns")
+	flakyTestNumFailedRunsKey    = bsonutil.MustHaveTag(FlakyTest{}, "NumFailedRuns")
+	flakyTestScoreKey            = bsonutil.MustHaveTag(FlakyTest{}, "Score")
+	flakyTestLastFlakyVersionKey = bsonutil.MustHaveTag(FlakyTest{}, "LastFlakyVersion")
+	flakyTestLastFlakyAtKey      = bsonutil.MustHaveTag(FlakyTest{}, "LastFlakyAt")
+	flakyTestDetectedAtKey       = bsonutil.MustHaveTag(FlakyTest{}, "DetectedAt")
+)
+
+// flakyTestID returns the ID of the flaky test of the given task. The fields
+// are delimited so that IDs do not collide when a field's suffix moves to the
+// next field.
+func flakyTestID(project, variant, taskName, testName string) string {
+	hash := sha1.New()
+	for _, field := range []string{project, variant, taskName, testName} {
+		_, _ = io.WriteString(hash, field)
+		_, _ = io.WriteString(hash, "\x00")
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// flakyTestsPipeline returns the aggregation pipeline over the test history
+// that scores the tests of the mainline versions created since the given
+// time. A version of a test is a run of the test; it passed if any of its
+// results passed and failed if any of its results failed. Results that
+// neither passed nor failed, e.g. skipped results, are ignored.
+func flakyTestsPipeline(since time.Time, minRuns int) []bson.M {
+	runKey := bson.M{
+		flakyTestProjectKey:  "$" + testHistoryEntryProjectKey,
+		flakyTestVariantKey:  "$" + testHistoryEntryVariantKey,
+		flakyTestTaskNameKey: "$" + testHistoryEntryTaskNameKey,
+		flakyTestTestNameKey: "$" + testHistoryEntryTestNameKey,
+	}
+	versionKey := bson.M{"version": "$" + testHistoryEntryVersionKey}
+	for key, value := range runKey {
+		versionKey[key] = value
+	}
+	flaky := bson.M{"$and": bson.A{"$passed", "$failed"}}
+
+	return []bson.M{
+		{"$match": bson.M{
+			testHistoryEntryMainlineKey:  true,
+			testHistoryEntryCreatedAtKey: bson.M{"$gte": since},
+		}},
+		{"$group": bson.M{
+			"_id":        versionKey,
+			"passed":     bson.M{"$max": bson.M{"$gt": bson.A{"$" + testHistoryEntryNumPassedKey, 0}}},
+			"failed":     bson.M{"$max": bson.M{"$gt": bson.A{"$" + testHistoryEntryNumFailedKey, 0}}},
+			"created_at": bson.M{"$max": "$" + testHistoryEntryCreatedAtKey},
+		}},
+		{"$group": bson.M{
+			"_id": bson.M{
+				flakyTestProjectKey:  "$_id." + flakyTestProjectKey,
+				flakyTestVariantKey:  "$_id." + flakyTestVariantKey,
+				flakyTestTaskNameKey: "$_id." + flakyTestTaskNameKey,
+				flakyTestTestNameKey: "$_id." + flakyTestTestNameKey,
+			},
+			flakyTestNumRunsKey:       bson.M{"$sum": 1},
+			flakyTestNumFlakyRunsKey:  bson.M{"$sum": bson.M{"$cond": bson.A{flaky, 1, 0}}},
+			flakyTestNumFailedRunsKey: bson.M{"$sum": bson.M{"$cond": bson.A{"$failed", 1, 0}}},
+			// Documents compare field by field, so the maximum is
+			// the most recent flaky version.
+			"last_flaky": bson.M{"$max": bson.M{"$cond": bson.A{
+				flaky,
+				bson.M{"created_at": "$created_at", "version": "$_id.version"},
+				nil,
+			}}},
+		}},
+		{"$match": bson.M{
+			flakyTestNumFlakyRunsKey: bson.M{"$gt": 0},
+			flakyTestNumRunsKey:      bson.M{"$gte": minRuns},
+		}},
+	}
+}
+
+// DetectFlakyTests scores the tests of the mainline test history within the
+// configured window, replaces the flaky tests of the previous detection with
+// the tests that were flaky on at least one version, and returns the number
+// of flaky tests. The environment should not be nil.
+func DetectFlakyTests(ctx context.Context, env cedar.Environment, conf FlakyTestsConfig) (int, error) {
+	if env == nil {
+		return 0, errors.New("cannot detect flaky tests with a nil environment")
+	}
+	if conf.Window <= 0 {
+		return 0, errors.New("flaky test detection window must be positive")
+	}
+
+	detectedAt := time.Now()
+	// The groups of every test of every project within the window may
+	// exceed the memory limit of aggregation stages.
+	aggOpts := options.Aggregate().SetAllowDiskUse(true)
+	cur, err := env.GetDB().Collection(testHistoryCollection).Aggregate(ctx, flakyTestsPipeline(detectedAt.Add(-conf.Window), conf.MinRuns), aggOpts)
+	if err != nil {
+		return 0, errors.Wrap(err, "aggregating test history")
+	}
+	defer cur.Close(ctx)
+
+	// The scored tests are saved in batches as the cursor is iterated
+	// since every scored test of every project may not fit in memory.
+	coll := env.GetDB().Collection(flakyTestsCollection)
+	var numFlaky int
+	models := make([]mongo.WriteModel, 0, flakyTestsBatchSize)
+	save := func() error {
+		if len(models) == 0 {
+			return nil
+		}
+		if _, err := coll.BulkWrite(ctx, models); err != nil {
+			return errors.Wrap(err, "saving flaky tests")
+		}
+		numFlaky += len(models)
+		models = models[:0]
+		return nil
+	}
+	for cur.Next(ctx) {
+		var test struct {
+			ID struct {
+				Project  string `bson:"project"`
+				Variant  string `bson:"variant"`
+				TaskName string `bson:"task_name"`
+				TestName string `bson:"test_name"`
+			} `bson:"_id"`
+			NumRuns       int `bson:"num_runs"`
+			NumFlakyRuns  int `bson:"num_flaky_runs"`
+			NumFailedRuns int `bson:"num_failed_runs"`
+			LastFlaky     struct {
+				CreatedAt time.Time `bson:"created_at"`
+				Version   string    `bson:"version"`
+			} `bson:"last_flaky"`
+		}
+		if err = cur.Decode(&test); err != nil {
+			return 0, errors.Wrap(err, "decoding scored test")
+		}
+
+		flakyTest := FlakyTest{
+			ID:               flakyTestID(test.ID.Project, test.ID.Variant, test.ID.TaskName, test.ID.TestName),
+			Project:          test.ID.Project,
+			Variant:          test.ID.Variant,
+			TaskName:         test.ID.TaskName,
+			TestName:         test.ID.TestName,
+			NumRuns:          test.NumRuns,
+			NumFlakyRuns:     test.NumFlakyRuns,
+			NumFailedRuns:    test.NumFailedRuns,
+			Score:            float64(test.NumFlakyRuns) / float64(test.NumRuns),
+			LastFlakyVersion: test.LastFlaky.Version,
+			LastFlakyAt:      test.LastFlaky.CreatedAt,
+			DetectedAt:       detectedAt,
+		}
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{flakyTestIDKey: flakyTest.ID}).
+			SetReplacement(flakyTest).
+			SetUpsert(true))
+		if len(models) >= flakyTestsBatchSize {
+			if err = save(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if err = cur.Err(); err != nil {
+		return 0, errors.Wrap(err, "iterating scored tests")
+	}
+	if err = save(); err != nil {
+		return 0, err
+	}
+
+	// Tests that are no longer flaky within the window were not replaced.
+	deleteResult, err := coll.DeleteMany(ctx, bson.M{flakyTestDetectedAtKey: bson.M{"$lt": detectedAt}})
+	if err != nil {
+		return 0, errors.Wrap(err, "removing tests no longer flaky")
+	}
+	grip.Debug(message.Fields{
+		"collection":   flakyTestsCollection,
+		"flaky":        numFlaky,
+		"deleteResult": deleteResult,
+		"op":           "detect flaky tests",
+	})
+
+	return numFlaky, nil
+}
+
+// FlakyTestsOptions specify the arguments for finding flaky tests. The
+// project is required.
+type FlakyTestsOptions struct {
+	Project  string
+	Variant  string
+	TaskName string
+	// MinScore excludes the tests with a lower flakiness score.
+	MinScore float64
+	// Limit is the maximum number of flaky tests returned. It defaults to
+	// DefaultFlakyTestsLimit and may not exceed MaxFlakyTestsLimit.
+	Limit int
+}
+
+// Validate ensures FlakyTestsOptions is configured correctly.
+func (o *FlakyTestsOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+
+	catcher.NewWhen(o.Project == "", "must specify a project")
+	catcher.NewWhen(o.MinScore < 0 || o.MinScore > 1, "min score must be between 0 and 1")
+	catcher.NewWhen(o.Limit < 0, "limit cannot be negative")
+	catcher.ErrorfWhen(o.Limit > MaxFlakyTestsLimit, "limit cannot exceed %d", MaxFlakyTestsLimit)
+	if o.Limit == 0 {
+		o.Limit = DefaultFlakyTestsLimit
+	}
+
+	return catcher.Resolve()
+}
+
+// FindFlakyTests returns the flaky tests of the last detection matching the
+// given options, most flaky first. The environment should not be nil.
+func FindFlakyTests(ctx context.Context, env cedar.Environment, opts FlakyTestsOptions) ([]FlakyTest, error) {
+	if env == nil {
+		return nil, errors.New("cannot find flaky tests with a nil environment")
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid flaky tests options")
+	}
+
+	query := bson.M{flakyTestProjectKey: opts.Project}
+	if opts.Variant != "" {
+		query[flakyTestVariantKey] = opts.Variant
+	}
+	if opts.TaskName != "" {
+		query[flakyTestTaskNameKey] = opts.TaskName
+	}
+	if opts.MinScore > 0 {
+		query[flakyTestScoreKey] = bson.M{"$gte": opts.MinScore}
+	}
+	findOpts := options.Find().
+		SetSort(bson.D{
+			{Key: flakyTestScoreKey, Value: -1},
+			{Key: flakyTestNumFlakyRunsKey, Value: -1},
+			{Key: flakyTestTestNameKey, Value: 1},
+		}).
+		SetLimit(int64(opts.Limit))
+	cur, err := env.GetDB().Collection(flakyTestsCollection).Find(ctx, query, findOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding flaky tests")
+	}
+
+	flakyTests := []FlakyTest{}
+	if err = cur.All(ctx, &flakyTests); err != nil {
+		return nil, errors.Wrap(err, "decoding flaky tests")
+	}
+
+	return flakyTests, nil
+}
@@ -0,0 +1,149 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlakyTestsOptionsValidate(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		opts   FlakyTestsOptions
+		hasErr bool
+	}{
+		{
+			name:   "NoProject",
+			opts:   FlakyTestsOptions{},
+			hasErr: true,
+		},
+		{
+			name:   "InvalidMinScore",
+			opts:   FlakyTestsOptions{Project: "project", MinScore: 1.5},
+			hasErr: true,
+		},
+		{
+			name:   "NegativeLimit",
+			opts:   FlakyTestsOptions{Project: "project", Limit: -1},
+			hasErr: true,
+		},
+		{
+			name:   "LimitTooLarge",
+			opts:   FlakyTestsOptions{Project: "project", Limit: MaxFlakyTestsLimit + 1},
+			hasErr: true,
+		},
+		{
+			name: "Valid",
+			opts: FlakyTestsOptions{Project: "project", MinScore: 0.5},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.opts.Validate()
+			if test.hasErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, DefaultFlakyTestsLimit, test.opts.Limit)
+			}
+		})
+	}
+}
+
+func TestFlakyTestID(t *testing.T) {
+	id := flakyTestID("project", "variant", "task", "test")
+	assert.Equal(t, id, flakyTestID("project", "variant", "task", "test"))
+	assert.NotEqual(t, id, flakyTestID("projectv", "ariant", "task", "test"))
+	assert.NotEqual(t, id, flakyTestID("project", "variant", "tas", "ktest"))
+}
+
+func TestDetectFlakyTests(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, db.Collection(testHistoryCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(flakyTestsCollection).Drop(ctx))
+	}()
+
+	_, err := DetectFlakyTests(ctx, nil, FlakyTestsConfig{Window: time.Hour})
+	assert.Error(t, err)
+	_, err = DetectFlakyTests(ctx, env, FlakyTestsConfig{})
+	assert.Error(t, err)
+
+	now := time.Now().Round(time.Millisecond).UTC()
+	entry := func(version string, execution int, testName string, numResults, numPassed, numFailed int, mainline bool, createdAt time.Time) interface{} {
+		testResultsID := version + testName + string(rune('0'+execution))
+		return TestHistoryEntry{
+			ID:            testHistoryEntryID(testResultsID, testName),
+			TestResultsID: testResultsID,
+			Project:       "project",
+			Version:       version,
+			Variant:       "linux",
+			TaskName:      "task",
+			Execution:     execution,
+			Mainline:      mainline,
+			CreatedAt:     createdAt,
+			TestName:      testName,
+			NumResults:    numResults,
+			NumPassed:     numPassed,
+			NumFailed:     numFailed,
+		}
+	}
+	_, err = db.Collection(testHistoryCollection).InsertMany(ctx, []interface{}{
+		// Fails on the first execution of v0 and passes on the second.
+		entry("v0", 0, "flaky_executions", 1, 0, 1, true, now.Add(-3*time.Hour)),
+		entry("v0", 1, "flaky_executions", 1, 1, 0, true, now.Add(-3*time.Hour)),
+		entry("v1", 0, "flaky_executions", 1, 1, 0, true, now.Add(-2*time.Hour)),
+		// Fails and passes across trials of a single execution.
+		entry("v1", 0, "flaky_trials", 2, 1, 1, true, now.Add(-2*time.Hour)),
+		// Consistently fails.
+		entry("v0", 0, "broken", 1, 0, 1, true, now.Add(-3*time.Hour)),
+		entry("v1", 0, "broken", 1, 0, 1, true, now.Add(-2*time.Hour)),
+		// Fails and is skipped, but never passes.
+		entry("v1", 0, "skipped", 2, 0, 1, true, now.Add(-2*time.Hour)),
+		// Only flaky in patches.
+		entry("p0", 0, "flaky_patch", 2, 1, 1, false, now.Add(-time.Hour)),
+		// Only flaky outside the window.
+		entry("v2", 0, "flaky_old", 2, 1, 1, true, now.Add(-48*time.Hour)),
+	})
+	require.NoError(t, err)
+
+	flaky, err := DetectFlakyTests(ctx, env, FlakyTestsConfig{Window: 24 * time.Hour})
+	require.NoError(t, err)
+	assert.Equal(t, 2, flaky)
+
+	flakyTests, err := FindFlakyTests(ctx, env, FlakyTestsOptions{Project: "project"})
+	require.NoError(t, err)
+	require.Len(t, flakyTests, 2)
+	assert.Equal(t, "flaky_trials", flakyTests[0].TestName)
+	assert.Equal(t, 1, flakyTests[0].NumRuns)
+	assert.Equal(t, 1.0, flakyTests[0].Score)
+	assert.Equal(t, "v1", flakyTests[0].LastFlakyVersion)
+	assert.Equal(t, "flaky_executions", flakyTests[1].TestName)
+	assert.Equal(t, 2, flakyTests[1].NumRuns)
+	assert.Equal(t, 1, flakyTests[1].NumFlakyRuns)
+	assert.Equal(t, 1, flakyTests[1].NumFailedRuns)
+	assert.Equal(t, 0.5, flakyTests[1].Score)
+	assert.Equal(t, "v0", flakyTests[1].LastFlakyVersion)
+	assert.Equal(t, now.Add(-3*time.Hour), flakyTests[1].LastFlakyAt.UTC())
+
+	flakyTests, err = FindFlakyTests(ctx, env, FlakyTestsOptions{Project: "project", MinScore: 0.75})
+	require.NoError(t, err)
+	require.Len(t, flakyTests, 1)
+	assert.Equal(t, "flaky_trials", flakyTests[0].TestName)
+
+	// Tests with fewer runs than the minimum are not flagged and flaky
+	// tests of previous detections are replaced.
+	flaky, err = DetectFlakyTests(ctx, env, FlakyTestsConfig{Window: 24 * time.Hour, MinRuns: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 1, flaky)
+	flakyTests, err = FindFlakyTests(ctx, env, FlakyTestsOptions{Project: "project"})
+	require.NoError(t, err)
+	require.Len(t, flakyTests, 1)
+	assert.Equal(t, "flaky_executions", flakyTests[0].TestName)
+}
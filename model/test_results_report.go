@@ -0,0 +1,613 @@
+package model
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/level"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TestReportFormat is the format of a test report produced by a test runner.
+type TestReportFormat string
+
+const (
+	TestReportFormatJUnit  TestReportFormat = "junit"
+	TestReportFormatTAP    TestReportFormat = "tap"
+	TestReportFormatGoTest TestReportFormat = "gotest"
+
+	testReportStatusPass = "pass"
+	testReportStatusFail = "fail"
+	testReportStatusSkip = "skip"
+
+	// maxTestReportLineSize is the maximum size of a single line of a TAP
+	// report.
+	maxTestReportLineSize = 16 * 1024 * 1024
+
+	// testReportLogProcessName is the process name of the buildlogger
+	// logs storing the output of failed tests of test reports.
+	testReportLogProcessName = "test_report"
+)
+
+// Validate ensures that the test report format is supported.
+func (f TestReportFormat) Validate() error {
+	switch f {
+	case TestReportFormatJUnit, TestReportFormatTAP, TestReportFormatGoTest:
+		return nil
+	default:
+		return errors.Errorf("unsupported test report format '%s'", f)
+	}
+}
+
+// TestReport is a parsed test report.
+type TestReport struct {
+	Format TestReportFormat
+	Tests  []ReportedTest
+}
+
+// ReportedTest is a single test of a test report.
+type ReportedTest struct {
+	Name      string
+	Status    string
+	StartTime time.Time
+	EndTime   time.Time
+	// Output holds the lines of the failure messages and output of a
+	// failed test.
+	Output []string
+}
+
+// ParseTestReport parses a JUnit XML, TAP, or `go test -json` report. Tests
+// of formats without timestamps are laid out back to back, the last test
+// ending when the report is parsed.
+func ParseTestReport(format TestReportFormat, report io.Reader) (*TestReport, error) {
+	if err := format.Validate(); err != nil {
+		return nil, err
+	}
+
+	var (
+		tests []ReportedTest
+		err   error
+	)
+	switch format {
+	case TestReportFormatJUnit:
+		tests, err = parseJUnitReport(report)
+	case TestReportFormatTAP:
+		tests, err = parseTAPReport(report)
+	case TestReportFormatGoTest:
+		tests, err = parseGoTestReport(report)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s test report", format)
+	}
+
+	return &TestReport{Format: format, Tests: tests}, nil
+}
+
+// TestResultsExistError is returned when importing a test report for a task
+// execution that already has test results.
+type TestResultsExistError struct {
+	ID string
+}
+
+func (e *TestResultsExistError) Error() string {
+	return fmt.Sprintf("test results record '%s' already exists", e.ID)
+}
+
+// IsTestResultsExist returns whether the cause of the given error is a task
+// execution that already has test results.
+func IsTestResultsExist(err error) bool {
+	_, ok := errors.Cause(err).(*TestResultsExistError)
+	return ok
+}
+
+// Import saves the results of the test report as a new, closed test results
+// record of the given task execution and returns the record. Importing a
+// report for a task execution that already has test results returns a
+// TestResultsExistError. The failure output of each failed test is saved as a
+// buildlogger log of the task named after the test and referenced by the
+// test's result. If the import fails, the record and logs saved so far are
+// removed so that it may be retried. The environment should not be nil.
+func (r *TestReport) Import(ctx context.Context, env cedar.Environment, info TestResultsInfo) (*TestResults, error) {
+	if env == nil {
+		return nil, errors.New("cannot import test report with a nil environment")
+	}
+
+	conf := &CedarConfig{}
+	conf.Setup(env)
+	if err := conf.Find(); err != nil {
+		return nil, errors.Wrap(err, "getting application configuration")
+	}
+	if conf.Bucket.TestResultsBucketType == "" {
+		return nil, errors.New("bucket type not specified")
+	}
+
+	record := CreateTestResults(info, conf.Bucket.TestResultsBucketType)
+	record.Setup(env)
+	if err := record.SaveNew(ctx); err != nil {
+		if mongo.IsDuplicateKeyError(errors.Cause(err)) {
+			return nil, &TestResultsExistError{ID: record.ID}
+		}
+		return nil, err
+	}
+
+	var logs []*Log
+	if err := r.importResults(ctx, env, conf, record, &logs); err != nil {
+		catcher := grip.NewBasicCatcher()
+		catcher.Add(err)
+		catcher.Wrap(removeImportedTestReport(ctx, record, logs), "removing partially imported test report")
+		return nil, catcher.Resolve()
+	}
+	grip.Info(message.Fields{
+		"message":         "imported test report",
+		"format":          r.Format,
+		"test_results_id": record.ID,
+		"tests":           len(r.Tests),
+		"logs":            len(logs),
+	})
+
+	return record, nil
+}
+
+// importResults saves the logs of the failed tests, adding them to the given
+// logs as they are saved, then appends the results of the tests to the record
+// and closes it.
+func (r *TestReport) importResults(ctx context.Context, env cedar.Environment, conf *CedarConfig, record *TestResults, logs *[]*Log) error {
+	results := make([]TestResult, 0, len(r.Tests))
+	// Tests reported more than once, for example parameterized or
+	// retried tests, are distinguished by their trial.
+	trials := map[string]int{}
+	for _, test := range r.Tests {
+		result := TestResult{
+			TaskID:        record.Info.TaskID,
+			Execution:     record.Info.Execution,
+			TestName:      test.Name,
+			Trial:         trials[test.Name],
+			Status:        test.Status,
+			TestStartTime: test.StartTime,
+			TestEndTime:   test.EndTime,
+		}
+		trials[test.Name]++
+		if isFailedTestStatus(test.Status) && len(test.Output) > 0 {
+			log, err := saveReportedTestLog(ctx, env, conf, record.Info, test, result.Trial)
+			if log != nil {
+				*logs = append(*logs, log)
+			}
+			if err != nil {
+				return err
+			}
+			result.LogInfo = &TestLogInfo{LogName: test.Name}
+		}
+		results = append(results, result)
+	}
+
+	if len(results) > 0 {
+		if err := record.Append(ctx, results); err != nil {
+			return errors.Wrapf(err, "appending results of %s test report", r.Format)
+		}
+	}
+
+	return record.Close(ctx)
+}
+
+// removeImportedTestReport removes the given test results record and logs of
+// a failed import, along with their artifacts.
+func removeImportedTestReport(ctx context.Context, record *TestResults, logs []*Log) error {
+	catcher := grip.NewBasicCatcher()
+	for _, log := range logs {
+		catcher.Add(log.RemoveArtifacts(ctx))
+		catcher.Add(log.Remove(ctx))
+	}
+	catcher.Add(record.RemoveArtifacts(ctx))
+	catcher.Add(record.Remove(ctx))
+
+	return catcher.Resolve()
+}
+
+// saveReportedTestLog saves the output of a failed test as a closed
+// buildlogger log of the task. The logs of test reports have their own
+// process name, so they never collide with the logs uploaded for the task's
+// tests, and the test's trial, so that tests reported more than once each
+// have their own log. The log is returned once it is saved, even if it could
+// not be appended to or closed.
+func saveReportedTestLog(ctx context.Context, env cedar.Environment, conf *CedarConfig, info TestResultsInfo, test ReportedTest, trial int) (*Log, error) {
+	if conf.Bucket.BuildLogsBucketType == "" {
+		return nil, errors.New("build logs bucket type not specified")
+	}
+
+	log := CreateLog(LogInfo{
+		Project:     info.Project,
+		Version:     info.Version,
+		Variant:     info.Variant,
+		TaskName:    info.TaskName,
+		TaskID:      info.TaskID,
+		Execution:   info.Execution,
+		TestName:    test.Name,
+		Trial:       trial,
+		ProcessName: testReportLogProcessName,
+		Format:      LogFormatText,
+		Mainline:    info.Mainline,
+	}, conf.Bucket.BuildLogsBucketType)
+	log.Setup(env)
+	if err := log.SaveNew(ctx); err != nil {
+		return nil, errors.Wrapf(err, "saving log of test '%s'", test.Name)
+	}
+
+	lines := make([]LogLine, 0, len(test.Output))
+	for _, data := range test.Output {
+		lines = append(lines, LogLine{Priority: level.Info, Timestamp: test.EndTime, Data: data})
+	}
+	if err := log.Append(ctx, lines); err != nil {
+		return log, errors.Wrapf(err, "appending log lines of test '%s'", test.Name)
+	}
+
+	return log, errors.Wrapf(log.Close(ctx, 1), "closing log of test '%s'", test.Name)
+}
+
+// splitReportOutput splits the output of a test into lines, dropping
+// surrounding blank lines.
+func splitReportOutput(output string) []string {
+	output = strings.Trim(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	if strings.TrimSpace(output) == "" {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
+
+// layOutReportedTests sets the start and end times of the tests without a
+// start time from their durations, so that they run back to back and the last
+// one ends at the given time.
+func layOutReportedTests(tests []ReportedTest, durations []time.Duration, end time.Time) {
+	var total time.Duration
+	for i := range tests {
+		if tests[i].StartTime.IsZero() {
+			total += durations[i]
+		}
+	}
+
+	next := end.Add(-total)
+	for i := range tests {
+		if !tests[i].StartTime.IsZero() {
+			continue
+		}
+		tests[i].StartTime = next
+		tests[i].EndTime = next.Add(durations[i])
+		next = tests[i].EndTime
+	}
+}
+
+////////
+// JUnit
+////////
+
+type junitTestSuite struct {
+	XMLName   xml.Name
+	Timestamp string           `xml:"timestamp,attr"`
+	Suites    []junitTestSuite `xml:"testsuite"`
+	Cases     []junitTestCase  `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string          `xml:"classname,attr"`
+	Name      string          `xml:"name,attr"`
+	Time      string          `xml:"time,attr"`
+	Failures  []junitFailure  `xml:"failure"`
+	Errors    []junitFailure  `xml:"error"`
+	Skipped   *junitFailure   `xml:"skipped"`
+	SystemOut []junitTextNode `xml:"system-out"`
+	SystemErr []junitTextNode `xml:"system-err"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitTextNode struct {
+	Text string `xml:",chardata"`
+}
+
+func parseJUnitReport(report io.Reader) ([]ReportedTest, error) {
+	root := junitTestSuite{}
+	if err := xml.NewDecoder(report).Decode(&root); err != nil {
+		return nil, errors.Wrap(err, "decoding XML")
+	}
+	if root.XMLName.Local != "testsuites" && root.XMLName.Local != "testsuite" {
+		return nil, errors.Errorf("unexpected root element '%s'", root.XMLName.Local)
+	}
+
+	var (
+		tests     []ReportedTest
+		durations []time.Duration
+	)
+	var walk func(suite junitTestSuite) error
+	walk = func(suite junitTestSuite) error {
+		start, err := parseJUnitTimestamp(suite.Timestamp)
+		if err != nil {
+			return err
+		}
+		for _, testCase := range suite.Cases {
+			test, duration, err := testCase.export()
+			if err != nil {
+				return err
+			}
+			if !start.IsZero() {
+				test.StartTime = start
+				test.EndTime = start.Add(duration)
+				start = test.EndTime
+			}
+			tests = append(tests, test)
+			durations = append(durations, duration)
+		}
+		for _, child := range suite.Suites {
+			if err := walk(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(root); err != nil {
+		return nil, err
+	}
+	layOutReportedTests(tests, durations, time.Now())
+
+	return tests, nil
+}
+
+func (c junitTestCase) export() (ReportedTest, time.Duration, error) {
+	if c.Name == "" {
+		return ReportedTest{}, 0, errors.New("test case missing name")
+	}
+	test := ReportedTest{Name: c.Name, Status: testReportStatusPass}
+	if c.ClassName != "" {
+		test.Name = c.ClassName + "." + c.Name
+	}
+
+	var duration time.Duration
+	if c.Time != "" {
+		// Some runners group the thousands of the duration.
+		seconds, err := strconv.ParseFloat(strings.ReplaceAll(c.Time, ",", ""), 64)
+		if err != nil {
+			return ReportedTest{}, 0, errors.Wrapf(err, "parsing duration of test '%s'", test.Name)
+		}
+		if seconds > 0 {
+			duration = time.Duration(seconds * float64(time.Second))
+		}
+	}
+
+	switch {
+	case len(c.Failures) > 0 || len(c.Errors) > 0:
+		test.Status = testReportStatusFail
+		for _, failures := range [][]junitFailure{c.Failures, c.Errors} {
+			for _, failure := range failures {
+				test.Output = append(test.Output, splitReportOutput(failure.Message)...)
+				test.Output = append(test.Output, splitReportOutput(failure.Text)...)
+			}
+		}
+		for _, nodes := range [][]junitTextNode{c.SystemOut, c.SystemErr} {
+			for _, node := range nodes {
+				test.Output = append(test.Output, splitReportOutput(node.Text)...)
+			}
+		}
+	case c.Skipped != nil:
+		test.Status = testReportStatusSkip
+	}
+
+	return test, duration, nil
+}
+
+// parseJUnitTimestamp parses the optional timestamp of a JUnit test suite,
+// which is usually ISO 8601 without a time zone.
+func parseJUnitTimestamp(timestamp string) (time.Time, error) {
+	if timestamp == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999"} {
+		if ts, err := time.Parse(layout, timestamp); err == nil {
+			return ts, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("invalid test suite timestamp '%s'", timestamp)
+}
+
+//////
+// TAP
+//////
+
+var (
+	tapTestLinePattern  = regexp.MustCompile(`^(not )?ok\b\s*(\d+)?\s*(?:-\s*)?(.*)$`)
+	tapDirectivePattern = regexp.MustCompile(`(?i)\s+#\s*(skip|todo)\b.*$`)
+	tapDurationPattern  = regexp.MustCompile(`^\s*duration_ms:\s*([0-9.]+)\s*$`)
+)
+
+// parseTAPReport parses the top level test lines of a TAP report. The YAML
+// diagnostic block following a test line is the test's output, and its
+// duration_ms key, if any, the test's duration.
+func parseTAPReport(report io.Reader) ([]ReportedTest, error) {
+	var (
+		tests     []ReportedTest
+		durations []time.Duration
+		inYAML    bool
+		yaml      []string
+	)
+	// endYAML attributes the YAML diagnostic block to the last test.
+	endYAML := func() error {
+		inYAML = false
+		if len(tests) == 0 {
+			return nil
+		}
+		last := len(tests) - 1
+		for _, line := range yaml {
+			if match := tapDurationPattern.FindStringSubmatch(line); match != nil {
+				ms, err := strconv.ParseFloat(match[1], 64)
+				if err != nil {
+					return errors.Wrapf(err, "parsing duration of test '%s'", tests[last].Name)
+				}
+				durations[last] = time.Duration(ms * float64(time.Millisecond))
+			}
+		}
+		if isFailedTestStatus(tests[last].Status) {
+			tests[last].Output = append(tests[last].Output, yaml...)
+		}
+		yaml = nil
+		return nil
+	}
+
+	scanner := bufio.NewScanner(report)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTestReportLineSize)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+		if inYAML {
+			if trimmed == "..." {
+				if err := endYAML(); err != nil {
+					return nil, err
+				}
+			} else {
+				yaml = append(yaml, trimmed)
+			}
+			continue
+		}
+		if trimmed == "---" && line != trimmed && len(tests) > 0 {
+			inYAML = true
+			continue
+		}
+		if strings.HasPrefix(line, "Bail out!") {
+			break
+		}
+
+		match := tapTestLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		description := match[3]
+		status := testReportStatusPass
+		if match[1] != "" {
+			status = testReportStatusFail
+		}
+		if directive := tapDirectivePattern.FindStringSubmatch(description); directive != nil {
+			description = strings.TrimSpace(strings.TrimSuffix(description, directive[0]))
+			// Failing TODO tests are not failures.
+			if strings.EqualFold(directive[1], "skip") || status == testReportStatusFail {
+				status = testReportStatusSkip
+			}
+		}
+		name := strings.TrimSpace(description)
+		if name == "" {
+			number := match[2]
+			if number == "" {
+				number = strconv.Itoa(len(tests) + 1)
+			}
+			name = "test " + number
+		}
+
+		tests = append(tests, ReportedTest{Name: name, Status: status})
+		durations = append(durations, 0)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading report")
+	}
+	if inYAML {
+		if err := endYAML(); err != nil {
+			return nil, err
+		}
+	}
+	layOutReportedTests(tests, durations, time.Now())
+
+	return tests, nil
+}
+
+//////////
+// go test
+//////////
+
+type goTestEvent struct {
+	Time    time.Time `json:"Time"`
+	Action  string    `json:"Action"`
+	Package string    `json:"Package"`
+	Test    string    `json:"Test"`
+	Elapsed float64   `json:"Elapsed"`
+	Output  string    `json:"Output"`
+}
+
+// parseGoTestReport parses the events of a `go test -json` report. Tests are
+// named after their package, and tests that never finished, e.g. because the
+// test binary panicked or timed out, are failed.
+func parseGoTestReport(report io.Reader) ([]ReportedTest, error) {
+	var (
+		tests   []ReportedTest
+		outputs []strings.Builder
+		done    []bool
+		lastAt  time.Time
+	)
+	indexes := map[string]int{}
+
+	decoder := json.NewDecoder(report)
+	for {
+		event := goTestEvent{}
+		if err := decoder.Decode(&event); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "decoding test event")
+		}
+		if event.Time.After(lastAt) {
+			lastAt = event.Time
+		}
+		if event.Test == "" {
+			continue
+		}
+
+		name := event.Test
+		if event.Package != "" {
+			name = event.Package + "." + event.Test
+		}
+		i, ok := indexes[name]
+		if !ok {
+			i = len(tests)
+			indexes[name] = i
+			tests = append(tests, ReportedTest{Name: name, StartTime: event.Time})
+			outputs = append(outputs, strings.Builder{})
+			done = append(done, false)
+		}
+
+		switch event.Action {
+		case "output":
+			outputs[i].WriteString(event.Output)
+		case "pass", "fail", "skip":
+			tests[i].Status = map[string]string{
+				"pass": testReportStatusPass,
+				"fail": testReportStatusFail,
+				"skip": testReportStatusSkip,
+			}[event.Action]
+			tests[i].EndTime = event.Time
+			if event.Elapsed > 0 {
+				tests[i].StartTime = event.Time.Add(-time.Duration(event.Elapsed * float64(time.Second)))
+			}
+			done[i] = true
+		}
+	}
+
+	for i := range tests {
+		if !done[i] {
+			tests[i].Status = testReportStatusFail
+			tests[i].EndTime = lastAt
+		}
+		if isFailedTestStatus(tests[i].Status) {
+			tests[i].Output = splitReportOutput(outputs[i].String())
+		}
+	}
+
+	return tests, nil
+}
@@ -0,0 +1,295 @@
+package model
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	testJUnitReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="suite" timestamp="2021-03-04T05:06:07">
+    <testcase classname="pkg.Class" name="testPass" time="1.5"/>
+    <testcase classname="pkg.Class" name="testFail" time="0.25">
+      <failure message="expected 1, got 2" type="AssertionError">stack line 1
+stack line 2</failure>
+      <system-out>some output</system-out>
+    </testcase>
+  </testsuite>
+  <testsuite name="other">
+    <testcase name="testSkip" time="1,000"><skipped/></testcase>
+    <testcase name="testError"><error message="boom"/></testcase>
+  </testsuite>
+</testsuites>`
+	testTAPReport = `TAP version 13
+1..5
+ok 1 - passes
+not ok 2 - fails
+  ---
+  message: expected 1, got 2
+  duration_ms: 250
+  ...
+ok 3 - skipped # SKIP not supported
+not ok 4 - unfinished # TODO later
+ok 5
+Bail out! database unavailable
+ok 6 - never run`
+	testGoTestReport = `{"Time":"2021-03-04T05:06:07Z","Action":"start","Package":"example.com/pkg"}
+{"Time":"2021-03-04T05:06:07Z","Action":"run","Package":"example.com/pkg","Test":"TestPass"}
+{"Time":"2021-03-04T05:06:07Z","Action":"output","Package":"example.com/pkg","Test":"TestPass","Output":"=== RUN   TestPass\n"}
+{"Time":"2021-03-04T05:06:08Z","Action":"pass","Package":"example.com/pkg","Test":"TestPass","Elapsed":1}
+{"Time":"2021-03-04T05:06:08Z","Action":"run","Package":"example.com/pkg","Test":"TestFail"}
+{"Time":"2021-03-04T05:06:08Z","Action":"output","Package":"example.com/pkg","Test":"TestFail","Output":"=== RUN   TestFail\n"}
+{"Time":"2021-03-04T05:06:08Z","Action":"output","Package":"example.com/pkg","Test":"TestFail","Output":"    pkg_test.go:10: expected 1, got 2\n"}
+{"Time":"2021-03-04T05:06:10Z","Action":"fail","Package":"example.com/pkg","Test":"TestFail","Elapsed":2}
+{"Time":"2021-03-04T05:06:10Z","Action":"run","Package":"example.com/pkg","Test":"TestTimeout"}
+{"Time":"2021-03-04T05:06:20Z","Action":"output","Package":"example.com/pkg","Output":"panic: test timed out\n"}
+{"Time":"2021-03-04T05:06:20Z","Action":"fail","Package":"example.com/pkg","Elapsed":13}`
+)
+
+func TestParseTestReport(t *testing.T) {
+	t.Run("InvalidFormat", func(t *testing.T) {
+		_, err := ParseTestReport("xunit", strings.NewReader(testJUnitReport))
+		assert.Error(t, err)
+	})
+	t.Run("JUnit", func(t *testing.T) {
+		report, err := ParseTestReport(TestReportFormatJUnit, strings.NewReader(testJUnitReport))
+		require.NoError(t, err)
+		require.Len(t, report.Tests, 4)
+
+		start := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+		assert.Equal(t, ReportedTest{
+			Name:      "pkg.Class.testPass",
+			Status:    "pass",
+			StartTime: start,
+			EndTime:   start.Add(1500 * time.Millisecond),
+		}, report.Tests[0])
+		assert.Equal(t, ReportedTest{
+			Name:      "pkg.Class.testFail",
+			Status:    "fail",
+			StartTime: start.Add(1500 * time.Millisecond),
+			EndTime:   start.Add(1750 * time.Millisecond),
+			Output:    []string{"expected 1, got 2", "stack line 1", "stack line 2", "some output"},
+		}, report.Tests[1])
+
+		assert.Equal(t, "testSkip", report.Tests[2].Name)
+		assert.Equal(t, "skip", report.Tests[2].Status)
+		assert.Equal(t, 1000*time.Second, report.Tests[2].EndTime.Sub(report.Tests[2].StartTime))
+		assert.Equal(t, "testError", report.Tests[3].Name)
+		assert.Equal(t, "fail", report.Tests[3].Status)
+		assert.Equal(t, []string{"boom"}, report.Tests[3].Output)
+		assert.Equal(t, report.Tests[2].EndTime, report.Tests[3].StartTime)
+		assert.WithinDuration(t, time.Now(), report.Tests[3].EndTime, time.Minute)
+	})
+	t.Run("InvalidJUnit", func(t *testing.T) {
+		_, err := ParseTestReport(TestReportFormatJUnit, strings.NewReader("<testsuites><testsuite>"))
+		assert.Error(t, err)
+		_, err = ParseTestReport(TestReportFormatJUnit, strings.NewReader("<results/>"))
+		assert.Error(t, err)
+		_, err = ParseTestReport(TestReportFormatJUnit, strings.NewReader(`<testsuite><testcase name="test" time="fast"/></testsuite>`))
+		assert.Error(t, err)
+	})
+	t.Run("TAP", func(t *testing.T) {
+		report, err := ParseTestReport(TestReportFormatTAP, strings.NewReader(testTAPReport))
+		require.NoError(t, err)
+		require.Len(t, report.Tests, 5)
+
+		for i, expected := range []struct {
+			name   string
+			status string
+		}{
+			{name: "passes", status: "pass"},
+			{name: "fails", status: "fail"},
+			{name: "skipped", status: "skip"},
+			{name: "unfinished", status: "skip"},
+			{name: "test 5", status: "pass"},
+		} {
+			assert.Equal(t, expected.name, report.Tests[i].Name)
+			assert.Equal(t, expected.status, report.Tests[i].Status)
+		}
+		assert.Equal(t, []string{"message: expected 1, got 2", "duration_ms: 250"}, report.Tests[1].Output)
+		assert.Equal(t, 250*time.Millisecond, report.Tests[1].EndTime.Sub(report.Tests[1].StartTime))
+		for i := 1; i < len(report.Tests); i++ {
+			assert.Equal(t, report.Tests[i-1].EndTime, report.Tests[i].StartTime)
+		}
+	})
+	t.Run("GoTest", func(t *testing.T) {
+		report, err := ParseTestReport(TestReportFormatGoTest, strings.NewReader(testGoTestReport))
+		require.NoError(t, err)
+		require.Len(t, report.Tests, 3)
+
+		start := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+		assert.Equal(t, ReportedTest{
+			Name:      "example.com/pkg.TestPass",
+			Status:    "pass",
+			StartTime: start,
+			EndTime:   start.Add(time.Second),
+		}, report.Tests[0])
+		assert.Equal(t, ReportedTest{
+			Name:      "example.com/pkg.TestFail",
+			Status:    "fail",
+			StartTime: start.Add(time.Second),
+			EndTime:   start.Add(3 * time.Second),
+			Output:    []string{"=== RUN   TestFail", "    pkg_test.go:10: expected 1, got 2"},
+		}, report.Tests[1])
+		assert.Equal(t, ReportedTest{
+			Name:      "example.com/pkg.TestTimeout",
+			Status:    "fail",
+			StartTime: start.Add(3 * time.Second),
+			EndTime:   start.Add(13 * time.Second),
+		}, report.Tests[2])
+	})
+	t.Run("InvalidGoTest", func(t *testing.T) {
+		_, err := ParseTestReport(TestReportFormatGoTest, strings.NewReader("# example.com/pkg\nbuild failed"))
+		assert.Error(t, err)
+	})
+}
+
+func TestTestReportImport(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir := t.TempDir()
+	defer func() {
+		assert.NoError(t, db.Collection(env.GetConfig().DbConfigurationCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(testResultsCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(testHistoryCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(buildloggerCollection).Drop(ctx))
+	}()
+
+	info := TestResultsInfo{
+		Project:   "project",
+		Version:   "version",
+		Variant:   "variant",
+		TaskName:  "task_name",
+		TaskID:    "task_id",
+		Execution: 1,
+		Mainline:  true,
+	}
+	report, err := ParseTestReport(TestReportFormatJUnit, strings.NewReader(testJUnitReport))
+	require.NoError(t, err)
+
+	t.Run("NoBucketType", func(t *testing.T) {
+		conf := &CedarConfig{populated: true}
+		conf.Setup(env)
+		require.NoError(t, conf.Save())
+
+		_, err := report.Import(ctx, env, info)
+		assert.Error(t, err)
+	})
+
+	conf := &CedarConfig{populated: true}
+	conf.Setup(env)
+	conf.Bucket.TestResultsBucket = tmpDir
+	conf.Bucket.TestResultsBucketType = PailLocal
+	conf.Bucket.PrestoBucket = tmpDir
+	conf.Bucket.PrestoTestResultsPrefix = "presto-test-results"
+	require.NoError(t, conf.Save())
+	t.Run("FailedImportIsRemoved", func(t *testing.T) {
+		_, err := report.Import(ctx, env, info)
+		assert.Error(t, err)
+		assert.False(t, IsTestResultsExist(err))
+
+		record := &TestResults{ID: info.ID()}
+		record.Setup(env)
+		assert.Error(t, record.Find(ctx))
+	})
+
+	conf.Bucket.BuildLogsBucket = tmpDir
+	conf.Bucket.BuildLogsBucketType = PailLocal
+	require.NoError(t, conf.Save())
+
+	t.Run("Import", func(t *testing.T) {
+		record, err := report.Import(ctx, env, info)
+		require.NoError(t, err)
+		require.NoError(t, record.Find(ctx))
+		assert.Equal(t, info, record.Info)
+		assert.False(t, record.CompletedAt.IsZero())
+		assert.Equal(t, 4, record.Stats.TotalCount)
+		assert.Equal(t, 2, record.Stats.FailedCount)
+
+		results, err := record.Download(ctx)
+		require.NoError(t, err)
+		require.Len(t, results, 4)
+		for _, result := range results {
+			assert.Equal(t, info.TaskID, result.TaskID)
+			assert.Equal(t, info.Execution, result.Execution)
+			if result.Status != "fail" {
+				assert.Nil(t, result.LogInfo)
+				continue
+			}
+			require.NotNil(t, result.LogInfo)
+			assert.Equal(t, result.TestName, result.LogInfo.LogName)
+
+			log := &Log{}
+			require.NoError(t, db.Collection(buildloggerCollection).FindOne(ctx, bson.M{
+				"info.task_id":   info.TaskID,
+				"info.execution": info.Execution,
+				"info.test_name": result.TestName,
+			}).Decode(log))
+			assert.Equal(t, LogFormatText, log.Info.Format)
+			assert.Equal(t, 1, log.Info.ExitCode)
+			assert.False(t, log.CompletedAt.IsZero())
+		}
+	})
+	t.Run("DuplicateTask", func(t *testing.T) {
+		_, err := report.Import(ctx, env, info)
+		assert.True(t, IsTestResultsExist(err))
+	})
+	t.Run("DuplicateTests", func(t *testing.T) {
+		dupInfo := info
+		dupInfo.TaskID = "dup_task_id"
+		// A log uploaded for the task's test does not collide with
+		// the logs of the report.
+		agentLog := CreateLog(LogInfo{
+			Project:   dupInfo.Project,
+			Version:   dupInfo.Version,
+			Variant:   dupInfo.Variant,
+			TaskName:  dupInfo.TaskName,
+			TaskID:    dupInfo.TaskID,
+			Execution: dupInfo.Execution,
+			TestName:  "pkg.Class.testCase",
+			Format:    LogFormatText,
+			Mainline:  dupInfo.Mainline,
+		}, PailLocal)
+		agentLog.Setup(env)
+		require.NoError(t, agentLog.SaveNew(ctx))
+
+		dupReport, err := ParseTestReport(TestReportFormatJUnit, strings.NewReader(`<testsuite>
+  <testcase classname="pkg.Class" name="testCase"><failure message="param 1"/></testcase>
+  <testcase classname="pkg.Class" name="testCase"><failure message="param 2"/></testcase>
+</testsuite>`))
+		require.NoError(t, err)
+		record, err := dupReport.Import(ctx, env, dupInfo)
+		require.NoError(t, err)
+
+		results, err := record.Download(ctx)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		for trial, message := range []string{"param 1", "param 2"} {
+			assert.Equal(t, trial, results[trial].Trial)
+
+			log := &Log{}
+			require.NoError(t, db.Collection(buildloggerCollection).FindOne(ctx, bson.M{
+				"info.task_id":   dupInfo.TaskID,
+				"info.test_name": "pkg.Class.testCase",
+				"info.trial":     trial,
+				"info.proc_name": testReportLogProcessName,
+			}).Decode(log))
+			log.Setup(env)
+			it, err := log.Download(ctx, TimeRange{EndAt: time.Now()})
+			require.NoError(t, err)
+			require.True(t, it.Next(ctx))
+			assert.Equal(t, message+"\n", it.Item().Data)
+			assert.NoError(t, it.Close())
+		}
+	})
+}
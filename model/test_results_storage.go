@@ -1,20 +1,34 @@
 package model
 
-import "github.com/mongodb/anser/bsonutil"
+import (
+	"time"
+
+	"github.com/mongodb/anser/bsonutil"
+)
 
 // TestResultsArtifactInfo describes a bucket of test results for a given task
 // execution stored in some kind of offline storage. It is the bridge between
 // pail-backed offline test results storage and the cedar-based test results metadata storage.
 // The prefix field indicates the name of the "sub-bucket". The top level
 // bucket is accesible via the cedar.Environment interface.
+//
+// Version 1 test results are stored in a single Parquet file that is
+// rewritten on every append. Version 2 test results are appended as Parquet
+// part files that are compacted into a single Parquet file once the record is
+// closed; CompactedParts are the part files merged into the compacted file
+// that have not yet been removed from offline storage.
 type TestResultsArtifactInfo struct {
-	Type    PailType `bson:"type"`
-	Prefix  string   `bson:"prefix"`
-	Version int      `bson:"version"`
+	Type           PailType  `bson:"type"`
+	Prefix         string    `bson:"prefix"`
+	Version        int       `bson:"version"`
+	CompactedAt    time.Time `bson:"compacted_at,omitempty"`
+	CompactedParts []string  `bson:"compacted_parts,omitempty"`
 }
 
 var (
-	testResultsArtifactInfoTypeKey    = bsonutil.MustHaveTag(TestResultsArtifactInfo{}, "Type")
-	testResultsArtifactInfoPrefixKey  = bsonutil.MustHaveTag(TestResultsArtifactInfo{}, "Prefix")
-	testResultsArtifactInfoVersionKey = bsonutil.MustHaveTag(TestResultsArtifactInfo{}, "Version")
+	testResultsArtifactInfoTypeKey           = bsonutil.MustHaveTag(TestResultsArtifactInfo{}, "Type")
+	testResultsArtifactInfoPrefixKey         = bsonutil.MustHaveTag(TestResultsArtifactInfo{}, "Prefix")
+	testResultsArtifactInfoVersionKey        = bsonutil.MustHaveTag(TestResultsArtifactInfo{}, "Version")
+	testResultsArtifactInfoCompactedAtKey    = bsonutil.MustHaveTag(TestResultsArtifactInfo{}, "CompactedAt")
+	testResultsArtifactInfoCompactedPartsKey = bsonutil.MustHaveTag(TestResultsArtifactInfo{}, "CompactedParts")
 )
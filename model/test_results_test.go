@@ -28,6 +28,7 @@ func init() {
 
 func TestCreateTestResults(t *testing.T) {
 	expected := getTestResults()
+	expected.Artifact.Version = 2
 	actual := CreateTestResults(expected.Info, PailLocal)
 	assert.Equal(t, expected.ID, actual.ID)
 	assert.Equal(t, expected.Info, actual.Info)
@@ -211,6 +212,28 @@ func TestTestResultsRemove(t *testing.T) {
 	})
 }
 
+func TestTestResultsRemoveArtifacts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.Run("Unpopulated", func(t *testing.T) {
+		tr := &TestResults{ID: "id", Artifact: TestResultsArtifactInfo{Prefix: "id"}}
+		tr.Setup(cedar.GetEnvironment())
+		assert.Error(t, tr.RemoveArtifacts(ctx))
+	})
+	t.Run("NoEnv", func(t *testing.T) {
+		tr := &TestResults{ID: "id", Artifact: TestResultsArtifactInfo{Prefix: "id"}, populated: true}
+		assert.Error(t, tr.RemoveArtifacts(ctx))
+	})
+	t.Run("NoPrefix", func(t *testing.T) {
+		for _, version := range []int{0, 1, 2} {
+			tr := &TestResults{ID: "id", Artifact: TestResultsArtifactInfo{Version: version}, populated: true}
+			tr.Setup(cedar.GetEnvironment())
+			assert.Error(t, tr.RemoveArtifacts(ctx))
+		}
+	})
+}
+
 func TestTestResultsAppend(t *testing.T) {
 	env := cedar.GetEnvironment()
 	db := env.GetDB()
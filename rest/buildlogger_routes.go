@@ -2,10 +2,13 @@ package rest
 
 import (
 	"context"
+	"fmt"
+	"mime"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/evergreen-ci/cedar/model"
 	"github.com/evergreen-ci/cedar/rest/data"
 	"github.com/evergreen-ci/gimlet"
 	"github.com/mongodb/grip"
@@ -14,17 +17,41 @@ import (
 )
 
 const (
-	logStartAt    = "start"
-	logEndAt      = "end"
-	execution     = "execution"
-	procName      = "proc_name"
-	tags          = "tags"
-	printTime     = "print_time"
-	printPriority = "print_priority"
-	limit         = "limit"
-	paginate      = "paginate"
-	trueString    = "true"
-	softSizeLimit = 10 * 1024 * 1024
+	logStartAt       = "start"
+	logEndAt         = "end"
+	fromLine         = "from_line"
+	execution        = "execution"
+	procName         = "proc_name"
+	tags             = "tags"
+	printTime        = "print_time"
+	printPriority    = "print_priority"
+	printSource      = "print_source"
+	limit            = "limit"
+	paginate         = "paginate"
+	follow           = "follow"
+	fieldFilter      = "filter"
+	minPriorityParam = "min_priority"
+	maxPriorityParam = "max_priority"
+	ndjson           = "ndjson"
+	archiveFormat    = "format"
+	usageDate        = "date"
+	searchQuery      = "q"
+	searchRegex      = "regex"
+	searchContext    = "context"
+	diffBase         = "base"
+	diffTestName     = "test_name"
+	diffNormalize    = "normalize"
+	diffWindow       = "window"
+	failureProject   = "project"
+	failureDays      = "days"
+	failureExclude   = "exclude_task_id"
+	trueString       = "true"
+	softSizeLimit    = 10 * 1024 * 1024
+
+	defaultSearchLimit = 100
+
+	defaultFailureSignatureDays  = 14
+	defaultFailureSignatureLimit = 100
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -32,8 +59,10 @@ const (
 // GET /buildlogger/{id}
 
 type logGetByIDHandler struct {
-	opts data.BuildloggerOptions
-	sc   data.Connector
+	opts   data.BuildloggerOptions
+	follow bool
+	lines  bool
+	sc     data.Connector
 }
 
 func makeGetLogByID(sc data.Connector) gimlet.RouteHandler {
@@ -58,6 +87,11 @@ func (h *logGetByIDHandler) Parse(_ context.Context, r *http.Request) error {
 	vals := r.URL.Query()
 	h.opts.PrintTime = vals.Get(printTime) == trueString
 	h.opts.PrintPriority = vals.Get(printPriority) == trueString
+	h.opts.NDJSON = vals.Get(ndjson) == trueString
+	h.opts.FieldFilters, err = parseLogFieldFilters(vals[fieldFilter])
+	catcher.Add(err)
+	h.opts.MinPriority, h.opts.MaxPriority, err = parsePriorityRange(vals)
+	catcher.Add(err)
 	h.opts.TimeRange, err = parseTimeRange(time.RFC3339Nano, vals.Get(logStartAt), vals.Get(logEndAt))
 	catcher.Add(err)
 	if len(vals[limit]) > 0 {
@@ -67,12 +101,32 @@ func (h *logGetByIDHandler) Parse(_ context.Context, r *http.Request) error {
 	if vals.Get(paginate) == trueString && h.opts.Limit <= 0 {
 		h.opts.SoftSizeLimit = softSizeLimit
 	}
+	h.follow = vals.Get(follow) == trueString
+	catcher.NewWhen(h.follow && (h.opts.Limit > 0 || h.opts.SoftSizeLimit > 0), "cannot paginate or limit a followed log")
+	if len(vals[fromLine]) > 0 {
+		h.lines = true
+		h.opts.FromLine, err = strconv.Atoi(vals[fromLine][0])
+		catcher.Add(err)
+		catcher.NewWhen(h.opts.FromLine < 0, "line number cannot be negative")
+		catcher.NewWhen(vals.Get(logStartAt) != "" || vals.Get(logEndAt) != "", "cannot address a log by both line number and time range")
+		catcher.NewWhen(h.follow, "cannot follow a log from a line number")
+	}
 
 	return catcher.Resolve()
 }
 
-// Run calls FindLogByID and returns the log.
+// Run calls FindLogByID and returns the log. If follow is set, FollowLogByID
+// is called instead and the log lines are streamed until the log is closed.
+// If a line number is set, FindLogLinesByID is called instead and the log is
+// paginated by line number.
 func (h *logGetByIDHandler) Run(ctx context.Context) gimlet.Responder {
+	if h.follow {
+		return h.runFollow(ctx)
+	}
+	if h.lines {
+		return h.runLines(ctx)
+	}
+
 	data, next, paginated, err := h.sc.FindLogByID(ctx, h.opts)
 	if err != nil {
 		err = errors.Wrapf(err, "getting log by ID '%s'", h.opts.ID)
@@ -88,6 +142,40 @@ func (h *logGetByIDHandler) Run(ctx context.Context) gimlet.Responder {
 	return newBuildloggerResponder(h.sc.GetBaseURL(), data, h.opts.TimeRange.StartAt, next, paginated)
 }
 
+func (h *logGetByIDHandler) runLines(ctx context.Context) gimlet.Responder {
+	data, next, paginated, err := h.sc.FindLogLinesByID(ctx, h.opts)
+	if err != nil {
+		err = errors.Wrapf(err, "getting lines of log by ID '%s'", h.opts.ID)
+		logFindError(err, message.Fields{
+			"request":   gimlet.GetRequestID(ctx),
+			"method":    "GET",
+			"route":     "/buildlogger/{id}",
+			"id":        h.opts.ID,
+			"from_line": h.opts.FromLine,
+		})
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+
+	return newBuildloggerLinesResponder(h.sc.GetBaseURL(), data, h.opts.FromLine, next, h.opts.Limit, paginated)
+}
+
+func (h *logGetByIDHandler) runFollow(ctx context.Context) gimlet.Responder {
+	r, err := h.sc.FollowLogByID(ctx, h.opts)
+	if err != nil {
+		err = errors.Wrapf(err, "following log by ID '%s'", h.opts.ID)
+		logFindError(err, message.Fields{
+			"request": gimlet.GetRequestID(ctx),
+			"method":  "GET",
+			"route":   "/buildlogger/{id}",
+			"id":      h.opts.ID,
+			"follow":  true,
+		})
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+
+	return gimlet.NewTextResponse(r)
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 //
 // GET /buildlogger/{id}/meta
@@ -166,6 +254,12 @@ func (h *logGetByTaskIDHandler) Parse(_ context.Context, r *http.Request) error
 	h.opts.Tags = vals[tags]
 	h.opts.PrintTime = vals.Get(printTime) == trueString
 	h.opts.PrintPriority = vals.Get(printPriority) == trueString
+	h.opts.PrintSource = vals.Get(printSource) == trueString
+	h.opts.NDJSON = vals.Get(ndjson) == trueString
+	h.opts.FieldFilters, err = parseLogFieldFilters(vals[fieldFilter])
+	catcher.Add(err)
+	h.opts.MinPriority, h.opts.MaxPriority, err = parsePriorityRange(vals)
+	catcher.Add(err)
 	h.opts.TimeRange, err = parseTimeRange(time.RFC3339Nano, vals.Get(logStartAt), vals.Get(logEndAt))
 	catcher.Add(err)
 	if len(vals[execution]) > 0 {
@@ -261,6 +355,439 @@ func (h *logMetaGetByTaskIDHandler) Run(ctx context.Context) gimlet.Responder {
 	return gimlet.NewJSONResponse(apiLogs)
 }
 
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /buildlogger/task_id/{task_id}/processes
+
+type logProcessesGetByTaskIDHandler struct {
+	opts data.BuildloggerOptions
+	sc   data.Connector
+}
+
+func makeGetLogProcessesByTaskID(sc data.Connector) gimlet.RouteHandler {
+	return &logProcessesGetByTaskIDHandler{
+		sc: sc,
+	}
+}
+
+// Factory returns a pointer to a new logProcessesGetByTaskIDHandler.
+func (h *logProcessesGetByTaskIDHandler) Factory() gimlet.RouteHandler {
+	return &logProcessesGetByTaskIDHandler{
+		sc: h.sc,
+	}
+}
+
+// Parse fetches the task ID, process name, tags, and execution from the HTTP
+// request.
+func (h *logProcessesGetByTaskIDHandler) Parse(_ context.Context, r *http.Request) error {
+	h.opts.TaskID = gimlet.GetVars(r)["task_id"]
+	vals := r.URL.Query()
+	h.opts.ProcessName = vals.Get(procName)
+	h.opts.Tags = vals[tags]
+	if len(vals[execution]) > 0 {
+		var err error
+		h.opts.Execution, err = strconv.Atoi(vals[execution][0])
+		return err
+	} else {
+		h.opts.EmptyExecution = true
+	}
+
+	return nil
+}
+
+// Run calls FindLogProcessesByTaskID and returns the processes of the logs.
+func (h *logProcessesGetByTaskIDHandler) Run(ctx context.Context) gimlet.Responder {
+	processes, err := h.sc.FindLogProcessesByTaskID(ctx, h.opts)
+	if err != nil {
+		err = errors.Wrapf(err, "getting log processes by task ID '%s'", h.opts.TaskID)
+		logFindError(err, message.Fields{
+			"request": gimlet.GetRequestID(ctx),
+			"method":  "GET",
+			"route":   "/buildlogger/task_id/{task_id}/processes",
+			"task_id": h.opts.TaskID,
+		})
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+
+	return gimlet.NewJSONResponse(processes)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /buildlogger/task_id/{task_id}/archive
+
+type logArchiveGetByTaskIDHandler struct {
+	opts        data.BuildloggerOptions
+	compression model.FileCompression
+	sc          data.Connector
+}
+
+func makeGetLogArchiveByTaskID(sc data.Connector) gimlet.RouteHandler {
+	return &logArchiveGetByTaskIDHandler{
+		sc: sc,
+	}
+}
+
+// Factory returns a pointer to a new logArchiveGetByTaskIDHandler.
+func (h *logArchiveGetByTaskIDHandler) Factory() gimlet.RouteHandler {
+	return &logArchiveGetByTaskIDHandler{
+		sc: h.sc,
+	}
+}
+
+// Parse fetches the task ID and archive format from the HTTP request.
+func (h *logArchiveGetByTaskIDHandler) Parse(_ context.Context, r *http.Request) error {
+	var err error
+	catcher := grip.NewBasicCatcher()
+
+	h.opts.TaskID = gimlet.GetVars(r)["task_id"]
+	vals := r.URL.Query()
+	h.opts.ProcessName = vals.Get(procName)
+	h.opts.Tags = vals[tags]
+	h.opts.PrintTime = vals.Get(printTime) == trueString
+	h.opts.PrintPriority = vals.Get(printPriority) == trueString
+	if len(vals[execution]) > 0 {
+		h.opts.Execution, err = strconv.Atoi(vals[execution][0])
+		catcher.Add(err)
+	} else {
+		h.opts.EmptyExecution = true
+	}
+	h.compression, err = parseArchiveFormat(vals.Get(archiveFormat))
+	catcher.Add(err)
+
+	return catcher.Resolve()
+}
+
+// Run calls FindLogArchiveByTaskID and streams the archive of the logs.
+func (h *logArchiveGetByTaskIDHandler) Run(ctx context.Context) gimlet.Responder {
+	r, err := h.sc.FindLogArchiveByTaskID(ctx, h.opts, h.compression)
+	if err != nil {
+		err = errors.Wrapf(err, "getting log archive by task ID '%s'", h.opts.TaskID)
+		logFindError(err, message.Fields{
+			"request": gimlet.GetRequestID(ctx),
+			"method":  "GET",
+			"route":   "/buildlogger/task_id/{task_id}/archive",
+			"task_id": h.opts.TaskID,
+		})
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+	setResponseHeader(ctx, "Content-Type", logArchiveContentType(h.compression))
+	setResponseHeader(ctx, "Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": logArchiveDownloadName(h.opts, h.compression),
+	}))
+
+	return gimlet.NewBinaryResponse(r)
+}
+
+func logArchiveContentType(compression model.FileCompression) string {
+	if compression == model.FileZip {
+		return "application/zip"
+	}
+	return "application/gzip"
+}
+
+// logArchiveDownloadName returns the file name suggested to clients
+// downloading the log archive of a task, including the execution if
+// requested.
+func logArchiveDownloadName(opts data.BuildloggerOptions, compression model.FileCompression) string {
+	name := opts.TaskID
+	if !opts.EmptyExecution {
+		name = fmt.Sprintf("%s.%d", name, opts.Execution)
+	}
+	if compression == model.FileZip {
+		return name + ".zip"
+	}
+	return name + ".tar.gz"
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /buildlogger/task_id/{task_id}/search
+
+type logSearchByTaskIDHandler struct {
+	opts       data.BuildloggerOptions
+	searchOpts model.LogSearchOptions
+	sc         data.Connector
+}
+
+func makeSearchLogsByTaskID(sc data.Connector) gimlet.RouteHandler {
+	return &logSearchByTaskIDHandler{
+		sc: sc,
+	}
+}
+
+// Factory returns a pointer to a new logSearchByTaskIDHandler.
+func (h *logSearchByTaskIDHandler) Factory() gimlet.RouteHandler {
+	return &logSearchByTaskIDHandler{
+		sc: h.sc,
+	}
+}
+
+// Parse fetches the task ID, search query, and parameters from the HTTP
+// request.
+func (h *logSearchByTaskIDHandler) Parse(_ context.Context, r *http.Request) error {
+	var err error
+	catcher := grip.NewBasicCatcher()
+
+	h.opts.TaskID = gimlet.GetVars(r)["task_id"]
+	vals := r.URL.Query()
+	h.opts.ProcessName = vals.Get(procName)
+	h.opts.Tags = vals[tags]
+	h.opts.TimeRange, err = parseTimeRange(time.RFC3339Nano, vals.Get(logStartAt), vals.Get(logEndAt))
+	catcher.Add(err)
+	if len(vals[execution]) > 0 {
+		h.opts.Execution, err = strconv.Atoi(vals[execution][0])
+		catcher.Add(err)
+	} else {
+		h.opts.EmptyExecution = true
+	}
+
+	h.searchOpts.Query = vals.Get(searchQuery)
+	h.searchOpts.Regex = vals.Get(searchRegex) == trueString
+	h.searchOpts.MaxMatches = defaultSearchLimit
+	if len(vals[limit]) > 0 {
+		h.searchOpts.MaxMatches, err = strconv.Atoi(vals[limit][0])
+		catcher.Add(err)
+	}
+	if len(vals[searchContext]) > 0 {
+		h.searchOpts.ContextLines, err = strconv.Atoi(vals[searchContext][0])
+		catcher.Add(err)
+	}
+	h.searchOpts.MinPriority, h.searchOpts.MaxPriority, err = parsePriorityRange(vals)
+	catcher.Add(err)
+	if !catcher.HasErrors() {
+		catcher.Add(h.searchOpts.Validate())
+	}
+
+	return catcher.Resolve()
+}
+
+// Run calls SearchLogsByTaskID and returns the matching log lines.
+func (h *logSearchByTaskIDHandler) Run(ctx context.Context) gimlet.Responder {
+	results, err := h.sc.SearchLogsByTaskID(ctx, h.opts, h.searchOpts)
+	if err != nil {
+		err = errors.Wrapf(err, "searching logs by task ID '%s'", h.opts.TaskID)
+		logFindError(err, message.Fields{
+			"request": gimlet.GetRequestID(ctx),
+			"method":  "GET",
+			"route":   "/buildlogger/task_id/{task_id}/search",
+			"task_id": h.opts.TaskID,
+		})
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+
+	return gimlet.NewJSONResponse(results)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /buildlogger/task_id/{task_id}/diff
+
+type logDiffByTaskIDHandler struct {
+	opts          data.BuildloggerOptions
+	baseExecution int
+	diffOpts      model.LogDiffOptions
+	sc            data.Connector
+}
+
+func makeDiffLogsByTaskID(sc data.Connector) gimlet.RouteHandler {
+	return &logDiffByTaskIDHandler{
+		sc: sc,
+	}
+}
+
+// Factory returns a pointer to a new logDiffByTaskIDHandler.
+func (h *logDiffByTaskIDHandler) Factory() gimlet.RouteHandler {
+	return &logDiffByTaskIDHandler{
+		sc: h.sc,
+	}
+}
+
+// Parse fetches the task ID, executions, and diff parameters from the HTTP
+// request.
+func (h *logDiffByTaskIDHandler) Parse(_ context.Context, r *http.Request) error {
+	var err error
+	catcher := grip.NewBasicCatcher()
+
+	h.opts.TaskID = gimlet.GetVars(r)["task_id"]
+	vals := r.URL.Query()
+	h.opts.TestName = vals.Get(diffTestName)
+	h.opts.ProcessName = vals.Get(procName)
+	h.opts.Tags = vals[tags]
+	if len(vals[diffBase]) > 0 {
+		h.baseExecution, err = strconv.Atoi(vals[diffBase][0])
+		catcher.Add(err)
+	} else {
+		catcher.New("must specify a base execution")
+	}
+	if len(vals[execution]) > 0 {
+		h.opts.Execution, err = strconv.Atoi(vals[execution][0])
+		catcher.Add(err)
+	} else {
+		h.opts.EmptyExecution = true
+	}
+
+	h.diffOpts.Normalizers = vals[diffNormalize]
+	if len(vals[searchContext]) > 0 {
+		h.diffOpts.ContextLines, err = strconv.Atoi(vals[searchContext][0])
+		catcher.Add(err)
+	}
+	if len(vals[diffWindow]) > 0 {
+		h.diffOpts.Window, err = strconv.Atoi(vals[diffWindow][0])
+		catcher.Add(err)
+	}
+	if !catcher.HasErrors() {
+		catcher.Add(h.diffOpts.Validate())
+	}
+
+	return catcher.Resolve()
+}
+
+// Run calls DiffLogsByTaskID and streams the diff of the logs.
+func (h *logDiffByTaskIDHandler) Run(ctx context.Context) gimlet.Responder {
+	r, err := h.sc.DiffLogsByTaskID(ctx, h.opts, h.baseExecution, h.diffOpts)
+	if err != nil {
+		err = errors.Wrapf(err, "diffing logs by task ID '%s'", h.opts.TaskID)
+		logFindError(err, message.Fields{
+			"request":        gimlet.GetRequestID(ctx),
+			"method":         "GET",
+			"route":          "/buildlogger/task_id/{task_id}/diff",
+			"task_id":        h.opts.TaskID,
+			"base_execution": h.baseExecution,
+		})
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+
+	return gimlet.NewTextResponse(r)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /buildlogger/task_id/{task_id}/failure_signatures
+
+type logFailuresGetByTaskIDHandler struct {
+	opts data.BuildloggerOptions
+	sc   data.Connector
+}
+
+func makeGetLogFailuresByTaskID(sc data.Connector) gimlet.RouteHandler {
+	return &logFailuresGetByTaskIDHandler{
+		sc: sc,
+	}
+}
+
+// Factory returns a pointer to a new logFailuresGetByTaskIDHandler.
+func (h *logFailuresGetByTaskIDHandler) Factory() gimlet.RouteHandler {
+	return &logFailuresGetByTaskIDHandler{
+		sc: h.sc,
+	}
+}
+
+// Parse fetches the task ID and filters from the HTTP request.
+func (h *logFailuresGetByTaskIDHandler) Parse(_ context.Context, r *http.Request) error {
+	var err error
+
+	h.opts.TaskID = gimlet.GetVars(r)["task_id"]
+	vals := r.URL.Query()
+	h.opts.ProcessName = vals.Get(procName)
+	h.opts.Tags = vals[tags]
+	if len(vals[execution]) > 0 {
+		h.opts.Execution, err = strconv.Atoi(vals[execution][0])
+	} else {
+		h.opts.EmptyExecution = true
+	}
+
+	return err
+}
+
+// Run calls FindLogFailuresByTaskID and returns the failure signatures of the
+// logs.
+func (h *logFailuresGetByTaskIDHandler) Run(ctx context.Context) gimlet.Responder {
+	failures, err := h.sc.FindLogFailuresByTaskID(ctx, h.opts)
+	if err != nil {
+		err = errors.Wrapf(err, "getting log failure signatures by task ID '%s'", h.opts.TaskID)
+		logFindError(err, message.Fields{
+			"request": gimlet.GetRequestID(ctx),
+			"method":  "GET",
+			"route":   "/buildlogger/task_id/{task_id}/failure_signatures",
+			"task_id": h.opts.TaskID,
+		})
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+
+	return gimlet.NewJSONResponse(failures)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /buildlogger/failure_signatures/{signature_id}
+
+type logsGetByFailureSignatureHandler struct {
+	opts model.LogFailureSignatureFindOptions
+	sc   data.Connector
+}
+
+func makeGetLogsByFailureSignature(sc data.Connector) gimlet.RouteHandler {
+	return &logsGetByFailureSignatureHandler{
+		sc: sc,
+	}
+}
+
+// Factory returns a pointer to a new logsGetByFailureSignatureHandler.
+func (h *logsGetByFailureSignatureHandler) Factory() gimlet.RouteHandler {
+	return &logsGetByFailureSignatureHandler{
+		sc: h.sc,
+	}
+}
+
+// Parse fetches the failure signature ID, project, and look back period, in
+// days, from the HTTP request.
+func (h *logsGetByFailureSignatureHandler) Parse(_ context.Context, r *http.Request) error {
+	var err error
+	catcher := grip.NewBasicCatcher()
+
+	h.opts.SignatureID = gimlet.GetVars(r)["signature_id"]
+	vals := r.URL.Query()
+	h.opts.Project = vals.Get(failureProject)
+	h.opts.ExcludeTaskID = vals.Get(failureExclude)
+	days := defaultFailureSignatureDays
+	if len(vals[failureDays]) > 0 {
+		days, err = strconv.Atoi(vals[failureDays][0])
+		catcher.Add(err)
+		catcher.NewWhen(err == nil && days <= 0, "days must be positive")
+	}
+	h.opts.Since = time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+	h.opts.Limit = defaultFailureSignatureLimit
+	if len(vals[limit]) > 0 {
+		h.opts.Limit, err = strconv.ParseInt(vals[limit][0], 10, 64)
+		catcher.Add(err)
+	}
+	if !catcher.HasErrors() {
+		catcher.Add(h.opts.Validate())
+	}
+
+	return catcher.Resolve()
+}
+
+// Run calls FindLogsByFailureSignature and returns the logs with the failure
+// signature.
+func (h *logsGetByFailureSignatureHandler) Run(ctx context.Context) gimlet.Responder {
+	matches, err := h.sc.FindLogsByFailureSignature(ctx, h.opts)
+	if err != nil {
+		err = errors.Wrapf(err, "getting logs by failure signature '%s'", h.opts.SignatureID)
+		logFindError(err, message.Fields{
+			"request":      gimlet.GetRequestID(ctx),
+			"method":       "GET",
+			"route":        "/buildlogger/failure_signatures/{signature_id}",
+			"signature_id": h.opts.SignatureID,
+			"project":      h.opts.Project,
+		})
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+
+	return gimlet.NewJSONResponse(matches)
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 //
 // GET /buildlogger/task_id/{task_id}/group/{group_id}
@@ -295,6 +822,12 @@ func (h *logGroupByTaskIDHandler) Parse(_ context.Context, r *http.Request) erro
 	h.opts.Tags = vals[tags]
 	h.opts.PrintTime = vals.Get(printTime) == trueString
 	h.opts.PrintPriority = vals.Get(printPriority) == trueString
+	h.opts.PrintSource = vals.Get(printSource) == trueString
+	h.opts.NDJSON = vals.Get(ndjson) == trueString
+	h.opts.FieldFilters, err = parseLogFieldFilters(vals[fieldFilter])
+	catcher.Add(err)
+	h.opts.MinPriority, h.opts.MaxPriority, err = parsePriorityRange(vals)
+	catcher.Add(err)
 	h.opts.TimeRange, err = parseTimeRange(time.RFC3339Nano, vals.Get(logStartAt), vals.Get(logEndAt))
 	catcher.Add(err)
 	if len(vals[execution]) > 0 {
@@ -366,6 +899,12 @@ func (h *logGetByTestNameHandler) Parse(_ context.Context, r *http.Request) erro
 	h.opts.Tags = vals[tags]
 	h.opts.PrintTime = vals.Get(printTime) == trueString
 	h.opts.PrintPriority = vals.Get(printPriority) == trueString
+	h.opts.PrintSource = vals.Get(printSource) == trueString
+	h.opts.NDJSON = vals.Get(ndjson) == trueString
+	h.opts.FieldFilters, err = parseLogFieldFilters(vals[fieldFilter])
+	catcher.Add(err)
+	h.opts.MinPriority, h.opts.MaxPriority, err = parsePriorityRange(vals)
+	catcher.Add(err)
 	h.opts.TimeRange, err = parseTimeRange(time.RFC3339Nano, vals.Get(logStartAt), vals.Get(logEndAt))
 	catcher.Add(err)
 	if len(vals[execution]) > 0 {
@@ -508,6 +1047,12 @@ func (h *logGroupByTestNameHandler) Parse(_ context.Context, r *http.Request) er
 	h.opts.Tags = vals[tags]
 	h.opts.PrintTime = vals.Get(printTime) == trueString
 	h.opts.PrintPriority = vals.Get(printPriority) == trueString
+	h.opts.PrintSource = vals.Get(printSource) == trueString
+	h.opts.NDJSON = vals.Get(ndjson) == trueString
+	h.opts.FieldFilters, err = parseLogFieldFilters(vals[fieldFilter])
+	catcher.Add(err)
+	h.opts.MinPriority, h.opts.MaxPriority, err = parsePriorityRange(vals)
+	catcher.Add(err)
 	if len(vals[execution]) > 0 {
 		h.opts.Execution, err = strconv.Atoi(vals[execution][0])
 		catcher.Add(err)
@@ -577,6 +1122,59 @@ func (h *logGroupByTestNameHandler) Run(ctx context.Context) gimlet.Responder {
 	return newBuildloggerResponder(h.sc.GetBaseURL(), data, h.opts.TimeRange.StartAt, next, paginated)
 }
 
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /admin/buildlogger/usage
+
+type logUsageGetHandler struct {
+	date time.Time
+	sc   data.Connector
+}
+
+func makeGetLogUsage(sc data.Connector) gimlet.RouteHandler {
+	return &logUsageGetHandler{
+		sc: sc,
+	}
+}
+
+// Factory returns a pointer to a new logUsageGetHandler.
+func (h *logUsageGetHandler) Factory() gimlet.RouteHandler {
+	return &logUsageGetHandler{
+		sc: h.sc,
+	}
+}
+
+// Parse fetches the UTC date, formatted as YYYY-MM-DD, from the HTTP request.
+// The date defaults to the current day.
+func (h *logUsageGetHandler) Parse(_ context.Context, r *http.Request) error {
+	val := r.URL.Query().Get(usageDate)
+	if val == "" {
+		h.date = time.Now().UTC()
+		return nil
+	}
+
+	var err error
+	h.date, err = time.Parse(model.LogUsageDateFormat, val)
+	return errors.Wrapf(err, "parsing date '%s'", val)
+}
+
+// Run calls FindLogUsageByDate and returns the usage of each project.
+func (h *logUsageGetHandler) Run(ctx context.Context) gimlet.Responder {
+	usage, err := h.sc.FindLogUsageByDate(ctx, h.date)
+	if err != nil {
+		err = errors.Wrapf(err, "getting log usage for '%s'", h.date.Format(model.LogUsageDateFormat))
+		logFindError(err, message.Fields{
+			"request": gimlet.GetRequestID(ctx),
+			"method":  "GET",
+			"route":   "/admin/buildlogger/usage",
+			"date":    h.date,
+		})
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+
+	return gimlet.NewJSONResponse(usage)
+}
+
 func newBuildloggerResponder(baseURL string, data []byte, last, next time.Time, paginated bool) gimlet.Responder {
 	resp := gimlet.NewTextResponse(data)
 
@@ -607,3 +1205,36 @@ func newBuildloggerResponder(baseURL string, data []byte, last, next time.Time,
 
 	return resp
 }
+
+func newBuildloggerLinesResponder(baseURL string, data []byte, from, next, lineLimit int, paginated bool) gimlet.Responder {
+	resp := gimlet.NewTextResponse(data)
+
+	if paginated {
+		pages := &gimlet.ResponsePages{
+			Prev: &gimlet.Page{
+				BaseURL:         baseURL,
+				KeyQueryParam:   fromLine,
+				LimitQueryParam: limit,
+				Key:             strconv.Itoa(from),
+				Limit:           lineLimit,
+				Relation:        "prev",
+			},
+		}
+		if next > 0 {
+			pages.Next = &gimlet.Page{
+				BaseURL:         baseURL,
+				KeyQueryParam:   fromLine,
+				LimitQueryParam: limit,
+				Key:             strconv.Itoa(next),
+				Limit:           lineLimit,
+				Relation:        "next",
+			}
+		}
+
+		if err := resp.SetPages(pages); err != nil {
+			return gimlet.MakeJSONErrorResponder(errors.Wrap(err, "setting response pages"))
+		}
+	}
+
+	return resp
+}
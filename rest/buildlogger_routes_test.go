@@ -1,7 +1,13 @@
 package rest
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -14,6 +20,8 @@ import (
 	"github.com/evergreen-ci/cedar/rest/model"
 	"github.com/evergreen-ci/gimlet"
 	"github.com/evergreen-ci/pail"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip/level"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -83,6 +91,9 @@ func (s *LogHandlerSuite) setup(tempDir string) {
 					Type:   dbModel.PailLocal,
 					Prefix: "ghi",
 				},
+				FailureSignatures: []dbModel.LogFailureSignature{
+					{ID: "sig1", Kind: dbModel.LogFailureTestFailure, Signature: "FAIL TestFoo", Count: 1},
+				},
 			},
 			"jkl": {
 				ID: "jkl",
@@ -100,6 +111,10 @@ func (s *LogHandlerSuite) setup(tempDir string) {
 					Type:   dbModel.PailLocal,
 					Prefix: "jkl",
 				},
+				FailureSignatures: []dbModel.LogFailureSignature{
+					{ID: "sig1", Kind: dbModel.LogFailureTestFailure, Signature: "FAIL TestFoo", Count: 2},
+					{ID: "sig2", Kind: dbModel.LogFailurePanic, Signature: "panic: oops", Count: 1},
+				},
 			},
 			"mno": {
 				ID: "mno",
@@ -134,16 +149,48 @@ func (s *LogHandlerSuite) setup(tempDir string) {
 				},
 			},
 		},
+		CachedLogUsage: []dbModel.LogUsage{
+			{
+				ID:       "small@2021-01-02",
+				Project:  "small",
+				Date:     time.Date(2021, time.January, 2, 0, 0, 0, 0, time.UTC),
+				Bytes:    1024,
+				NumLines: 10,
+			},
+			{
+				ID:            "large@2021-01-02",
+				Project:       "large",
+				Date:          time.Date(2021, time.January, 2, 0, 0, 0, 0, time.UTC),
+				Bytes:         4096,
+				NumLines:      40,
+				RejectedBytes: 2048,
+				TruncatedLogs: 1,
+			},
+			{
+				ID:       "small@2021-01-03",
+				Project:  "small",
+				Date:     time.Date(2021, time.January, 3, 0, 0, 0, 0, time.UTC),
+				Bytes:    512,
+				NumLines: 5,
+			},
+		},
 	}
 	s.rh = map[string]gimlet.RouteHandler{
-		"id":              makeGetLogByID(&s.sc),
-		"meta_id":         makeGetLogMetaByID(&s.sc),
-		"task_id":         makeGetLogByTaskID(&s.sc),
-		"meta_task_id":    makeGetLogMetaByTaskID(&s.sc),
-		"group_task_id":   makeGetLogGroupByTaskID(&s.sc),
-		"test_name":       makeGetLogByTestName(&s.sc),
-		"meta_test_name":  makeGetLogMetaByTestName(&s.sc),
-		"group_test_name": makeGetLogGroupByTestName(&s.sc),
+		"id":                makeGetLogByID(&s.sc),
+		"meta_id":           makeGetLogMetaByID(&s.sc),
+		"task_id":           makeGetLogByTaskID(&s.sc),
+		"meta_task_id":      makeGetLogMetaByTaskID(&s.sc),
+		"proc_task_id":      makeGetLogProcessesByTaskID(&s.sc),
+		"search_task_id":    makeSearchLogsByTaskID(&s.sc),
+		"archive_task_id":   makeGetLogArchiveByTaskID(&s.sc),
+		"diff_task_id":      makeDiffLogsByTaskID(&s.sc),
+		"failures_task_id":  makeGetLogFailuresByTaskID(&s.sc),
+		"failure_signature": makeGetLogsByFailureSignature(&s.sc),
+		"group_task_id":     makeGetLogGroupByTaskID(&s.sc),
+		"test_name":         makeGetLogByTestName(&s.sc),
+		"meta_test_name":    makeGetLogMetaByTestName(&s.sc),
+		"group_test_name":   makeGetLogGroupByTestName(&s.sc),
+		"usage":             makeGetLogUsage(&s.sc),
 	}
 	s.apiResults = map[string]model.APILog{}
 	s.buckets = map[string]pail.Bucket{}
@@ -274,6 +321,96 @@ func (s *LogHandlerSuite) TestLogGetByIDHandlerCtxErr() {
 	s.NotEqual(http.StatusOK, resp.Status())
 }
 
+func (s *LogHandlerSuite) TestLogGetByIDHandlerFollow() {
+	rh := s.rh["id"].Factory()
+	rh.(*logGetByIDHandler).opts.ID = "abc"
+	rh.(*logGetByIDHandler).opts.TimeRange = dbModel.TimeRange{
+		StartAt: time.Now().Add(-24 * time.Hour),
+		EndAt:   time.Now(),
+	}
+	rh.(*logGetByIDHandler).opts.PrintTime = true
+	rh.(*logGetByIDHandler).follow = true
+	it := dbModel.NewBatchedLogIterator(
+		s.buckets["abc"],
+		s.sc.CachedLogs["abc"].Artifact.Chunks,
+		batchSize,
+		dbModel.TimeRange{
+			StartAt: rh.(*logGetByIDHandler).opts.TimeRange.StartAt,
+			EndAt:   utility.MaxTime,
+		},
+	)
+	r := dbModel.NewLogIteratorReader(context.TODO(), it, dbModel.LogIteratorReaderOptions{PrintTime: true})
+	expected, err := ioutil.ReadAll(r)
+	s.Require().NoError(err)
+
+	resp := rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusOK, resp.Status())
+	s.Nil(resp.Pages())
+	data, ok := resp.Data().(io.Reader)
+	s.Require().True(ok)
+	actual, err := ioutil.ReadAll(data)
+	s.Require().NoError(err)
+	s.Equal(expected, actual)
+}
+
+func (s *LogHandlerSuite) TestLogGetByIDHandlerFollowNotFound() {
+	rh := s.rh["id"].Factory()
+	rh.(*logGetByIDHandler).opts.ID = "DNE"
+	rh.(*logGetByIDHandler).follow = true
+
+	resp := rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusNotFound, resp.Status())
+}
+
+func (s *LogHandlerSuite) TestLogGetByIDHandlerLines() {
+	chunks := s.sc.CachedLogs["abc"].Artifact.Chunks
+	rh := s.rh["id"].Factory()
+	rh.(*logGetByIDHandler).opts.ID = "abc"
+	rh.(*logGetByIDHandler).opts.FromLine = 5
+	rh.(*logGetByIDHandler).opts.Limit = 10
+	rh.(*logGetByIDHandler).lines = true
+	r := dbModel.NewLogIteratorReader(
+		context.TODO(),
+		dbModel.NewLineOffsetLogIterator(s.buckets["abc"], chunks, 5),
+		dbModel.LogIteratorReaderOptions{Limit: 10},
+	)
+	expected, err := ioutil.ReadAll(r)
+	s.Require().NoError(err)
+
+	resp := rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusOK, resp.Status())
+	s.Equal(expected, resp.Data())
+	pages := resp.Pages()
+	s.Require().NotNil(pages)
+	s.Equal("5", pages.Prev.Key)
+	s.Equal(fromLine, pages.Prev.KeyQueryParam)
+	s.Require().NotNil(pages.Next)
+	s.Equal("15", pages.Next.Key)
+	s.Equal(10, pages.Next.Limit)
+
+	// last page
+	var numLines int
+	for _, chunk := range chunks {
+		numLines += chunk.NumLines
+	}
+	rh.(*logGetByIDHandler).opts.FromLine = numLines - 5
+	resp = rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusOK, resp.Status())
+	pages = resp.Pages()
+	s.Require().NotNil(pages)
+	s.Nil(pages.Next)
+
+	// not found
+	rh.(*logGetByIDHandler).opts.ID = "DNE"
+	resp = rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusNotFound, resp.Status())
+}
+
 func (s *LogHandlerSuite) TestLogMetaGetByIDHandlerFound() {
 	rh := s.rh["meta_id"].Factory()
 	rh.(*logMetaGetByIDHandler).id = "abc"
@@ -481,6 +618,53 @@ func (s *LogHandlerSuite) TestLogGetByTaskIDHandlerFound() {
 	}
 }
 
+func (s *LogHandlerSuite) TestLogGetByTaskIDHandlerPrintSource() {
+	for _, ndjson := range []bool{true, false} {
+		rh := s.rh["task_id"].Factory()
+		rh.(*logGetByTaskIDHandler).opts.TaskID = "task_id1"
+		rh.(*logGetByTaskIDHandler).opts.TimeRange = dbModel.TimeRange{
+			StartAt: time.Now().Add(-24 * time.Hour),
+			EndAt:   time.Now(),
+		}
+		rh.(*logGetByTaskIDHandler).opts.PrintSource = true
+		rh.(*logGetByTaskIDHandler).opts.NDJSON = ndjson
+
+		var its []dbModel.LogIterator
+		for _, id := range []string{"def", "jkl", "mno", "pqr"} {
+			log := s.sc.CachedLogs[id]
+			its = append(its, dbModel.NewSourceLogIterator(
+				dbModel.NewBatchedLogIterator(
+					s.buckets[id],
+					log.Artifact.Chunks,
+					batchSize,
+					rh.(*logGetByTaskIDHandler).opts.TimeRange,
+				),
+				log.Source(),
+			))
+		}
+		r := dbModel.NewLogIteratorReader(context.TODO(), dbModel.NewMergingIterator(its...), dbModel.LogIteratorReaderOptions{
+			PrintSource: true,
+			NDJSON:      ndjson,
+		})
+		expected, err := ioutil.ReadAll(r)
+		s.Require().NoError(err)
+		s.Require().NotEmpty(expected)
+
+		resp := rh.Run(context.TODO())
+		s.Require().NotNil(resp)
+		s.Equal(http.StatusOK, resp.Status())
+		s.EqualValues(expected, resp.Data())
+		if ndjson {
+			s.Contains(string(expected), `{"log_id":"jkl","proc_name":"mongod1",`)
+			s.Contains(string(expected), `{"log_id":"def","ts":`)
+		} else {
+			s.Contains(string(expected), "[mongod1/test1] ")
+			s.Contains(string(expected), "[test2] ")
+			s.Contains(string(expected), "[sys] ")
+		}
+	}
+}
+
 func (s *LogHandlerSuite) TestLogGetByTaskIDHandlerNotFound() {
 	rh := s.rh["task_id"].Factory()
 	rh.(*logGetByTaskIDHandler).opts.TaskID = "DNE"
@@ -552,6 +736,439 @@ func (s *LogHandlerSuite) TestLogMetaGetByTaskIDHandlerCtxErr() {
 	s.NotEqual(http.StatusOK, resp.Status())
 }
 
+func (s *LogHandlerSuite) TestLogProcessesGetByTaskIDHandlerFound() {
+	getProcesses := func(ids ...string) []model.APILogProcess {
+		processes := make([]model.APILogProcess, len(ids))
+		for i, id := range ids {
+			s.Require().NoError(processes[i].Import(s.sc.CachedLogs[id]))
+		}
+		return processes
+	}
+
+	rh := s.rh["proc_task_id"].Factory()
+	rh.(*logProcessesGetByTaskIDHandler).opts.TaskID = "task_id1"
+	resp := rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusOK, resp.Status())
+	s.Equal(getProcesses("pqr", "mno", "jkl", "def"), resp.Data())
+
+	// with process name
+	rh.(*logProcessesGetByTaskIDHandler).opts.ProcessName = "sys"
+	resp = rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusOK, resp.Status())
+	s.Equal(getProcesses("pqr", "mno"), resp.Data())
+
+	// with tags
+	rh.(*logProcessesGetByTaskIDHandler).opts.ProcessName = ""
+	rh.(*logProcessesGetByTaskIDHandler).opts.Tags = []string{"tag1"}
+	resp = rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusOK, resp.Status())
+	s.Equal(getProcesses("mno", "jkl"), resp.Data())
+
+	// with latest execution
+	rh = s.rh["proc_task_id"].Factory()
+	rh.(*logProcessesGetByTaskIDHandler).opts.TaskID = "task_id1"
+	rh.(*logProcessesGetByTaskIDHandler).opts.EmptyExecution = true
+	resp = rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusOK, resp.Status())
+	s.Equal(getProcesses("abc"), resp.Data())
+}
+
+func (s *LogHandlerSuite) TestLogProcessesGetByTaskIDHandlerNotFound() {
+	rh := s.rh["proc_task_id"].Factory()
+	rh.(*logProcessesGetByTaskIDHandler).opts.TaskID = "DNE"
+
+	resp := rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusNotFound, resp.Status())
+}
+
+func (s *LogHandlerSuite) TestLogProcessesGetByTaskIDHandlerCtxErr() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rh := s.rh["proc_task_id"].Factory()
+	rh.(*logProcessesGetByTaskIDHandler).opts.TaskID = "task_id1"
+
+	resp := rh.Run(ctx)
+	s.Require().NotNil(resp)
+	s.NotEqual(http.StatusOK, resp.Status())
+}
+
+func (s *LogHandlerSuite) TestParseProcesses() {
+	ctx := context.Background()
+	urlString := "http://cedar.mongodb.com/buildlogger/task_id/task_id1/processes"
+
+	req := &http.Request{Method: "GET"}
+	req.URL, _ = url.Parse(urlString + "?proc_name=mongod&tags=tag1&execution=2")
+	rh := s.rh["proc_task_id"].Factory()
+	s.Require().NoError(rh.Parse(ctx, req))
+	opts := rh.(*logProcessesGetByTaskIDHandler).opts
+	s.Equal("mongod", opts.ProcessName)
+	s.Equal([]string{"tag1"}, opts.Tags)
+	s.Equal(2, opts.Execution)
+	s.False(opts.EmptyExecution)
+
+	req.URL, _ = url.Parse(urlString)
+	rh = s.rh["proc_task_id"].Factory()
+	s.Require().NoError(rh.Parse(ctx, req))
+	s.True(rh.(*logProcessesGetByTaskIDHandler).opts.EmptyExecution)
+
+	req.URL, _ = url.Parse(urlString + "?execution=first")
+	rh = s.rh["proc_task_id"].Factory()
+	s.Error(rh.Parse(ctx, req))
+}
+
+func (s *LogHandlerSuite) TestLogArchiveGetByTaskIDHandlerFound() {
+	expectedNames := map[string]string{
+		"test2.0.log":         "def",
+		"test1-mongod1.0.log": "jkl",
+		"sys.0.log":           "mno",
+		"sys.0.pqr.log":       "pqr",
+	}
+	for _, compression := range []dbModel.FileCompression{dbModel.FileTarGz, dbModel.FileZip} {
+		s.Run(string(compression), func() {
+			rh := s.rh["archive_task_id"].Factory()
+			rh.(*logArchiveGetByTaskIDHandler).opts.TaskID = "task_id1"
+			rh.(*logArchiveGetByTaskIDHandler).compression = compression
+
+			resp := rh.Run(context.TODO())
+			s.Require().NotNil(resp)
+			s.Require().Equal(http.StatusOK, resp.Status())
+			r, ok := resp.Data().(io.Reader)
+			s.Require().True(ok)
+			data, err := ioutil.ReadAll(r)
+			s.Require().NoError(err)
+
+			files := s.readArchive(compression, data)
+			s.Require().Len(files, len(expectedNames)+1)
+			var manifest []model.APILog
+			s.Require().NoError(json.Unmarshal(files["manifest.json"], &manifest))
+			s.Len(manifest, len(expectedNames))
+			for name, id := range expectedNames {
+				s.Equal(s.readLog(id), string(files[name]), name)
+			}
+		})
+	}
+}
+
+func (s *LogHandlerSuite) TestLogArchiveGetByTaskIDHandlerNotFound() {
+	rh := s.rh["archive_task_id"].Factory()
+	rh.(*logArchiveGetByTaskIDHandler).opts.TaskID = "DNE"
+	rh.(*logArchiveGetByTaskIDHandler).compression = dbModel.FileTarGz
+
+	resp := rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusNotFound, resp.Status())
+}
+
+func (s *LogHandlerSuite) TestLogArchiveGetByTaskIDHandlerCtxErr() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rh := s.rh["archive_task_id"].Factory()
+	rh.(*logArchiveGetByTaskIDHandler).opts.TaskID = "task_id1"
+	rh.(*logArchiveGetByTaskIDHandler).compression = dbModel.FileTarGz
+
+	resp := rh.Run(ctx)
+	s.Require().NotNil(resp)
+	s.NotEqual(http.StatusOK, resp.Status())
+}
+
+func (s *LogHandlerSuite) readArchive(compression dbModel.FileCompression, data []byte) map[string][]byte {
+	files := map[string][]byte{}
+	switch compression {
+	case dbModel.FileTarGz:
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		s.Require().NoError(err)
+		tr := tar.NewReader(gz)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			s.Require().NoError(err)
+			files[header.Name], err = ioutil.ReadAll(tr)
+			s.Require().NoError(err)
+		}
+	case dbModel.FileZip:
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		s.Require().NoError(err)
+		for _, file := range zr.File {
+			r, err := file.Open()
+			s.Require().NoError(err)
+			files[file.Name], err = ioutil.ReadAll(r)
+			s.Require().NoError(err)
+			s.Require().NoError(r.Close())
+		}
+	}
+
+	return files
+}
+
+func (s *LogHandlerSuite) readLog(id string) string {
+	it := dbModel.NewBatchedLogIterator(
+		s.buckets[id],
+		s.sc.CachedLogs[id].Artifact.Chunks,
+		batchSize,
+		dbModel.TimeRange{EndAt: utility.MaxTime},
+	)
+	data, err := ioutil.ReadAll(dbModel.NewLogIteratorReader(context.TODO(), it, dbModel.LogIteratorReaderOptions{}))
+	s.Require().NoError(err)
+
+	return string(data)
+}
+
+func (s *LogHandlerSuite) TestLogSearchByTaskIDHandlerFound() {
+	it := dbModel.NewBatchedLogIterator(
+		s.buckets["ghi"],
+		s.sc.CachedLogs["ghi"].Artifact.Chunks,
+		batchSize,
+		dbModel.TimeRange{EndAt: time.Now().Add(24 * time.Hour)},
+	)
+	s.Require().True(it.Next(context.TODO()))
+	line := it.Item()
+	s.Require().NoError(it.Close())
+
+	rh := s.rh["search_task_id"].Factory()
+	rh.(*logSearchByTaskIDHandler).opts.TaskID = "task_id2"
+	rh.(*logSearchByTaskIDHandler).opts.TimeRange = dbModel.TimeRange{EndAt: time.Now().Add(24 * time.Hour)}
+	rh.(*logSearchByTaskIDHandler).searchOpts = dbModel.LogSearchOptions{
+		Query:        line.Data[10:40],
+		ContextLines: 1,
+		MaxMatches:   10,
+	}
+
+	resp := rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusOK, resp.Status())
+	results, ok := resp.Data().(*model.APILogSearchResults)
+	s.Require().True(ok)
+	s.False(results.Truncated)
+	s.Require().Len(results.Matches, 1)
+	s.Equal("ghi", *results.Matches[0].LogID)
+	s.Equal(line.Data, *results.Matches[0].Line.Data)
+	s.Equal(model.NewTime(line.Timestamp), results.Matches[0].Line.Timestamp)
+	s.Empty(results.Matches[0].Before)
+	s.Len(results.Matches[0].After, 1)
+}
+
+func (s *LogHandlerSuite) TestLogSearchByTaskIDHandlerNotFound() {
+	rh := s.rh["search_task_id"].Factory()
+	rh.(*logSearchByTaskIDHandler).opts.TaskID = "DNE"
+	rh.(*logSearchByTaskIDHandler).searchOpts = dbModel.LogSearchOptions{Query: "failed", MaxMatches: 10}
+
+	resp := rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusNotFound, resp.Status())
+}
+
+func (s *LogHandlerSuite) TestLogSearchByTaskIDHandlerCtxErr() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rh := s.rh["search_task_id"].Factory()
+	rh.(*logSearchByTaskIDHandler).opts.TaskID = "task_id1"
+	rh.(*logSearchByTaskIDHandler).opts.EmptyExecution = true
+	rh.(*logSearchByTaskIDHandler).opts.TimeRange = dbModel.TimeRange{EndAt: time.Now()}
+	rh.(*logSearchByTaskIDHandler).searchOpts = dbModel.LogSearchOptions{Query: "failed", MaxMatches: 10}
+
+	resp := rh.Run(ctx)
+	s.Require().NotNil(resp)
+	s.NotEqual(http.StatusOK, resp.Status())
+}
+
+func (s *LogHandlerSuite) TestLogDiffByTaskIDHandlerFound() {
+	s.Run("SameExecution", func() {
+		rh := s.rh["diff_task_id"].Factory()
+		rh.(*logDiffByTaskIDHandler).opts.TaskID = "task_id1"
+
+		resp := rh.Run(context.TODO())
+		s.Require().NotNil(resp)
+		s.Require().Equal(http.StatusOK, resp.Status())
+		r, ok := resp.Data().(io.Reader)
+		s.Require().True(ok)
+		data, err := ioutil.ReadAll(r)
+		s.Require().NoError(err)
+		s.Empty(data)
+	})
+	s.Run("DifferentExecutions", func() {
+		rh := s.rh["diff_task_id"].Factory()
+		rh.(*logDiffByTaskIDHandler).opts.TaskID = "task_id1"
+		rh.(*logDiffByTaskIDHandler).opts.TestName = "test1"
+		rh.(*logDiffByTaskIDHandler).baseExecution = 1
+		rh.(*logDiffByTaskIDHandler).diffOpts = dbModel.LogDiffOptions{ContextLines: 2}
+
+		resp := rh.Run(context.TODO())
+		s.Require().NotNil(resp)
+		s.Require().Equal(http.StatusOK, resp.Status())
+		r, ok := resp.Data().(io.Reader)
+		s.Require().True(ok)
+		data, err := ioutil.ReadAll(r)
+		s.Require().NoError(err)
+
+		timeRange := dbModel.TimeRange{EndAt: utility.MaxTime}
+		expected, err := dbModel.NewLogDiffReader(
+			context.TODO(),
+			dbModel.NewBatchedLogIterator(s.buckets["abc"], s.sc.CachedLogs["abc"].Artifact.Chunks, batchSize, timeRange),
+			dbModel.NewBatchedLogIterator(s.buckets["jkl"], s.sc.CachedLogs["jkl"].Artifact.Chunks, batchSize, timeRange),
+			dbModel.LogDiffOptions{ContextLines: 2},
+		)
+		s.Require().NoError(err)
+		expectedData, err := ioutil.ReadAll(expected)
+		s.Require().NoError(err)
+		s.Require().NotEmpty(expectedData)
+		s.Equal(string(expectedData), string(data))
+	})
+}
+
+func (s *LogHandlerSuite) TestLogDiffByTaskIDHandlerNotFound() {
+	rh := s.rh["diff_task_id"].Factory()
+	rh.(*logDiffByTaskIDHandler).opts.TaskID = "DNE"
+	resp := rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusNotFound, resp.Status())
+
+	rh = s.rh["diff_task_id"].Factory()
+	rh.(*logDiffByTaskIDHandler).opts.TaskID = "task_id1"
+	rh.(*logDiffByTaskIDHandler).baseExecution = 5
+	resp = rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusNotFound, resp.Status())
+}
+
+func (s *LogHandlerSuite) TestLogDiffByTaskIDHandlerInvalidOptions() {
+	rh := s.rh["diff_task_id"].Factory()
+	rh.(*logDiffByTaskIDHandler).opts.TaskID = "task_id1"
+	rh.(*logDiffByTaskIDHandler).diffOpts = dbModel.LogDiffOptions{Normalizers: []string{"("}}
+
+	resp := rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusBadRequest, resp.Status())
+}
+
+func (s *LogHandlerSuite) TestLogDiffByTaskIDHandlerCtxErr() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rh := s.rh["diff_task_id"].Factory()
+	rh.(*logDiffByTaskIDHandler).opts.TaskID = "task_id1"
+	rh.(*logDiffByTaskIDHandler).opts.EmptyExecution = true
+
+	resp := rh.Run(ctx)
+	s.Require().NotNil(resp)
+	s.NotEqual(http.StatusOK, resp.Status())
+}
+
+func (s *LogHandlerSuite) TestLogFailuresGetByTaskIDHandlerFound() {
+	rh := s.rh["failures_task_id"].Factory()
+	rh.(*logFailuresGetByTaskIDHandler).opts.TaskID = "task_id1"
+	resp := rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusOK, resp.Status())
+	expected := model.APILogFailures{}
+	s.Require().NoError(expected.Import(s.sc.CachedLogs["jkl"]))
+	s.Equal([]model.APILogFailures{expected}, resp.Data())
+
+	// without failure signatures
+	rh.(*logFailuresGetByTaskIDHandler).opts.ProcessName = "sys"
+	resp = rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusOK, resp.Status())
+	s.Equal([]model.APILogFailures{}, resp.Data())
+}
+
+func (s *LogHandlerSuite) TestLogFailuresGetByTaskIDHandlerNotFound() {
+	rh := s.rh["failures_task_id"].Factory()
+	rh.(*logFailuresGetByTaskIDHandler).opts.TaskID = "DNE"
+
+	resp := rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusNotFound, resp.Status())
+}
+
+func (s *LogHandlerSuite) TestLogFailuresGetByTaskIDHandlerCtxErr() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rh := s.rh["failures_task_id"].Factory()
+	rh.(*logFailuresGetByTaskIDHandler).opts.TaskID = "task_id1"
+
+	resp := rh.Run(ctx)
+	s.Require().NotNil(resp)
+	s.NotEqual(http.StatusOK, resp.Status())
+}
+
+func (s *LogHandlerSuite) TestLogsGetByFailureSignatureHandlerFound() {
+	getMatches := func(ids ...string) []model.APILogFailureMatch {
+		matches := make([]model.APILogFailureMatch, len(ids))
+		for i, id := range ids {
+			s.Require().NoError(matches[i].Import(s.sc.CachedLogs[id]))
+		}
+		return matches
+	}
+
+	rh := s.rh["failure_signature"].Factory()
+	rh.(*logsGetByFailureSignatureHandler).opts = dbModel.LogFailureSignatureFindOptions{
+		Project:     "project",
+		SignatureID: "sig1",
+		Since:       time.Now().Add(-7 * 24 * time.Hour),
+	}
+	resp := rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusOK, resp.Status())
+	s.Equal(getMatches("ghi", "jkl"), resp.Data())
+
+	// excluding a task
+	rh.(*logsGetByFailureSignatureHandler).opts.ExcludeTaskID = "task_id2"
+	resp = rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusOK, resp.Status())
+	s.Equal(getMatches("jkl"), resp.Data())
+
+	// with limit
+	rh.(*logsGetByFailureSignatureHandler).opts.ExcludeTaskID = ""
+	rh.(*logsGetByFailureSignatureHandler).opts.Limit = 1
+	resp = rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusOK, resp.Status())
+	s.Equal(getMatches("ghi"), resp.Data())
+
+	// with since
+	rh.(*logsGetByFailureSignatureHandler).opts.Limit = 0
+	rh.(*logsGetByFailureSignatureHandler).opts.Since = time.Now().Add(-3 * time.Hour)
+	resp = rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusOK, resp.Status())
+	s.Equal(getMatches("ghi"), resp.Data())
+
+	// with other project
+	rh.(*logsGetByFailureSignatureHandler).opts.Project = "DNE"
+	resp = rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusOK, resp.Status())
+	s.Equal(getMatches(), resp.Data())
+}
+
+func (s *LogHandlerSuite) TestLogsGetByFailureSignatureHandlerInvalidOptions() {
+	rh := s.rh["failure_signature"].Factory()
+	rh.(*logsGetByFailureSignatureHandler).opts.SignatureID = "sig1"
+
+	resp := rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusBadRequest, resp.Status())
+}
+
+func (s *LogHandlerSuite) TestLogsGetByFailureSignatureHandlerCtxErr() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rh := s.rh["failure_signature"].Factory()
+	rh.(*logsGetByFailureSignatureHandler).opts.Project = "project"
+	rh.(*logsGetByFailureSignatureHandler).opts.SignatureID = "sig1"
+
+	resp := rh.Run(ctx)
+	s.Require().NotNil(resp)
+	s.NotEqual(http.StatusOK, resp.Status())
+}
+
 func (s *LogHandlerSuite) TestLogGroupByTaskIDHandlerFound() {
 	for _, printTime := range []bool{true, false} {
 		opts := dbModel.LogIteratorReaderOptions{
@@ -1079,6 +1696,60 @@ func (s *LogHandlerSuite) TestLogGroupByTestNameHandlerCtxErr() {
 	s.NotEqual(http.StatusOK, resp.Status())
 }
 
+func (s *LogHandlerSuite) TestLogUsageGetHandlerFound() {
+	rh := s.rh["usage"].Factory()
+	rh.(*logUsageGetHandler).date = time.Date(2021, time.January, 2, 12, 0, 0, 0, time.UTC)
+
+	resp := rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusOK, resp.Status())
+	usage, ok := resp.Data().([]model.APILogUsage)
+	s.Require().True(ok)
+	s.Require().Len(usage, 2)
+	for i, expected := range []dbModel.LogUsage{s.sc.CachedLogUsage[1], s.sc.CachedLogUsage[0]} {
+		apiUsage := model.APILogUsage{}
+		s.Require().NoError(apiUsage.Import(expected))
+		s.Equal(apiUsage, usage[i])
+	}
+
+	rh.(*logUsageGetHandler).date = time.Date(2021, time.January, 4, 0, 0, 0, 0, time.UTC)
+	resp = rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusOK, resp.Status())
+	s.Empty(resp.Data())
+}
+
+func (s *LogHandlerSuite) TestLogUsageGetHandlerCtxErr() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rh := s.rh["usage"].Factory()
+	rh.(*logUsageGetHandler).date = time.Date(2021, time.January, 2, 0, 0, 0, 0, time.UTC)
+
+	resp := rh.Run(ctx)
+	s.Require().NotNil(resp)
+	s.NotEqual(http.StatusOK, resp.Status())
+}
+
+func (s *LogHandlerSuite) TestParseUsage() {
+	ctx := context.Background()
+	urlString := "http://cedar.mongodb.com/admin/buildlogger/usage"
+
+	req := &http.Request{Method: "GET"}
+	req.URL, _ = url.Parse(urlString + "?date=2021-01-02")
+	rh := s.rh["usage"].Factory()
+	s.Require().NoError(rh.Parse(ctx, req))
+	s.Equal(time.Date(2021, time.January, 2, 0, 0, 0, 0, time.UTC), rh.(*logUsageGetHandler).date)
+
+	req.URL, _ = url.Parse(urlString)
+	rh = s.rh["usage"].Factory()
+	s.Require().NoError(rh.Parse(ctx, req))
+	s.WithinDuration(time.Now(), rh.(*logUsageGetHandler).date, time.Minute)
+
+	req.URL, _ = url.Parse(urlString + "?date=yesterday")
+	rh = s.rh["usage"].Factory()
+	s.Error(rh.Parse(ctx, req))
+}
+
 func (s *LogHandlerSuite) TestParse() {
 	for _, test := range []struct {
 		urlString string
@@ -1116,6 +1787,289 @@ func (s *LogHandlerSuite) TestParse() {
 	}
 }
 
+func (s *LogHandlerSuite) TestParseFollow() {
+	ctx := context.Background()
+	urlString := "http://cedar.mongodb.com/buildlogger/id1"
+
+	req := &http.Request{Method: "GET"}
+	req.URL, _ = url.Parse(urlString + "?follow=true&print_time=true")
+	rh := s.rh["id"].Factory()
+	s.Require().NoError(rh.Parse(ctx, req))
+	s.True(rh.(*logGetByIDHandler).follow)
+	s.True(rh.(*logGetByIDHandler).opts.PrintTime)
+
+	for _, query := range []string{"?follow=true&limit=10", "?follow=true&paginate=true"} {
+		req.URL, _ = url.Parse(urlString + query)
+		rh = s.rh["id"].Factory()
+		s.Error(rh.Parse(ctx, req))
+	}
+}
+
+func (s *LogHandlerSuite) TestParseFromLine() {
+	ctx := context.Background()
+	urlString := "http://cedar.mongodb.com/buildlogger/id1"
+
+	req := &http.Request{Method: "GET"}
+	req.URL, _ = url.Parse(urlString + "?from_line=45213&limit=100")
+	rh := s.rh["id"].Factory()
+	s.Require().NoError(rh.Parse(ctx, req))
+	s.True(rh.(*logGetByIDHandler).lines)
+	s.Equal(45213, rh.(*logGetByIDHandler).opts.FromLine)
+	s.Equal(100, rh.(*logGetByIDHandler).opts.Limit)
+
+	for _, query := range []string{
+		"?from_line=-1",
+		"?from_line=one",
+		"?from_line=10&follow=true",
+		"?from_line=10&start=2020-01-01T00:00:00Z",
+	} {
+		req.URL, _ = url.Parse(urlString + query)
+		rh = s.rh["id"].Factory()
+		s.Error(rh.Parse(ctx, req), query)
+	}
+}
+
+func (s *LogHandlerSuite) TestParseArchive() {
+	ctx := context.Background()
+	urlString := "http://cedar.mongodb.com/buildlogger/task_id/task_id1/archive"
+
+	for query, expected := range map[string]dbModel.FileCompression{
+		"":               dbModel.FileTarGz,
+		"?format=tar.gz": dbModel.FileTarGz,
+		"?format=targz":  dbModel.FileTarGz,
+		"?format=zip":    dbModel.FileZip,
+	} {
+		req := &http.Request{Method: "GET"}
+		req.URL, _ = url.Parse(urlString + query)
+		rh := s.rh["archive_task_id"].Factory()
+		s.Require().NoError(rh.Parse(ctx, req), query)
+		s.Equal(expected, rh.(*logArchiveGetByTaskIDHandler).compression, query)
+		s.True(rh.(*logArchiveGetByTaskIDHandler).opts.EmptyExecution)
+	}
+
+	req := &http.Request{Method: "GET"}
+	req.URL, _ = url.Parse(urlString + "?format=xz")
+	rh := s.rh["archive_task_id"].Factory()
+	s.Error(rh.Parse(ctx, req))
+}
+
+func (s *LogHandlerSuite) TestParseSearch() {
+	ctx := context.Background()
+	urlString := "http://cedar.mongodb.com/buildlogger/task_id/task_id1/search"
+
+	req := &http.Request{Method: "GET"}
+	req.URL, _ = url.Parse(urlString + "?q=assert.*failed&regex=true&context=3&limit=20&proc_name=mongod&execution=2")
+	rh := s.rh["search_task_id"].Factory()
+	s.Require().NoError(rh.Parse(ctx, req))
+	s.Equal(dbModel.LogSearchOptions{
+		Query:        "assert.*failed",
+		Regex:        true,
+		ContextLines: 3,
+		MaxMatches:   20,
+	}, rh.(*logSearchByTaskIDHandler).searchOpts)
+	s.Equal("mongod", rh.(*logSearchByTaskIDHandler).opts.ProcessName)
+	s.Equal(2, rh.(*logSearchByTaskIDHandler).opts.Execution)
+	s.False(rh.(*logSearchByTaskIDHandler).opts.EmptyExecution)
+
+	req.URL, _ = url.Parse(urlString + "?q=failed")
+	rh = s.rh["search_task_id"].Factory()
+	s.Require().NoError(rh.Parse(ctx, req))
+	s.Equal(dbModel.LogSearchOptions{Query: "failed", MaxMatches: defaultSearchLimit}, rh.(*logSearchByTaskIDHandler).searchOpts)
+	s.True(rh.(*logSearchByTaskIDHandler).opts.EmptyExecution)
+
+	for _, query := range []string{
+		"",
+		"?q=assert(&regex=true",
+		"?q=failed&context=-1",
+		"?q=failed&limit=0",
+		"?q=failed&limit=hello",
+		"?q=failed&start=hello",
+	} {
+		req.URL, _ = url.Parse(urlString + query)
+		rh = s.rh["search_task_id"].Factory()
+		s.Error(rh.Parse(ctx, req), query)
+	}
+}
+
+func (s *LogHandlerSuite) TestParseDiff() {
+	ctx := context.Background()
+	urlString := "http://cedar.mongodb.com/buildlogger/task_id/task_id1/diff"
+
+	req := &http.Request{Method: "GET"}
+	req.URL, _ = url.Parse(urlString + `?base=0&execution=1&test_name=test1&proc_name=mongod&tags=a&normalize=pid\s\d%2B&normalize=port&context=3&window=10`)
+	rh := s.rh["diff_task_id"].Factory()
+	s.Require().NoError(rh.Parse(ctx, req))
+	s.Equal(dbModel.LogDiffOptions{
+		Normalizers:  []string{`pid\s\d+`, "port"},
+		ContextLines: 3,
+		Window:       10,
+	}, rh.(*logDiffByTaskIDHandler).diffOpts)
+	s.Equal(0, rh.(*logDiffByTaskIDHandler).baseExecution)
+	s.Equal(1, rh.(*logDiffByTaskIDHandler).opts.Execution)
+	s.False(rh.(*logDiffByTaskIDHandler).opts.EmptyExecution)
+	s.Equal("test1", rh.(*logDiffByTaskIDHandler).opts.TestName)
+	s.Equal("mongod", rh.(*logDiffByTaskIDHandler).opts.ProcessName)
+	s.Equal([]string{"a"}, rh.(*logDiffByTaskIDHandler).opts.Tags)
+
+	req.URL, _ = url.Parse(urlString + "?base=2")
+	rh = s.rh["diff_task_id"].Factory()
+	s.Require().NoError(rh.Parse(ctx, req))
+	s.Equal(dbModel.LogDiffOptions{}, rh.(*logDiffByTaskIDHandler).diffOpts)
+	s.Equal(2, rh.(*logDiffByTaskIDHandler).baseExecution)
+	s.True(rh.(*logDiffByTaskIDHandler).opts.EmptyExecution)
+
+	for _, query := range []string{
+		"",
+		"?base=hello",
+		"?base=0&execution=hello",
+		"?base=0&normalize=(",
+		"?base=0&context=-1",
+		"?base=0&window=hello",
+	} {
+		req.URL, _ = url.Parse(urlString + query)
+		rh = s.rh["diff_task_id"].Factory()
+		s.Error(rh.Parse(ctx, req), query)
+	}
+}
+
+func (s *LogHandlerSuite) TestParseFailureSignatures() {
+	ctx := context.Background()
+	req := &http.Request{Method: "GET"}
+	req.URL, _ = url.Parse("http://cedar.mongodb.com/buildlogger/task_id/task_id1/failure_signatures?execution=1&proc_name=mongod&tags=a")
+	rh := s.rh["failures_task_id"].Factory()
+	s.Require().NoError(rh.Parse(ctx, req))
+	s.Equal(1, rh.(*logFailuresGetByTaskIDHandler).opts.Execution)
+	s.False(rh.(*logFailuresGetByTaskIDHandler).opts.EmptyExecution)
+	s.Equal("mongod", rh.(*logFailuresGetByTaskIDHandler).opts.ProcessName)
+	s.Equal([]string{"a"}, rh.(*logFailuresGetByTaskIDHandler).opts.Tags)
+
+	req.URL, _ = url.Parse("http://cedar.mongodb.com/buildlogger/task_id/task_id1/failure_signatures?execution=hello")
+	rh = s.rh["failures_task_id"].Factory()
+	s.Error(rh.Parse(ctx, req))
+
+	urlString := "http://cedar.mongodb.com/buildlogger/failure_signatures/sig1"
+	req = gimlet.SetURLVars(req, map[string]string{"signature_id": "sig1"})
+	req.URL, _ = url.Parse(urlString + "?project=project&days=2&exclude_task_id=task_id1&limit=10")
+	rh = s.rh["failure_signature"].Factory()
+	s.Require().NoError(rh.Parse(ctx, req))
+	opts := rh.(*logsGetByFailureSignatureHandler).opts
+	s.Equal("sig1", opts.SignatureID)
+	s.Equal("project", opts.Project)
+	s.Equal("task_id1", opts.ExcludeTaskID)
+	s.EqualValues(10, opts.Limit)
+	s.WithinDuration(time.Now().Add(-48*time.Hour), opts.Since, time.Minute)
+
+	req.URL, _ = url.Parse(urlString + "?project=project")
+	rh = s.rh["failure_signature"].Factory()
+	s.Require().NoError(rh.Parse(ctx, req))
+	opts = rh.(*logsGetByFailureSignatureHandler).opts
+	s.EqualValues(defaultFailureSignatureLimit, opts.Limit)
+	s.WithinDuration(time.Now().Add(-defaultFailureSignatureDays*24*time.Hour), opts.Since, time.Minute)
+
+	for _, query := range []string{
+		"",
+		"?project=project&days=hello",
+		"?project=project&days=0",
+		"?project=project&limit=hello",
+		"?project=project&limit=-1",
+	} {
+		req.URL, _ = url.Parse(urlString + query)
+		rh = s.rh["failure_signature"].Factory()
+		s.Error(rh.Parse(ctx, req), query)
+	}
+}
+
+func (s *LogHandlerSuite) TestParsePrintSource() {
+	ctx := context.Background()
+	for handler, urlString := range map[string]string{
+		"task_id":         "http://cedar.mongodb.com/buildlogger/task_id/task_id1",
+		"group_task_id":   "http://cedar.mongodb.com/buildlogger/task_id/task_id1/group/group0",
+		"test_name":       "http://cedar.mongodb.com/buildlogger/test_name/task_id1/test0",
+		"group_test_name": "http://cedar.mongodb.com/buildlogger/test_name/task_id1/test0/group/group0",
+	} {
+		req := &http.Request{Method: "GET"}
+		req.URL, _ = url.Parse(urlString + "?print_source=true")
+		rh := s.rh[handler].Factory()
+		s.Require().NoError(rh.Parse(ctx, req), handler)
+		s.True(getLogPrintSource(rh, handler), handler)
+
+		req.URL, _ = url.Parse(urlString)
+		rh = s.rh[handler].Factory()
+		s.Require().NoError(rh.Parse(ctx, req), handler)
+		s.False(getLogPrintSource(rh, handler), handler)
+	}
+}
+
+func (s *LogHandlerSuite) TestParseStructured() {
+	ctx := context.Background()
+	for handler, urlString := range map[string]string{
+		"id":              "http://cedar.mongodb.com/buildlogger/id1",
+		"task_id":         "http://cedar.mongodb.com/buildlogger/task_id/task_id1",
+		"group_task_id":   "http://cedar.mongodb.com/buildlogger/task_id/task_id1/group/group0",
+		"test_name":       "http://cedar.mongodb.com/buildlogger/test_name/task_id1/test0",
+		"group_test_name": "http://cedar.mongodb.com/buildlogger/test_name/task_id1/test0/group/group0",
+	} {
+		req := &http.Request{Method: "GET"}
+		req.URL, _ = url.Parse(urlString + "?ndjson=true&filter=level%3Derror&filter=attr.component%3Dreplication")
+		rh := s.rh[handler].Factory()
+		s.Require().NoError(rh.Parse(ctx, req), handler)
+		s.True(getLogNDJSON(rh, handler), handler)
+		s.Equal([]dbModel.LogFieldFilter{
+			{Key: "level", Value: "error"},
+			{Key: "attr.component", Value: "replication"},
+		}, getLogFieldFilters(rh, handler), handler)
+
+		req.URL, _ = url.Parse(urlString)
+		rh = s.rh[handler].Factory()
+		s.Require().NoError(rh.Parse(ctx, req), handler)
+		s.False(getLogNDJSON(rh, handler), handler)
+		s.Empty(getLogFieldFilters(rh, handler), handler)
+
+		req.URL, _ = url.Parse(urlString + "?filter=level")
+		rh = s.rh[handler].Factory()
+		s.Error(rh.Parse(ctx, req), handler)
+	}
+}
+
+func (s *LogHandlerSuite) TestParsePriority() {
+	ctx := context.Background()
+	for handler, urlString := range map[string]string{
+		"id":              "http://cedar.mongodb.com/buildlogger/id1",
+		"task_id":         "http://cedar.mongodb.com/buildlogger/task_id/task_id1",
+		"search_task_id":  "http://cedar.mongodb.com/buildlogger/task_id/task_id1/search",
+		"group_task_id":   "http://cedar.mongodb.com/buildlogger/task_id/task_id1/group/group0",
+		"test_name":       "http://cedar.mongodb.com/buildlogger/test_name/task_id1/test0",
+		"group_test_name": "http://cedar.mongodb.com/buildlogger/test_name/task_id1/test0/group/group0",
+	} {
+		if handler == "search_task_id" {
+			urlString += "?q=failed&"
+		} else {
+			urlString += "?"
+		}
+
+		req := &http.Request{Method: "GET"}
+		req.URL, _ = url.Parse(urlString + "min_priority=warning&max_priority=80")
+		rh := s.rh[handler].Factory()
+		s.Require().NoError(rh.Parse(ctx, req), handler)
+		minPriority, maxPriority := getLogPriorityRange(rh, handler)
+		s.Equal(level.Warning, minPriority, handler)
+		s.Equal(level.Critical, maxPriority, handler)
+
+		req.URL, _ = url.Parse(urlString)
+		rh = s.rh[handler].Factory()
+		s.Require().NoError(rh.Parse(ctx, req), handler)
+		minPriority, maxPriority = getLogPriorityRange(rh, handler)
+		s.Zero(minPriority, handler)
+		s.Zero(maxPriority, handler)
+
+		for _, query := range []string{"min_priority=loud", "max_priority=0", "max_priority=101", "min_priority=error&max_priority=info"} {
+			req.URL, _ = url.Parse(urlString + query)
+			rh = s.rh[handler].Factory()
+			s.Error(rh.Parse(ctx, req), handler, query)
+		}
+	}
+}
+
 func (s *LogHandlerSuite) testParseValid(handler, urlString string, tags bool) {
 	ctx := context.Background()
 	urlString += "?start=2012-11-01T22:08:00%2B00:00"
@@ -1256,6 +2210,21 @@ func getLogPrintPriority(rh gimlet.RouteHandler, handler string) bool {
 	}
 }
 
+func getLogPrintSource(rh gimlet.RouteHandler, handler string) bool {
+	switch handler {
+	case "task_id":
+		return rh.(*logGetByTaskIDHandler).opts.PrintSource
+	case "group_task_id":
+		return rh.(*logGroupByTaskIDHandler).opts.PrintSource
+	case "test_name":
+		return rh.(*logGetByTestNameHandler).opts.PrintSource
+	case "group_test_name":
+		return rh.(*logGroupByTestNameHandler).opts.PrintSource
+	default:
+		return false
+	}
+}
+
 func getLogPaginate(rh gimlet.RouteHandler, handler string) bool {
 	switch handler {
 	case "id":
@@ -1290,6 +2259,59 @@ func getLogLimit(rh gimlet.RouteHandler, handler string) int {
 	}
 }
 
+func getLogNDJSON(rh gimlet.RouteHandler, handler string) bool {
+	switch handler {
+	case "id":
+		return rh.(*logGetByIDHandler).opts.NDJSON
+	case "task_id":
+		return rh.(*logGetByTaskIDHandler).opts.NDJSON
+	case "group_task_id":
+		return rh.(*logGroupByTaskIDHandler).opts.NDJSON
+	case "test_name":
+		return rh.(*logGetByTestNameHandler).opts.NDJSON
+	case "group_test_name":
+		return rh.(*logGroupByTestNameHandler).opts.NDJSON
+	default:
+		return false
+	}
+}
+
+func getLogPriorityRange(rh gimlet.RouteHandler, handler string) (level.Priority, level.Priority) {
+	switch handler {
+	case "id":
+		return rh.(*logGetByIDHandler).opts.MinPriority, rh.(*logGetByIDHandler).opts.MaxPriority
+	case "task_id":
+		return rh.(*logGetByTaskIDHandler).opts.MinPriority, rh.(*logGetByTaskIDHandler).opts.MaxPriority
+	case "search_task_id":
+		return rh.(*logSearchByTaskIDHandler).searchOpts.MinPriority, rh.(*logSearchByTaskIDHandler).searchOpts.MaxPriority
+	case "group_task_id":
+		return rh.(*logGroupByTaskIDHandler).opts.MinPriority, rh.(*logGroupByTaskIDHandler).opts.MaxPriority
+	case "test_name":
+		return rh.(*logGetByTestNameHandler).opts.MinPriority, rh.(*logGetByTestNameHandler).opts.MaxPriority
+	case "group_test_name":
+		return rh.(*logGroupByTestNameHandler).opts.MinPriority, rh.(*logGroupByTestNameHandler).opts.MaxPriority
+	default:
+		return 0, 0
+	}
+}
+
+func getLogFieldFilters(rh gimlet.RouteHandler, handler string) []dbModel.LogFieldFilter {
+	switch handler {
+	case "id":
+		return rh.(*logGetByIDHandler).opts.FieldFilters
+	case "task_id":
+		return rh.(*logGetByTaskIDHandler).opts.FieldFilters
+	case "group_task_id":
+		return rh.(*logGroupByTaskIDHandler).opts.FieldFilters
+	case "test_name":
+		return rh.(*logGetByTestNameHandler).opts.FieldFilters
+	case "group_test_name":
+		return rh.(*logGroupByTestNameHandler).opts.FieldFilters
+	default:
+		return nil
+	}
+}
+
 func TestNewBuildloggerResponder(t *testing.T) {
 	data := []byte("data")
 	last := time.Now().Add(-time.Hour)
@@ -1340,3 +2362,8 @@ func TestNewBuildloggerResponder(t *testing.T) {
 		assert.Nil(t, pages.Next)
 	})
 }
+
+func TestLogArchiveDownloadName(t *testing.T) {
+	assert.Equal(t, "task.tar.gz", logArchiveDownloadName(data.BuildloggerOptions{TaskID: "task", EmptyExecution: true}, dbModel.FileTarGz))
+	assert.Equal(t, "task.2.zip", logArchiveDownloadName(data.BuildloggerOptions{TaskID: "task", Execution: 2}, dbModel.FileZip))
+}
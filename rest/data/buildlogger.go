@@ -3,6 +3,7 @@ package data
 import (
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"sort"
@@ -12,10 +13,15 @@ import (
 	"github.com/evergreen-ci/cedar/rest/model"
 	"github.com/evergreen-ci/gimlet"
 	"github.com/evergreen-ci/pail"
+	"github.com/evergreen-ci/utility"
 	"github.com/mongodb/anser/db"
 	"github.com/pkg/errors"
 )
 
+// followLogPollInterval is the interval at which followed logs are checked
+// for new lines.
+const followLogPollInterval = time.Second
+
 /////////////////////////////
 // DBConnector Implementation
 /////////////////////////////
@@ -65,6 +71,78 @@ func (dbc *DBConnector) FindLogByID(ctx context.Context, opts BuildloggerOptions
 	return data, next, paginated, nil
 }
 
+func (dbc *DBConnector) FindLogLinesByID(ctx context.Context, opts BuildloggerOptions) ([]byte, int, bool, error) {
+	log := dbModel.Log{ID: opts.ID}
+	log.Setup(dbc.env)
+	if err := log.Find(ctx); db.ResultsNotFound(err) {
+		return nil, 0, false, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("log '%s' not found", opts.ID),
+		}
+	} else if err != nil {
+		return nil, 0, false, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "finding log '%s'", opts.ID).Error(),
+		}
+	}
+
+	log.Setup(dbc.env)
+	it, err := log.DownloadFromLine(ctx, opts.FromLine)
+	if err != nil {
+		return nil, 0, false, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "downloading log '%s'", opts.ID).Error(),
+		}
+	}
+
+	data, next, paginated, err := paginateLines(ctx, it, opts)
+	if err != nil {
+		return nil, 0, false, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "paginating log '%s'", opts.ID).Error(),
+		}
+	}
+
+	return data, next, paginated, nil
+}
+
+func (dbc *DBConnector) FollowLogByID(ctx context.Context, opts BuildloggerOptions) (io.Reader, error) {
+	log := dbModel.Log{ID: opts.ID}
+	log.Setup(dbc.env)
+	if err := log.Find(ctx); db.ResultsNotFound(err) {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("log '%s' not found", opts.ID),
+		}
+	} else if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "finding log '%s'", opts.ID).Error(),
+		}
+	}
+
+	it, err := log.Follow(ctx, opts.TimeRange.StartAt, followLogPollInterval)
+	if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "following log '%s'", opts.ID).Error(),
+		}
+	}
+
+	if len(opts.FieldFilters) > 0 {
+		it = dbModel.NewFieldFilteringLogIterator(it, opts.FieldFilters)
+	}
+
+	return dbModel.NewLogIteratorReader(ctx, it, dbModel.LogIteratorReaderOptions{
+		PrintTime:     opts.PrintTime,
+		PrintPriority: opts.PrintPriority,
+		NDJSON:        opts.NDJSON,
+		MinPriority:   opts.MinPriority,
+		MaxPriority:   opts.MaxPriority,
+		Follow:        true,
+	}), nil
+}
+
 func (dbc *DBConnector) FindLogMetadataByID(ctx context.Context, id string) (*model.APILog, error) {
 	log := dbModel.Log{ID: id}
 	log.Setup(dbc.env)
@@ -182,6 +260,117 @@ func (dbc *DBConnector) FindLogMetadataByTaskID(ctx context.Context, opts Buildl
 	return apiLogs, nil
 }
 
+func (dbc *DBConnector) FindLogProcessesByTaskID(ctx context.Context, opts BuildloggerOptions) ([]model.APILogProcess, error) {
+	dbOpts := dbModel.LogFindOptions{
+		TimeRange: dbModel.TimeRange{EndAt: time.Now()},
+		Info: dbModel.LogInfo{
+			TaskID:      opts.TaskID,
+			Execution:   opts.Execution,
+			ProcessName: opts.ProcessName,
+			Tags:        opts.Tags,
+		},
+		LatestExecution: opts.EmptyExecution,
+	}
+	logs := dbModel.Logs{}
+	logs.Setup(dbc.env)
+	if err := logs.Find(ctx, dbOpts); db.ResultsNotFound(err) {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("logs with task ID '%s' not found", opts.TaskID),
+		}
+	} else if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "finding logs with task ID '%s'", opts.TaskID).Error(),
+		}
+	}
+
+	return importLogProcesses(logs.Logs, opts.TaskID)
+}
+
+func (dbc *DBConnector) FindLogArchiveByTaskID(ctx context.Context, opts BuildloggerOptions, compression dbModel.FileCompression) (io.Reader, error) {
+	dbOpts := dbModel.LogFindOptions{
+		TimeRange: dbModel.TimeRange{EndAt: time.Now()},
+		Info: dbModel.LogInfo{
+			TaskID:      opts.TaskID,
+			Execution:   opts.Execution,
+			ProcessName: opts.ProcessName,
+			Tags:        opts.Tags,
+		},
+		LatestExecution: opts.EmptyExecution,
+	}
+	logs := dbModel.Logs{}
+	logs.Setup(dbc.env)
+	if err := logs.Find(ctx, dbOpts); db.ResultsNotFound(err) {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("logs with task ID '%s' not found", opts.TaskID),
+		}
+	} else if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "finding logs with task ID '%s'", opts.TaskID).Error(),
+		}
+	}
+
+	return newLogArchiveReader(ctx, logs.Logs, compression, opts, func(ctx context.Context, log dbModel.Log) (dbModel.LogIterator, error) {
+		log.Setup(dbc.env)
+		return log.Download(ctx, dbModel.TimeRange{EndAt: utility.MaxTime})
+	})
+}
+
+func (dbc *DBConnector) SearchLogsByTaskID(ctx context.Context, opts BuildloggerOptions, searchOpts dbModel.LogSearchOptions) (*model.APILogSearchResults, error) {
+	if err := searchOpts.Validate(); err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "invalid search options").Error(),
+		}
+	}
+
+	dbOpts := dbModel.LogFindOptions{
+		TimeRange: opts.TimeRange,
+		Info: dbModel.LogInfo{
+			TaskID:      opts.TaskID,
+			Execution:   opts.Execution,
+			ProcessName: opts.ProcessName,
+			Tags:        opts.Tags,
+		},
+		LatestExecution: opts.EmptyExecution,
+	}
+	logs := dbModel.Logs{}
+	logs.Setup(dbc.env)
+	if err := logs.Find(ctx, dbOpts); db.ResultsNotFound(err) {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("logs with task ID '%s' not found", opts.TaskID),
+		}
+	} else if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "finding logs with task ID '%s'", opts.TaskID).Error(),
+		}
+	}
+
+	logs.Setup(dbc.env)
+	results, err := logs.Search(ctx, searchOpts)
+	if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "searching logs with task ID '%s'", opts.TaskID).Error(),
+		}
+	}
+
+	apiResults := &model.APILogSearchResults{}
+	if err = apiResults.Import(*results); err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrap(err, "converting search results to output format").Error(),
+		}
+	}
+
+	return apiResults, nil
+}
+
 func (dbc *DBConnector) FindLogsByTestName(ctx context.Context, opts BuildloggerOptions) ([]byte, time.Time, bool, error) {
 	var (
 		data      []byte
@@ -383,6 +572,66 @@ func (mc *MockConnector) FindLogByID(ctx context.Context, opts BuildloggerOption
 	return data, next, paginated, ctx.Err()
 }
 
+func (mc *MockConnector) FindLogLinesByID(ctx context.Context, opts BuildloggerOptions) ([]byte, int, bool, error) {
+	log, ok := mc.CachedLogs[opts.ID]
+	if !ok {
+		return nil, 0, false, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("log '%s' not found", opts.ID),
+		}
+	}
+
+	bucket, err := mc.getBucket(ctx, log.Artifact.Prefix)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	it := dbModel.NewLineOffsetLogIterator(bucket, log.Artifact.Chunks, opts.FromLine)
+
+	data, next, paginated, err := paginateLines(ctx, it, opts)
+	if err != nil {
+		return nil, 0, false, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrap(err, "paginating log").Error(),
+		}
+	}
+
+	return data, next, paginated, ctx.Err()
+}
+
+func (mc *MockConnector) FollowLogByID(ctx context.Context, opts BuildloggerOptions) (io.Reader, error) {
+	log, ok := mc.CachedLogs[opts.ID]
+	if !ok {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("log '%s' not found", opts.ID),
+		}
+	}
+
+	bucket, err := mc.getBucket(ctx, log.Artifact.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	// Cached logs are static, so following them is equivalent to reading
+	// them from the start time.
+	it := dbModel.NewBatchedLogIterator(bucket, log.Artifact.Chunks, 2, dbModel.TimeRange{
+		StartAt: opts.TimeRange.StartAt,
+		EndAt:   utility.MaxTime,
+	})
+
+	if len(opts.FieldFilters) > 0 {
+		it = dbModel.NewFieldFilteringLogIterator(it, opts.FieldFilters)
+	}
+
+	return dbModel.NewLogIteratorReader(ctx, it, dbModel.LogIteratorReaderOptions{
+		PrintTime:     opts.PrintTime,
+		PrintPriority: opts.PrintPriority,
+		NDJSON:        opts.NDJSON,
+		MinPriority:   opts.MinPriority,
+		MaxPriority:   opts.MaxPriority,
+		Follow:        true,
+	}), ctx.Err()
+}
+
 func (mc *MockConnector) FindLogMetadataByID(ctx context.Context, id string) (*model.APILog, error) {
 	log, ok := mc.CachedLogs[id]
 	if !ok {
@@ -450,7 +699,7 @@ func (mc *MockConnector) FindLogsByTaskID(ctx context.Context, opts BuildloggerO
 			}
 		}
 
-		its = append(its, dbModel.NewBatchedLogIterator(bucket, log.Artifact.Chunks, 2, opts.TimeRange))
+		its = append(its, dbModel.NewSourceLogIterator(dbModel.NewBatchedLogIterator(bucket, log.Artifact.Chunks, 2, opts.TimeRange), log.Source()))
 	}
 	it := dbModel.NewMergingIterator(its...)
 
@@ -510,6 +759,169 @@ func (mc *MockConnector) FindLogMetadataByTaskID(ctx context.Context, opts Build
 	return apiLogs, ctx.Err()
 }
 
+func (mc *MockConnector) FindLogProcessesByTaskID(ctx context.Context, opts BuildloggerOptions) ([]model.APILogProcess, error) {
+	logs := []dbModel.Log{}
+	for _, log := range mc.CachedLogs {
+		if log.Info.TaskID == opts.TaskID {
+			logs = append(logs, log)
+		}
+	}
+	if opts.EmptyExecution {
+		opts.Execution = getMaxExecution(logs)
+	}
+
+	processLogs := []dbModel.Log{}
+	for _, log := range logs {
+		if opts.Execution != log.Info.Execution {
+			continue
+		}
+		if opts.ProcessName != "" && opts.ProcessName != log.Info.ProcessName {
+			continue
+		}
+		if !containsTags(opts.Tags, log.Info.Tags) {
+			continue
+		}
+		processLogs = append(processLogs, log)
+	}
+	if len(processLogs) == 0 {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("logs with task ID '%s' not found", opts.TaskID),
+		}
+	}
+
+	processes, err := importLogProcesses(processLogs, opts.TaskID)
+	if err != nil {
+		return nil, err
+	}
+
+	return processes, ctx.Err()
+}
+
+func (mc *MockConnector) FindLogArchiveByTaskID(ctx context.Context, opts BuildloggerOptions, compression dbModel.FileCompression) (io.Reader, error) {
+	logs := []dbModel.Log{}
+	for _, log := range mc.CachedLogs {
+		if log.Info.TaskID == opts.TaskID {
+			logs = append(logs, log)
+		}
+	}
+	if len(logs) == 0 {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("logs with task ID '%s' not found", opts.TaskID),
+		}
+	}
+
+	if opts.EmptyExecution {
+		opts.Execution = getMaxExecution(logs)
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i].CreatedAt.After(logs[j].CreatedAt) })
+
+	archived := []dbModel.Log{}
+	for _, log := range logs {
+		if opts.ProcessName != "" && opts.ProcessName != log.Info.ProcessName {
+			continue
+		}
+		if opts.Execution != log.Info.Execution {
+			continue
+		}
+		if !containsTags(opts.Tags, log.Info.Tags) {
+			continue
+		}
+		archived = append(archived, log)
+	}
+
+	r, err := newLogArchiveReader(ctx, archived, compression, opts, func(ctx context.Context, log dbModel.Log) (dbModel.LogIterator, error) {
+		bucket, err := mc.getBucket(ctx, log.Artifact.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		return dbModel.NewBatchedLogIterator(bucket, log.Artifact.Chunks, 2, dbModel.TimeRange{EndAt: utility.MaxTime}), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, ctx.Err()
+}
+
+func (mc *MockConnector) SearchLogsByTaskID(ctx context.Context, opts BuildloggerOptions, searchOpts dbModel.LogSearchOptions) (*model.APILogSearchResults, error) {
+	if err := searchOpts.Validate(); err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "invalid search options").Error(),
+		}
+	}
+
+	logs := []dbModel.Log{}
+	for _, log := range mc.CachedLogs {
+		if log.Info.TaskID == opts.TaskID {
+			logs = append(logs, log)
+		}
+	}
+	if len(logs) == 0 {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("logs with task ID '%s' not found", opts.TaskID),
+		}
+	}
+
+	if opts.EmptyExecution {
+		opts.Execution = getMaxExecution(logs)
+	}
+
+	results := dbModel.LogSearchResults{}
+	for _, log := range logs {
+		if results.Truncated {
+			break
+		}
+		if opts.ProcessName != "" && opts.ProcessName != log.Info.ProcessName {
+			continue
+		}
+		if opts.Execution != log.Info.Execution {
+			continue
+		}
+		if !containsTags(opts.Tags, log.Info.Tags) {
+			continue
+		}
+
+		bucket, err := mc.getBucket(ctx, log.Artifact.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		it := dbModel.NewBatchedLogIterator(bucket, log.Artifact.Chunks, 2, opts.TimeRange)
+		logSearchOpts := searchOpts
+		logSearchOpts.MaxMatches = searchOpts.MaxMatches - len(results.Matches)
+		matches, truncated, err := dbModel.SearchLogIterator(ctx, it, logSearchOpts)
+		if err != nil {
+			return nil, gimlet.ErrorResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    errors.Wrap(err, "searching log").Error(),
+			}
+		}
+		for i := range matches {
+			matches[i].LogID = log.ID
+			matches[i].ProcessName = log.Info.ProcessName
+		}
+		results.Matches = append(results.Matches, matches...)
+		results.Truncated = results.Truncated || truncated
+	}
+
+	sort.SliceStable(results.Matches, func(i, j int) bool {
+		return results.Matches[i].Line.Timestamp.Before(results.Matches[j].Line.Timestamp)
+	})
+
+	apiResults := &model.APILogSearchResults{}
+	if err := apiResults.Import(results); err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrap(err, "converting search results to output format").Error(),
+		}
+	}
+
+	return apiResults, ctx.Err()
+}
+
 func (mc *MockConnector) FindLogsByTestName(ctx context.Context, opts BuildloggerOptions) ([]byte, time.Time, bool, error) {
 	var (
 		data      []byte
@@ -678,12 +1090,35 @@ func (mc *MockConnector) findLogsByTestName(ctx context.Context, opts *Buildlogg
 			}
 		}
 
-		its = append(its, dbModel.NewBatchedLogIterator(bucket, log.Artifact.Chunks, 2, opts.TimeRange))
+		its = append(its, dbModel.NewSourceLogIterator(dbModel.NewBatchedLogIterator(bucket, log.Artifact.Chunks, 2, opts.TimeRange), log.Source()))
 	}
 
 	return dbModel.NewMergingIterator(its...), ctx.Err()
 }
 
+// importLogProcesses returns the processes of the given logs sorted by start
+// time.
+func importLogProcesses(logs []dbModel.Log, taskID string) ([]model.APILogProcess, error) {
+	sort.SliceStable(logs, func(i, j int) bool {
+		if !logs[i].CreatedAt.Equal(logs[j].CreatedAt) {
+			return logs[i].CreatedAt.Before(logs[j].CreatedAt)
+		}
+		return logs[i].ID < logs[j].ID
+	})
+
+	processes := make([]model.APILogProcess, len(logs))
+	for i, log := range logs {
+		if err := processes[i].Import(log); err != nil {
+			return nil, gimlet.ErrorResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    errors.Wrapf(err, "corrupt data for logs with task ID '%s'", taskID).Error(),
+			}
+		}
+	}
+
+	return processes, nil
+}
+
 func getMaxExecution(logs []dbModel.Log) int {
 	max := 0
 	for _, log := range logs {
@@ -701,6 +1136,13 @@ func paginateData(ctx context.Context, it dbModel.LogIterator, opts BuildloggerO
 		TailN:         opts.Tail,
 		PrintTime:     opts.PrintTime,
 		PrintPriority: opts.PrintPriority,
+		PrintSource:   opts.PrintSource,
+		NDJSON:        opts.NDJSON,
+		MinPriority:   opts.MinPriority,
+		MaxPriority:   opts.MaxPriority,
+	}
+	if len(opts.FieldFilters) > 0 {
+		it = dbModel.NewFieldFilteringLogIterator(it, opts.FieldFilters)
 	}
 
 	var paginated bool
@@ -713,3 +1155,39 @@ func paginateData(ctx context.Context, it dbModel.LogIterator, opts BuildloggerO
 	data, err := ioutil.ReadAll(reader)
 	return data, paginated, err
 }
+
+// paginateLines reads the lines of the given line numbered iterator and
+// returns the line number of the next page, or zero if the iterator is
+// exhausted. Since pages are addressed by line number, both limited and size
+// limited reads are paginated and lines sharing a timestamp may be split
+// across pages.
+func paginateLines(ctx context.Context, it dbModel.LineNumberedLogIterator, opts BuildloggerOptions) ([]byte, int, bool, error) {
+	readerOpts := dbModel.LogIteratorReaderOptions{
+		Limit:           opts.Limit,
+		PrintTime:       opts.PrintTime,
+		PrintPriority:   opts.PrintPriority,
+		NDJSON:          opts.NDJSON,
+		MinPriority:     opts.MinPriority,
+		MaxPriority:     opts.MaxPriority,
+		SplitTimestamps: true,
+	}
+	if readerOpts.Limit <= 0 {
+		readerOpts.SoftSizeLimit = opts.SoftSizeLimit
+	}
+	var filtered dbModel.LogIterator = it
+	if len(opts.FieldFilters) > 0 {
+		filtered = dbModel.NewFieldFilteringLogIterator(it, opts.FieldFilters)
+	}
+
+	data, err := ioutil.ReadAll(dbModel.NewLogIteratorReader(ctx, filtered, readerOpts))
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	var next int
+	if !it.Exhausted() {
+		next = it.LineNumber()
+	}
+
+	return data, next, readerOpts.Limit > 0 || readerOpts.SoftSizeLimit > 0, nil
+}
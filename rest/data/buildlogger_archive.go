@@ -0,0 +1,283 @@
+package data
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	dbModel "github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/cedar/rest/model"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/recovery"
+	"github.com/pkg/errors"
+)
+
+// logArchiveManifestName is the name of the file in a log archive holding
+// the metadata of the archived logs.
+const logArchiveManifestName = "manifest.json"
+
+// logArchiveDownloader returns an iterator over all of the lines of the given
+// log.
+type logArchiveDownloader func(context.Context, dbModel.Log) (dbModel.LogIterator, error)
+
+// logArchiveWriter writes the files of a log archive. Each file is read in
+// full from the given reader, which must read exactly the given size.
+type logArchiveWriter interface {
+	WriteFile(name string, modTime time.Time, size int64, r io.Reader) error
+	Close() error
+}
+
+// newLogArchiveReader returns a reader streaming an archive with one file per
+// log and a manifest of the logs' metadata. The archive is written as it is
+// read, downloading one log at a time.
+func newLogArchiveReader(ctx context.Context, logs []dbModel.Log, compression dbModel.FileCompression, opts BuildloggerOptions, download logArchiveDownloader) (io.Reader, error) {
+	manifest := make([]model.APILog, len(logs))
+	for i, log := range logs {
+		if err := manifest[i].Import(log); err != nil {
+			return nil, gimlet.ErrorResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    errors.Wrapf(err, "corrupt data for log '%s'", log.ID).Error(),
+			}
+		}
+	}
+	manifestData, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrap(err, "marshalling log archive manifest").Error(),
+		}
+	}
+
+	var newWriter func(io.Writer) logArchiveWriter
+	switch compression {
+	case dbModel.FileTarGz:
+		newWriter = newTarGzLogArchiveWriter
+	case dbModel.FileZip:
+		newWriter = newZipLogArchiveWriter
+	default:
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("unsupported log archive compression '%s'", compression),
+		}
+	}
+
+	pr, pw := newContextPipe(ctx)
+	go func() {
+		var err error
+		defer func() {
+			err = recovery.HandlePanicWithError(recover(), err, "writing log archive")
+			_ = pw.CloseWithError(err)
+		}()
+
+		// The archive is only finalized once all of the logs are
+		// written so that failures are not mistaken for a complete
+		// archive.
+		aw := newWriter(pw)
+		if err = writeLogArchive(ctx, aw, logs, manifestData, opts, download); err == nil {
+			err = errors.Wrap(aw.Close(), "closing log archive")
+		}
+	}()
+
+	return pr, nil
+}
+
+func writeLogArchive(ctx context.Context, aw logArchiveWriter, logs []dbModel.Log, manifest []byte, opts BuildloggerOptions, download logArchiveDownloader) error {
+	if err := aw.WriteFile(logArchiveManifestName, time.Now(), int64(len(manifest)), bytes.NewReader(manifest)); err != nil {
+		return errors.Wrap(err, "writing log archive manifest")
+	}
+
+	names := map[string]bool{logArchiveManifestName: true}
+	for _, log := range logs {
+		name := logArchiveFileName(log, names)
+		names[name] = true
+
+		size, err := logArchiveFileSize(ctx, log, opts, download)
+		if err != nil {
+			return errors.Wrapf(err, "getting archived size of log '%s'", log.ID)
+		}
+		it, r, err := newLogArchiveFileReader(ctx, log, opts, download)
+		if err != nil {
+			return err
+		}
+		modTime := log.CompletedAt
+		if modTime.IsZero() {
+			modTime = log.CreatedAt
+		}
+		// Lines appended to the log after its size was taken are
+		// left out of the archive.
+		err = aw.WriteFile(name, modTime, size, io.LimitReader(r, size))
+		// The reader only closes the iterator once it is exhausted,
+		// which the limit may prevent.
+		_ = it.Close()
+		if err != nil {
+			return errors.Wrapf(err, "writing log '%s' to archive", log.ID)
+		}
+	}
+
+	return nil
+}
+
+// newLogArchiveFileReader returns a reader over the lines of the given log as
+// they are written to a log archive, along with the underlying iterator.
+func newLogArchiveFileReader(ctx context.Context, log dbModel.Log, opts BuildloggerOptions, download logArchiveDownloader) (dbModel.LogIterator, io.Reader, error) {
+	it, err := download(ctx, log)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "downloading log '%s'", log.ID)
+	}
+
+	return it, dbModel.NewLogIteratorReader(ctx, it, dbModel.LogIteratorReaderOptions{
+		PrintTime:     opts.PrintTime,
+		PrintPriority: opts.PrintPriority,
+	}), nil
+}
+
+// Sizes of the prefixes added to each line of an archived log, see
+// dbModel.LogIteratorReaderOptions.
+const (
+	logArchiveTimePrefixSize     = int64(len("[2006/01/02 15:04:05.000] "))
+	logArchivePriorityPrefixSize = int64(len("[P:100] "))
+)
+
+// logArchiveFileSize returns the size of the file of the given log in a log
+// archive. The size of closed logs whose stats account for every chunk of
+// their chunk index is derived from the stats: each line is stored with a
+// trailing newline and the requested prefixes. Other logs, whether still
+// open or partly appended before stats were maintained, are read once to
+// count their size instead.
+func logArchiveFileSize(ctx context.Context, log dbModel.Log, opts BuildloggerOptions, download logArchiveDownloader) (int64, error) {
+	if hasCompleteLogStats(log) {
+		lineSize := int64(1)
+		if opts.PrintTime {
+			lineSize += logArchiveTimePrefixSize
+		}
+		if opts.PrintPriority {
+			lineSize += logArchivePriorityPrefixSize
+		}
+		return log.Stats.Size + int64(log.Stats.NumLines)*lineSize, nil
+	}
+
+	it, r, err := newLogArchiveFileReader(ctx, log, opts, download)
+	if err != nil {
+		return 0, err
+	}
+	size, err := io.Copy(ioutil.Discard, r)
+	if err != nil {
+		_ = it.Close()
+		return 0, errors.Wrapf(err, "reading log '%s'", log.ID)
+	}
+
+	return size, nil
+}
+
+// hasCompleteLogStats returns whether the stats of the given log account for
+// all of its lines, which is only known for closed logs with a chunk index.
+func hasCompleteLogStats(log dbModel.Log) bool {
+	if log.CompletedAt.IsZero() || log.Stats.NumLines == 0 || len(log.Artifact.Chunks) == 0 {
+		return false
+	}
+
+	var numLines int
+	for _, chunk := range log.Artifact.Chunks {
+		numLines += chunk.NumLines
+	}
+
+	return numLines == log.Stats.NumLines
+}
+
+// logArchiveFileName returns the name of the file of the given log in a log
+// archive, based on its test name, process name, and trial. Logs whose name
+// is already taken are disambiguated by their ID.
+func logArchiveFileName(log dbModel.Log, taken map[string]bool) string {
+	var parts []string
+	for _, part := range []string{log.Info.TestName, log.Info.ProcessName} {
+		if part != "" {
+			parts = append(parts, strings.NewReplacer("/", "_", "\\", "_").Replace(part))
+		}
+	}
+	base := "task"
+	if len(parts) > 0 {
+		base = strings.Join(parts, "-")
+	}
+
+	name := fmt.Sprintf("%s.%d.log", base, log.Info.Trial)
+	if taken[name] {
+		name = fmt.Sprintf("%s.%d.%s.log", base, log.Info.Trial, log.ID)
+	}
+
+	return name
+}
+
+type tarGzLogArchiveWriter struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func newTarGzLogArchiveWriter(w io.Writer) logArchiveWriter {
+	gz := gzip.NewWriter(w)
+	return &tarGzLogArchiveWriter{gz: gz, tw: tar.NewWriter(gz)}
+}
+
+// WriteFile streams the file into the archive. Since tar headers require the
+// size of the file up front, only the given size is read and a file shorter
+// than the given size is rejected.
+func (w *tarGzLogArchiveWriter) WriteFile(name string, modTime time.Time, size int64, r io.Reader) error {
+	if err := w.tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    size,
+		ModTime: modTime,
+	}); err != nil {
+		return errors.Wrapf(err, "writing header of file '%s'", name)
+	}
+	if _, err := io.CopyN(w.tw, r, size); err != nil {
+		if err == io.EOF {
+			return errors.Errorf("file '%s' is shorter than its expected size of %d bytes", name, size)
+		}
+		return errors.Wrapf(err, "writing file '%s'", name)
+	}
+
+	return nil
+}
+
+func (w *tarGzLogArchiveWriter) Close() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.Add(w.tw.Close())
+	catcher.Add(w.gz.Close())
+	return catcher.Resolve()
+}
+
+type zipLogArchiveWriter struct {
+	zw *zip.Writer
+}
+
+func newZipLogArchiveWriter(w io.Writer) logArchiveWriter {
+	return &zipLogArchiveWriter{zw: zip.NewWriter(w)}
+}
+
+func (w *zipLogArchiveWriter) WriteFile(name string, modTime time.Time, _ int64, r io.Reader) error {
+	fw, err := w.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modTime,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "writing header of file '%s'", name)
+	}
+	_, err = io.Copy(fw, r)
+
+	return errors.Wrapf(err, "writing file '%s'", name)
+}
+
+func (w *zipLogArchiveWriter) Close() error {
+	return w.zw.Close()
+}
@@ -0,0 +1,181 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	dbModel "github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/anser/db"
+	"github.com/pkg/errors"
+)
+
+/////////////////////////////
+// DBConnector Implementation
+/////////////////////////////
+
+func (dbc *DBConnector) DiffLogsByTaskID(ctx context.Context, opts BuildloggerOptions, baseExecution int, diffOpts dbModel.LogDiffOptions) (io.Reader, error) {
+	if err := diffOpts.Validate(); err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "invalid diff options").Error(),
+		}
+	}
+
+	baseOpts := opts
+	baseOpts.Execution = baseExecution
+	baseOpts.EmptyExecution = false
+	base, err := dbc.mergeDiffLogs(ctx, baseOpts)
+	if err != nil {
+		return nil, err
+	}
+	compare, err := dbc.mergeDiffLogs(ctx, opts)
+	if err != nil {
+		_ = base.Close()
+		return nil, err
+	}
+
+	return newLogDiffReader(ctx, base, compare, diffOpts)
+}
+
+// mergeDiffLogs returns an iterator over the merged lines of the logs with
+// the given task ID and execution.
+func (dbc *DBConnector) mergeDiffLogs(ctx context.Context, opts BuildloggerOptions) (dbModel.LogIterator, error) {
+	dbOpts := dbModel.LogFindOptions{
+		TimeRange: dbModel.TimeRange{EndAt: time.Now()},
+		Info: dbModel.LogInfo{
+			TaskID:      opts.TaskID,
+			TestName:    opts.TestName,
+			Execution:   opts.Execution,
+			ProcessName: opts.ProcessName,
+			Tags:        opts.Tags,
+		},
+		LatestExecution: opts.EmptyExecution,
+	}
+	logs := dbModel.Logs{}
+	logs.Setup(dbc.env)
+	if err := logs.Find(ctx, dbOpts); db.ResultsNotFound(err) {
+		return nil, diffLogsNotFound(opts)
+	} else if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "finding logs with task ID '%s'", opts.TaskID).Error(),
+		}
+	}
+
+	logs.Setup(dbc.env)
+	it, err := logs.Merge(ctx)
+	if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "merging logs with task ID '%s'", opts.TaskID).Error(),
+		}
+	}
+
+	return it, nil
+}
+
+///////////////////////////////
+// MockConnector Implementation
+///////////////////////////////
+
+func (mc *MockConnector) DiffLogsByTaskID(ctx context.Context, opts BuildloggerOptions, baseExecution int, diffOpts dbModel.LogDiffOptions) (io.Reader, error) {
+	if err := diffOpts.Validate(); err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "invalid diff options").Error(),
+		}
+	}
+
+	logs := []dbModel.Log{}
+	for _, log := range mc.CachedLogs {
+		if log.Info.TaskID == opts.TaskID {
+			logs = append(logs, log)
+		}
+	}
+	if opts.EmptyExecution {
+		opts.Execution = getMaxExecution(logs)
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i].CreatedAt.After(logs[j].CreatedAt) })
+
+	baseOpts := opts
+	baseOpts.Execution = baseExecution
+	base, err := mc.mergeDiffLogs(ctx, logs, baseOpts)
+	if err != nil {
+		return nil, err
+	}
+	compare, err := mc.mergeDiffLogs(ctx, logs, opts)
+	if err != nil {
+		_ = base.Close()
+		return nil, err
+	}
+
+	r, err := newLogDiffReader(ctx, base, compare, diffOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, ctx.Err()
+}
+
+func (mc *MockConnector) mergeDiffLogs(ctx context.Context, logs []dbModel.Log, opts BuildloggerOptions) (dbModel.LogIterator, error) {
+	its := []dbModel.LogIterator{}
+	for _, log := range logs {
+		if opts.TestName != "" && opts.TestName != log.Info.TestName {
+			continue
+		}
+		if opts.ProcessName != "" && opts.ProcessName != log.Info.ProcessName {
+			continue
+		}
+		if opts.Execution != log.Info.Execution {
+			continue
+		}
+		if !containsTags(opts.Tags, log.Info.Tags) {
+			continue
+		}
+
+		bucket, err := mc.getBucket(ctx, log.Artifact.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		its = append(its, dbModel.NewBatchedLogIterator(bucket, log.Artifact.Chunks, 2, dbModel.TimeRange{EndAt: utility.MaxTime}))
+	}
+	if len(its) == 0 {
+		return nil, diffLogsNotFound(opts)
+	}
+
+	return dbModel.NewMergingIterator(its...), nil
+}
+
+func newLogDiffReader(ctx context.Context, base, compare dbModel.LogIterator, diffOpts dbModel.LogDiffOptions) (io.Reader, error) {
+	r, err := dbModel.NewLogDiffReader(ctx, base, compare, diffOpts)
+	if err != nil {
+		_ = base.Close()
+		_ = compare.Close()
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrap(err, "diffing logs").Error(),
+		}
+	}
+
+	return r, nil
+}
+
+func diffLogsNotFound(opts BuildloggerOptions) gimlet.ErrorResponse {
+	if opts.EmptyExecution {
+		return gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("logs with task ID '%s' not found", opts.TaskID),
+		}
+	}
+
+	return gimlet.ErrorResponse{
+		StatusCode: http.StatusNotFound,
+		Message:    fmt.Sprintf("logs with task ID '%s' and execution %d not found", opts.TaskID, opts.Execution),
+	}
+}
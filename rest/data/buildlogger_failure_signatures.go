@@ -0,0 +1,188 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	dbModel "github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/cedar/rest/model"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/mongodb/anser/db"
+	"github.com/pkg/errors"
+)
+
+/////////////////////////////
+// DBConnector Implementation
+/////////////////////////////
+
+func (dbc *DBConnector) FindLogFailuresByTaskID(ctx context.Context, opts BuildloggerOptions) ([]model.APILogFailures, error) {
+	dbOpts := dbModel.LogFindOptions{
+		TimeRange: dbModel.TimeRange{EndAt: time.Now()},
+		Info: dbModel.LogInfo{
+			TaskID:      opts.TaskID,
+			Execution:   opts.Execution,
+			ProcessName: opts.ProcessName,
+			Tags:        opts.Tags,
+		},
+		LatestExecution: opts.EmptyExecution,
+	}
+	logs := dbModel.Logs{}
+	logs.Setup(dbc.env)
+	if err := logs.Find(ctx, dbOpts); db.ResultsNotFound(err) {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("logs with task ID '%s' not found", opts.TaskID),
+		}
+	} else if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "finding logs with task ID '%s'", opts.TaskID).Error(),
+		}
+	}
+
+	return importLogFailures(logs.Logs)
+}
+
+func (dbc *DBConnector) FindLogsByFailureSignature(ctx context.Context, opts dbModel.LogFailureSignatureFindOptions) ([]model.APILogFailureMatch, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "invalid find options").Error(),
+		}
+	}
+
+	logs, err := dbModel.FindLogsByFailureSignature(ctx, dbc.env, opts)
+	if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "finding logs with failure signature '%s'", opts.SignatureID).Error(),
+		}
+	}
+
+	return importLogFailureMatches(logs)
+}
+
+///////////////////////////////
+// MockConnector Implementation
+///////////////////////////////
+
+func (mc *MockConnector) FindLogFailuresByTaskID(ctx context.Context, opts BuildloggerOptions) ([]model.APILogFailures, error) {
+	logs := []dbModel.Log{}
+	for _, log := range mc.CachedLogs {
+		if log.Info.TaskID == opts.TaskID {
+			logs = append(logs, log)
+		}
+	}
+	if opts.EmptyExecution {
+		opts.Execution = getMaxExecution(logs)
+	}
+
+	filtered := []dbModel.Log{}
+	for _, log := range logs {
+		if opts.ProcessName != "" && opts.ProcessName != log.Info.ProcessName {
+			continue
+		}
+		if opts.Execution != log.Info.Execution {
+			continue
+		}
+		if !containsTags(opts.Tags, log.Info.Tags) {
+			continue
+		}
+		filtered = append(filtered, log)
+	}
+	if len(filtered) == 0 {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("logs with task ID '%s' not found", opts.TaskID),
+		}
+	}
+
+	failures, err := importLogFailures(filtered)
+	if err != nil {
+		return nil, err
+	}
+
+	return failures, ctx.Err()
+}
+
+func (mc *MockConnector) FindLogsByFailureSignature(ctx context.Context, opts dbModel.LogFailureSignatureFindOptions) ([]model.APILogFailureMatch, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "invalid find options").Error(),
+		}
+	}
+
+	logs := []dbModel.Log{}
+	for _, log := range mc.CachedLogs {
+		if log.Info.Project != opts.Project || log.CreatedAt.Before(opts.Since) {
+			continue
+		}
+		if opts.ExcludeTaskID != "" && log.Info.TaskID == opts.ExcludeTaskID {
+			continue
+		}
+		for _, signature := range log.FailureSignatures {
+			if signature.ID == opts.SignatureID {
+				logs = append(logs, log)
+				break
+			}
+		}
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i].CreatedAt.After(logs[j].CreatedAt) })
+	if opts.Limit > 0 && int64(len(logs)) > opts.Limit {
+		logs = logs[:opts.Limit]
+	}
+
+	matches, err := importLogFailureMatches(logs)
+	if err != nil {
+		return nil, err
+	}
+
+	return matches, ctx.Err()
+}
+
+// importLogFailures returns the failure signatures of the given logs with
+// any, sorted by creation time.
+func importLogFailures(logs []dbModel.Log) ([]model.APILogFailures, error) {
+	sort.SliceStable(logs, func(i, j int) bool {
+		if logs[i].CreatedAt.Equal(logs[j].CreatedAt) {
+			return logs[i].ID < logs[j].ID
+		}
+		return logs[i].CreatedAt.Before(logs[j].CreatedAt)
+	})
+
+	failures := []model.APILogFailures{}
+	for _, log := range logs {
+		if len(log.FailureSignatures) == 0 {
+			continue
+		}
+
+		apiFailures := model.APILogFailures{}
+		if err := apiFailures.Import(log); err != nil {
+			return nil, gimlet.ErrorResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    errors.Wrapf(err, "corrupt data for log '%s'", log.ID).Error(),
+			}
+		}
+		failures = append(failures, apiFailures)
+	}
+
+	return failures, nil
+}
+
+func importLogFailureMatches(logs []dbModel.Log) ([]model.APILogFailureMatch, error) {
+	matches := make([]model.APILogFailureMatch, len(logs))
+	for i, log := range logs {
+		if err := matches[i].Import(log); err != nil {
+			return nil, gimlet.ErrorResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    errors.Wrapf(err, "corrupt data for log '%s'", log.ID).Error(),
+			}
+		}
+	}
+
+	return matches, nil
+}
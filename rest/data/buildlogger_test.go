@@ -1,17 +1,28 @@
 package data
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"io"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/evergreen-ci/cedar"
 	"github.com/evergreen-ci/cedar/model"
+	restModel "github.com/evergreen-ci/cedar/rest/model"
 	"github.com/evergreen-ci/pail"
+	"github.com/evergreen-ci/utility"
 	"github.com/mongodb/amboy/queue"
 	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -53,6 +64,7 @@ type buildloggerConnectorSuite struct {
 	sc      Connector
 	env     cedar.Environment
 	logs    map[string]model.Log
+	usage   []model.LogUsage
 	setup   func()
 	tempDir string
 
@@ -73,9 +85,10 @@ func TestBuildloggerConnectorSuiteMock(t *testing.T) {
 	s.setup = func() {
 		s.setupData()
 		s.sc = &MockConnector{
-			CachedLogs: s.logs,
-			env:        cedar.GetEnvironment(),
-			Bucket:     s.tempDir,
+			CachedLogs:     s.logs,
+			CachedLogUsage: s.usage,
+			env:            cedar.GetEnvironment(),
+			Bucket:         s.tempDir,
 		}
 	}
 	suite.Run(t, s)
@@ -288,12 +301,28 @@ func (s *buildloggerConnectorSuite) setupData() {
 		chunks, _, err := model.GenerateTestLog(s.ctx, bucket, 100, 10)
 		s.Require().NoError(err)
 		log.Artifact.Chunks = chunks
+		if logInfo.ExitCode != 0 {
+			log.FailureSignatures = []model.LogFailureSignature{
+				{ID: "sig1", Kind: model.LogFailureTestFailure, Signature: "FAIL TestFoo", Count: 1},
+			}
+		}
 
 		log.Setup(s.env)
 		s.Require().NoError(log.SaveNew(s.ctx))
 		s.logs[log.ID] = *log
 		time.Sleep(time.Second)
 	}
+
+	date := time.Date(2021, time.January, 2, 0, 0, 0, 0, time.UTC)
+	s.usage = []model.LogUsage{
+		{ID: "small@2021-01-02", Project: "small", Date: date, Bytes: 1024, NumLines: 10},
+		{ID: "large@2021-01-02", Project: "large", Date: date, Bytes: 4096, NumLines: 40, RejectedBytes: 2048, TruncatedLogs: 1},
+		{ID: "small@2021-01-03", Project: "small", Date: date.Add(24 * time.Hour), Bytes: 512, NumLines: 5},
+	}
+	for _, usage := range s.usage {
+		_, err = s.env.GetDB().Collection("buildlogger.usage").InsertOne(s.ctx, usage)
+		s.Require().NoError(err)
+	}
 }
 
 func (s *buildloggerConnectorSuite) SetupSuite() {
@@ -355,6 +384,28 @@ func (s *buildloggerConnectorSuite) TestFindLogByIDExists() {
 	}
 }
 
+func (s *buildloggerConnectorSuite) TestFindLogLinesByID() {
+	for id, log := range s.logs {
+		findOpts := BuildloggerOptions{
+			ID:       id,
+			FromLine: 3,
+			Limit:    5,
+		}
+		data, next, paginated, err := s.sc.FindLogLinesByID(s.ctx, findOpts)
+		s.Require().NoError(err)
+		s.True(paginated)
+		s.Equal(8, next)
+		it, err := log.DownloadFromLine(s.ctx, findOpts.FromLine)
+		s.Require().NoError(err)
+		expected, err := ioutil.ReadAll(model.NewLogIteratorReader(s.ctx, it, model.LogIteratorReaderOptions{Limit: 5}))
+		s.Require().NoError(err)
+		s.Equal(expected, data)
+	}
+
+	_, _, _, err := s.sc.FindLogLinesByID(s.ctx, BuildloggerOptions{ID: "DNE"})
+	s.Error(err)
+}
+
 func (s *buildloggerConnectorSuite) TestFindLogByIDDNE() {
 	_, _, _, err := s.sc.FindLogByID(s.ctx, BuildloggerOptions{ID: "DNE"})
 	s.Error(err)
@@ -364,6 +415,44 @@ func (s *buildloggerConnectorSuite) TestFindLogByIDDNE() {
 	s.Nil(l)
 }
 
+func (s *buildloggerConnectorSuite) TestFollowLogByIDExists() {
+	for id, log := range s.logs {
+		for _, printTime := range []bool{true, false} {
+			followOpts := BuildloggerOptions{
+				ID:            id,
+				PrintTime:     printTime,
+				PrintPriority: !printTime,
+			}
+			it, err := log.Download(s.ctx, model.TimeRange{EndAt: utility.MaxTime})
+			s.Require().NoError(err)
+			readerOpts := model.LogIteratorReaderOptions{
+				PrintTime:     printTime,
+				PrintPriority: !printTime,
+			}
+			expected, err := ioutil.ReadAll(model.NewLogIteratorReader(s.ctx, it, readerOpts))
+			s.Require().NoError(err)
+
+			// The test logs are never closed, so following them
+			// streams every line and then waits for new lines
+			// until the context is canceled.
+			ctx, cancel := context.WithCancel(s.ctx)
+			r, err := s.sc.FollowLogByID(ctx, followOpts)
+			s.Require().NoError(err)
+			data := make([]byte, len(expected))
+			_, err = io.ReadFull(r, data)
+			cancel()
+			s.Require().NoError(err)
+			s.Equal(expected, data)
+		}
+	}
+}
+
+func (s *buildloggerConnectorSuite) TestFollowLogByIDDNE() {
+	r, err := s.sc.FollowLogByID(s.ctx, BuildloggerOptions{ID: "DNE"})
+	s.Error(err)
+	s.Nil(r)
+}
+
 func (s *buildloggerConnectorSuite) TestFindLogsByTaskIDExists() {
 	for _, printTime := range []bool{true} {
 		opts := model.LogFindOptions{
@@ -521,6 +610,445 @@ func (s *buildloggerConnectorSuite) TestFindLogsByTaskIDDNE() {
 	s.Nil(apiLogs)
 }
 
+func (s *buildloggerConnectorSuite) TestFindLogProcessesByTaskIDExists() {
+	for _, log := range s.logs {
+		opts := BuildloggerOptions{
+			TaskID:      log.Info.TaskID,
+			Execution:   log.Info.Execution,
+			ProcessName: log.Info.ProcessName,
+			Tags:        log.Info.Tags,
+		}
+		processes, err := s.sc.FindLogProcessesByTaskID(s.ctx, opts)
+		s.Require().NoError(err)
+		s.Require().NotEmpty(processes)
+
+		var found bool
+		for i, process := range processes {
+			found = found || utility.FromStringPtr(process.LogID) == log.ID
+			s.Equal(log.Info.ProcessName, utility.FromStringPtr(process.ProcessName))
+			if i > 0 {
+				s.False(time.Time(process.StartAt).Before(time.Time(processes[i-1].StartAt)))
+			}
+		}
+		s.True(found)
+	}
+}
+
+func (s *buildloggerConnectorSuite) TestFindLogProcessesByTaskIDDNE() {
+	processes, err := s.sc.FindLogProcessesByTaskID(s.ctx, BuildloggerOptions{TaskID: "DNE"})
+	s.Error(err)
+	s.Nil(processes)
+}
+
+func (s *buildloggerConnectorSuite) TestFindLogArchiveByTaskIDExists() {
+	for _, log := range s.logs {
+		opts := BuildloggerOptions{
+			TaskID:      log.Info.TaskID,
+			Execution:   log.Info.Execution,
+			ProcessName: log.Info.ProcessName,
+			Tags:        log.Info.Tags,
+			PrintTime:   true,
+		}
+		r, err := s.sc.FindLogArchiveByTaskID(s.ctx, opts, model.FileZip)
+		s.Require().NoError(err)
+		data, err := ioutil.ReadAll(r)
+		s.Require().NoError(err)
+
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		s.Require().NoError(err)
+		files := map[string][]byte{}
+		for _, file := range zr.File {
+			fr, err := file.Open()
+			s.Require().NoError(err)
+			files[file.Name], err = ioutil.ReadAll(fr)
+			s.Require().NoError(err)
+			s.Require().NoError(fr.Close())
+		}
+		s.Require().Contains(files, logArchiveManifestName)
+		var manifest []restModel.APILog
+		s.Require().NoError(json.Unmarshal(files[logArchiveManifestName], &manifest))
+		s.Require().Len(files, len(manifest)+1)
+
+		name := logArchiveFileName(log, map[string]bool{})
+		s.Require().Contains(files, name)
+		it, err := log.Download(s.ctx, model.TimeRange{EndAt: utility.MaxTime})
+		s.Require().NoError(err)
+		expected, err := ioutil.ReadAll(model.NewLogIteratorReader(s.ctx, it, model.LogIteratorReaderOptions{PrintTime: true}))
+		s.Require().NoError(err)
+		s.Equal(expected, files[name])
+	}
+}
+
+func (s *buildloggerConnectorSuite) TestFindLogArchiveByTaskIDDNE() {
+	r, err := s.sc.FindLogArchiveByTaskID(s.ctx, BuildloggerOptions{TaskID: "DNE"}, model.FileTarGz)
+	s.Error(err)
+	s.Nil(r)
+}
+
+func (s *buildloggerConnectorSuite) TestFindLogArchiveByTaskIDInvalidCompression() {
+	r, err := s.sc.FindLogArchiveByTaskID(s.ctx, BuildloggerOptions{TaskID: "task1"}, model.FileXz)
+	s.Error(err)
+	s.Nil(r)
+}
+
+func TestLogArchiveFileName(t *testing.T) {
+	taken := map[string]bool{}
+	for _, test := range []struct {
+		log      model.Log
+		expected string
+	}{
+		{
+			log:      model.Log{ID: "task", Info: model.LogInfo{}},
+			expected: "task.0.log",
+		},
+		{
+			log:      model.Log{ID: "proc", Info: model.LogInfo{ProcessName: "mongod", Trial: 2}},
+			expected: "mongod.2.log",
+		},
+		{
+			log:      model.Log{ID: "test", Info: model.LogInfo{TestName: "jstests/core/find.js", ProcessName: "mongod", Trial: 2}},
+			expected: "jstests_core_find.js-mongod.2.log",
+		},
+		{
+			log:      model.Log{ID: "dup", Info: model.LogInfo{ProcessName: "mongod", Trial: 2}},
+			expected: "mongod.2.dup.log",
+		},
+	} {
+		name := logArchiveFileName(test.log, taken)
+		assert.Equal(t, test.expected, name)
+		taken[name] = true
+	}
+}
+
+func TestLogArchiveFileSize(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tmpDir, err := ioutil.TempDir(".", "log-archive-size-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: tmpDir})
+	require.NoError(t, err)
+	chunks, lines, err := model.GenerateTestLog(ctx, bucket, 20, 7)
+	require.NoError(t, err)
+	download := func(context.Context, model.Log) (model.LogIterator, error) {
+		return model.NewSerializedLogIterator(bucket, chunks, model.TimeRange{EndAt: time.Now().Add(24 * time.Hour)}), nil
+	}
+
+	stats := model.LogStats{NumLines: len(lines)}
+	for _, line := range lines {
+		stats.Size += int64(len(strings.TrimSuffix(line.Data, "\n")))
+	}
+	for _, opts := range []BuildloggerOptions{
+		{},
+		{PrintTime: true},
+		{PrintPriority: true},
+		{PrintTime: true, PrintPriority: true},
+	} {
+		counted, err := logArchiveFileSize(ctx, model.Log{ID: "legacy"}, opts, download)
+		require.NoError(t, err)
+		_, r, err := newLogArchiveFileReader(ctx, model.Log{}, opts, download)
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		assert.EqualValues(t, len(data), counted)
+
+		closed := model.Log{
+			ID:          "stats",
+			CompletedAt: time.Now(),
+			Artifact:    model.LogArtifactInfo{Version: 2, Chunks: chunks},
+			Stats:       stats,
+		}
+		fromStats, err := logArchiveFileSize(ctx, closed, opts, nil)
+		require.NoError(t, err)
+		assert.Equal(t, counted, fromStats)
+
+		open := closed
+		open.CompletedAt = time.Time{}
+		fromOpen, err := logArchiveFileSize(ctx, open, opts, download)
+		require.NoError(t, err)
+		assert.Equal(t, counted, fromOpen)
+
+		partial := closed
+		partial.Stats.NumLines--
+		partial.Stats.Size--
+		fromPartial, err := logArchiveFileSize(ctx, partial, opts, download)
+		require.NoError(t, err)
+		assert.Equal(t, counted, fromPartial)
+	}
+}
+
+func TestWriteLogArchiveGrowingLog(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tmpDir, err := ioutil.TempDir(".", "log-archive-growing-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: tmpDir})
+	require.NoError(t, err)
+	chunks, _, err := model.GenerateTestLog(ctx, bucket, 20, 5)
+	require.NoError(t, err)
+	timeRange := model.TimeRange{EndAt: time.Now().Add(24 * time.Hour)}
+
+	// The log gains a chunk after its size is taken.
+	var downloads int
+	download := func(context.Context, model.Log) (model.LogIterator, error) {
+		downloads++
+		if downloads == 1 {
+			return model.NewSerializedLogIterator(bucket, chunks[:len(chunks)-1], timeRange), nil
+		}
+		return model.NewSerializedLogIterator(bucket, chunks, timeRange), nil
+	}
+	_, r, err := newLogArchiveFileReader(ctx, model.Log{}, BuildloggerOptions{}, func(context.Context, model.Log) (model.LogIterator, error) {
+		return model.NewSerializedLogIterator(bucket, chunks[:len(chunks)-1], timeRange), nil
+	})
+	require.NoError(t, err)
+	expected, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	aw := newTarGzLogArchiveWriter(buf)
+	log := model.Log{ID: "open", CreatedAt: time.Now()}
+	require.NoError(t, writeLogArchive(ctx, aw, []model.Log{log}, []byte("[]"), BuildloggerOptions{}, download))
+	require.NoError(t, aw.Close())
+	assert.Equal(t, 2, downloads)
+
+	gz, err := gzip.NewReader(buf)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	files := map[string][]byte{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		files[header.Name], err = ioutil.ReadAll(tr)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, expected, files[logArchiveFileName(log, map[string]bool{})])
+}
+
+func TestTarGzLogArchiveWriter(t *testing.T) {
+	for name, test := range map[string]struct {
+		size   int64
+		hasErr bool
+	}{
+		"ExactSize": {size: 4},
+		"Shorter":   {size: 5, hasErr: true},
+		"Longer":    {size: 3},
+	} {
+		t.Run(name, func(t *testing.T) {
+			aw := newTarGzLogArchiveWriter(&bytes.Buffer{})
+			err := aw.WriteFile("file", time.Now(), test.size, strings.NewReader("data"))
+			if test.hasErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.NoError(t, aw.Close())
+			}
+		})
+	}
+}
+
+func (s *buildloggerConnectorSuite) TestFindLogUsageByDate() {
+	usage, err := s.sc.FindLogUsageByDate(s.ctx, time.Date(2021, time.January, 2, 12, 0, 0, 0, time.UTC))
+	s.Require().NoError(err)
+	s.Require().Len(usage, 2)
+	for i, expected := range []model.LogUsage{s.usage[1], s.usage[0]} {
+		apiUsage := restModel.APILogUsage{}
+		s.Require().NoError(apiUsage.Import(expected))
+		s.Equal(apiUsage, usage[i])
+	}
+
+	usage, err = s.sc.FindLogUsageByDate(s.ctx, time.Date(2021, time.January, 4, 0, 0, 0, 0, time.UTC))
+	s.Require().NoError(err)
+	s.Empty(usage)
+}
+
+func (s *buildloggerConnectorSuite) TestSearchLogsByTaskIDExists() {
+	for id, log := range s.logs {
+		it, err := log.Download(s.ctx, model.TimeRange{EndAt: time.Now().Add(24 * time.Hour)})
+		s.Require().NoError(err)
+		s.Require().True(it.Next(s.ctx))
+		line := it.Item()
+		s.Require().NoError(it.Close())
+
+		opts := BuildloggerOptions{
+			TaskID:      log.Info.TaskID,
+			Execution:   log.Info.Execution,
+			ProcessName: log.Info.ProcessName,
+			Tags:        log.Info.Tags,
+			TimeRange:   model.TimeRange{EndAt: time.Now().Add(24 * time.Hour)},
+		}
+		searchOpts := model.LogSearchOptions{
+			Query:        line.Data[:50],
+			ContextLines: 2,
+			MaxMatches:   10,
+		}
+		results, err := s.sc.SearchLogsByTaskID(s.ctx, opts, searchOpts)
+		s.Require().NoError(err)
+		s.False(results.Truncated)
+		s.Require().Len(results.Matches, 1)
+		s.Equal(id, *results.Matches[0].LogID)
+		s.Equal(log.Info.ProcessName, *results.Matches[0].ProcessName)
+		s.Equal(line.Data, *results.Matches[0].Line.Data)
+		s.Empty(results.Matches[0].Before)
+		s.Len(results.Matches[0].After, 2)
+	}
+}
+
+func (s *buildloggerConnectorSuite) TestSearchLogsByTaskIDDNE() {
+	results, err := s.sc.SearchLogsByTaskID(s.ctx, BuildloggerOptions{TaskID: "DNE"}, model.LogSearchOptions{Query: "failed", MaxMatches: 10})
+	s.Error(err)
+	s.Nil(results)
+}
+
+func (s *buildloggerConnectorSuite) TestSearchLogsByTaskIDInvalidOptions() {
+	results, err := s.sc.SearchLogsByTaskID(s.ctx, BuildloggerOptions{TaskID: "task1"}, model.LogSearchOptions{Query: "(", Regex: true, MaxMatches: 10})
+	s.Error(err)
+	s.Nil(results)
+}
+
+func (s *buildloggerConnectorSuite) TestDiffLogsByTaskIDExists() {
+	var base, compare model.Log
+	for _, log := range s.logs {
+		if log.Info.TaskID != "task1" || log.Info.TestName != "test0" || log.Info.ProcessName != "mongod0" {
+			continue
+		}
+		if log.Info.Execution == 0 {
+			base = log
+		} else {
+			compare = log
+		}
+	}
+	s.Require().NotEmpty(base.ID)
+	s.Require().NotEmpty(compare.ID)
+
+	opts := BuildloggerOptions{
+		TaskID:      "task1",
+		TestName:    "test0",
+		ProcessName: "mongod0",
+		Execution:   1,
+	}
+	diffOpts := model.LogDiffOptions{ContextLines: 1}
+	r, err := s.sc.DiffLogsByTaskID(s.ctx, opts, 0, diffOpts)
+	s.Require().NoError(err)
+	data, err := ioutil.ReadAll(r)
+	s.Require().NoError(err)
+
+	baseIt, err := base.Download(s.ctx, model.TimeRange{EndAt: time.Now()})
+	s.Require().NoError(err)
+	compareIt, err := compare.Download(s.ctx, model.TimeRange{EndAt: time.Now()})
+	s.Require().NoError(err)
+	expected, err := model.NewLogDiffReader(s.ctx, baseIt, compareIt, diffOpts)
+	s.Require().NoError(err)
+	expectedData, err := ioutil.ReadAll(expected)
+	s.Require().NoError(err)
+	s.NotEmpty(expectedData)
+	s.Equal(expectedData, data)
+
+	r, err = s.sc.DiffLogsByTaskID(s.ctx, opts, 1, diffOpts)
+	s.Require().NoError(err)
+	data, err = ioutil.ReadAll(r)
+	s.Require().NoError(err)
+	s.Empty(data)
+}
+
+func (s *buildloggerConnectorSuite) TestDiffLogsByTaskIDDNE() {
+	r, err := s.sc.DiffLogsByTaskID(s.ctx, BuildloggerOptions{TaskID: "DNE"}, 0, model.LogDiffOptions{})
+	s.Error(err)
+	s.Nil(r)
+
+	r, err = s.sc.DiffLogsByTaskID(s.ctx, BuildloggerOptions{TaskID: "task1", Execution: 1}, 5, model.LogDiffOptions{})
+	s.Error(err)
+	s.Nil(r)
+}
+
+func (s *buildloggerConnectorSuite) TestDiffLogsByTaskIDInvalidOptions() {
+	r, err := s.sc.DiffLogsByTaskID(s.ctx, BuildloggerOptions{TaskID: "task1"}, 1, model.LogDiffOptions{Normalizers: []string{"("}})
+	s.Error(err)
+	s.Nil(r)
+}
+
+func (s *buildloggerConnectorSuite) TestFindLogFailuresByTaskIDExists() {
+	failures, err := s.sc.FindLogFailuresByTaskID(s.ctx, BuildloggerOptions{TaskID: "task1", Execution: 1})
+	s.Require().NoError(err)
+	s.Len(failures, 4)
+	for i, failure := range failures {
+		log, ok := s.logs[utility.FromStringPtr(failure.LogID)]
+		s.Require().True(ok)
+		s.Equal(1, log.Info.Execution)
+		s.NotZero(log.Info.ExitCode)
+		s.Require().Len(failure.Signatures, 1)
+		s.Equal("sig1", utility.FromStringPtr(failure.Signatures[0].ID))
+		if i > 0 {
+			s.True(s.logs[utility.FromStringPtr(failures[i-1].LogID)].CreatedAt.Before(log.CreatedAt))
+		}
+	}
+
+	failures, err = s.sc.FindLogFailuresByTaskID(s.ctx, BuildloggerOptions{TaskID: "task1", Execution: 1, ProcessName: "mongod1"})
+	s.Require().NoError(err)
+	s.Len(failures, 2)
+
+	failures, err = s.sc.FindLogFailuresByTaskID(s.ctx, BuildloggerOptions{TaskID: "task1", EmptyExecution: true})
+	s.Require().NoError(err)
+	s.Empty(failures)
+}
+
+func (s *buildloggerConnectorSuite) TestFindLogFailuresByTaskIDDNE() {
+	failures, err := s.sc.FindLogFailuresByTaskID(s.ctx, BuildloggerOptions{TaskID: "DNE"})
+	s.Error(err)
+	s.Nil(failures)
+}
+
+func (s *buildloggerConnectorSuite) TestFindLogsByFailureSignatureExists() {
+	opts := model.LogFailureSignatureFindOptions{
+		Project:     "test",
+		SignatureID: "sig1",
+		Since:       time.Now().Add(-24 * time.Hour),
+	}
+	matches, err := s.sc.FindLogsByFailureSignature(s.ctx, opts)
+	s.Require().NoError(err)
+	s.Len(matches, 5)
+	for i, match := range matches {
+		log, ok := s.logs[utility.FromStringPtr(match.LogID)]
+		s.Require().True(ok)
+		s.NotZero(log.Info.ExitCode)
+		if i > 0 {
+			s.True(time.Time(match.CreatedAt).Before(time.Time(matches[i-1].CreatedAt)))
+		}
+	}
+
+	opts.Limit = 2
+	matches, err = s.sc.FindLogsByFailureSignature(s.ctx, opts)
+	s.Require().NoError(err)
+	s.Len(matches, 2)
+
+	opts.Limit = 0
+	opts.ExcludeTaskID = "task1"
+	matches, err = s.sc.FindLogsByFailureSignature(s.ctx, opts)
+	s.Require().NoError(err)
+	s.Empty(matches)
+
+	opts.ExcludeTaskID = ""
+	opts.SignatureID = "DNE"
+	matches, err = s.sc.FindLogsByFailureSignature(s.ctx, opts)
+	s.Require().NoError(err)
+	s.Empty(matches)
+}
+
+func (s *buildloggerConnectorSuite) TestFindLogsByFailureSignatureInvalidOptions() {
+	matches, err := s.sc.FindLogsByFailureSignature(s.ctx, model.LogFailureSignatureFindOptions{SignatureID: "sig1"})
+	s.Error(err)
+	s.Nil(matches)
+}
+
 func (s *buildloggerConnectorSuite) TestFindLogsByTestNameExists() {
 	for _, printTime := range []bool{true, false} {
 		opts := model.LogFindOptions{
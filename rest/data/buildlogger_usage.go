@@ -0,0 +1,70 @@
+package data
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	dbModel "github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/cedar/rest/model"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/pkg/errors"
+)
+
+/////////////////////////////
+// DBConnector Implementation
+/////////////////////////////
+
+func (dbc *DBConnector) FindLogUsageByDate(ctx context.Context, date time.Time) ([]model.APILogUsage, error) {
+	usage, err := dbModel.FindLogUsageByDate(ctx, dbc.env, date)
+	if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrap(err, "finding log usage").Error(),
+		}
+	}
+
+	return importLogUsage(usage)
+}
+
+///////////////////////////////
+// MockConnector Implementation
+///////////////////////////////
+
+func (mc *MockConnector) FindLogUsageByDate(ctx context.Context, date time.Time) ([]model.APILogUsage, error) {
+	date = date.UTC().Truncate(24 * time.Hour)
+	usage := []dbModel.LogUsage{}
+	for _, u := range mc.CachedLogUsage {
+		if u.Date.Equal(date) {
+			usage = append(usage, u)
+		}
+	}
+	sort.SliceStable(usage, func(i, j int) bool {
+		if usage[i].Bytes != usage[j].Bytes {
+			return usage[i].Bytes > usage[j].Bytes
+		}
+		return usage[i].Project < usage[j].Project
+	})
+
+	apiUsage, err := importLogUsage(usage)
+	if err != nil {
+		return nil, err
+	}
+
+	return apiUsage, ctx.Err()
+}
+
+func importLogUsage(usage []dbModel.LogUsage) ([]model.APILogUsage, error) {
+	apiUsage := make([]model.APILogUsage, len(usage))
+	for i, u := range usage {
+		if err := apiUsage[i].Import(u); err != nil {
+			return nil, gimlet.ErrorResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    errors.Wrapf(err, "corrupt data for log usage of project '%s'", u.Project).Error(),
+			}
+		}
+	}
+
+	return apiUsage, nil
+}
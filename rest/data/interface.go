@@ -2,10 +2,12 @@ package data
 
 import (
 	"context"
+	"io"
 	"time"
 
 	dbModel "github.com/evergreen-ci/cedar/model"
 	"github.com/evergreen-ci/cedar/rest/model"
+	"github.com/mongodb/grip/level"
 )
 
 // Connector abstracts the link between cedar's service and API layers,
@@ -22,9 +24,26 @@ type Connector interface {
 	// returned is the next timestamp for pagination and the bool indicates
 	// whether the log is paginated or not. If the log is not paginated,
 	// the timestamp should be ignored.
-	// ID, PrintTime, PrintPriority, TimeRange, Limit, and SoftSizeLimit
-	// are respected from BuildloggerOptions.
+	// ID, FieldFilters, MinPriority, MaxPriority, PrintTime,
+	// PrintPriority, NDJSON, TimeRange, Limit, and SoftSizeLimit are
+	// respected from BuildloggerOptions.
 	FindLogByID(context.Context, BuildloggerOptions) ([]byte, time.Time, bool, error)
+	// FindLogLinesByID returns the lines of the buildlogger log with the
+	// given ID starting at the zero-based line number FromLine. The int
+	// returned is the line number of the next page, or zero if there are
+	// no more lines, and the bool indicates whether the log is paginated
+	// or not.
+	// ID, FromLine, FieldFilters, MinPriority, MaxPriority, PrintTime,
+	// PrintPriority, NDJSON, Limit, and SoftSizeLimit are respected from
+	// BuildloggerOptions.
+	FindLogLinesByID(context.Context, BuildloggerOptions) ([]byte, int, bool, error)
+	// FollowLogByID returns a reader that streams the lines of the
+	// buildlogger log with the given ID as they are appended, until the
+	// log is closed.
+	// ID, FieldFilters, MinPriority, MaxPriority, PrintTime,
+	// PrintPriority, NDJSON, and TimeRange.StartAt are respected from
+	// BuildloggerOptions.
+	FollowLogByID(context.Context, BuildloggerOptions) (io.Reader, error)
 	// FindLogMetadataByID returns the metadata for the buildlogger log
 	// with the given ID.
 	FindLogMetadataByID(context.Context, string) (*model.APILog, error)
@@ -32,21 +51,57 @@ type Connector interface {
 	// id. The time returned is the next timestamp for pagination and the
 	// bool indicates whether the logs are paginated or not. If the logs
 	// are not paginated, the timestamp should be ignored.
-	// TaskID, ProcessName, Execution, Tags, TimeRange, PrintTime,
-	// PrintPriority, Limit, Tail, and SoftSizeLimit are respected from
+	// TaskID, ProcessName, Execution, Tags, TimeRange, FieldFilters,
+	// MinPriority, MaxPriority, PrintTime, PrintPriority, PrintSource,
+	// NDJSON, Limit, Tail, and SoftSizeLimit are respected from
 	// BuildloggerOptions.
 	FindLogsByTaskID(context.Context, BuildloggerOptions) ([]byte, time.Time, bool, error)
 	// FindLogsByTaskID returns the metadata for the buildlogger logs with
 	// the given task ID and tags.
 	FindLogMetadataByTaskID(context.Context, BuildloggerOptions) ([]model.APILog, error)
+	// FindLogProcessesByTaskID returns the processes of the buildlogger
+	// logs with the given task ID, sorted by start time. Only the logs'
+	// metadata is read.
+	// TaskID, ProcessName, Execution, and Tags are respected from
+	// BuildloggerOptions.
+	FindLogProcessesByTaskID(context.Context, BuildloggerOptions) ([]model.APILogProcess, error)
+	// FindLogArchiveByTaskID returns a reader that streams an archive,
+	// with the given compression, of the buildlogger logs with the given
+	// task ID. The archive holds one file per log and a manifest of the
+	// logs' metadata.
+	// TaskID, ProcessName, Execution, Tags, PrintTime, and PrintPriority
+	// are respected from BuildloggerOptions.
+	FindLogArchiveByTaskID(context.Context, BuildloggerOptions, dbModel.FileCompression) (io.Reader, error)
+	// SearchLogsByTaskID searches the lines of the buildlogger logs with
+	// the given task ID and returns the matching lines, sorted by
+	// timestamp.
+	// TaskID, ProcessName, Execution, Tags, and TimeRange are respected
+	// from BuildloggerOptions.
+	SearchLogsByTaskID(context.Context, BuildloggerOptions, dbModel.LogSearchOptions) (*model.APILogSearchResults, error)
+	// DiffLogsByTaskID returns a reader that streams the line-level diff
+	// of the merged buildlogger logs with the given task ID of the given
+	// base execution and of the execution of the BuildloggerOptions.
+	// TaskID, TestName, ProcessName, Execution, and Tags are respected
+	// from BuildloggerOptions.
+	DiffLogsByTaskID(context.Context, BuildloggerOptions, int, dbModel.LogDiffOptions) (io.Reader, error)
+	// FindLogFailuresByTaskID returns the failure signatures extracted
+	// from the buildlogger logs with the given task ID, sorted by the
+	// logs' start time. Logs without failure signatures are omitted.
+	// TaskID, ProcessName, Execution, and Tags are respected from
+	// BuildloggerOptions.
+	FindLogFailuresByTaskID(context.Context, BuildloggerOptions) ([]model.APILogFailures, error)
+	// FindLogsByFailureSignature returns the buildlogger logs with the
+	// given failure signature, sorted by descending creation time.
+	FindLogsByFailureSignature(context.Context, dbModel.LogFailureSignatureFindOptions) ([]model.APILogFailureMatch, error)
 	// FindLogsByTestName returns the buildlogger logs with the given task
 	// ID and test name. The time returned is the next timestamp for
 	// pagination and the bool indicates whether the logs are paginated
 	// or not. If the logs are not paginated, the timestamp should be
 	// ignored.
 	// TaskID, TestName, ProcessName, Execution, Tags, TimeRange,
-	// PrintTime, PrintPriority, Limit, and SoftSizeLimit are respected
-	// from BuildloggerOptions.
+	// FieldFilters, MinPriority, MaxPriority, PrintTime, PrintPriority,
+	// PrintSource, NDJSON, Limit, and SoftSizeLimit are respected from
+	// BuildloggerOptions.
 	FindLogsByTestName(context.Context, BuildloggerOptions) ([]byte, time.Time, bool, error)
 	// FindLogsByTestName returns the metadata for the buildlogger logs
 	// with the given task ID, test name, and tags.
@@ -57,10 +112,15 @@ type Connector interface {
 	// id. The time returned is the next timestamp for pagination and the
 	// bool indicates whether the logs are paginated or not. If the logs
 	// are not paginated, the timestamp should be ignored.
-	// TaskID, TestName, Execution, Tags, TimeRange, PrintTime,
-	// PrintPriority, Limit, and SoftSizeLimit are respected from
+	// TaskID, TestName, Execution, Tags, TimeRange, FieldFilters,
+	// MinPriority, MaxPriority, PrintTime, PrintPriority, PrintSource,
+	// NDJSON, Limit, and SoftSizeLimit are respected from
 	// BuildloggerOptions.
 	FindGroupedLogs(context.Context, BuildloggerOptions) ([]byte, time.Time, bool, error)
+	// FindLogUsageByDate returns the buildlogger usage of every project
+	// with usage on the UTC day of the given time, sorted by descending
+	// size.
+	FindLogUsageByDate(context.Context, time.Time) ([]model.APILogUsage, error)
 
 	///////////////
 	// Test Results
@@ -68,6 +128,12 @@ type Connector interface {
 	// FindTestResults returns the merged test results of the given tasks
 	// and optional filter, sort, and pagination options.
 	FindTestResults(context.Context, []TestResultsTaskOptions, *TestResultsFilterAndSortOptions) (*model.APITestResults, error)
+	// ExportTestResults returns a reader streaming the test results of the
+	// given tasks, one task at a time, and optional filter and sort options
+	// in the given export format, either TestResultsExportJUnit or
+	// TestResultsExportCSV. Results are sorted within each task and cannot
+	// be paginated.
+	ExportTestResults(context.Context, []TestResultsTaskOptions, *TestResultsFilterAndSortOptions, string) (io.Reader, error)
 	// FindTestResultsStats returns basic aggregated stats of test results
 	// results for the given tasks.
 	FindTestResultsStats(context.Context, []TestResultsTaskOptions) (*model.APITestResultsStats, error)
@@ -77,6 +143,15 @@ type Connector interface {
 	// FindFailedTestResultsSamples returns failed test result samples for
 	// the given tasks and optional regex filters.
 	FindFailedTestResultsSamples(context.Context, []TestResultsTaskOptions, []string) ([]model.APITestResultsSample, error)
+	// FindTestHistory returns the per task execution history of a test,
+	// most recent first.
+	FindTestHistory(context.Context, TestHistoryOptions) ([]model.APITestHistoryEntry, error)
+	// FindFlakyTests returns the flaky tests found by the last flaky test
+	// detection, most flaky first.
+	FindFlakyTests(context.Context, FlakyTestsOptions) ([]model.APIFlakyTest, error)
+	// ImportTestReport saves the results of a test report as the closed
+	// test results of a task execution and returns the created record.
+	ImportTestReport(context.Context, TestReportOptions) (*model.APITestReportImport, error)
 }
 
 // BuildloggerOptions contains arguments for buildlogger related Connector
@@ -92,8 +167,14 @@ type BuildloggerOptions struct {
 	Group          string
 	Tags           []string
 	TimeRange      dbModel.TimeRange
+	FromLine       int
+	FieldFilters   []dbModel.LogFieldFilter
+	MinPriority    level.Priority
+	MaxPriority    level.Priority
 	PrintTime      bool
 	PrintPriority  bool
+	PrintSource    bool
+	NDJSON         bool
 	Limit          int
 	Tail           int
 	SoftSizeLimit  int
@@ -131,6 +212,46 @@ type TestResultsFilterAndSortOptions struct {
 	SortOrderDSC bool   `json:"sort_order_dsc"`
 }
 
+// TestHistoryOptions specify the arguments for fetching the history of a
+// test using the Connector functions.
+type TestHistoryOptions struct {
+	Project      string
+	Variant      string
+	TaskName     string
+	TestName     string
+	Mainline     bool
+	TimeRange    dbModel.TimeRange
+	StartVersion string
+	EndVersion   string
+	Limit        int
+}
+
+// FlakyTestsOptions specify the arguments for fetching flaky tests using the
+// Connector functions.
+type FlakyTestsOptions struct {
+	Project  string
+	Variant  string
+	TaskName string
+	MinScore float64
+	Limit    int
+}
+
+// TestReportOptions specify the task execution and test report to import
+// using the Connector functions.
+type TestReportOptions struct {
+	Format          string
+	Project         string
+	Version         string
+	Variant         string
+	TaskName        string
+	DisplayTaskName string
+	TaskID          string
+	Execution       int
+	RequestType     string
+	Mainline        bool
+	Report          []byte
+}
+
 // PerformanceOptions holds all values required to find a specific
 // PerformanceResult or PerformanceResults using connector functions.
 type PerformanceOptions struct {
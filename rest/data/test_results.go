@@ -1,12 +1,17 @@
 package data
 
 import (
+	"bytes"
 	"context"
+	"io"
 	"net/http"
 
 	dbModel "github.com/evergreen-ci/cedar/model"
 	"github.com/evergreen-ci/cedar/rest/model"
+	"github.com/evergreen-ci/cedar/units"
 	"github.com/evergreen-ci/gimlet"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
 	"github.com/pkg/errors"
 )
 
@@ -46,6 +51,29 @@ func (dbc *DBConnector) FindTestResults(ctx context.Context, taskOpts []TestResu
 	}, nil
 }
 
+func (dbc *DBConnector) ExportTestResults(ctx context.Context, taskOpts []TestResultsTaskOptions, filterOpts *TestResultsFilterAndSortOptions, format string) (io.Reader, error) {
+	dbFilterOpts, err := convertToDBTestResultsFilterAndSortOptions(filterOpts)
+	if err != nil {
+		return nil, err
+	}
+	if dbFilterOpts != nil && dbFilterOpts.Limit > 0 {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "cannot paginate exported test results",
+		}
+	}
+
+	return newTestResultsExportReader(ctx, format, dbc.baseURL, func(w testResultsExportWriter) error {
+		return dbModel.ExportTestResults(ctx, dbc.env, convertToDBTestResultsTaskOptions(taskOpts), dbFilterOpts, func(results []dbModel.TestResult) error {
+			apiResults, err := importTestResults(ctx, results)
+			if err != nil {
+				return err
+			}
+			return w.writeTask(apiResults)
+		})
+	})
+}
+
 func (dbc *DBConnector) FindTestResultsStats(ctx context.Context, opts []TestResultsTaskOptions) (*model.APITestResultsStats, error) {
 	stats, err := dbModel.FindTestResultsStats(ctx, dbc.env, convertToDBTestResultsTaskOptions(opts))
 	if err != nil {
@@ -90,6 +118,137 @@ func (dbc *DBConnector) FindFailedTestResultsSamples(ctx context.Context, taskOp
 	return importTestResultsSamples(samples)
 }
 
+func (dbc *DBConnector) FindTestHistory(ctx context.Context, opts TestHistoryOptions) ([]model.APITestHistoryEntry, error) {
+	dbOpts := dbModel.TestHistoryOptions{
+		Project:      opts.Project,
+		Variant:      opts.Variant,
+		TaskName:     opts.TaskName,
+		TestName:     opts.TestName,
+		Mainline:     opts.Mainline,
+		TimeRange:    opts.TimeRange,
+		StartVersion: opts.StartVersion,
+		EndVersion:   opts.EndVersion,
+		Limit:        opts.Limit,
+	}
+	if err := dbOpts.Validate(); err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "invalid test history options").Error(),
+		}
+	}
+
+	entries, err := dbModel.FindTestHistory(ctx, dbc.env, dbOpts)
+	if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrap(err, "retrieving test history").Error(),
+		}
+	}
+
+	apiEntries := make([]model.APITestHistoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		apiEntry := model.APITestHistoryEntry{}
+		if err = apiEntry.Import(entry); err != nil {
+			return nil, gimlet.ErrorResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    errors.Wrap(err, "importing entry into APITestHistoryEntry struct").Error(),
+			}
+		}
+		apiEntries = append(apiEntries, apiEntry)
+	}
+
+	return apiEntries, nil
+}
+
+func (dbc *DBConnector) FindFlakyTests(ctx context.Context, opts FlakyTestsOptions) ([]model.APIFlakyTest, error) {
+	dbOpts := dbModel.FlakyTestsOptions{
+		Project:  opts.Project,
+		Variant:  opts.Variant,
+		TaskName: opts.TaskName,
+		MinScore: opts.MinScore,
+		Limit:    opts.Limit,
+	}
+	if err := dbOpts.Validate(); err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "invalid flaky tests options").Error(),
+		}
+	}
+
+	flakyTests, err := dbModel.FindFlakyTests(ctx, dbc.env, dbOpts)
+	if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrap(err, "retrieving flaky tests").Error(),
+		}
+	}
+
+	apiFlakyTests := make([]model.APIFlakyTest, 0, len(flakyTests))
+	for _, flakyTest := range flakyTests {
+		apiFlakyTest := model.APIFlakyTest{}
+		if err = apiFlakyTest.Import(flakyTest); err != nil {
+			return nil, gimlet.ErrorResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    errors.Wrap(err, "importing flaky test into APIFlakyTest struct").Error(),
+			}
+		}
+		apiFlakyTests = append(apiFlakyTests, apiFlakyTest)
+	}
+
+	return apiFlakyTests, nil
+}
+
+func (dbc *DBConnector) ImportTestReport(ctx context.Context, opts TestReportOptions) (*model.APITestReportImport, error) {
+	report, err := dbModel.ParseTestReport(dbModel.TestReportFormat(opts.Format), bytes.NewReader(opts.Report))
+	if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "invalid test report").Error(),
+		}
+	}
+
+	record, err := report.Import(ctx, dbc.env, dbModel.TestResultsInfo{
+		Project:         opts.Project,
+		Version:         opts.Version,
+		Variant:         opts.Variant,
+		TaskName:        opts.TaskName,
+		DisplayTaskName: opts.DisplayTaskName,
+		TaskID:          opts.TaskID,
+		Execution:       opts.Execution,
+		RequestType:     opts.RequestType,
+		Mainline:        opts.Mainline,
+	})
+	if dbModel.IsTestResultsExist(err) {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusConflict,
+			Message:    errors.Errorf("test results of task '%s' execution %d already exist", opts.TaskID, opts.Execution).Error(),
+		}
+	}
+	if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrap(err, "importing test report").Error(),
+		}
+	}
+
+	if queue := dbc.env.GetRemoteQueue(); queue != nil && record.Artifact.Version == 2 {
+		grip.Warning(message.WrapError(queue.Put(ctx, units.NewCompactTestResultsPartsJob(dbc.env, record.ID)), message.Fields{
+			"message":         "could not enqueue test results parts compaction job",
+			"test_results_id": record.ID,
+		}))
+	}
+
+	apiImport := &model.APITestReportImport{}
+	if err = apiImport.Import(*record); err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrap(err, "importing test results record into APITestReportImport struct").Error(),
+		}
+	}
+
+	return apiImport, nil
+}
+
 ///////////////////////////////
 // MockConnector Implementation
 ///////////////////////////////
@@ -98,6 +257,10 @@ func (mc *MockConnector) FindTestResults(_ context.Context, _ []TestResultsTaskO
 	return nil, errors.New("not implemented")
 }
 
+func (mc *MockConnector) ExportTestResults(_ context.Context, _ []TestResultsTaskOptions, _ *TestResultsFilterAndSortOptions, _ string) (io.Reader, error) {
+	return nil, errors.New("not implemented")
+}
+
 func (mc *MockConnector) FindTestResultsStats(_ context.Context, _ []TestResultsTaskOptions) (*model.APITestResultsStats, error) {
 	return nil, errors.New("not implemented")
 }
@@ -109,6 +272,18 @@ func (mc *MockConnector) FindFailedTestResultsSamples(ctx context.Context, _ []T
 	return nil, errors.New("not implemented")
 }
 
+func (mc *MockConnector) FindTestHistory(_ context.Context, _ TestHistoryOptions) ([]model.APITestHistoryEntry, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (mc *MockConnector) FindFlakyTests(_ context.Context, _ FlakyTestsOptions) ([]model.APIFlakyTest, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (mc *MockConnector) ImportTestReport(_ context.Context, _ TestReportOptions) (*model.APITestReportImport, error) {
+	return nil, errors.New("not implemented")
+}
+
 ///////////////////
 // Helper Functions
 ///////////////////
@@ -0,0 +1,359 @@
+package data
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/cedar/rest/model"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip/recovery"
+	"github.com/pkg/errors"
+)
+
+// Test results export formats.
+const (
+	TestResultsExportJSON  = "json"
+	TestResultsExportJUnit = "junit"
+	TestResultsExportCSV   = "csv"
+)
+
+// testResultsExportWriter writes test results in an export format, one task
+// execution at a time, so that large exports are not buffered.
+type testResultsExportWriter interface {
+	// writeTask writes the test results of a single task execution.
+	writeTask([]model.APITestResult) error
+	// close writes the end of the export.
+	close() error
+}
+
+// newTestResultsExportWriter returns a writer of the given export format
+// writing to w. Nothing is written until the first call to the returned
+// writer.
+func newTestResultsExportWriter(format string, w io.Writer, baseURL string) (testResultsExportWriter, error) {
+	switch format {
+	case TestResultsExportJUnit:
+		return &junitExportWriter{w: w, baseURL: baseURL}, nil
+	case TestResultsExportCSV:
+		return &csvExportWriter{cw: csv.NewWriter(w), baseURL: baseURL}, nil
+	default:
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("unsupported test results export format '%s'", format),
+		}
+	}
+}
+
+// newTestResultsExportReader returns a reader streaming the test results
+// exported in the given format by the given function. The export is written
+// as it is read.
+func newTestResultsExportReader(ctx context.Context, format, baseURL string, export func(testResultsExportWriter) error) (io.Reader, error) {
+	pr, pw := newContextPipe(ctx)
+	w, err := newTestResultsExportWriter(format, pw, baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		var err error
+		defer func() {
+			err = recovery.HandlePanicWithError(recover(), err, "writing test results export")
+			_ = pw.CloseWithError(err)
+		}()
+
+		if err = export(w); err != nil {
+			return
+		}
+		err = w.close()
+	}()
+
+	return pr, nil
+}
+
+// testResultLogURL returns the URL of the log of the given test result: the
+// buildlogger log of the test, if any, or its legacy log URL.
+func testResultLogURL(baseURL string, result model.APITestResult) string {
+	if result.LogInfo != nil && utility.FromStringPtr(result.LogInfo.LogName) != "" {
+		return fmt.Sprintf("%s/rest/v1/buildlogger/test_name/%s/%s?execution=%d",
+			strings.TrimSuffix(baseURL, "/"),
+			url.PathEscape(utility.FromStringPtr(result.TaskID)),
+			url.PathEscape(utility.FromStringPtr(result.LogInfo.LogName)),
+			result.Execution,
+		)
+	}
+	return utility.FromStringPtr(result.LogURL)
+}
+
+func testResultDisplayName(result model.APITestResult) string {
+	if result.DisplayTestName != nil {
+		return *result.DisplayTestName
+	}
+	return utility.FromStringPtr(result.TestName)
+}
+
+// testResultStatusContains returns whether the status of the test result
+// contains the given status, e.g. "fail" for both failed and silently failed
+// tests.
+func testResultStatusContains(result model.APITestResult, status string) bool {
+	return strings.Contains(strings.ToLower(utility.FromStringPtr(result.Status)), status)
+}
+
+func testResultDuration(result model.APITestResult) time.Duration {
+	return time.Time(result.TestEndTime).Sub(time.Time(result.TestStartTime))
+}
+
+func formatTestResultsExportSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+func formatTestResultsExportTime(t model.APITime) string {
+	if time.Time(t).IsZero() {
+		return ""
+	}
+	return time.Time(t).UTC().Format(time.RFC3339Nano)
+}
+
+//////
+// CSV
+//////
+
+var testResultsCSVHeader = []string{
+	"task_id",
+	"execution",
+	"test_name",
+	"display_test_name",
+	"group_id",
+	"trial",
+	"status",
+	"test_start_time",
+	"test_end_time",
+	"duration_secs",
+	"log_url",
+	"raw_log_url",
+}
+
+type csvExportWriter struct {
+	cw            *csv.Writer
+	baseURL       string
+	headerWritten bool
+}
+
+func (w *csvExportWriter) writeHeader() error {
+	if w.headerWritten {
+		return nil
+	}
+	w.headerWritten = true
+
+	return errors.Wrap(w.cw.Write(testResultsCSVHeader), "writing CSV header")
+}
+
+func (w *csvExportWriter) writeTask(results []model.APITestResult) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	for _, result := range results {
+		if err := w.cw.Write([]string{
+			utility.FromStringPtr(result.TaskID),
+			strconv.Itoa(result.Execution),
+			utility.FromStringPtr(result.TestName),
+			testResultDisplayName(result),
+			utility.FromStringPtr(result.GroupID),
+			strconv.Itoa(result.Trial),
+			utility.FromStringPtr(result.Status),
+			formatTestResultsExportTime(result.TestStartTime),
+			formatTestResultsExportTime(result.TestEndTime),
+			formatTestResultsExportSeconds(testResultDuration(result)),
+			testResultLogURL(w.baseURL, result),
+			utility.FromStringPtr(result.RawLogURL),
+		}); err != nil {
+			return errors.Wrapf(err, "writing CSV record of test '%s'", utility.FromStringPtr(result.TestName))
+		}
+	}
+	w.cw.Flush()
+
+	return errors.Wrap(w.cw.Error(), "flushing CSV")
+}
+
+func (w *csvExportWriter) close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.cw.Flush()
+
+	return errors.Wrap(w.cw.Error(), "flushing CSV")
+}
+
+////////
+// JUnit
+////////
+
+type junitExportTestCase struct {
+	XMLName    xml.Name              `xml:"testcase"`
+	Name       string                `xml:"name,attr"`
+	ClassName  string                `xml:"classname,attr,omitempty"`
+	Time       string                `xml:"time,attr"`
+	Failure    *junitExportMessage   `xml:"failure,omitempty"`
+	Skipped    *junitExportMessage   `xml:"skipped,omitempty"`
+	Properties []junitExportProperty `xml:"properties>property,omitempty"`
+}
+
+type junitExportMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+type junitExportProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// junitExportSuite is the test suite of the results of a single task
+// execution.
+type junitExportSuite struct {
+	taskID    string
+	execution int
+	results   []model.APITestResult
+	failures  int
+	skipped   int
+	duration  time.Duration
+	timestamp time.Time
+}
+
+// groupJUnitExportSuites groups the results by task execution, in the order
+// the task executions first appear in the results.
+func groupJUnitExportSuites(results []model.APITestResult) []*junitExportSuite {
+	var suites []*junitExportSuite
+	byTask := map[string]*junitExportSuite{}
+	for _, result := range results {
+		taskID := utility.FromStringPtr(result.TaskID)
+		key := fmt.Sprintf("%s_%d", taskID, result.Execution)
+		suite, ok := byTask[key]
+		if !ok {
+			suite = &junitExportSuite{taskID: taskID, execution: result.Execution}
+			byTask[key] = suite
+			suites = append(suites, suite)
+		}
+
+		suite.results = append(suite.results, result)
+		switch {
+		case testResultStatusContains(result, "fail"):
+			suite.failures++
+		case testResultStatusContains(result, "skip"):
+			suite.skipped++
+		}
+		suite.duration += testResultDuration(result)
+		start := time.Time(result.TestStartTime)
+		if !start.IsZero() && (suite.timestamp.IsZero() || start.Before(suite.timestamp)) {
+			suite.timestamp = start
+		}
+	}
+
+	return suites
+}
+
+func exportJUnitTestCase(baseURL string, result model.APITestResult) junitExportTestCase {
+	status := utility.FromStringPtr(result.Status)
+	testCase := junitExportTestCase{
+		Name:      testResultDisplayName(result),
+		ClassName: utility.FromStringPtr(result.GroupID),
+		Time:      formatTestResultsExportSeconds(testResultDuration(result)),
+		Properties: []junitExportProperty{
+			{Name: "status", Value: status},
+			{Name: "trial", Value: strconv.Itoa(result.Trial)},
+		},
+	}
+	switch {
+	case testResultStatusContains(result, "fail"):
+		testCase.Failure = &junitExportMessage{Message: status}
+	case testResultStatusContains(result, "skip"):
+		testCase.Skipped = &junitExportMessage{}
+	}
+	if testName := utility.FromStringPtr(result.TestName); testName != testCase.Name {
+		testCase.Properties = append(testCase.Properties, junitExportProperty{Name: "test_name", Value: testName})
+	}
+	if logURL := testResultLogURL(baseURL, result); logURL != "" {
+		testCase.Properties = append(testCase.Properties, junitExportProperty{Name: "log_url", Value: logURL})
+	}
+	if rawLogURL := utility.FromStringPtr(result.RawLogURL); rawLogURL != "" {
+		testCase.Properties = append(testCase.Properties, junitExportProperty{Name: "raw_log_url", Value: rawLogURL})
+	}
+
+	return testCase
+}
+
+// junitExportWriter writes JUnit XML with one test suite per task
+// execution. Test cases are encoded one at a time.
+type junitExportWriter struct {
+	w       io.Writer
+	baseURL string
+	enc     *xml.Encoder
+	root    xml.StartElement
+}
+
+func (w *junitExportWriter) writeHeader() error {
+	if w.enc != nil {
+		return nil
+	}
+
+	if _, err := io.WriteString(w.w, xml.Header); err != nil {
+		return errors.Wrap(err, "writing XML header")
+	}
+	w.enc = xml.NewEncoder(w.w)
+	w.enc.Indent("", "  ")
+	w.root = xml.StartElement{Name: xml.Name{Local: "testsuites"}}
+
+	return errors.Wrap(w.enc.EncodeToken(w.root), "writing test suites")
+}
+
+func (w *junitExportWriter) writeTask(results []model.APITestResult) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+
+	for _, suite := range groupJUnitExportSuites(results) {
+		start := xml.StartElement{
+			Name: xml.Name{Local: "testsuite"},
+			Attr: []xml.Attr{
+				{Name: xml.Name{Local: "name"}, Value: fmt.Sprintf("%s.%d", suite.taskID, suite.execution)},
+				{Name: xml.Name{Local: "tests"}, Value: strconv.Itoa(len(suite.results))},
+				{Name: xml.Name{Local: "failures"}, Value: strconv.Itoa(suite.failures)},
+				{Name: xml.Name{Local: "skipped"}, Value: strconv.Itoa(suite.skipped)},
+				{Name: xml.Name{Local: "time"}, Value: formatTestResultsExportSeconds(suite.duration)},
+			},
+		}
+		if !suite.timestamp.IsZero() {
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "timestamp"}, Value: suite.timestamp.UTC().Format(time.RFC3339)})
+		}
+		if err := w.enc.EncodeToken(start); err != nil {
+			return errors.Wrapf(err, "writing test suite of task '%s'", suite.taskID)
+		}
+		for _, result := range suite.results {
+			if err := w.enc.Encode(exportJUnitTestCase(w.baseURL, result)); err != nil {
+				return errors.Wrapf(err, "writing test case '%s'", utility.FromStringPtr(result.TestName))
+			}
+		}
+		if err := w.enc.EncodeToken(start.End()); err != nil {
+			return errors.Wrapf(err, "writing test suite of task '%s'", suite.taskID)
+		}
+	}
+
+	return errors.Wrap(w.enc.Flush(), "flushing XML")
+}
+
+func (w *junitExportWriter) close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	if err := w.enc.EncodeToken(w.root.End()); err != nil {
+		return errors.Wrap(err, "writing test suites")
+	}
+
+	return errors.Wrap(w.enc.Flush(), "flushing XML")
+}
@@ -1,9 +1,13 @@
 package data
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
+	"encoding/xml"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"testing"
 	"time"
@@ -11,7 +15,10 @@ import (
 	"github.com/evergreen-ci/cedar"
 	dbModel "github.com/evergreen-ci/cedar/model"
 	"github.com/evergreen-ci/cedar/rest/model"
+	"github.com/evergreen-ci/gimlet"
 	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -460,3 +467,176 @@ func (s *testResultsConnectorSuite) TestFindFailedTestResultsSamples() {
 		})
 	}
 }
+
+func (s *testResultsConnectorSuite) TestExportTestResults() {
+	tasks := []TestResultsTaskOptions{
+		{TaskID: "task1", Execution: 0},
+		{TaskID: "task2", Execution: 0},
+	}
+	filter := &TestResultsFilterAndSortOptions{Sort: []TestResultsSortBy{{Key: dbModel.TestResultsSortByTestNameKey}}}
+
+	s.Run("InvalidFormat", func() {
+		r, err := s.sc.ExportTestResults(s.ctx, tasks, filter, "xlsx")
+		s.Require().Error(err)
+		s.Nil(r)
+		errResp, ok := err.(gimlet.ErrorResponse)
+		s.Require().True(ok)
+		s.Equal(http.StatusBadRequest, errResp.StatusCode)
+	})
+	s.Run("Paginated", func() {
+		r, err := s.sc.ExportTestResults(s.ctx, tasks, &TestResultsFilterAndSortOptions{Limit: 2}, TestResultsExportCSV)
+		s.Require().Error(err)
+		s.Nil(r)
+		errResp, ok := err.(gimlet.ErrorResponse)
+		s.Require().True(ok)
+		s.Equal(http.StatusBadRequest, errResp.StatusCode)
+	})
+	s.Run("CSV", func() {
+		r, err := s.sc.ExportTestResults(s.ctx, tasks, filter, TestResultsExportCSV)
+		s.Require().NoError(err)
+		records, err := csv.NewReader(r).ReadAll()
+		s.Require().NoError(err)
+		s.Require().Len(records, 7)
+		s.Equal(testResultsCSVHeader, records[0])
+		for _, record := range records[1:] {
+			s.Contains([]string{"task1", "task2"}, record[0])
+			s.Equal("teststatus-fail", record[6])
+			s.Equal(fmt.Sprintf("/rest/v1/buildlogger/test_name/%s/log0?execution=0", record[0]), record[10])
+		}
+	})
+	s.Run("JUnit", func() {
+		r, err := s.sc.ExportTestResults(s.ctx, tasks, filter, TestResultsExportJUnit)
+		s.Require().NoError(err)
+		var suites struct {
+			Suites []struct {
+				Name     string `xml:"name,attr"`
+				Failures int    `xml:"failures,attr"`
+				Cases    []struct {
+					Name    string    `xml:"name,attr"`
+					Failure *struct{} `xml:"failure"`
+				} `xml:"testcase"`
+			} `xml:"testsuite"`
+		}
+		s.Require().NoError(xml.NewDecoder(r).Decode(&suites))
+		s.Require().Len(suites.Suites, 2)
+		for _, suite := range suites.Suites {
+			s.Contains([]string{"task1.0", "task2.0"}, suite.Name)
+			s.Equal(3, suite.Failures)
+			s.Require().Len(suite.Cases, 3)
+			for _, testCase := range suite.Cases {
+				s.NotNil(testCase.Failure)
+			}
+		}
+	})
+}
+
+func TestTestResultsExportWriters(t *testing.T) {
+	start := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	results := []model.APITestResult{
+		{
+			TaskID:          utility.ToStringPtr("task"),
+			Execution:       1,
+			TestName:        utility.ToStringPtr("test0"),
+			DisplayTestName: utility.ToStringPtr("display test0"),
+			GroupID:         utility.ToStringPtr("group"),
+			Status:          utility.ToStringPtr("fail"),
+			LogInfo:         &model.APITestLogInfo{LogName: utility.ToStringPtr("log/0")},
+			TestStartTime:   model.NewTime(start),
+			TestEndTime:     model.NewTime(start.Add(1500 * time.Millisecond)),
+		},
+		{
+			TaskID:        utility.ToStringPtr("task"),
+			Execution:     1,
+			TestName:      utility.ToStringPtr("test1"),
+			Trial:         1,
+			Status:        utility.ToStringPtr("skip"),
+			LogURL:        utility.ToStringPtr("https://example.com/log"),
+			RawLogURL:     utility.ToStringPtr("https://example.com/log?raw"),
+			TestStartTime: model.NewTime(start.Add(time.Second)),
+			TestEndTime:   model.NewTime(start.Add(2 * time.Second)),
+		},
+		{
+			TaskID:        utility.ToStringPtr("other"),
+			Execution:     0,
+			TestName:      utility.ToStringPtr("test2"),
+			Status:        utility.ToStringPtr("pass"),
+			TestStartTime: model.NewTime(start),
+			TestEndTime:   model.NewTime(start),
+		},
+	}
+
+	t.Run("CSV", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		w, err := newTestResultsExportWriter(TestResultsExportCSV, buf, "https://cedar.mongodb.com/")
+		require.NoError(t, err)
+		require.NoError(t, w.writeTask(results[:2]))
+		require.NoError(t, w.writeTask(results[2:]))
+		require.NoError(t, w.close())
+		records, err := csv.NewReader(buf).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{
+			testResultsCSVHeader,
+			{"task", "1", "test0", "display test0", "group", "0", "fail", "2021-03-04T05:06:07Z", "2021-03-04T05:06:08.5Z", "1.500", "https://cedar.mongodb.com/rest/v1/buildlogger/test_name/task/log%2F0?execution=1", ""},
+			{"task", "1", "test1", "test1", "", "1", "skip", "2021-03-04T05:06:08Z", "2021-03-04T05:06:09Z", "1.000", "https://example.com/log", "https://example.com/log?raw"},
+			{"other", "0", "test2", "test2", "", "0", "pass", "2021-03-04T05:06:07Z", "2021-03-04T05:06:07Z", "0.000", "", ""},
+		}, records)
+	})
+	t.Run("NoResults", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		w, err := newTestResultsExportWriter(TestResultsExportCSV, buf, "")
+		require.NoError(t, err)
+		require.NoError(t, w.close())
+		records, err := csv.NewReader(buf).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{testResultsCSVHeader}, records)
+
+		buf.Reset()
+		w, err = newTestResultsExportWriter(TestResultsExportJUnit, buf, "")
+		require.NoError(t, err)
+		require.NoError(t, w.close())
+		assert.Equal(t, xml.Header+"<testsuites></testsuites>", buf.String())
+	})
+	t.Run("InvalidFormat", func(t *testing.T) {
+		w, err := newTestResultsExportWriter("xlsx", &bytes.Buffer{}, "")
+		assert.Error(t, err)
+		assert.Nil(t, w)
+	})
+	t.Run("JUnit", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		w, err := newTestResultsExportWriter(TestResultsExportJUnit, buf, "https://cedar.mongodb.com")
+		require.NoError(t, err)
+		require.NoError(t, w.writeTask(results[:2]))
+		require.NoError(t, w.writeTask(results[2:]))
+		require.NoError(t, w.close())
+		assert.Equal(t, xml.Header+`<testsuites>
+  <testsuite name="task.1" tests="2" failures="1" skipped="1" time="2.500" timestamp="2021-03-04T05:06:07Z">
+    <testcase name="display test0" classname="group" time="1.500">
+      <failure message="fail"></failure>
+      <properties>
+        <property name="status" value="fail"></property>
+        <property name="trial" value="0"></property>
+        <property name="test_name" value="test0"></property>
+        <property name="log_url" value="https://cedar.mongodb.com/rest/v1/buildlogger/test_name/task/log%2F0?execution=1"></property>
+      </properties>
+    </testcase>
+    <testcase name="test1" time="1.000">
+      <skipped></skipped>
+      <properties>
+        <property name="status" value="skip"></property>
+        <property name="trial" value="1"></property>
+        <property name="log_url" value="https://example.com/log"></property>
+        <property name="raw_log_url" value="https://example.com/log?raw"></property>
+      </properties>
+    </testcase>
+  </testsuite>
+  <testsuite name="other.0" tests="1" failures="0" skipped="0" time="0.000" timestamp="2021-03-04T05:06:07Z">
+    <testcase name="test2" time="0.000">
+      <properties>
+        <property name="status" value="pass"></property>
+        <property name="trial" value="0"></property>
+      </properties>
+    </testcase>
+  </testsuite>
+</testsuites>`, buf.String())
+	})
+}
@@ -1,5 +1,10 @@
 package data
 
+import (
+	"context"
+	"io"
+)
+
 func containsTags(subset, tags []string) bool {
 	if len(subset) == 0 {
 		return true
@@ -19,3 +24,17 @@ func containsTags(subset, tags []string) bool {
 
 	return false
 }
+
+// newContextPipe returns a synchronous in-memory pipe whose reader is closed
+// with the context's error once the context is done, so that a goroutine
+// writing to the pipe does not block forever on a reader abandoned by a
+// disconnected client.
+func newContextPipe(ctx context.Context) (*io.PipeReader, *io.PipeWriter) {
+	pr, pw := io.Pipe()
+	go func() {
+		<-ctx.Done()
+		_ = pr.CloseWithError(ctx.Err())
+	}()
+
+	return pr, pw
+}
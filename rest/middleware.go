@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 
 	"github.com/evergreen-ci/cedar"
 	"github.com/evergreen-ci/cedar/model"
@@ -107,6 +108,36 @@ func (m *evgAuthReadLogByTaskIDMiddleware) ServeHTTP(rw http.ResponseWriter, r *
 	next(rw, r)
 }
 
+type evgAuthReadLogByProjectMiddleware struct {
+	evgConf *model.EvergreenConfig
+}
+
+// newEvgAuthReadLogByProjectMiddleware returns an implementation of
+// gimlet.Middleware that sends a HTTP request to Evergreen to check if the
+// user is authorized to read the logs of the project given by the "project"
+// query parameter.
+func newEvgAuthReadLogByProjectMiddleware(evgConf *model.EvergreenConfig) *evgAuthReadLogByProjectMiddleware {
+	return &evgAuthReadLogByProjectMiddleware{evgConf: evgConf}
+}
+
+func (m *evgAuthReadLogByProjectMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	project := r.URL.Query().Get("project")
+	if project == "" {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "must specify a project",
+		}))
+		return
+	}
+
+	if resp := evgAuthReadLog(r.Context(), r, m.evgConf, project); resp != nil {
+		gimlet.WriteResponse(rw, resp)
+		return
+	}
+
+	next(rw, r)
+}
+
 func evgAuthReadLog(ctx context.Context, r *http.Request, evgConf *model.EvergreenConfig, resourceID string) gimlet.Responder {
 	req, errResp := createEvgAuthRequest(ctx, r, evgConf, resourceID)
 	if errResp != nil {
@@ -127,7 +158,7 @@ func createEvgAuthRequest(ctx context.Context, r *http.Request, evgConf *model.E
 		})
 	}
 
-	urlString := fmt.Sprintf("%s/rest/v2/auth?resource=%s&resource_type=project&permission=project_logs&required_level=10", evgConf.URL, resourceID)
+	urlString := fmt.Sprintf("%s/rest/v2/auth?resource=%s&resource_type=project&permission=project_logs&required_level=10", evgConf.URL, url.QueryEscape(resourceID))
 	req, err := http.NewRequest(http.MethodGet, urlString, nil)
 	if err != nil {
 		return nil, gimlet.MakeJSONInternalErrorResponder(errors.Wrap(err, "creating HTTP request"))
@@ -173,3 +204,53 @@ func doEvgAuthRequest(req *http.Request, resourceID string) gimlet.Responder {
 
 	return nil
 }
+
+type responseHeadersKey struct{}
+
+type responseHeadersMiddleware struct{}
+
+// newResponseHeadersMiddleware returns an implementation of gimlet.Middleware
+// that allows route handlers to set HTTP response headers, which gimlet
+// responders do not support, with setResponseHeader. Headers set by route
+// handlers override those set by gimlet, e.g. the content type.
+func newResponseHeadersMiddleware() *responseHeadersMiddleware {
+	return &responseHeadersMiddleware{}
+}
+
+func (m *responseHeadersMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	header := http.Header{}
+	ctx := context.WithValue(r.Context(), responseHeadersKey{}, header)
+	next(&responseHeadersWriter{ResponseWriter: rw, header: header}, r.WithContext(ctx))
+}
+
+// setResponseHeader sets the HTTP response header of the route handler with
+// the given context. It is a no-op unless the route is wrapped by the response
+// headers middleware.
+func setResponseHeader(ctx context.Context, key, value string) {
+	if header, ok := ctx.Value(responseHeadersKey{}).(http.Header); ok {
+		header.Set(key, value)
+	}
+}
+
+type responseHeadersWriter struct {
+	http.ResponseWriter
+	header      http.Header
+	wroteHeader bool
+}
+
+func (w *responseHeadersWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		for key, values := range w.header {
+			w.ResponseWriter.Header()[key] = values
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseHeadersWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(data)
+}
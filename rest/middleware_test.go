@@ -9,6 +9,7 @@ import (
 
 	"github.com/evergreen-ci/cedar"
 	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/gimlet"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -118,6 +119,84 @@ func TestDoEvgAuthRequest(t *testing.T) {
 
 }
 
+func TestEvgAuthReadLogByProjectMiddleware(t *testing.T) {
+	handler := &evgAuthMockHandler{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	evgConf := &model.EvergreenConfig{
+		URL:             server.URL,
+		AuthTokenCookie: "mci-token",
+		HeaderKeyName:   "Api-Key",
+		HeaderUserName:  "Username",
+	}
+	m := newEvgAuthReadLogByProjectMiddleware(evgConf)
+	serve := func(t *testing.T, target string, authenticated bool) (*httptest.ResponseRecorder, bool) {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if authenticated {
+			req.AddCookie(&http.Cookie{Name: evgConf.AuthTokenCookie, Value: "value"})
+		}
+		rw := httptest.NewRecorder()
+		var called bool
+		m.ServeHTTP(rw, req, func(http.ResponseWriter, *http.Request) { called = true })
+		return rw, called
+	}
+
+	t.Run("NoProject", func(t *testing.T) {
+		handler.returnTrue = true
+		rw, called := serve(t, "/buildlogger/failure_signatures/signature", true)
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		assert.False(t, called)
+		handler.returnTrue = false
+	})
+	t.Run("Unauthenticated", func(t *testing.T) {
+		handler.returnTrue = true
+		rw, called := serve(t, "/buildlogger/failure_signatures/signature?project=project", false)
+		assert.Equal(t, http.StatusUnauthorized, rw.Code)
+		assert.False(t, called)
+		handler.returnTrue = false
+	})
+	t.Run("Unauthorized", func(t *testing.T) {
+		rw, called := serve(t, "/buildlogger/failure_signatures/signature?project=project", true)
+		assert.Equal(t, http.StatusUnauthorized, rw.Code)
+		assert.False(t, called)
+	})
+	t.Run("Authorized", func(t *testing.T) {
+		handler.returnTrue = true
+		_, called := serve(t, "/buildlogger/failure_signatures/signature?project=project", true)
+		assert.True(t, called)
+		handler.returnTrue = false
+	})
+}
+
+func TestResponseHeadersMiddleware(t *testing.T) {
+	m := newResponseHeadersMiddleware()
+	t.Run("SetHeader", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		m.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil), func(rw http.ResponseWriter, r *http.Request) {
+			setResponseHeader(r.Context(), "Content-Type", "text/csv")
+			setResponseHeader(r.Context(), "Content-Disposition", "attachment")
+			gimlet.WriteResponse(rw, gimlet.NewTextResponse("data"))
+		})
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "text/csv", rw.Header().Get("Content-Type"))
+		assert.Equal(t, "attachment", rw.Header().Get("Content-Disposition"))
+		assert.Equal(t, "data", rw.Body.String())
+	})
+	t.Run("NoHeader", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		m.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil), func(rw http.ResponseWriter, r *http.Request) {
+			gimlet.WriteResponse(rw, gimlet.NewTextResponse("data"))
+		})
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "text/plain; charset=utf-8", rw.Header().Get("Content-Type"))
+	})
+	t.Run("WithoutMiddleware", func(t *testing.T) {
+		assert.NotPanics(t, func() {
+			setResponseHeader(context.Background(), "Content-Type", "text/csv")
+		})
+	})
+}
+
 type evgAuthMockHandler struct {
 	returnUnauthorized bool
 	returnTrue         bool
@@ -14,6 +14,9 @@ type APILog struct {
 	CompletedAt APITime            `json:"completed_at"`
 	Duration    float64            `json:"duration_secs"`
 	Artifact    APILogArtifactInfo `json:"artifact"`
+	Stats       APILogStats        `json:"stats"`
+	Sequences   APILogSequences    `json:"sequences"`
+	Truncated   bool               `json:"truncated"`
 }
 
 // Import transforms a Log object into an APILog object.
@@ -26,6 +29,9 @@ func (apiResult *APILog) Import(i interface{}) error {
 		apiResult.CompletedAt = NewTime(l.CompletedAt)
 		apiResult.Duration = l.CompletedAt.Sub(l.CreatedAt).Seconds()
 		apiResult.Artifact = getLogArtifactInfo(l.Artifact)
+		apiResult.Stats = getLogStats(l.Stats)
+		apiResult.Sequences = getLogSequences(l.Sequences)
+		apiResult.Truncated = l.Truncated
 	default:
 		return errors.New("incorrect type when fetching converting Log type")
 	}
@@ -109,3 +115,256 @@ func getLogChunkInfo(l dbmodel.LogChunkInfo) APILogChunkInfo {
 		End:      NewTime(l.End),
 	}
 }
+
+// APILogStats describes the counters of a buildlogger log. Priorities maps
+// the priorities of the log's lines, formatted as decimal numbers, to their
+// number of lines. Redactions is the number of secrets redacted from the
+// log's lines.
+type APILogStats struct {
+	NumLines    int            `json:"num_lines"`
+	Size        int64          `json:"size"`
+	Priorities  map[string]int `json:"priorities,omitempty"`
+	FirstLineAt APITime        `json:"first_line_at"`
+	LastLineAt  APITime        `json:"last_line_at"`
+	Redactions  int            `json:"redactions"`
+}
+
+func getLogStats(s dbmodel.LogStats) APILogStats {
+	return APILogStats{
+		NumLines:    s.NumLines,
+		Size:        s.Size,
+		Priorities:  s.Priorities,
+		FirstLineAt: NewTime(s.FirstLineAt),
+		LastLineAt:  NewTime(s.LastLineAt),
+		Redactions:  s.Redactions,
+	}
+}
+
+// APILogSequences describes the sequence numbers of the chunks appended to a
+// buildlogger log. All sequence numbers up to LastSequence were appended,
+// except for those in Gaps, which indicate incomplete uploads.
+type APILogSequences struct {
+	LastSequence int64                 `json:"last_sequence"`
+	Gaps         []APILogSequenceRange `json:"gaps,omitempty"`
+}
+
+// APILogSequenceRange describes an inclusive range of sequence numbers.
+type APILogSequenceRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+func getLogSequences(s dbmodel.LogSequences) APILogSequences {
+	var gaps []APILogSequenceRange
+	for _, gap := range s.Gaps {
+		gaps = append(gaps, APILogSequenceRange{Start: gap.Start, End: gap.End})
+	}
+
+	return APILogSequences{
+		LastSequence: s.Last,
+		Gaps:         gaps,
+	}
+}
+
+// APILogProcess describes the process of a buildlogger log. The end time,
+// duration, and exit code are only set once the log is closed.
+type APILogProcess struct {
+	LogID       *string `json:"log_id"`
+	ProcessName *string `json:"proc_name,omitempty"`
+	TestName    *string `json:"test_name,omitempty"`
+	Trial       int     `json:"trial"`
+	StartAt     APITime `json:"start"`
+	EndAt       APITime `json:"end"`
+	Duration    float64 `json:"duration_secs"`
+	ExitCode    int     `json:"exit_code"`
+	Completed   bool    `json:"completed"`
+}
+
+// Import transforms a Log object into an APILogProcess object.
+func (apiProcess *APILogProcess) Import(i interface{}) error {
+	switch l := i.(type) {
+	case dbmodel.Log:
+		apiProcess.LogID = utility.ToStringPtr(l.ID)
+		apiProcess.ProcessName = utility.ToStringPtr(l.Info.ProcessName)
+		apiProcess.TestName = utility.ToStringPtr(l.Info.TestName)
+		apiProcess.Trial = l.Info.Trial
+		apiProcess.StartAt = NewTime(l.CreatedAt)
+		apiProcess.EndAt = NewTime(l.CompletedAt)
+		apiProcess.Completed = !l.CompletedAt.IsZero()
+		if apiProcess.Completed {
+			apiProcess.Duration = l.CompletedAt.Sub(l.CreatedAt).Seconds()
+			apiProcess.ExitCode = l.Info.ExitCode
+		}
+	default:
+		return errors.New("incorrect type when converting Log type to process")
+	}
+	return nil
+}
+
+// APILogUsage describes the size of the buildlogger log lines ingested for a
+// project in a UTC day.
+type APILogUsage struct {
+	Project       *string `json:"project"`
+	Date          APITime `json:"date"`
+	Bytes         int64   `json:"bytes"`
+	NumLines      int     `json:"num_lines"`
+	RejectedBytes int64   `json:"rejected_bytes"`
+	TruncatedLogs int     `json:"truncated_logs"`
+}
+
+// Import transforms a LogUsage object into an APILogUsage object.
+func (apiUsage *APILogUsage) Import(i interface{}) error {
+	switch u := i.(type) {
+	case dbmodel.LogUsage:
+		apiUsage.Project = utility.ToStringPtr(u.Project)
+		apiUsage.Date = NewTime(u.Date)
+		apiUsage.Bytes = u.Bytes
+		apiUsage.NumLines = u.NumLines
+		apiUsage.RejectedBytes = u.RejectedBytes
+		apiUsage.TruncatedLogs = u.TruncatedLogs
+	default:
+		return errors.New("incorrect type when converting LogUsage type")
+	}
+	return nil
+}
+
+// APILogLine describes a single buildlogger log line.
+type APILogLine struct {
+	Priority  int     `json:"priority"`
+	Timestamp APITime `json:"timestamp"`
+	Data      *string `json:"data"`
+}
+
+func getLogLine(l dbmodel.LogLine) APILogLine {
+	return APILogLine{
+		Priority:  int(l.Priority),
+		Timestamp: NewTime(l.Timestamp),
+		Data:      utility.ToStringPtr(l.Data),
+	}
+}
+
+func getLogLines(lines []dbmodel.LogLine) []APILogLine {
+	apiLines := make([]APILogLine, len(lines))
+	for i, line := range lines {
+		apiLines[i] = getLogLine(line)
+	}
+
+	return apiLines
+}
+
+// APILogSearchMatch describes a buildlogger log line matching a search along
+// with its surrounding context lines.
+type APILogSearchMatch struct {
+	LogID       *string      `json:"log_id"`
+	ProcessName *string      `json:"proc_name,omitempty"`
+	Line        APILogLine   `json:"line"`
+	Before      []APILogLine `json:"before,omitempty"`
+	After       []APILogLine `json:"after,omitempty"`
+}
+
+// APILogSearchResults describes the results of a buildlogger log search.
+type APILogSearchResults struct {
+	Matches   []APILogSearchMatch `json:"matches"`
+	Truncated bool                `json:"truncated"`
+}
+
+// Import transforms a LogSearchResults object into an APILogSearchResults
+// object.
+func (apiResults *APILogSearchResults) Import(i interface{}) error {
+	switch r := i.(type) {
+	case dbmodel.LogSearchResults:
+		apiResults.Matches = make([]APILogSearchMatch, len(r.Matches))
+		for j, match := range r.Matches {
+			apiResults.Matches[j] = APILogSearchMatch{
+				LogID:       utility.ToStringPtr(match.LogID),
+				ProcessName: utility.ToStringPtr(match.ProcessName),
+				Line:        getLogLine(match.Line),
+				Before:      getLogLines(match.Before),
+				After:       getLogLines(match.After),
+			}
+		}
+		apiResults.Truncated = r.Truncated
+	default:
+		return errors.New("incorrect type when converting LogSearchResults type")
+	}
+	return nil
+}
+
+// APILogFailures describes the failure signatures extracted from a
+// buildlogger log closed with a non-zero exit code.
+type APILogFailures struct {
+	LogID       *string                  `json:"log_id"`
+	ProcessName *string                  `json:"proc_name,omitempty"`
+	TestName    *string                  `json:"test_name,omitempty"`
+	ExitCode    int                      `json:"exit_code"`
+	Signatures  []APILogFailureSignature `json:"signatures"`
+}
+
+// Import transforms a Log object into an APILogFailures object.
+func (apiFailures *APILogFailures) Import(i interface{}) error {
+	switch l := i.(type) {
+	case dbmodel.Log:
+		apiFailures.LogID = utility.ToStringPtr(l.ID)
+		apiFailures.ProcessName = utility.ToStringPtr(l.Info.ProcessName)
+		apiFailures.TestName = utility.ToStringPtr(l.Info.TestName)
+		apiFailures.ExitCode = l.Info.ExitCode
+		apiFailures.Signatures = make([]APILogFailureSignature, len(l.FailureSignatures))
+		for j, signature := range l.FailureSignatures {
+			apiFailures.Signatures[j] = APILogFailureSignature{
+				ID:         utility.ToStringPtr(signature.ID),
+				Kind:       utility.ToStringPtr(string(signature.Kind)),
+				Signature:  utility.ToStringPtr(signature.Signature),
+				Line:       utility.ToStringPtr(signature.Line),
+				LineNumber: signature.LineNumber,
+				Count:      signature.Count,
+			}
+		}
+	default:
+		return errors.New("incorrect type when converting Log type to failures")
+	}
+	return nil
+}
+
+// APILogFailureSignature describes a normalized error line of a buildlogger
+// log.
+type APILogFailureSignature struct {
+	ID         *string `json:"id"`
+	Kind       *string `json:"kind"`
+	Signature  *string `json:"signature"`
+	Line       *string `json:"line"`
+	LineNumber int     `json:"line_number"`
+	Count      int     `json:"count"`
+}
+
+// APILogFailureMatch describes a buildlogger log with a given failure
+// signature.
+type APILogFailureMatch struct {
+	LogID       *string `json:"log_id"`
+	Version     *string `json:"version,omitempty"`
+	Variant     *string `json:"variant,omitempty"`
+	TaskName    *string `json:"task_name,omitempty"`
+	TaskID      *string `json:"task_id"`
+	Execution   int     `json:"execution"`
+	TestName    *string `json:"test_name,omitempty"`
+	ProcessName *string `json:"proc_name,omitempty"`
+	CreatedAt   APITime `json:"created_at"`
+}
+
+// Import transforms a Log object into an APILogFailureMatch object.
+func (apiMatch *APILogFailureMatch) Import(i interface{}) error {
+	switch l := i.(type) {
+	case dbmodel.Log:
+		apiMatch.LogID = utility.ToStringPtr(l.ID)
+		apiMatch.Version = utility.ToStringPtr(l.Info.Version)
+		apiMatch.Variant = utility.ToStringPtr(l.Info.Variant)
+		apiMatch.TaskName = utility.ToStringPtr(l.Info.TaskName)
+		apiMatch.TaskID = utility.ToStringPtr(l.Info.TaskID)
+		apiMatch.Execution = l.Info.Execution
+		apiMatch.TestName = utility.ToStringPtr(l.Info.TestName)
+		apiMatch.ProcessName = utility.ToStringPtr(l.Info.ProcessName)
+		apiMatch.CreatedAt = NewTime(l.CreatedAt)
+	default:
+		return errors.New("incorrect type when converting Log type to failure match")
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 
 	dbmodel "github.com/evergreen-ci/cedar/model"
 	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip/level"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -54,6 +55,19 @@ func TestBuildloggerImport(t *testing.T) {
 					},
 				},
 			},
+			Stats: dbmodel.LogStats{
+				NumLines:    301,
+				Size:        4096,
+				Priorities:  map[string]int{"40": 300, "70": 1},
+				FirstLineAt: time.Now().Add(-24 * time.Hour),
+				LastLineAt:  time.Now().Add(-22 * time.Hour),
+				Redactions:  2,
+			},
+			Sequences: dbmodel.LogSequences{
+				Last: 10,
+				Gaps: []dbmodel.LogSequenceRange{{Start: 3, End: 4}, {Start: 7, End: 7}},
+			},
+			Truncated: true,
 		}
 		log.ID = log.Info.ID()
 		expected := &APILog{
@@ -95,6 +109,19 @@ func TestBuildloggerImport(t *testing.T) {
 					},
 				},
 			},
+			Stats: APILogStats{
+				NumLines:    301,
+				Size:        4096,
+				Priorities:  log.Stats.Priorities,
+				FirstLineAt: NewTime(log.Stats.FirstLineAt),
+				LastLineAt:  NewTime(log.Stats.LastLineAt),
+				Redactions:  2,
+			},
+			Sequences: APILogSequences{
+				LastSequence: 10,
+				Gaps:         []APILogSequenceRange{{Start: 3, End: 4}, {Start: 7, End: 7}},
+			},
+			Truncated: true,
 		}
 
 		apiLog := &APILog{}
@@ -102,3 +129,217 @@ func TestBuildloggerImport(t *testing.T) {
 		assert.Equal(t, expected, apiLog)
 	})
 }
+
+func TestLogProcessImport(t *testing.T) {
+	t.Run("InvalidType", func(t *testing.T) {
+		apiProcess := &APILogProcess{}
+		assert.Error(t, apiProcess.Import(dbmodel.LogUsage{}))
+	})
+	t.Run("CompletedLog", func(t *testing.T) {
+		log := dbmodel.Log{
+			ID: "log",
+			Info: dbmodel.LogInfo{
+				TestName:    "test",
+				Trial:       2,
+				ProcessName: "mongod",
+				ExitCode:    1,
+			},
+			CreatedAt:   time.Now().Add(-time.Minute),
+			CompletedAt: time.Now(),
+		}
+		expected := &APILogProcess{
+			LogID:       utility.ToStringPtr("log"),
+			ProcessName: utility.ToStringPtr("mongod"),
+			TestName:    utility.ToStringPtr("test"),
+			Trial:       2,
+			StartAt:     NewTime(log.CreatedAt),
+			EndAt:       NewTime(log.CompletedAt),
+			Duration:    log.CompletedAt.Sub(log.CreatedAt).Seconds(),
+			ExitCode:    1,
+			Completed:   true,
+		}
+
+		apiProcess := &APILogProcess{}
+		assert.NoError(t, apiProcess.Import(log))
+		assert.Equal(t, expected, apiProcess)
+	})
+	t.Run("RunningLog", func(t *testing.T) {
+		log := dbmodel.Log{
+			ID:        "log",
+			Info:      dbmodel.LogInfo{ProcessName: "mongod"},
+			CreatedAt: time.Now().Add(-time.Minute),
+		}
+		expected := &APILogProcess{
+			LogID:       utility.ToStringPtr("log"),
+			ProcessName: utility.ToStringPtr("mongod"),
+			TestName:    utility.ToStringPtr(""),
+			StartAt:     NewTime(log.CreatedAt),
+			EndAt:       NewTime(time.Time{}),
+		}
+
+		apiProcess := &APILogProcess{}
+		assert.NoError(t, apiProcess.Import(log))
+		assert.Equal(t, expected, apiProcess)
+	})
+}
+
+func TestLogUsageImport(t *testing.T) {
+	t.Run("InvalidType", func(t *testing.T) {
+		apiUsage := &APILogUsage{}
+		assert.Error(t, apiUsage.Import(dbmodel.Log{}))
+	})
+	t.Run("ValidUsage", func(t *testing.T) {
+		usage := dbmodel.LogUsage{
+			ID:            "project@2021-01-02",
+			Project:       "project",
+			Date:          time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC),
+			Bytes:         4096,
+			NumLines:      100,
+			RejectedBytes: 1024,
+			TruncatedLogs: 1,
+		}
+		expected := &APILogUsage{
+			Project:       utility.ToStringPtr("project"),
+			Date:          NewTime(usage.Date),
+			Bytes:         4096,
+			NumLines:      100,
+			RejectedBytes: 1024,
+			TruncatedLogs: 1,
+		}
+
+		apiUsage := &APILogUsage{}
+		assert.NoError(t, apiUsage.Import(usage))
+		assert.Equal(t, expected, apiUsage)
+	})
+}
+
+func TestLogSearchResultsImport(t *testing.T) {
+	t.Run("InvalidType", func(t *testing.T) {
+		apiResults := &APILogSearchResults{}
+		assert.Error(t, apiResults.Import(dbmodel.Log{}))
+	})
+	t.Run("ValidResults", func(t *testing.T) {
+		ts := time.Now().Round(time.Millisecond)
+		results := dbmodel.LogSearchResults{
+			Matches: []dbmodel.LogSearchMatch{
+				{
+					LogID:       "log",
+					ProcessName: "proc",
+					Line:        dbmodel.LogLine{Priority: level.Error, Timestamp: ts, Data: "assertion failed\n"},
+					Before:      []dbmodel.LogLine{{Priority: level.Info, Timestamp: ts.Add(-time.Second), Data: "before\n"}},
+				},
+			},
+			Truncated: true,
+		}
+		expected := &APILogSearchResults{
+			Matches: []APILogSearchMatch{
+				{
+					LogID:       utility.ToStringPtr("log"),
+					ProcessName: utility.ToStringPtr("proc"),
+					Line: APILogLine{
+						Priority:  int(level.Error),
+						Timestamp: NewTime(ts),
+						Data:      utility.ToStringPtr("assertion failed\n"),
+					},
+					Before: []APILogLine{
+						{
+							Priority:  int(level.Info),
+							Timestamp: NewTime(ts.Add(-time.Second)),
+							Data:      utility.ToStringPtr("before\n"),
+						},
+					},
+					After: []APILogLine{},
+				},
+			},
+			Truncated: true,
+		}
+
+		apiResults := &APILogSearchResults{}
+		assert.NoError(t, apiResults.Import(results))
+		assert.Equal(t, expected, apiResults)
+	})
+}
+
+func TestLogFailuresImport(t *testing.T) {
+	t.Run("InvalidType", func(t *testing.T) {
+		apiFailures := &APILogFailures{}
+		assert.Error(t, apiFailures.Import(dbmodel.LogUsage{}))
+	})
+	t.Run("Log", func(t *testing.T) {
+		log := dbmodel.Log{
+			ID: "log",
+			Info: dbmodel.LogInfo{
+				TestName:    "test",
+				ProcessName: "mongod",
+				ExitCode:    2,
+			},
+			FailureSignatures: []dbmodel.LogFailureSignature{
+				{
+					ID:         "id",
+					Kind:       dbmodel.LogFailurePanic,
+					Signature:  "panic: boom @ main.main",
+					Line:       "panic: boom",
+					LineNumber: 10,
+					Count:      1,
+				},
+			},
+		}
+		expected := &APILogFailures{
+			LogID:       utility.ToStringPtr("log"),
+			ProcessName: utility.ToStringPtr("mongod"),
+			TestName:    utility.ToStringPtr("test"),
+			ExitCode:    2,
+			Signatures: []APILogFailureSignature{
+				{
+					ID:         utility.ToStringPtr("id"),
+					Kind:       utility.ToStringPtr("panic"),
+					Signature:  utility.ToStringPtr("panic: boom @ main.main"),
+					Line:       utility.ToStringPtr("panic: boom"),
+					LineNumber: 10,
+					Count:      1,
+				},
+			},
+		}
+
+		apiFailures := &APILogFailures{}
+		assert.NoError(t, apiFailures.Import(log))
+		assert.Equal(t, expected, apiFailures)
+	})
+}
+
+func TestLogFailureMatchImport(t *testing.T) {
+	t.Run("InvalidType", func(t *testing.T) {
+		apiMatch := &APILogFailureMatch{}
+		assert.Error(t, apiMatch.Import(dbmodel.LogUsage{}))
+	})
+	t.Run("Log", func(t *testing.T) {
+		log := dbmodel.Log{
+			ID: "log",
+			Info: dbmodel.LogInfo{
+				Version:     "version",
+				Variant:     "variant",
+				TaskName:    "task",
+				TaskID:      "task_id",
+				Execution:   1,
+				TestName:    "test",
+				ProcessName: "mongod",
+			},
+			CreatedAt: time.Now(),
+		}
+		expected := &APILogFailureMatch{
+			LogID:       utility.ToStringPtr("log"),
+			Version:     utility.ToStringPtr("version"),
+			Variant:     utility.ToStringPtr("variant"),
+			TaskName:    utility.ToStringPtr("task"),
+			TaskID:      utility.ToStringPtr("task_id"),
+			Execution:   1,
+			TestName:    utility.ToStringPtr("test"),
+			ProcessName: utility.ToStringPtr("mongod"),
+			CreatedAt:   NewTime(log.CreatedAt),
+		}
+
+		apiMatch := &APILogFailureMatch{}
+		assert.NoError(t, apiMatch.Import(log))
+		assert.Equal(t, expected, apiMatch)
+	})
+}
@@ -126,6 +126,28 @@ func (a *APITestResultsStats) Import(i interface{}) error {
 	return nil
 }
 
+// APITestReportImport describes the test results record created by importing
+// a test report.
+type APITestReportImport struct {
+	ID        *string             `json:"id"`
+	TaskID    *string             `json:"task_id"`
+	Execution int                 `json:"execution"`
+	Stats     APITestResultsStats `json:"stats"`
+}
+
+// Import transforms a TestResults object into an APITestReportImport object.
+func (a *APITestReportImport) Import(i interface{}) error {
+	switch record := i.(type) {
+	case dbModel.TestResults:
+		a.ID = utility.ToStringPtr(record.ID)
+		a.TaskID = utility.ToStringPtr(record.Info.TaskID)
+		a.Execution = record.Info.Execution
+		return a.Stats.Import(record.Stats)
+	default:
+		return errors.Errorf("incorrect type %T when converting to APITestReportImport type", i)
+	}
+}
+
 // APITestResultsSample is a sample of test names for a given task and execution.
 type APITestResultsSample struct {
 	TaskID                  *string  `json:"task_id"`
@@ -149,3 +171,89 @@ func (a *APITestResultsSample) Import(i interface{}) error {
 
 	return nil
 }
+
+// APITestHistoryEntry describes the results of a test in a single task
+// execution.
+type APITestHistoryEntry struct {
+	Project     *string `json:"project"`
+	Version     *string `json:"version"`
+	Variant     *string `json:"variant"`
+	TaskName    *string `json:"task_name"`
+	TaskID      *string `json:"task_id"`
+	Execution   int     `json:"execution"`
+	RequestType *string `json:"request_type"`
+	Mainline    bool    `json:"mainline"`
+	CreatedAt   APITime `json:"created_at"`
+	TestName    *string `json:"test_name"`
+	Status      *string `json:"status"`
+	// Duration is the duration of the test in seconds.
+	Duration   float64 `json:"duration"`
+	NumResults int     `json:"num_results"`
+	NumPassed  int     `json:"num_passed"`
+	NumFailed  int     `json:"num_failed"`
+}
+
+// Import transforms a TestHistoryEntry object into an APITestHistoryEntry
+// object.
+func (a *APITestHistoryEntry) Import(i interface{}) error {
+	switch entry := i.(type) {
+	case dbModel.TestHistoryEntry:
+		a.Project = utility.ToStringPtr(entry.Project)
+		a.Version = utility.ToStringPtr(entry.Version)
+		a.Variant = utility.ToStringPtr(entry.Variant)
+		a.TaskName = utility.ToStringPtr(entry.TaskName)
+		a.TaskID = utility.ToStringPtr(entry.TaskID)
+		a.Execution = entry.Execution
+		a.RequestType = utility.ToStringPtr(entry.RequestType)
+		a.Mainline = entry.Mainline
+		a.CreatedAt = NewTime(entry.CreatedAt)
+		a.TestName = utility.ToStringPtr(entry.TestName)
+		a.Status = utility.ToStringPtr(entry.Status)
+		a.Duration = entry.Duration.Seconds()
+		a.NumResults = entry.NumResults
+		a.NumPassed = entry.NumPassed
+		a.NumFailed = entry.NumFailed
+	default:
+		return errors.Errorf("incorrect type %T when converting to APITestHistoryEntry type", i)
+	}
+
+	return nil
+}
+
+// APIFlakyTest describes a test that both passed and failed on the same
+// mainline version of a task.
+type APIFlakyTest struct {
+	Project          *string `json:"project"`
+	Variant          *string `json:"variant"`
+	TaskName         *string `json:"task_name"`
+	TestName         *string `json:"test_name"`
+	NumRuns          int     `json:"num_runs"`
+	NumFlakyRuns     int     `json:"num_flaky_runs"`
+	NumFailedRuns    int     `json:"num_failed_runs"`
+	Score            float64 `json:"score"`
+	LastFlakyVersion *string `json:"last_flaky_version"`
+	LastFlakyAt      APITime `json:"last_flaky_at"`
+	DetectedAt       APITime `json:"detected_at"`
+}
+
+// Import transforms a FlakyTest object into an APIFlakyTest object.
+func (a *APIFlakyTest) Import(i interface{}) error {
+	switch flakyTest := i.(type) {
+	case dbModel.FlakyTest:
+		a.Project = utility.ToStringPtr(flakyTest.Project)
+		a.Variant = utility.ToStringPtr(flakyTest.Variant)
+		a.TaskName = utility.ToStringPtr(flakyTest.TaskName)
+		a.TestName = utility.ToStringPtr(flakyTest.TestName)
+		a.NumRuns = flakyTest.NumRuns
+		a.NumFlakyRuns = flakyTest.NumFlakyRuns
+		a.NumFailedRuns = flakyTest.NumFailedRuns
+		a.Score = flakyTest.Score
+		a.LastFlakyVersion = utility.ToStringPtr(flakyTest.LastFlakyVersion)
+		a.LastFlakyAt = NewTime(flakyTest.LastFlakyAt)
+		a.DetectedAt = NewTime(flakyTest.DetectedAt)
+	default:
+		return errors.Errorf("incorrect type %T when converting to APIFlakyTest type", i)
+	}
+
+	return nil
+}
@@ -68,3 +68,116 @@ func TestTestResultImport(t *testing.T) {
 		assert.Equal(t, expected, apiTestResult)
 	})
 }
+
+func TestTestHistoryEntryImport(t *testing.T) {
+	t.Run("InvalidType", func(t *testing.T) {
+		apiEntry := &APITestHistoryEntry{}
+		assert.Error(t, apiEntry.Import(dbmodel.TestResult{}))
+	})
+	t.Run("ValidEntry", func(t *testing.T) {
+		entry := dbmodel.TestHistoryEntry{
+			Project:     "project",
+			Version:     "version",
+			Variant:     "variant",
+			TaskName:    "task_name",
+			TaskID:      "task_id",
+			Execution:   1,
+			RequestType: "gitter_request",
+			Mainline:    true,
+			CreatedAt:   time.Now().Add(-time.Hour),
+			TestName:    "test_name",
+			Status:      "fail",
+			Duration:    1500 * time.Millisecond,
+			NumResults:  2,
+			NumPassed:   1,
+			NumFailed:   1,
+		}
+		expected := &APITestHistoryEntry{
+			Project:     utility.ToStringPtr(entry.Project),
+			Version:     utility.ToStringPtr(entry.Version),
+			Variant:     utility.ToStringPtr(entry.Variant),
+			TaskName:    utility.ToStringPtr(entry.TaskName),
+			TaskID:      utility.ToStringPtr(entry.TaskID),
+			Execution:   entry.Execution,
+			RequestType: utility.ToStringPtr(entry.RequestType),
+			Mainline:    true,
+			CreatedAt:   NewTime(entry.CreatedAt),
+			TestName:    utility.ToStringPtr(entry.TestName),
+			Status:      utility.ToStringPtr(entry.Status),
+			Duration:    1.5,
+			NumResults:  2,
+			NumPassed:   1,
+			NumFailed:   1,
+		}
+		apiEntry := &APITestHistoryEntry{}
+		assert.NoError(t, apiEntry.Import(entry))
+		assert.Equal(t, expected, apiEntry)
+	})
+}
+
+func TestFlakyTestImport(t *testing.T) {
+	t.Run("InvalidType", func(t *testing.T) {
+		apiFlakyTest := &APIFlakyTest{}
+		assert.Error(t, apiFlakyTest.Import(dbmodel.TestResult{}))
+	})
+	t.Run("ValidFlakyTest", func(t *testing.T) {
+		flakyTest := dbmodel.FlakyTest{
+			Project:          "project",
+			Variant:          "variant",
+			TaskName:         "task_name",
+			TestName:         "test_name",
+			NumRuns:          4,
+			NumFlakyRuns:     1,
+			NumFailedRuns:    2,
+			Score:            0.25,
+			LastFlakyVersion: "version",
+			LastFlakyAt:      time.Now().Add(-time.Hour),
+			DetectedAt:       time.Now(),
+		}
+		expected := &APIFlakyTest{
+			Project:          utility.ToStringPtr(flakyTest.Project),
+			Variant:          utility.ToStringPtr(flakyTest.Variant),
+			TaskName:         utility.ToStringPtr(flakyTest.TaskName),
+			TestName:         utility.ToStringPtr(flakyTest.TestName),
+			NumRuns:          4,
+			NumFlakyRuns:     1,
+			NumFailedRuns:    2,
+			Score:            0.25,
+			LastFlakyVersion: utility.ToStringPtr(flakyTest.LastFlakyVersion),
+			LastFlakyAt:      NewTime(flakyTest.LastFlakyAt),
+			DetectedAt:       NewTime(flakyTest.DetectedAt),
+		}
+		apiFlakyTest := &APIFlakyTest{}
+		assert.NoError(t, apiFlakyTest.Import(flakyTest))
+		assert.Equal(t, expected, apiFlakyTest)
+	})
+}
+
+func TestTestReportImportImport(t *testing.T) {
+	t.Run("InvalidType", func(t *testing.T) {
+		apiImport := &APITestReportImport{}
+		assert.Error(t, apiImport.Import(dbmodel.TestResult{}))
+	})
+	t.Run("ValidTestResults", func(t *testing.T) {
+		record := dbmodel.TestResults{
+			ID:   "id",
+			Info: dbmodel.TestResultsInfo{TaskID: "task_id", Execution: 2},
+			Stats: dbmodel.TestResultsStats{
+				TotalCount:  3,
+				FailedCount: 1,
+			},
+		}
+		expected := &APITestReportImport{
+			ID:        utility.ToStringPtr("id"),
+			TaskID:    utility.ToStringPtr("task_id"),
+			Execution: 2,
+			Stats: APITestResultsStats{
+				TotalCount:  3,
+				FailedCount: 1,
+			},
+		}
+		apiImport := &APITestReportImport{}
+		assert.NoError(t, apiImport.Import(record))
+		assert.Equal(t, expected, apiImport)
+	})
+}
@@ -1,9 +1,12 @@
 package rest
 
 import (
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/evergreen-ci/cedar/model"
+	"github.com/mongodb/grip/level"
 	"github.com/pkg/errors"
 )
 
@@ -28,3 +31,68 @@ func parseTimeRange(format, start, end string) (model.TimeRange, error) {
 
 	return tr, nil
 }
+
+func parseLogFieldFilters(predicates []string) ([]model.LogFieldFilter, error) {
+	var filters []model.LogFieldFilter
+	for _, predicate := range predicates {
+		filter, err := model.ParseLogFieldFilter(predicate)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+
+	return filters, nil
+}
+
+// parsePriorityRange parses the min_priority and max_priority query
+// parameters. Priorities are given either as a number between 1 and 100 or
+// by name, e.g. "warning". A missing bound is returned as 0.
+func parsePriorityRange(vals url.Values) (level.Priority, level.Priority, error) {
+	minPriority, err := parsePriority(vals.Get(minPriorityParam))
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "parsing min priority")
+	}
+	maxPriority, err := parsePriority(vals.Get(maxPriorityParam))
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "parsing max priority")
+	}
+	if minPriority > 0 && maxPriority > 0 && minPriority > maxPriority {
+		return 0, 0, errors.New("min priority cannot be greater than max priority")
+	}
+
+	return minPriority, maxPriority, nil
+}
+
+func parsePriority(val string) (level.Priority, error) {
+	if val == "" {
+		return 0, nil
+	}
+
+	if n, err := strconv.Atoi(val); err == nil {
+		if n < 1 || n > int(level.Emergency) {
+			return 0, errors.Errorf("priority %d must be between 1 and %d", n, level.Emergency)
+		}
+		return level.Priority(n), nil
+	}
+
+	p := level.FromString(val)
+	if p == level.Invalid {
+		return 0, errors.Errorf("invalid priority '%s'", val)
+	}
+
+	return p, nil
+}
+
+// parseArchiveFormat parses the format of a log archive, either "tar.gz" or
+// "zip". The format defaults to "tar.gz".
+func parseArchiveFormat(val string) (model.FileCompression, error) {
+	switch val {
+	case "", "tar.gz", string(model.FileTarGz):
+		return model.FileTarGz, nil
+	case string(model.FileZip):
+		return model.FileZip, nil
+	default:
+		return "", errors.Errorf("invalid archive format '%s'", val)
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"path"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/evergreen-ci/cedar"
@@ -13,6 +16,8 @@ import (
 	"github.com/evergreen-ci/certdepot"
 	"github.com/evergreen-ci/gimlet"
 	"github.com/evergreen-ci/pail"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/anser/db"
 	"github.com/mongodb/grip"
 	"github.com/mongodb/grip/level"
 	"github.com/mongodb/grip/message"
@@ -224,7 +229,10 @@ type SimpleLogContentResponse struct {
 	URLS  []string `json:"urls"`
 }
 
-// simpleLogRetrieval takes in a log ID and returns the log documents associated with that log ID.
+// simpleLogRetrieval takes in a log ID and returns the URLs of the content
+// associated with that log ID: the text route of the simple log, which reads
+// the buildlogger log storing it, or, if the simple log is not yet migrated,
+// its legacy segments.
 func (s *Service) simpleLogRetrieval(w http.ResponseWriter, r *http.Request) {
 	resp := &SimpleLogContentResponse{}
 
@@ -234,8 +242,21 @@ func (s *Service) simpleLogRetrieval(w http.ResponseWriter, r *http.Request) {
 		gimlet.WriteJSONError(w, resp)
 		return
 	}
-	allLogs := &model.LogSegments{}
 
+	_, err := model.FindSimpleLog(r.Context(), s.Environment, resp.LogID)
+	if err == nil {
+		resp.URLS = []string{strings.TrimSuffix(s.sc.GetBaseURL(), "/") + path.Join("/", s.Prefix, "v1", "simple_log", url.PathEscape(resp.LogID), "text")}
+		gimlet.WriteJSON(w, resp)
+		return
+	}
+	if !db.ResultsNotFound(errors.Cause(err)) {
+		resp.Error = err.Error()
+		gimlet.WriteJSONInternalError(w, resp)
+		return
+	}
+
+	allLogs := &model.LogSegments{}
+	allLogs.Setup(s.Environment)
 	if err := allLogs.Find(resp.LogID, false); err != nil {
 		resp.Error = err.Error()
 		gimlet.WriteJSONError(w, resp)
@@ -253,17 +274,35 @@ func (s *Service) simpleLogRetrieval(w http.ResponseWriter, r *http.Request) {
 //
 // GET /simple_log/{id}/text
 
+// simpleLogGetText writes the content of the buildlogger log storing the
+// simple log or, if the simple log is not yet migrated, of its legacy
+// segments.
 func (s *Service) simpleLogGetText(w http.ResponseWriter, r *http.Request) {
 	id := gimlet.GetVars(r)["id"]
-	allLogs := &model.LogSegments{}
 
+	log, err := model.FindSimpleLog(r.Context(), s.Environment, id)
+	if err == nil {
+		it, err := log.Download(r.Context(), model.TimeRange{EndAt: utility.MaxTime})
+		if err != nil {
+			gimlet.WriteTextInternalError(w, err.Error())
+			return
+		}
+		gimlet.WriteText(w, model.NewLogIteratorReader(r.Context(), it, model.LogIteratorReaderOptions{}))
+		return
+	}
+	if !db.ResultsNotFound(errors.Cause(err)) {
+		gimlet.WriteTextInternalError(w, err.Error())
+		return
+	}
+
+	allLogs := &model.LogSegments{}
+	allLogs.Setup(s.Environment)
 	if err := allLogs.Find(id, true); err != nil {
 		gimlet.WriteTextError(w, err.Error())
 		return
 	}
 
 	buckets := make(map[string]pail.Bucket)
-	var err error
 	for _, l := range allLogs.Slice() {
 		bucket, ok := buckets[l.Bucket]
 		if !ok {
@@ -285,6 +285,8 @@ func (s *Service) addRoutes() {
 	checkDepot := newCertCheckDepotMiddleware(s.Depot == nil)
 	evgAuthReadLogByID := newEvgAuthReadLogByIDMiddleware(s.sc, &s.Conf.Evergreen)
 	evgAuthReadLogByTaskID := newEvgAuthReadLogByTaskIDMiddleware(s.sc, &s.Conf.Evergreen)
+	evgAuthReadLogByProject := newEvgAuthReadLogByProjectMiddleware(&s.Conf.Evergreen)
+	responseHeaders := newResponseHeadersMiddleware()
 
 	s.app.AddRoute("/admin/status").Version(1).Get().Handler(s.statusHandler)
 	s.app.AddRoute("/admin/status/event/{id}").Version(1).Get().Wrap(checkUser).Handler(s.getSystemEvent)
@@ -292,6 +294,7 @@ func (s *Service) addRoutes() {
 	s.app.AddRoute("/admin/status/events/{level}").Version(1).Get().Wrap(checkUser).Handler(s.getSystemEvents)
 	s.app.AddRoute("/admin/service/flag/{flagName}/enabled").Version(1).Post().Wrap(checkUser).Handler(s.setServiceFlagEnabled)
 	s.app.AddRoute("/admin/service/flag/{flagName}/disabled").Version(1).Post().Wrap(checkUser).Handler(s.setServiceFlagDisabled)
+	s.app.AddRoute("/admin/buildlogger/usage").Version(1).Get().Wrap(checkUser).RouteHandler(makeGetLogUsage(s.sc))
 	s.app.AddRoute("/admin/ca").Version(1).Get().Wrap(checkDepot).Handler(s.fetchRootCert)
 	s.app.AddRoute("/admin/users/certificate").Version(1).Post().Get().Wrap(checkDepot).Handler(s.fetchUserCert)
 	s.app.AddRoute("/admin/users/certificate/key").Version(1).Post().Get().Wrap(checkDepot).Handler(s.fetchUserCertKey)
@@ -306,13 +309,22 @@ func (s *Service) addRoutes() {
 	s.app.AddRoute("/buildlogger/{id}/meta").Version(1).Get().Wrap(evgAuthReadLogByID).RouteHandler(makeGetLogMetaByID(s.sc))
 	s.app.AddRoute("/buildlogger/task_id/{task_id}").Version(1).Get().Wrap(evgAuthReadLogByTaskID).RouteHandler(makeGetLogByTaskID(s.sc))
 	s.app.AddRoute("/buildlogger/task_id/{task_id}/meta").Version(1).Get().Wrap(evgAuthReadLogByTaskID).RouteHandler(makeGetLogMetaByTaskID(s.sc))
+	s.app.AddRoute("/buildlogger/task_id/{task_id}/processes").Version(1).Get().Wrap(evgAuthReadLogByTaskID).RouteHandler(makeGetLogProcessesByTaskID(s.sc))
+	s.app.AddRoute("/buildlogger/task_id/{task_id}/archive").Version(1).Get().Wrap(evgAuthReadLogByTaskID, responseHeaders).RouteHandler(makeGetLogArchiveByTaskID(s.sc))
+	s.app.AddRoute("/buildlogger/task_id/{task_id}/search").Version(1).Get().Wrap(evgAuthReadLogByTaskID).RouteHandler(makeSearchLogsByTaskID(s.sc))
+	s.app.AddRoute("/buildlogger/task_id/{task_id}/diff").Version(1).Get().Wrap(evgAuthReadLogByTaskID).RouteHandler(makeDiffLogsByTaskID(s.sc))
+	s.app.AddRoute("/buildlogger/task_id/{task_id}/failure_signatures").Version(1).Get().Wrap(evgAuthReadLogByTaskID).RouteHandler(makeGetLogFailuresByTaskID(s.sc))
 	s.app.AddRoute("/buildlogger/task_id/{task_id}/group/{group_id}").Version(1).Get().Wrap(evgAuthReadLogByTaskID).RouteHandler(makeGetLogGroupByTaskID(s.sc))
 	s.app.AddRoute("/buildlogger/test_name/{task_id}/{test_name}").Version(1).Get().Wrap(evgAuthReadLogByTaskID).RouteHandler(makeGetLogByTestName(s.sc))
 	s.app.AddRoute("/buildlogger/test_name/{task_id}/{test_name}/meta").Version(1).Get().Wrap(evgAuthReadLogByTaskID).RouteHandler(makeGetLogMetaByTestName(s.sc))
 	s.app.AddRoute("/buildlogger/test_name/{task_id}/{test_name}/group/{group_id}").Version(1).Get().Wrap(evgAuthReadLogByTaskID).RouteHandler(makeGetLogGroupByTestName(s.sc))
+	s.app.AddRoute("/buildlogger/failure_signatures/{signature_id}").Version(1).Get().Wrap(evgAuthReadLogByProject).RouteHandler(makeGetLogsByFailureSignature(s.sc))
 
-	s.app.AddRoute("/test_results/tasks").Version(1).Get().RouteHandler(makeGetTestResultsByTasks(s.sc))
+	s.app.AddRoute("/test_results/tasks").Version(1).Get().Wrap(responseHeaders).RouteHandler(makeGetTestResultsByTasks(s.sc))
 	s.app.AddRoute("/test_results/tasks/stats").Version(1).Get().RouteHandler(makeGetTestResultsStatsByTasks(s.sc))
 	s.app.AddRoute("/test_results/tasks/failed_sample").Version(1).Get().RouteHandler(makeGetTestResultsFailedSampleByTasks(s.sc))
 	s.app.AddRoute("/test_results/filtered_samples").Version(1).Get().RouteHandler(makeGetTestResultsFilteredSamples(s.sc))
+	s.app.AddRoute("/test_results/history").Version(1).Get().RouteHandler(makeGetTestHistory(s.sc))
+	s.app.AddRoute("/test_results/flaky").Version(1).Get().RouteHandler(makeGetFlakyTests(s.sc))
+	s.app.AddRoute("/test_results/report/{format}").Version(1).Post().Wrap(checkUser).RouteHandler(makePostTestReport(s.sc))
 }
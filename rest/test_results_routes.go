@@ -3,7 +3,11 @@ package rest
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/evergreen-ci/cedar/rest/data"
 	"github.com/evergreen-ci/gimlet"
@@ -13,6 +17,26 @@ import (
 	"github.com/pkg/errors"
 )
 
+const (
+	testResultsProject         = "project"
+	testResultsVersion         = "version"
+	testResultsVariant         = "variant"
+	testResultsTaskName        = "task_name"
+	testResultsDisplayTaskName = "display_task_name"
+	testResultsTaskID          = "task_id"
+	testResultsRequestType     = "request_type"
+	testResultsTestName        = "test_name"
+	testResultsMainline        = "mainline"
+	testResultsStartVersion    = "start_version"
+	testResultsEndVersion      = "end_version"
+	testReportFormat           = "format"
+	testResultsExportFormat    = "format"
+	flakyMinScore              = "min_score"
+
+	// maxTestReportSize is the maximum size of a test report in bytes.
+	maxTestReportSize = 64 * 1024 * 1024
+)
+
 type testResultsBaseHandler struct {
 	sc      data.Connector
 	payload struct {
@@ -45,6 +69,7 @@ func (h *testResultsBaseHandler) Parse(_ context.Context, r *http.Request) error
 
 type testResultsGetByTasksHandler struct {
 	testResultsBaseHandler
+	format string
 }
 
 func makeGetTestResultsByTasks(sc data.Connector) *testResultsGetByTasksHandler {
@@ -61,7 +86,66 @@ func (h *testResultsGetByTasksHandler) Factory() gimlet.RouteHandler {
 	return newHandler
 }
 
+// Parse fetches the tasks and filter options from the request payload and the
+// export format from the http request. The format query parameter takes
+// precedence over the Accept header, and the format defaults to JSON.
+func (h *testResultsGetByTasksHandler) Parse(ctx context.Context, r *http.Request) error {
+	if err := h.testResultsBaseHandler.Parse(ctx, r); err != nil {
+		return err
+	}
+
+	h.format = r.URL.Query().Get(testResultsExportFormat)
+	switch h.format {
+	case "":
+		h.format = negotiateTestResultsExportFormat(r.Header.Get("Accept"))
+		return nil
+	case data.TestResultsExportJSON, data.TestResultsExportJUnit, data.TestResultsExportCSV:
+		return nil
+	default:
+		return errors.Errorf("unsupported export format '%s'", h.format)
+	}
+}
+
+// negotiateTestResultsExportFormat returns the export format of the first
+// supported media type in the given Accept header, defaulting to JSON.
+func negotiateTestResultsExportFormat(accept string) string {
+	for _, mediaType := range strings.Split(accept, ",") {
+		if i := strings.Index(mediaType, ";"); i >= 0 {
+			mediaType = mediaType[:i]
+		}
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case "application/json":
+			return data.TestResultsExportJSON
+		case "application/xml", "text/xml":
+			return data.TestResultsExportJUnit
+		case "text/csv":
+			return data.TestResultsExportCSV
+		}
+	}
+
+	return data.TestResultsExportJSON
+}
+
+// testResultsExportContentType returns the content type of the given export
+// format.
+func testResultsExportContentType(format string) string {
+	switch format {
+	case data.TestResultsExportJUnit:
+		return "application/xml; charset=utf-8"
+	case data.TestResultsExportCSV:
+		return "text/csv; charset=utf-8"
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+// Run finds and returns the test results of the tasks as JSON, or streams
+// them in the requested export format.
 func (h *testResultsGetByTasksHandler) Run(ctx context.Context) gimlet.Responder {
+	if h.format == data.TestResultsExportJUnit || h.format == data.TestResultsExportCSV {
+		return h.runExport(ctx)
+	}
+
 	testResults, err := h.sc.FindTestResults(ctx, h.payload.TaskOpts, h.payload.FilterOpts)
 	if err != nil {
 		err = errors.Wrap(err, "getting test results by tasks")
@@ -77,6 +161,24 @@ func (h *testResultsGetByTasksHandler) Run(ctx context.Context) gimlet.Responder
 	return gimlet.NewJSONResponse(testResults)
 }
 
+func (h *testResultsGetByTasksHandler) runExport(ctx context.Context) gimlet.Responder {
+	r, err := h.sc.ExportTestResults(ctx, h.payload.TaskOpts, h.payload.FilterOpts, h.format)
+	if err != nil {
+		err = errors.Wrapf(err, "exporting test results by tasks as %s", h.format)
+		logFindError(err, message.Fields{
+			"request":         gimlet.GetRequestID(ctx),
+			"method":          "GET",
+			"route":           "/test_results/tasks",
+			"format":          h.format,
+			"request_payload": h.payload,
+		})
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+	setResponseHeader(ctx, "Content-Type", testResultsExportContentType(h.format))
+
+	return gimlet.NewTextResponse(r)
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 //
 // GET /test_results/tasks/stats
@@ -204,3 +306,223 @@ func (h *testResultsGetFilteredSamplesHandler) Run(ctx context.Context) gimlet.R
 
 	return gimlet.NewJSONResponse(samples)
 }
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /test_results/history
+
+type testHistoryGetHandler struct {
+	sc   data.Connector
+	opts data.TestHistoryOptions
+}
+
+func makeGetTestHistory(sc data.Connector) gimlet.RouteHandler {
+	return &testHistoryGetHandler{
+		sc: sc,
+	}
+}
+
+// Factory returns a pointer to a new testHistoryGetHandler.
+func (h *testHistoryGetHandler) Factory() gimlet.RouteHandler {
+	return &testHistoryGetHandler{
+		sc: h.sc,
+	}
+}
+
+// Parse fetches the project, variant, task name, test name, mainline, time
+// or version range, and limit from the http request.
+func (h *testHistoryGetHandler) Parse(_ context.Context, r *http.Request) error {
+	var err error
+	catcher := grip.NewBasicCatcher()
+
+	vals := r.URL.Query()
+	h.opts.Project = vals.Get(testResultsProject)
+	h.opts.Variant = vals.Get(testResultsVariant)
+	h.opts.TaskName = vals.Get(testResultsTaskName)
+	h.opts.TestName = vals.Get(testResultsTestName)
+	h.opts.Mainline = vals.Get(testResultsMainline) == trueString
+	h.opts.StartVersion = vals.Get(testResultsStartVersion)
+	h.opts.EndVersion = vals.Get(testResultsEndVersion)
+	catcher.NewWhen(h.opts.Project == "", "must specify a project")
+	catcher.NewWhen(h.opts.TestName == "", "must specify a test name")
+	if vals.Get(logStartAt) != "" || vals.Get(logEndAt) != "" {
+		h.opts.TimeRange, err = parseTimeRange(time.RFC3339, vals.Get(logStartAt), vals.Get(logEndAt))
+		catcher.Add(err)
+	}
+	if len(vals[limit]) > 0 {
+		h.opts.Limit, err = strconv.Atoi(vals[limit][0])
+		catcher.Add(err)
+	}
+
+	return catcher.Resolve()
+}
+
+// Run finds and returns the history of the test.
+func (h *testHistoryGetHandler) Run(ctx context.Context) gimlet.Responder {
+	history, err := h.sc.FindTestHistory(ctx, h.opts)
+	if err != nil {
+		err = errors.Wrapf(err, "getting history of test '%s'", h.opts.TestName)
+		logFindError(err, message.Fields{
+			"request":   gimlet.GetRequestID(ctx),
+			"method":    "GET",
+			"route":     "/test_results/history",
+			"project":   h.opts.Project,
+			"test_name": h.opts.TestName,
+		})
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+
+	return gimlet.NewJSONResponse(history)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /test_results/flaky
+
+type flakyTestsGetHandler struct {
+	sc   data.Connector
+	opts data.FlakyTestsOptions
+}
+
+func makeGetFlakyTests(sc data.Connector) gimlet.RouteHandler {
+	return &flakyTestsGetHandler{
+		sc: sc,
+	}
+}
+
+// Factory returns a pointer to a new flakyTestsGetHandler.
+func (h *flakyTestsGetHandler) Factory() gimlet.RouteHandler {
+	return &flakyTestsGetHandler{
+		sc: h.sc,
+	}
+}
+
+// Parse fetches the project, variant, task name, min score, and limit from
+// the http request.
+func (h *flakyTestsGetHandler) Parse(_ context.Context, r *http.Request) error {
+	var err error
+	catcher := grip.NewBasicCatcher()
+
+	vals := r.URL.Query()
+	h.opts.Project = vals.Get(testResultsProject)
+	h.opts.Variant = vals.Get(testResultsVariant)
+	h.opts.TaskName = vals.Get(testResultsTaskName)
+	catcher.NewWhen(h.opts.Project == "", "must specify a project")
+	if len(vals[flakyMinScore]) > 0 {
+		h.opts.MinScore, err = strconv.ParseFloat(vals[flakyMinScore][0], 64)
+		catcher.Add(err)
+	}
+	if len(vals[limit]) > 0 {
+		h.opts.Limit, err = strconv.Atoi(vals[limit][0])
+		catcher.Add(err)
+	}
+
+	return catcher.Resolve()
+}
+
+// Run finds and returns the flaky tests of the project.
+func (h *flakyTestsGetHandler) Run(ctx context.Context) gimlet.Responder {
+	flakyTests, err := h.sc.FindFlakyTests(ctx, h.opts)
+	if err != nil {
+		err = errors.Wrapf(err, "getting flaky tests of project '%s'", h.opts.Project)
+		logFindError(err, message.Fields{
+			"request": gimlet.GetRequestID(ctx),
+			"method":  "GET",
+			"route":   "/test_results/flaky",
+			"project": h.opts.Project,
+		})
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+
+	return gimlet.NewJSONResponse(flakyTests)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// POST /test_results/report/{format}
+
+type testReportPostHandler struct {
+	sc   data.Connector
+	opts data.TestReportOptions
+}
+
+func makePostTestReport(sc data.Connector) gimlet.RouteHandler {
+	return &testReportPostHandler{
+		sc: sc,
+	}
+}
+
+// Factory returns a pointer to a new testReportPostHandler.
+func (h *testReportPostHandler) Factory() gimlet.RouteHandler {
+	return &testReportPostHandler{
+		sc: h.sc,
+	}
+}
+
+// Parse fetches the report format from the URL, the project, version,
+// variant, task name, display task name, task ID, execution, request type,
+// and mainline from the query parameters, and the report from the body of the
+// http request. Reports larger than maxTestReportSize are rejected.
+func (h *testReportPostHandler) Parse(_ context.Context, r *http.Request) error {
+	var err error
+	catcher := grip.NewBasicCatcher()
+
+	h.opts.Format = gimlet.GetVars(r)[testReportFormat]
+	vals := r.URL.Query()
+	h.opts.Project = vals.Get(testResultsProject)
+	h.opts.Version = vals.Get(testResultsVersion)
+	h.opts.Variant = vals.Get(testResultsVariant)
+	h.opts.TaskName = vals.Get(testResultsTaskName)
+	h.opts.DisplayTaskName = vals.Get(testResultsDisplayTaskName)
+	h.opts.TaskID = vals.Get(testResultsTaskID)
+	h.opts.RequestType = vals.Get(testResultsRequestType)
+	h.opts.Mainline = vals.Get(testResultsMainline) == trueString
+	catcher.NewWhen(h.opts.Project == "", "must specify a project")
+	catcher.NewWhen(h.opts.Version == "", "must specify a version")
+	catcher.NewWhen(h.opts.Variant == "", "must specify a variant")
+	catcher.NewWhen(h.opts.TaskID == "", "must specify a task ID")
+	catcher.NewWhen(len(vals[execution]) == 0, "must specify an execution")
+	if len(vals[execution]) > 0 {
+		h.opts.Execution, err = strconv.Atoi(vals[execution][0])
+		catcher.Add(err)
+	}
+	if catcher.HasErrors() {
+		return catcher.Resolve()
+	}
+
+	if r.Body == nil {
+		return errors.New("missing test report")
+	}
+	body := http.MaxBytesReader(nil, r.Body, maxTestReportSize)
+	defer body.Close()
+	h.opts.Report, err = io.ReadAll(body)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return gimlet.ErrorResponse{
+			StatusCode: http.StatusRequestEntityTooLarge,
+			Message:    errors.Errorf("test report exceeds the maximum size of %d bytes", maxTestReportSize).Error(),
+		}
+	}
+
+	return errors.Wrap(err, "reading test report")
+}
+
+// Run imports the test report as the test results of the task execution and
+// returns the ID and stats of the created test results record.
+func (h *testReportPostHandler) Run(ctx context.Context) gimlet.Responder {
+	imported, err := h.sc.ImportTestReport(ctx, h.opts)
+	if err != nil {
+		err = errors.Wrapf(err, "importing %s test report of task '%s'", h.opts.Format, h.opts.TaskID)
+		logFindError(err, message.Fields{
+			"request":   gimlet.GetRequestID(ctx),
+			"method":    "POST",
+			"route":     "/test_results/report/{format}",
+			"format":    h.opts.Format,
+			"task_id":   h.opts.TaskID,
+			"execution": h.opts.Execution,
+		})
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+
+	return gimlet.NewJSONResponse(imported)
+}
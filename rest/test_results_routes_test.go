@@ -9,6 +9,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -21,6 +22,8 @@ import (
 	"github.com/evergreen-ci/utility"
 	"github.com/mongodb/amboy/queue"
 	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -77,6 +80,9 @@ func (s *TestResultsHandlerSuite) setup(tempDir string) {
 	conf := dbModel.NewCedarConfig(s.env)
 	conf.Bucket = dbModel.BucketConfig{
 		TestResultsBucket:       tempDir,
+		TestResultsBucketType:   dbModel.PailLocal,
+		BuildLogsBucket:         tempDir,
+		BuildLogsBucketType:     dbModel.PailLocal,
 		PrestoBucket:            tempDir,
 		PrestoTestResultsPrefix: "presto-test-results",
 	}
@@ -406,6 +412,88 @@ func (s *TestResultsHandlerSuite) TestTestResultsGetByTasksHandler() {
 	}
 }
 
+func (s *TestResultsHandlerSuite) TestTestResultsGetByTasksHandlerExport() {
+	for _, test := range []struct {
+		name     string
+		format   string
+		expected []string
+	}{
+		{
+			name:   "CSV",
+			format: data.TestResultsExportCSV,
+			expected: []string{
+				"task_id,execution,test_name,display_test_name,group_id,trial,status,",
+				"task1,0,test0,test0,,0,teststatus-fail,",
+				"url/rest/v1/buildlogger/test_name/task1/log0?execution=0",
+			},
+		},
+		{
+			name:   "JUnit",
+			format: data.TestResultsExportJUnit,
+			expected: []string{
+				`<testsuites>`,
+				`<testsuite name="task1.0" tests="3" failures="3" skipped="0"`,
+				`<testcase name="test2"`,
+				`<property name="log_url" value="url/rest/v1/buildlogger/test_name/task1/log0?execution=0">`,
+			},
+		},
+	} {
+		s.Run(test.name, func() {
+			rh := makeGetTestResultsByTasks(s.sc)
+			rh.payload.TaskOpts = []data.TestResultsTaskOptions{{TaskID: "task1", Execution: 0}}
+			rh.format = test.format
+			resp := rh.Run(context.Background())
+			s.Require().NotNil(resp)
+			s.Require().Equal(http.StatusOK, resp.Status())
+
+			r, ok := resp.Data().(io.Reader)
+			s.Require().True(ok)
+			export, err := io.ReadAll(r)
+			s.Require().NoError(err)
+			for _, expected := range test.expected {
+				s.Contains(string(export), expected)
+			}
+		})
+	}
+}
+
+func TestTestResultsGetByTasksHandlerParseFormat(t *testing.T) {
+	for _, test := range []struct {
+		query  string
+		accept string
+		format string
+		hasErr bool
+	}{
+		{query: "", format: "json"},
+		{query: "?format=json", format: "json"},
+		{query: "?format=junit", format: "junit"},
+		{query: "?format=csv", format: "csv"},
+		{query: "?format=xlsx", hasErr: true},
+		{query: "?format=json", accept: "text/csv", format: "json"},
+		{accept: "application/xml", format: "junit"},
+		{accept: "text/xml;q=0.9", format: "junit"},
+		{accept: "text/csv", format: "csv"},
+		{accept: "text/html, text/csv;q=0.8, */*", format: "csv"},
+		{accept: "application/json, text/csv", format: "json"},
+		{accept: "*/*", format: "json"},
+	} {
+		t.Run(test.query+test.accept, func(t *testing.T) {
+			rh := makeGetTestResultsByTasks(nil)
+			req, err := http.NewRequest(http.MethodGet, "https://cedar.mongodb.com/rest/v1/test_results/tasks"+test.query, bytes.NewBufferString(`{"tasks": [{"task_id": "task1"}]}`))
+			require.NoError(t, err)
+			req.Header.Set("Accept", test.accept)
+			err = rh.Parse(context.TODO(), req)
+			if test.hasErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.format, rh.format)
+			assert.Equal(t, []data.TestResultsTaskOptions{{TaskID: "task1"}}, rh.payload.TaskOpts)
+		})
+	}
+}
+
 func (s *TestResultsHandlerSuite) TestTestResultsGetStatsByTasksHandler() {
 	canceledCtx, cancel := context.WithCancel(context.Background())
 	cancel()
@@ -545,3 +633,282 @@ func (s *TestResultsHandlerSuite) TestTestResultsGetFailedSampleByTasksHandler()
 		})
 	}
 }
+
+func (s *TestResultsHandlerSuite) TestTestHistoryGetHandlerFound() {
+	rh := makeGetTestHistory(s.sc).(*testHistoryGetHandler)
+	rh.opts = data.TestHistoryOptions{
+		Project:  "test",
+		Variant:  "linux",
+		TestName: "test0",
+	}
+	resp := rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusOK, resp.Status())
+	history, ok := resp.Data().([]model.APITestHistoryEntry)
+	s.Require().True(ok)
+	s.Require().Len(history, 3)
+	for _, entry := range history {
+		s.Equal("test0", *entry.TestName)
+		s.Equal("teststatus-fail", *entry.Status)
+		s.Equal(1, entry.NumFailed)
+	}
+}
+
+func (s *TestResultsHandlerSuite) TestTestHistoryGetHandlerInvalidOptions() {
+	rh := makeGetTestHistory(s.sc).(*testHistoryGetHandler)
+	rh.opts = data.TestHistoryOptions{
+		Project:  "test",
+		TestName: "test0",
+		Limit:    dbModel.MaxTestHistoryLimit + 1,
+	}
+	resp := rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusBadRequest, resp.Status())
+}
+
+func TestTestHistoryGetHandlerParse(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		query    string
+		expected data.TestHistoryOptions
+		hasErr   bool
+	}{
+		{
+			name:   "NoProject",
+			query:  "test_name=test0",
+			hasErr: true,
+		},
+		{
+			name:   "NoTestName",
+			query:  "project=project",
+			hasErr: true,
+		},
+		{
+			name:   "InvalidLimit",
+			query:  "project=project&test_name=test0&limit=many",
+			hasErr: true,
+		},
+		{
+			name:   "InvalidStart",
+			query:  "project=project&test_name=test0&start=yesterday",
+			hasErr: true,
+		},
+		{
+			name:  "AllOptions",
+			query: "project=project&variant=linux&task_name=task&test_name=test0&mainline=true&start=2021-01-01T00:00:00Z&end=2021-02-01T00:00:00Z&limit=50",
+			expected: data.TestHistoryOptions{
+				Project:  "project",
+				Variant:  "linux",
+				TaskName: "task",
+				TestName: "test0",
+				Mainline: true,
+				TimeRange: dbModel.TimeRange{
+					StartAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+					EndAt:   time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+				},
+				Limit: 50,
+			},
+		},
+		{
+			name:  "VersionRange",
+			query: "project=project&test_name=test0&start_version=v1&end_version=v2",
+			expected: data.TestHistoryOptions{
+				Project:      "project",
+				TestName:     "test0",
+				StartVersion: "v1",
+				EndVersion:   "v2",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			rh := makeGetTestHistory(nil).(*testHistoryGetHandler)
+			req, err := http.NewRequest(http.MethodGet, "https://cedar.mongodb.com/rest/v1/test_results/history?"+test.query, nil)
+			require.NoError(t, err)
+			err = rh.Parse(context.TODO(), req)
+			if test.hasErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, rh.opts)
+		})
+	}
+}
+
+func (s *TestResultsHandlerSuite) TestFlakyTestsGetHandlerInvalidOptions() {
+	rh := makeGetFlakyTests(s.sc).(*flakyTestsGetHandler)
+	rh.opts = data.FlakyTestsOptions{
+		Project:  "test",
+		MinScore: 2,
+	}
+	resp := rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusBadRequest, resp.Status())
+}
+
+func TestFlakyTestsGetHandlerParse(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		query    string
+		expected data.FlakyTestsOptions
+		hasErr   bool
+	}{
+		{
+			name:   "NoProject",
+			query:  "variant=linux",
+			hasErr: true,
+		},
+		{
+			name:   "InvalidMinScore",
+			query:  "project=project&min_score=high",
+			hasErr: true,
+		},
+		{
+			name:   "InvalidLimit",
+			query:  "project=project&limit=many",
+			hasErr: true,
+		},
+		{
+			name:  "AllOptions",
+			query: "project=project&variant=linux&task_name=task&min_score=0.25&limit=10",
+			expected: data.FlakyTestsOptions{
+				Project:  "project",
+				Variant:  "linux",
+				TaskName: "task",
+				MinScore: 0.25,
+				Limit:    10,
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			rh := makeGetFlakyTests(nil).(*flakyTestsGetHandler)
+			req, err := http.NewRequest(http.MethodGet, "https://cedar.mongodb.com/rest/v1/test_results/flaky?"+test.query, nil)
+			require.NoError(t, err)
+			err = rh.Parse(context.TODO(), req)
+			if test.hasErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, rh.opts)
+		})
+	}
+}
+
+func (s *TestResultsHandlerSuite) TestTestReportPostHandler() {
+	rh := makePostTestReport(s.sc).(*testReportPostHandler)
+	rh.opts = data.TestReportOptions{
+		Format:    "junit",
+		Project:   "project",
+		Version:   "version",
+		Variant:   "variant",
+		TaskName:  "task_name",
+		TaskID:    "report_task",
+		Execution: 0,
+		Report: []byte(`<testsuite>
+  <testcase name="testPass" time="1"/>
+  <testcase name="testFail" time="2"><failure message="expected 1, got 2"/></testcase>
+</testsuite>`),
+	}
+
+	resp := rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Require().Equal(http.StatusOK, resp.Status())
+	imported, ok := resp.Data().(*model.APITestReportImport)
+	s.Require().True(ok)
+	s.NotEmpty(utility.FromStringPtr(imported.ID))
+	s.Equal("report_task", utility.FromStringPtr(imported.TaskID))
+	s.Equal(2, imported.Stats.TotalCount)
+	s.Equal(1, imported.Stats.FailedCount)
+
+	resp = rh.Run(context.TODO())
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusConflict, resp.Status())
+}
+
+func (s *TestResultsHandlerSuite) TestTestReportPostHandlerInvalidReport() {
+	rh := makePostTestReport(s.sc).(*testReportPostHandler)
+	for _, opts := range []data.TestReportOptions{
+		{Format: "xunit", TaskID: "invalid_report_task", Report: []byte("<testsuite/>")},
+		{Format: "junit", TaskID: "invalid_report_task", Report: []byte("<testsuite>")},
+	} {
+		rh.opts = opts
+		resp := rh.Run(context.TODO())
+		s.Require().NotNil(resp)
+		s.Equal(http.StatusBadRequest, resp.Status())
+	}
+}
+
+func TestTestReportPostHandlerParse(t *testing.T) {
+	const taskQuery = "project=project&version=version&variant=linux&task_id=task&execution=1"
+	for _, test := range []struct {
+		name     string
+		query    string
+		body     string
+		expected data.TestReportOptions
+		hasErr   bool
+	}{
+		{
+			name:   "NoProject",
+			query:  "version=version&variant=linux&task_id=task&execution=1",
+			body:   "<testsuite/>",
+			hasErr: true,
+		},
+		{
+			name:   "NoTaskID",
+			query:  "project=project&version=version&variant=linux&execution=1",
+			body:   "<testsuite/>",
+			hasErr: true,
+		},
+		{
+			name:   "NoExecution",
+			query:  "project=project&version=version&variant=linux&task_id=task",
+			body:   "<testsuite/>",
+			hasErr: true,
+		},
+		{
+			name:   "InvalidExecution",
+			query:  "project=project&version=version&variant=linux&task_id=task&execution=first",
+			body:   "<testsuite/>",
+			hasErr: true,
+		},
+		{
+			name:   "TooLarge",
+			query:  taskQuery,
+			body:   strings.Repeat(" ", maxTestReportSize+1),
+			hasErr: true,
+		},
+		{
+			name:  "AllOptions",
+			query: taskQuery + "&task_name=task_name&display_task_name=display&request_type=patch_request&mainline=true",
+			body:  "<testsuite/>",
+			expected: data.TestReportOptions{
+				Format:          "junit",
+				Project:         "project",
+				Version:         "version",
+				Variant:         "linux",
+				TaskName:        "task_name",
+				DisplayTaskName: "display",
+				TaskID:          "task",
+				Execution:       1,
+				RequestType:     "patch_request",
+				Mainline:        true,
+				Report:          []byte("<testsuite/>"),
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			rh := makePostTestReport(nil).(*testReportPostHandler)
+			req, err := http.NewRequest(http.MethodPost, "https://cedar.mongodb.com/rest/v1/test_results/report/junit?"+test.query, bytes.NewBufferString(test.body))
+			require.NoError(t, err)
+			req = gimlet.SetURLVars(req, map[string]string{"format": "junit"})
+			err = rh.Parse(context.TODO(), req)
+			if test.hasErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, rh.opts)
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package internal
 
 import (
+	"time"
+
 	"github.com/evergreen-ci/cedar/model"
 	"github.com/mongodb/grip/level"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // Export exports LogFormat to the corresponding LogFormat type in the model
@@ -45,6 +48,15 @@ func (l *LogLine) Export() model.LogLine {
 	}
 }
 
+// ImportLogLine converts a LogLine type from the model package into a LogLine.
+func ImportLogLine(line model.LogLine) *LogLine {
+	return &LogLine{
+		Priority:  int32(line.Priority),
+		Timestamp: timestamppb.New(line.Timestamp),
+		Data:      []byte(line.Data),
+	}
+}
+
 // Export exports LogInfo to the corresponding LogInfo type in the model
 // package.
 func (l *LogInfo) Export() model.LogInfo {
@@ -64,3 +76,18 @@ func (l *LogInfo) Export() model.LogInfo {
 		Mainline:    l.Mainline,
 	}
 }
+
+// ExportTimeRange exports the start and end timestamps of the LogReadRequest
+// to the corresponding TimeRange type in the model package. If the end
+// timestamp is not set, it defaults to the current time.
+func (r *LogReadRequest) ExportTimeRange() model.TimeRange {
+	tr := model.TimeRange{EndAt: time.Now()}
+	if r.Start != nil {
+		tr.StartAt = r.Start.AsTime()
+	}
+	if r.End != nil {
+		tr.EndAt = r.End.AsTime()
+	}
+
+	return tr
+}
@@ -119,3 +119,34 @@ func TestLogInfoExport(t *testing.T) {
 	assert.Equal(t, logInfo.Mainline, modelLogInfo.Mainline)
 
 }
+
+func TestImportLogLine(t *testing.T) {
+	modelLogLine := model.LogLine{
+		Priority:  level.Alert,
+		Timestamp: time.Date(9999, time.December, 31, 23, 59, 59, 0, time.UTC),
+		Data:      "Goodbye year 9999\n",
+	}
+	logLine := ImportLogLine(modelLogLine)
+	assert.EqualValues(t, level.Alert, logLine.Priority)
+	assert.Equal(t, modelLogLine.Timestamp, logLine.Timestamp.AsTime())
+	assert.EqualValues(t, modelLogLine.Data, logLine.Data)
+}
+
+func TestLogReadRequestExportTimeRange(t *testing.T) {
+	start := time.Now().Add(-time.Hour).UTC().Round(time.Millisecond)
+	end := time.Now().Add(-time.Minute).UTC().Round(time.Millisecond)
+	t.Run("Set", func(t *testing.T) {
+		req := &LogReadRequest{
+			Start: timestamppb.New(start),
+			End:   timestamppb.New(end),
+		}
+		tr := req.ExportTimeRange()
+		assert.Equal(t, start, tr.StartAt)
+		assert.Equal(t, end, tr.EndAt)
+	})
+	t.Run("Unset", func(t *testing.T) {
+		tr := (&LogReadRequest{}).ExportTimeRange()
+		assert.True(t, tr.StartAt.IsZero())
+		assert.WithinDuration(t, time.Now(), tr.EndAt, time.Second)
+	})
+}
@@ -23,6 +23,7 @@ const (
 	Buildlogger_AppendLogLines_FullMethodName = "/cedar.Buildlogger/AppendLogLines"
 	Buildlogger_StreamLogLines_FullMethodName = "/cedar.Buildlogger/StreamLogLines"
 	Buildlogger_CloseLog_FullMethodName       = "/cedar.Buildlogger/CloseLog"
+	Buildlogger_ReadLogLines_FullMethodName   = "/cedar.Buildlogger/ReadLogLines"
 )
 
 // BuildloggerClient is the client API for Buildlogger service.
@@ -33,6 +34,7 @@ type BuildloggerClient interface {
 	AppendLogLines(ctx context.Context, in *LogLines, opts ...grpc.CallOption) (*BuildloggerResponse, error)
 	StreamLogLines(ctx context.Context, opts ...grpc.CallOption) (Buildlogger_StreamLogLinesClient, error)
 	CloseLog(ctx context.Context, in *LogEndInfo, opts ...grpc.CallOption) (*BuildloggerResponse, error)
+	ReadLogLines(ctx context.Context, in *LogReadRequest, opts ...grpc.CallOption) (Buildlogger_ReadLogLinesClient, error)
 }
 
 type buildloggerClient struct {
@@ -104,6 +106,38 @@ func (c *buildloggerClient) CloseLog(ctx context.Context, in *LogEndInfo, opts .
 	return out, nil
 }
 
+func (c *buildloggerClient) ReadLogLines(ctx context.Context, in *LogReadRequest, opts ...grpc.CallOption) (Buildlogger_ReadLogLinesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Buildlogger_ServiceDesc.Streams[1], Buildlogger_ReadLogLines_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &buildloggerReadLogLinesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Buildlogger_ReadLogLinesClient interface {
+	Recv() (*LogLine, error)
+	grpc.ClientStream
+}
+
+type buildloggerReadLogLinesClient struct {
+	grpc.ClientStream
+}
+
+func (x *buildloggerReadLogLinesClient) Recv() (*LogLine, error) {
+	m := new(LogLine)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // BuildloggerServer is the server API for Buildlogger service.
 // All implementations must embed UnimplementedBuildloggerServer
 // for forward compatibility
@@ -112,6 +146,7 @@ type BuildloggerServer interface {
 	AppendLogLines(context.Context, *LogLines) (*BuildloggerResponse, error)
 	StreamLogLines(Buildlogger_StreamLogLinesServer) error
 	CloseLog(context.Context, *LogEndInfo) (*BuildloggerResponse, error)
+	ReadLogLines(*LogReadRequest, Buildlogger_ReadLogLinesServer) error
 	mustEmbedUnimplementedBuildloggerServer()
 }
 
@@ -131,6 +166,9 @@ func (UnimplementedBuildloggerServer) StreamLogLines(Buildlogger_StreamLogLinesS
 func (UnimplementedBuildloggerServer) CloseLog(context.Context, *LogEndInfo) (*BuildloggerResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CloseLog not implemented")
 }
+func (UnimplementedBuildloggerServer) ReadLogLines(*LogReadRequest, Buildlogger_ReadLogLinesServer) error {
+	return status.Errorf(codes.Unimplemented, "method ReadLogLines not implemented")
+}
 func (UnimplementedBuildloggerServer) mustEmbedUnimplementedBuildloggerServer() {}
 
 // UnsafeBuildloggerServer may be embedded to opt out of forward compatibility for this service.
@@ -224,6 +262,27 @@ func _Buildlogger_CloseLog_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Buildlogger_ReadLogLines_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LogReadRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BuildloggerServer).ReadLogLines(m, &buildloggerReadLogLinesServer{stream})
+}
+
+type Buildlogger_ReadLogLinesServer interface {
+	Send(*LogLine) error
+	grpc.ServerStream
+}
+
+type buildloggerReadLogLinesServer struct {
+	grpc.ServerStream
+}
+
+func (x *buildloggerReadLogLinesServer) Send(m *LogLine) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // Buildlogger_ServiceDesc is the grpc.ServiceDesc for Buildlogger service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -250,6 +309,11 @@ var Buildlogger_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Buildlogger_StreamLogLines_Handler,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "ReadLogLines",
+			Handler:       _Buildlogger_ReadLogLines_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "buildlogger.proto",
 }
@@ -3,15 +3,23 @@ package internal
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/evergreen-ci/cedar"
 	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/cedar/units"
 	"github.com/mongodb/anser/db"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 )
 
+// followLogPollInterval is the interval at which followed logs are checked
+// for new lines.
+const followLogPollInterval = time.Second
+
 type buildloggerService struct {
 	env cedar.Environment
 
@@ -41,11 +49,11 @@ func (s *buildloggerService) CreateLog(ctx context.Context, data *LogData) (*Bui
 	return &BuildloggerResponse{LogId: log.ID}, newRPCError(codes.Internal, errors.Wrap(log.SaveNew(ctx), "saving log record"))
 }
 
-// AppendLogLines adds log lines to an existing buildlogger log.
+// AppendLogLines adds log lines to an existing buildlogger log. Lines
+// malformed for the log's format are rejected as an invalid argument, lines
+// exceeding a quota of the log's project are rejected as resource exhausted
+// and lines appended to a closed log as a failed precondition.
 func (s *buildloggerService) AppendLogLines(ctx context.Context, lines *LogLines) (*BuildloggerResponse, error) {
-	// TODO: some type of size check? We should probably limit the size of
-	// log lines.
-
 	log := &model.Log{ID: lines.LogId}
 	log.Setup(s.env)
 	if err := log.Find(ctx); err != nil {
@@ -60,8 +68,35 @@ func (s *buildloggerService) AppendLogLines(ctx context.Context, lines *LogLines
 		exportedLines = append(exportedLines, line.Export())
 	}
 
+	if lines.Sequence < 0 {
+		return nil, newRPCError(codes.InvalidArgument, errors.Errorf("invalid sequence number %d for log '%s'", lines.Sequence, lines.LogId))
+	}
+	if lines.Sequence > 0 {
+		// Replayed sequence numbers were already appended and are
+		// acknowledged without appending the lines again.
+		_, err := log.AppendSequence(ctx, lines.Sequence, exportedLines)
+		return &BuildloggerResponse{LogId: log.ID},
+			newAppendRPCError(errors.Wrapf(err, "appending log lines '%s' with sequence number %d", lines.LogId, lines.Sequence))
+	}
+
 	return &BuildloggerResponse{LogId: log.ID},
-		newRPCError(codes.Internal, errors.Wrapf(log.Append(ctx, exportedLines), "appending log lines '%s'", lines.LogId))
+		newAppendRPCError(errors.Wrapf(log.Append(ctx, exportedLines), "appending log lines '%s'", lines.LogId))
+}
+
+// newAppendRPCError returns the RPC error for a failed append, distinguishing
+// appends rejected for malformed lines, for exceeding a quota, or for a closed
+// log.
+func newAppendRPCError(err error) error {
+	if model.IsLogLineInvalid(err) {
+		return newRPCError(codes.InvalidArgument, err)
+	}
+	if model.IsLogQuotaExceeded(err) {
+		return newRPCError(codes.ResourceExhausted, err)
+	}
+	if model.IsLogCompleted(err) {
+		return newRPCError(codes.FailedPrecondition, err)
+	}
+	return newRPCError(codes.Internal, err)
 }
 
 // StreamLogLines adds log lines via client-side streaming to an existing
@@ -97,7 +132,8 @@ func (s *buildloggerService) StreamLogLines(stream Buildlogger_StreamLogLinesSer
 }
 
 // CloseLog "closes out" a buildlogger log by setting the completed at
-// timestamp and the exit code. This should be the last rcp call made on a log.
+// timestamp and the exit code. This should be the last rcp call made on a log,
+// closing a log that was already closed is a failed precondition.
 func (s *buildloggerService) CloseLog(ctx context.Context, info *LogEndInfo) (*BuildloggerResponse, error) {
 	log := &model.Log{ID: info.LogId}
 	log.Setup(s.env)
@@ -108,6 +144,140 @@ func (s *buildloggerService) CloseLog(ctx context.Context, info *LogEndInfo) (*B
 		return nil, newRPCError(codes.Internal, errors.Wrapf(err, "finding log record '%s'", info.LogId))
 	}
 
-	return &BuildloggerResponse{LogId: log.ID},
-		newRPCError(codes.Internal, errors.Wrapf(log.Close(ctx, int(info.ExitCode)), "closing log '%s'", log.ID))
+	if err := log.Close(ctx, int(info.ExitCode)); err != nil {
+		if model.IsLogCompleted(err) {
+			return nil, newRPCError(codes.FailedPrecondition, err)
+		}
+		return nil, newRPCError(codes.Internal, errors.Wrapf(err, "closing log '%s'", log.ID))
+	}
+
+	if queue := s.env.GetRemoteQueue(); queue != nil {
+		grip.Warning(message.WrapError(queue.Put(ctx, units.NewCompactLogChunksJob(s.env, log.ID)), message.Fields{
+			"message": "could not enqueue chunk compaction job",
+			"log_id":  log.ID,
+		}))
+		if info.ExitCode != 0 {
+			grip.Warning(message.WrapError(queue.Put(ctx, units.NewExtractFailureSignaturesJob(s.env, log.ID)), message.Fields{
+				"message": "could not enqueue failure signature extraction job",
+				"log_id":  log.ID,
+			}))
+		}
+	}
+
+	return &BuildloggerResponse{LogId: log.ID}, nil
+}
+
+// ReadLogLines streams, via server-side streaming, the lines of an existing
+// buildlogger log. The log is either identified by its ID or, if no ID is
+// given, the logs matching the given task ID, test name, process name, and
+// tags are merged. Only lines within the requested time range are sent.
+func (s *buildloggerService) ReadLogLines(req *LogReadRequest, stream Buildlogger_ReadLogLinesServer) error {
+	ctx := stream.Context()
+
+	timeRange := req.ExportTimeRange()
+	if !timeRange.IsValid() {
+		return newRPCError(codes.InvalidArgument, errors.New("invalid time range"))
+	}
+
+	var (
+		it  model.LogIterator
+		err error
+	)
+	switch {
+	case req.Follow && req.LogId == "":
+		return newRPCError(codes.InvalidArgument, errors.New("must specify a log ID to follow"))
+	case req.Follow:
+		it, err = s.followLogByID(ctx, req.LogId, timeRange)
+	case req.LogId != "":
+		it, err = s.downloadLogByID(ctx, req.LogId, timeRange)
+	case req.TaskId != "":
+		it, err = s.mergeLogsByTaskID(ctx, req, timeRange)
+	default:
+		return newRPCError(codes.InvalidArgument, errors.New("must specify either a log ID or a task ID"))
+	}
+	if err != nil {
+		return err
+	}
+
+	for it.Next(ctx) {
+		if err = stream.Send(ImportLogLine(it.Item())); err != nil {
+			catcher := grip.NewBasicCatcher()
+			catcher.Wrap(err, "sending log line")
+			catcher.Add(it.Close())
+			return newRPCError(codes.Aborted, catcher.Resolve())
+		}
+	}
+
+	catcher := grip.NewBasicCatcher()
+	catcher.Add(it.Err())
+	catcher.Add(it.Close())
+	return newRPCError(codes.Internal, errors.Wrap(catcher.Resolve(), "iterating log lines"))
+}
+
+func (s *buildloggerService) downloadLogByID(ctx context.Context, id string, timeRange model.TimeRange) (model.LogIterator, error) {
+	log := &model.Log{ID: id}
+	log.Setup(s.env)
+	if err := log.Find(ctx); err != nil {
+		if db.ResultsNotFound(err) {
+			return nil, newRPCError(codes.NotFound, err)
+		}
+		return nil, newRPCError(codes.Internal, errors.Wrapf(err, "finding log record '%s'", id))
+	}
+
+	it, err := log.Download(ctx, timeRange)
+	if err != nil {
+		return nil, newRPCError(codes.Internal, errors.Wrapf(err, "downloading log '%s'", id))
+	}
+
+	return it, nil
+}
+
+func (s *buildloggerService) followLogByID(ctx context.Context, id string, timeRange model.TimeRange) (model.LogIterator, error) {
+	log := &model.Log{ID: id}
+	log.Setup(s.env)
+	if err := log.Find(ctx); err != nil {
+		if db.ResultsNotFound(err) {
+			return nil, newRPCError(codes.NotFound, err)
+		}
+		return nil, newRPCError(codes.Internal, errors.Wrapf(err, "finding log record '%s'", id))
+	}
+
+	it, err := log.Follow(ctx, timeRange.StartAt, followLogPollInterval)
+	if err != nil {
+		return nil, newRPCError(codes.Internal, errors.Wrapf(err, "following log '%s'", id))
+	}
+
+	return it, nil
+}
+
+func (s *buildloggerService) mergeLogsByTaskID(ctx context.Context, req *LogReadRequest, timeRange model.TimeRange) (model.LogIterator, error) {
+	opts := model.LogFindOptions{
+		TimeRange: timeRange,
+		Info: model.LogInfo{
+			TaskID:      req.TaskId,
+			TestName:    req.TestName,
+			ProcessName: req.ProcName,
+			Tags:        req.Tags,
+		},
+		LatestExecution: req.Execution == nil,
+	}
+	if req.Execution != nil {
+		opts.Info.Execution = int(*req.Execution)
+	}
+
+	logs := &model.Logs{}
+	logs.Setup(s.env)
+	if err := logs.Find(ctx, opts); err != nil {
+		if db.ResultsNotFound(err) {
+			return nil, newRPCError(codes.NotFound, err)
+		}
+		return nil, newRPCError(codes.Internal, errors.Wrapf(err, "finding logs with task ID '%s'", req.TaskId))
+	}
+
+	it, err := logs.Merge(ctx)
+	if err != nil {
+		return nil, newRPCError(codes.Internal, errors.Wrapf(err, "merging logs with task ID '%s'", req.TaskId))
+	}
+
+	return it, nil
 }
@@ -3,6 +3,7 @@ package internal
 import (
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"os"
@@ -14,10 +15,13 @@ import (
 	"github.com/evergreen-ci/cedar/model"
 	"github.com/evergreen-ci/pail"
 	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/level"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -108,6 +112,9 @@ func TestAppendLogLines(t *testing.T) {
 	log := model.CreateLog(model.LogInfo{Project: "test"}, model.PailLocal)
 	log.Setup(env)
 	require.NoError(t, log.SaveNew(ctx))
+	jsonLog := model.CreateLog(model.LogInfo{Project: "test", Format: model.LogFormatJSON}, model.PailLocal)
+	jsonLog.Setup(env)
+	require.NoError(t, jsonLog.SaveNew(ctx))
 
 	bucket, err := pail.NewLocalBucket(pail.LocalOptions{
 		Path:   tempDir,
@@ -121,6 +128,7 @@ func TestAppendLogLines(t *testing.T) {
 		env         cedar.Environment
 		invalidConf bool
 		hasErr      bool
+		code        codes.Code
 	}{
 		{
 			name: "ValidData",
@@ -222,6 +230,27 @@ func TestAppendLogLines(t *testing.T) {
 			invalidConf: true,
 			hasErr:      true,
 		},
+		{
+			name: "InvalidStructuredLine",
+			lines: &LogLines{
+				LogId: jsonLog.ID,
+				Lines: []*LogLine{
+					{
+						Priority:  30,
+						Timestamp: &timestamppb.Timestamp{Seconds: time.Now().Unix()},
+						Data:      []byte(`{"msg": "This is the first log line."}`),
+					},
+					{
+						Priority:  30,
+						Timestamp: &timestamppb.Timestamp{Seconds: time.Now().Unix()},
+						Data:      []byte("This is the second log line.\n"),
+					},
+				},
+			},
+			env:    env,
+			hasErr: true,
+			code:   codes.InvalidArgument,
+		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			port := getPort()
@@ -241,6 +270,9 @@ func TestAppendLogLines(t *testing.T) {
 			if test.hasErr {
 				assert.Error(t, err)
 				assert.Nil(t, resp)
+				if test.code != codes.OK {
+					assert.Equal(t, test.code, status.Code(err))
+				}
 			} else {
 				require.NoError(t, err)
 				require.NotNil(t, resp)
@@ -262,6 +294,151 @@ func TestAppendLogLines(t *testing.T) {
 	}
 }
 
+func TestAppendLogLinesSequence(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env, err := createBuildloggerEnv()
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, teardownBuildloggerEnv(ctx, env))
+	}()
+	tempDir, err := ioutil.TempDir(".", "buildlogger-test")
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tempDir))
+	}()
+	require.NoError(t, err)
+
+	conf, err := model.LoadCedarConfig(filepath.Join("testdata", "cedarconf.yaml"))
+	require.NoError(t, err)
+	conf.Bucket.BuildLogsBucket = tempDir
+	conf.Setup(env)
+	require.NoError(t, conf.Save())
+
+	log := model.CreateLog(model.LogInfo{Project: "test"}, model.PailLocal)
+	log.Setup(env)
+	require.NoError(t, log.SaveNew(ctx))
+
+	port := getPort()
+	require.NoError(t, startBuildloggerService(ctx, env, port))
+	client, err := getBuildloggerGRPCClient(ctx, fmt.Sprintf("localhost:%d", port), []grpc.DialOption{grpc.WithInsecure()})
+	require.NoError(t, err)
+
+	ts := time.Now().Add(-time.Hour)
+	appendLines := func(sequence int64) (*BuildloggerResponse, error) {
+		return client.AppendLogLines(ctx, &LogLines{
+			LogId:    log.ID,
+			Sequence: sequence,
+			Lines: []*LogLine{
+				{
+					Priority:  30,
+					Timestamp: timestamppb.New(ts.Add(time.Duration(sequence) * time.Second)),
+					Data:      []byte("This is a log line.\n"),
+				},
+			},
+		})
+	}
+	findLog := func(t *testing.T) *model.Log {
+		l := &model.Log{ID: log.ID}
+		l.Setup(env)
+		require.NoError(t, l.Find(ctx))
+		return l
+	}
+
+	t.Run("InvalidSequence", func(t *testing.T) {
+		resp, err := appendLines(-1)
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+	})
+	t.Run("Replay", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			resp, err := appendLines(1)
+			require.NoError(t, err)
+			require.NotNil(t, resp)
+			assert.Equal(t, log.ID, resp.LogId)
+		}
+
+		l := findLog(t)
+		assert.Equal(t, int64(1), l.Sequences.Last)
+		assert.Empty(t, l.Sequences.Gaps)
+		assert.Equal(t, 1, l.Stats.NumLines)
+	})
+	t.Run("Gap", func(t *testing.T) {
+		_, err := appendLines(3)
+		require.NoError(t, err)
+
+		l := findLog(t)
+		assert.Equal(t, int64(3), l.Sequences.Last)
+		assert.Equal(t, []model.LogSequenceRange{{Start: 2, End: 2}}, l.Sequences.Gaps)
+		assert.Equal(t, 2, l.Stats.NumLines)
+	})
+}
+
+func TestAppendLogLinesQuota(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env, err := createBuildloggerEnv()
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, teardownBuildloggerEnv(ctx, env))
+	}()
+	tempDir, err := ioutil.TempDir(".", "buildlogger-test")
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tempDir))
+	}()
+	require.NoError(t, err)
+
+	conf, err := model.LoadCedarConfig(filepath.Join("testdata", "cedarconf.yaml"))
+	require.NoError(t, err)
+	conf.Bucket.BuildLogsBucket = tempDir
+	conf.Quota = model.QuotaConfig{Rules: []model.QuotaRule{{MaxAppendBytes: 30, MaxLogBytes: 50}}}
+	conf.Setup(env)
+	require.NoError(t, conf.Save())
+
+	log := model.CreateLog(model.LogInfo{Project: "test"}, model.PailLocal)
+	log.Setup(env)
+	require.NoError(t, log.SaveNew(ctx))
+
+	port := getPort()
+	require.NoError(t, startBuildloggerService(ctx, env, port))
+	client, err := getBuildloggerGRPCClient(ctx, fmt.Sprintf("localhost:%d", port), []grpc.DialOption{grpc.WithInsecure()})
+	require.NoError(t, err)
+
+	appendLines := func(data ...string) (*BuildloggerResponse, error) {
+		lines := &LogLines{LogId: log.ID}
+		for _, d := range data {
+			lines.Lines = append(lines.Lines, &LogLine{
+				Priority:  30,
+				Timestamp: timestamppb.New(time.Now()),
+				Data:      []byte(d),
+			})
+		}
+		return client.AppendLogLines(ctx, lines)
+	}
+
+	t.Run("WithinQuota", func(t *testing.T) {
+		_, err := appendLines("This is a log line.\n")
+		require.NoError(t, err)
+	})
+	t.Run("ExceedsAppendQuota", func(t *testing.T) {
+		resp, err := appendLines("This is a log line.\n", "This is a log line.\n")
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.Nil(t, resp)
+	})
+	t.Run("ExceedsLogQuota", func(t *testing.T) {
+		_, err := appendLines("This is a log line.\n")
+		require.NoError(t, err)
+		resp, err := appendLines("This is a log line.\n")
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.Nil(t, resp)
+
+		l := &model.Log{ID: log.ID}
+		l.Setup(env)
+		require.NoError(t, l.Find(ctx))
+		assert.True(t, l.Truncated)
+		assert.Equal(t, 3, l.Stats.NumLines)
+	})
+}
+
 func TestStreamLogLines(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -497,62 +674,81 @@ func TestCloseLog(t *testing.T) {
 		assert.NoError(t, teardownBuildloggerEnv(ctx, env))
 	}()
 
-	log := model.CreateLog(model.LogInfo{Project: "test"}, model.PailLocal)
-	log.Setup(env)
-	require.NoError(t, log.SaveNew(ctx))
+	createLog := func(t *testing.T, taskID string) *model.Log {
+		log := model.CreateLog(model.LogInfo{Project: "test", TaskID: taskID}, model.PailLocal)
+		log.Setup(env)
+		require.NoError(t, log.SaveNew(ctx))
+		return log
+	}
+	closedLog := createLog(t, "closed")
+	require.NoError(t, closedLog.Close(ctx, 0))
 
 	for _, test := range []struct {
 		name   string
+		taskID string
 		info   *LogEndInfo
 		env    cedar.Environment
-		hasErr bool
+		code   codes.Code
 	}{
 		{
-			name: "ValidData",
-			info: &LogEndInfo{
-				LogId:    log.ID,
-				ExitCode: 1,
-			},
-			env: env,
+			name:   "ValidData",
+			taskID: "valid",
+			info:   &LogEndInfo{ExitCode: 1},
+			env:    env,
 		},
 		{
-			name: "DefaultData",
-			info: &LogEndInfo{LogId: log.ID},
+			name:   "DefaultData",
+			taskID: "default",
+			info:   &LogEndInfo{},
+			env:    env,
+		},
+		{
+			name: "LogDNE",
+			info: &LogEndInfo{LogId: "DNE"},
 			env:  env,
+			code: codes.NotFound,
 		},
 		{
-			name:   "LogDNE",
-			info:   &LogEndInfo{LogId: "DNE"},
-			env:    env,
-			hasErr: true,
+			name: "AlreadyClosed",
+			info: &LogEndInfo{LogId: closedLog.ID},
+			env:  env,
+			code: codes.FailedPrecondition,
 		},
 		{
 			name:   "InvalidEnv",
-			info:   &LogEndInfo{LogId: log.ID},
+			taskID: "invalid_env",
+			info:   &LogEndInfo{},
 			env:    nil,
-			hasErr: true,
+			code:   codes.Internal,
 		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
+			if test.taskID != "" {
+				test.info.LogId = createLog(t, test.taskID).ID
+			}
 			port := getPort()
 			require.NoError(t, startBuildloggerService(ctx, test.env, port))
 			client, err := getBuildloggerGRPCClient(ctx, fmt.Sprintf("localhost:%d", port), []grpc.DialOption{grpc.WithInsecure()})
 			require.NoError(t, err)
 
 			resp, err := client.CloseLog(ctx, test.info)
-			if test.hasErr {
+			if test.code != codes.OK {
 				assert.Error(t, err)
 				assert.Nil(t, resp)
+				if test.code != codes.Internal {
+					assert.Equal(t, test.code, status.Code(err))
+				}
 			} else {
 				require.NoError(t, err)
 				require.NotNil(t, resp)
-				assert.Equal(t, log.ID, resp.LogId)
+				assert.Equal(t, test.info.LogId, resp.LogId)
 
 				l := &model.Log{ID: resp.LogId}
 				l.Setup(env)
 				require.NoError(t, l.Find(ctx))
-				assert.Equal(t, log.ID, l.Info.ID())
-				assert.Equal(t, log.Artifact, l.Artifact)
+				assert.Equal(t, l.ID, l.Info.ID())
+				assert.Equal(t, model.PailLocal, l.Artifact.Type)
+				assert.Equal(t, 2, l.Artifact.Version)
 				assert.Equal(t, int(test.info.ExitCode), l.Info.ExitCode)
 				assert.True(t, time.Since(l.CompletedAt) <= time.Second)
 			}
@@ -560,6 +756,138 @@ func TestCloseLog(t *testing.T) {
 	}
 }
 
+func TestReadLogLines(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env, err := createBuildloggerEnv()
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, teardownBuildloggerEnv(ctx, env))
+	}()
+	tempDir, err := ioutil.TempDir(".", "buildlogger-test")
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tempDir))
+	}()
+	require.NoError(t, err)
+
+	conf, err := model.LoadCedarConfig(filepath.Join("testdata", "cedarconf.yaml"))
+	require.NoError(t, err)
+	conf.Bucket.BuildLogsBucket = tempDir
+	conf.Setup(env)
+	require.NoError(t, conf.Save())
+
+	ts := time.Now().Add(-time.Hour).Truncate(time.Millisecond).UTC()
+	log1 := model.CreateLog(model.LogInfo{Project: "test", TaskID: "task", ProcessName: "proc1"}, model.PailLocal)
+	log1.Setup(env)
+	require.NoError(t, log1.SaveNew(ctx))
+	lines1 := []model.LogLine{
+		{Priority: level.Info, Timestamp: ts, Data: "first line\n"},
+		{Priority: level.Error, Timestamp: ts.Add(2 * time.Second), Data: "third line\n"},
+	}
+	require.NoError(t, log1.Append(ctx, lines1))
+	require.NoError(t, log1.Close(ctx, 0))
+	log2 := model.CreateLog(model.LogInfo{Project: "test", TaskID: "task", ProcessName: "proc2"}, model.PailLocal)
+	log2.Setup(env)
+	require.NoError(t, log2.SaveNew(ctx))
+	lines2 := []model.LogLine{
+		{Priority: level.Debug, Timestamp: ts.Add(time.Second), Data: "second line\n"},
+	}
+	require.NoError(t, log2.Append(ctx, lines2))
+	require.NoError(t, log2.Close(ctx, 0))
+
+	for _, test := range []struct {
+		name          string
+		req           *LogReadRequest
+		expectedLines []model.LogLine
+		hasErr        bool
+	}{
+		{
+			name:          "LogID",
+			req:           &LogReadRequest{LogId: log1.ID},
+			expectedLines: lines1,
+		},
+		{
+			name:          "TaskID",
+			req:           &LogReadRequest{TaskId: "task"},
+			expectedLines: []model.LogLine{lines1[0], lines2[0], lines1[1]},
+		},
+		{
+			name:          "TaskIDAndProcessName",
+			req:           &LogReadRequest{TaskId: "task", ProcName: "proc2"},
+			expectedLines: lines2,
+		},
+		{
+			name: "TimeRange",
+			req: &LogReadRequest{
+				TaskId: "task",
+				Start:  timestamppb.New(ts.Add(time.Second)),
+				End:    timestamppb.New(ts.Add(2 * time.Second)),
+			},
+			expectedLines: []model.LogLine{lines2[0], lines1[1]},
+		},
+		{
+			name:          "FollowClosedLog",
+			req:           &LogReadRequest{LogId: log1.ID, Follow: true},
+			expectedLines: lines1,
+		},
+		{
+			name:   "FollowWithoutLogID",
+			req:    &LogReadRequest{TaskId: "task", Follow: true},
+			hasErr: true,
+		},
+		{
+			name:   "LogDNE",
+			req:    &LogReadRequest{LogId: "DNE"},
+			hasErr: true,
+		},
+		{
+			name:   "TaskDNE",
+			req:    &LogReadRequest{TaskId: "DNE"},
+			hasErr: true,
+		},
+		{
+			name:   "NoID",
+			req:    &LogReadRequest{},
+			hasErr: true,
+		},
+		{
+			name: "InvalidTimeRange",
+			req: &LogReadRequest{
+				LogId: log1.ID,
+				Start: timestamppb.New(ts.Add(time.Second)),
+				End:   timestamppb.New(ts),
+			},
+			hasErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			port := getPort()
+			require.NoError(t, startBuildloggerService(ctx, env, port))
+			client, err := getBuildloggerGRPCClient(ctx, fmt.Sprintf("localhost:%d", port), []grpc.DialOption{grpc.WithInsecure()})
+			require.NoError(t, err)
+
+			stream, err := client.ReadLogLines(ctx, test.req)
+			require.NoError(t, err)
+
+			var lines []model.LogLine
+			for {
+				line, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if test.hasErr {
+					assert.Error(t, err)
+					return
+				}
+				require.NoError(t, err)
+				lines = append(lines, line.Export())
+			}
+			assert.False(t, test.hasErr)
+			assert.Equal(t, test.expectedLines, lines)
+		})
+	}
+}
+
 func createBuildloggerEnv() (cedar.Environment, error) {
 	env, err := cedar.NewEnvironment(context.Background(), testDBName, &cedar.Configuration{
 		MongoDBURI:                "mongodb://localhost:27017",
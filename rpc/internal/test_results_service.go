@@ -6,7 +6,10 @@ import (
 
 	"github.com/evergreen-ci/cedar"
 	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/cedar/units"
 	"github.com/mongodb/anser/db"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -116,8 +119,12 @@ func (s *testResultsService) StreamTestResults(stream CedarTestResults_StreamTes
 }
 
 // CloseTestResultsRecord "closes out" a test results record by setting the
-// completed at timestamp. This should be the last rpc call made on a test
-// results record.
+// completed at timestamp and compacts its Parquet part files, which Presto
+// ignores, so that its test results are visible to Presto once the record is
+// closed. A job is then enqueued to retry a failed compaction and to remove
+// the compacted parts after their grace period. Records whose job cannot be
+// enqueued are compacted later by the periodic test results compaction job.
+// This should be the last rpc call made on a test results record.
 func (s *testResultsService) CloseTestResultsRecord(ctx context.Context, info *TestResultsEndInfo) (*TestResultsResponse, error) {
 	record := &model.TestResults{ID: info.TestResultsRecordId}
 	record.Setup(s.env)
@@ -131,5 +138,20 @@ func (s *testResultsService) CloseTestResultsRecord(ctx context.Context, info *T
 	if err := record.Close(ctx); err != nil {
 		return nil, newRPCError(codes.Internal, errors.Wrapf(err, "closing test results '%s'", record.ID))
 	}
+
+	if record.Artifact.Version == 2 {
+		_, err := record.CompactParts(ctx)
+		grip.Warning(message.WrapError(err, message.Fields{
+			"message":         "could not compact test results parts",
+			"test_results_id": record.ID,
+		}))
+		if queue := s.env.GetRemoteQueue(); queue != nil {
+			grip.Warning(message.WrapError(queue.Put(ctx, units.NewCompactTestResultsPartsJob(s.env, record.ID)), message.Fields{
+				"message":         "could not enqueue test results parts compaction job",
+				"test_results_id": record.ID,
+			}))
+		}
+	}
+
 	return &TestResultsResponse{TestResultsRecordId: record.ID}, nil
 }
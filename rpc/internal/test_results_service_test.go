@@ -378,18 +378,41 @@ func TestCloseTestResultsRecord(t *testing.T) {
 		assert.NoError(t, os.RemoveAll(tmpDir))
 	}()
 
+	conf := model.NewCedarConfig(env)
+	conf.Bucket.TestResultsBucket = tmpDir
+	conf.Bucket.PrestoBucket = tmpDir
+	conf.Bucket.PrestoTestResultsPrefix = "presto-test-results"
+	require.NoError(t, conf.Save())
+
 	info := getTestResultsInfo()
 	exported, err := info.Export()
 	require.NoError(t, err)
 	record := model.CreateTestResults(exported, model.PailLocal)
 	record.Setup(env)
 	require.NoError(t, record.SaveNew(ctx))
+	info = getTestResultsInfo()
+	exported, err = info.Export()
+	require.NoError(t, err)
+	recordWithResults := model.CreateTestResults(exported, model.PailLocal)
+	recordWithResults.Setup(env)
+	require.NoError(t, recordWithResults.SaveNew(ctx))
+	require.NoError(t, recordWithResults.Append(ctx, []model.TestResult{
+		{
+			TaskID:        recordWithResults.Info.TaskID,
+			Execution:     recordWithResults.Info.Execution,
+			TestName:      "test",
+			Status:        "pass",
+			TestStartTime: time.Now().Add(-time.Minute),
+			TestEndTime:   time.Now(),
+		},
+	}))
 
 	for _, test := range []struct {
-		name   string
-		env    cedar.Environment
-		info   *TestResultsEndInfo
-		hasErr bool
+		name      string
+		env       cedar.Environment
+		info      *TestResultsEndInfo
+		compacted bool
+		hasErr    bool
 	}{
 		{
 			name:   "InvalidEnv",
@@ -407,6 +430,12 @@ func TestCloseTestResultsRecord(t *testing.T) {
 			env:  env,
 			info: &TestResultsEndInfo{TestResultsRecordId: record.ID},
 		},
+		{
+			name:      "CompactsParts",
+			env:       env,
+			info:      &TestResultsEndInfo{TestResultsRecordId: recordWithResults.ID},
+			compacted: true,
+		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			port := getPort()
@@ -428,6 +457,16 @@ func TestCloseTestResultsRecord(t *testing.T) {
 				require.NoError(t, r.Find(ctx))
 				assert.Equal(t, r.ID, r.Info.ID())
 				assert.True(t, time.Since(r.CompletedAt) <= time.Second)
+				if test.compacted {
+					// The test results are visible to Presto
+					// once the record is closed.
+					assert.False(t, r.Artifact.CompactedAt.IsZero())
+					bucket, err := r.GetPrestoBucket(ctx)
+					require.NoError(t, err)
+					compacted, err := bucket.Get(ctx, r.PrestoPartitionKey())
+					require.NoError(t, err)
+					assert.NoError(t, compacted.Close())
+				}
 			}
 		})
 	}
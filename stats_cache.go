@@ -18,9 +18,11 @@ const statChanBufferSize = 1000
 
 func newStatsCacheRegistry(ctx context.Context) map[string]*statsCache {
 	registry := map[string]*statsCache{
-		StatsCacheBuildlogger: newStatsCache(StatsCacheBuildlogger),
-		StatsCacheTestResults: newStatsCache(StatsCacheTestResults),
-		StatsCachePerf:        newStatsCache(StatsCachePerf),
+		StatsCacheBuildlogger:            newStatsCache(StatsCacheBuildlogger),
+		StatsCacheBuildloggerCacheHits:   newStatsCache(StatsCacheBuildloggerCacheHits),
+		StatsCacheBuildloggerCacheMisses: newStatsCache(StatsCacheBuildloggerCacheMisses),
+		StatsCacheTestResults:            newStatsCache(StatsCacheTestResults),
+		StatsCachePerf:                   newStatsCache(StatsCachePerf),
 	}
 	for _, r := range registry {
 		go r.consumerLoop(ctx)
@@ -0,0 +1,43 @@
+package units
+
+import (
+	"context"
+
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/mongodb/grip"
+)
+
+// batchResult is the outcome of a single batch of a batch job.
+type batchResult struct {
+	// Found is the number of records found for the batch.
+	Found int
+	// Processed is the number of found records that were processed.
+	Processed int
+}
+
+// runBatches runs batches of the size configured by the given batch job
+// controller until the controller's iterations are exhausted, the context is
+// done, or a batch makes no progress. A batch returning a nil result stops the
+// job. The errors of every batch are returned.
+func runBatches(ctx context.Context, controller *model.BatchJobController, batch func(context.Context, int) (*batchResult, error)) error {
+	catcher := grip.NewBasicCatcher()
+	for i := 0; controller.Iterations <= 0 || i < controller.Iterations; i++ {
+		if err := ctx.Err(); err != nil {
+			catcher.Add(err)
+			break
+		}
+
+		result, err := batch(ctx, controller.BatchSize)
+		catcher.Add(err)
+		if result == nil {
+			break
+		}
+		// Stop if the batch made no progress, otherwise records that
+		// cannot be processed are retried indefinitely.
+		if result.Found < controller.BatchSize || result.Processed == 0 {
+			break
+		}
+	}
+
+	return catcher.Resolve()
+}
@@ -0,0 +1,85 @@
+package units
+
+import (
+	"context"
+	"testing"
+
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunBatches(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		controller model.BatchJobController
+		results    []*batchResult
+		errs       []error
+		batches    int
+		hasErr     bool
+	}{
+		{
+			name:       "StopsAfterIterations",
+			controller: model.BatchJobController{BatchSize: 2, Iterations: 2},
+			results:    []*batchResult{{Found: 2, Processed: 2}, {Found: 2, Processed: 2}, {Found: 2, Processed: 2}},
+			batches:    2,
+		},
+		{
+			name:       "StopsOnLastBatch",
+			controller: model.BatchJobController{BatchSize: 2},
+			results:    []*batchResult{{Found: 2, Processed: 2}, {Found: 1, Processed: 1}, {Found: 2, Processed: 2}},
+			batches:    2,
+		},
+		{
+			name:       "StopsWithoutProgress",
+			controller: model.BatchJobController{BatchSize: 2},
+			results:    []*batchResult{{Found: 2, Processed: 1}, {Found: 2}, {Found: 2, Processed: 2}},
+			batches:    2,
+		},
+		{
+			name:       "ContinuesAfterPartialFailure",
+			controller: model.BatchJobController{BatchSize: 2},
+			results:    []*batchResult{{Found: 2, Processed: 1}, {Found: 0}},
+			errs:       []error{errors.New("failed to process record")},
+			batches:    2,
+			hasErr:     true,
+		},
+		{
+			name:       "StopsWithoutResult",
+			controller: model.BatchJobController{BatchSize: 2},
+			results:    []*batchResult{nil, {Found: 2, Processed: 2}},
+			errs:       []error{errors.New("failed to find records")},
+			batches:    1,
+			hasErr:     true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var batches int
+			err := runBatches(context.Background(), &test.controller, func(_ context.Context, batchSize int) (*batchResult, error) {
+				assert.Equal(t, test.controller.BatchSize, batchSize)
+				var err error
+				if batches < len(test.errs) {
+					err = test.errs[batches]
+				}
+				result := test.results[batches]
+				batches++
+				return result, err
+			})
+			if test.hasErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.batches, batches)
+		})
+	}
+	t.Run("StopsWhenContextIsDone", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := runBatches(ctx, &model.BatchJobController{BatchSize: 2}, func(context.Context, int) (*batchResult, error) {
+			t.Fatal("batch should not run")
+			return nil, nil
+		})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,101 @@
+package units
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/anser/db"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const migrateLogChunkIndexJobName = "migrate-log-chunk-index"
+
+type migrateLogChunkIndexJob struct {
+	job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
+	env      cedar.Environment
+}
+
+func init() {
+	registry.AddJobType(migrateLogChunkIndexJobName,
+		func() amboy.Job { return makeMigrateLogChunkIndexJob() })
+}
+
+func makeMigrateLogChunkIndexJob() *migrateLogChunkIndexJob {
+	j := &migrateLogChunkIndexJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    migrateLogChunkIndexJobName,
+				Version: 0,
+			},
+		},
+		env: cedar.GetEnvironment(),
+	}
+	return j
+}
+
+// NewMigrateLogChunkIndexJob creates a new amboy job to backfill the chunk
+// index of closed buildlogger logs, upgrading them from artifact version 1 to
+// version 2. The job is controlled by the batch job controller with the job's
+// name as its ID: it does nothing if the controller does not exist, migrates
+// logs in batches of the controller's batch size, and runs at most the
+// controller's number of iterations.
+func NewMigrateLogChunkIndexJob(env cedar.Environment, id string) amboy.Job {
+	j := makeMigrateLogChunkIndexJob()
+	j.SetID(fmt.Sprintf("%s.%s", migrateLogChunkIndexJobName, id))
+	j.env = env
+	return j
+}
+
+func (j *migrateLogChunkIndexJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+	if j.env == nil {
+		j.env = cedar.GetEnvironment()
+	}
+
+	controller, err := model.FindBatchJobController(ctx, j.env, migrateLogChunkIndexJobName)
+	if db.ResultsNotFound(err) {
+		grip.Debug(message.Fields{
+			"job_id":  j.ID(),
+			"message": "chunk index migration is disabled, no batch job controller found",
+		})
+		return
+	}
+	if err != nil {
+		j.AddError(err)
+		return
+	}
+	if controller.BatchSize <= 0 {
+		j.AddError(errors.Errorf("invalid batch size %d for chunk index migration", controller.BatchSize))
+		return
+	}
+	if controller.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, controller.Timeout)
+		defer cancel()
+	}
+
+	var numMigrated int
+	j.AddError(runBatches(ctx, controller, func(ctx context.Context, batchSize int) (*batchResult, error) {
+		migrated, err := model.MigrateLogChunkIndexes(ctx, j.env, batchSize)
+		err = errors.Wrap(err, "migrating chunk indexes")
+		if migrated == nil {
+			return nil, err
+		}
+		numMigrated += len(migrated.Migrated)
+
+		return &batchResult{Found: migrated.Found, Processed: len(migrated.Migrated)}, err
+	}))
+
+	grip.Info(message.Fields{
+		"job_id":   j.ID(),
+		"message":  "migrated buildlogger chunk indexes",
+		"migrated": numMigrated,
+	})
+}
@@ -0,0 +1,95 @@
+package units
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const compactLogChunksJobName = "compact-log-chunks"
+
+type compactLogChunksJob struct {
+	LogID    string `bson:"log_id" json:"log_id" yaml:"log_id"`
+	job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
+	env      cedar.Environment
+}
+
+func init() {
+	registry.AddJobType(compactLogChunksJobName,
+		func() amboy.Job { return makeCompactLogChunksJob() })
+}
+
+func makeCompactLogChunksJob() *compactLogChunksJob {
+	j := &compactLogChunksJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    compactLogChunksJobName,
+				Version: 0,
+			},
+		},
+		env: cedar.GetEnvironment(),
+	}
+	return j
+}
+
+// NewCompactLogChunksJob creates a new amboy job to merge the small chunks of
+// a closed buildlogger log into larger chunks. Once the chunks replaced by the
+// compaction are past their grace period, a follow up job removes them from
+// offline storage.
+func NewCompactLogChunksJob(env cedar.Environment, logID string) amboy.Job {
+	j := makeCompactLogChunksJob()
+	j.SetID(fmt.Sprintf("%s.%s", compactLogChunksJobName, logID))
+	j.LogID = logID
+	j.env = env
+	return j
+}
+
+func (j *compactLogChunksJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+	if j.env == nil {
+		j.env = cedar.GetEnvironment()
+	}
+
+	log := &model.Log{ID: j.LogID}
+	log.Setup(j.env)
+	remaining, err := log.RemoveReplacedChunks(ctx, model.ReplacedLogChunkGracePeriod)
+	if err != nil {
+		j.AddError(errors.Wrapf(err, "removing replaced chunks of log '%s'", j.LogID))
+		return
+	}
+
+	if log.Artifact.Version == 2 {
+		replaced, err := log.CompactChunks(ctx, model.DefaultCompactedLogChunkLines)
+		if err != nil {
+			j.AddError(errors.Wrapf(err, "compacting chunks of log '%s'", j.LogID))
+			return
+		}
+		remaining += replaced
+		grip.InfoWhen(replaced > 0, message.Fields{
+			"job_id":   j.ID(),
+			"message":  "compacted buildlogger log chunks",
+			"log_id":   j.LogID,
+			"replaced": replaced,
+		})
+	}
+	if remaining == 0 {
+		return
+	}
+
+	// Replaced chunks are removed by a follow up job once all of them are
+	// past their grace period.
+	waitUntil := time.Now().Add(model.ReplacedLogChunkGracePeriod)
+	cleanup := NewCompactLogChunksJob(j.env, j.LogID).(*compactLogChunksJob)
+	cleanup.SetID(fmt.Sprintf("%s.%s", cleanup.ID(), waitUntil.Format(tsFormat)))
+	cleanup.UpdateTimeInfo(amboy.JobTimeInfo{WaitUntil: waitUntil})
+	j.AddError(errors.Wrapf(j.env.GetRemoteQueue().Put(ctx, cleanup), "enqueueing removal of replaced chunks of log '%s'", j.LogID))
+}
@@ -0,0 +1,81 @@
+package units
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const extractFailureSignaturesJobName = "extract-failure-signatures"
+
+type extractFailureSignaturesJob struct {
+	LogID    string `bson:"log_id" json:"log_id" yaml:"log_id"`
+	job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
+	env      cedar.Environment
+}
+
+func init() {
+	registry.AddJobType(extractFailureSignaturesJobName,
+		func() amboy.Job { return makeExtractFailureSignaturesJob() })
+}
+
+func makeExtractFailureSignaturesJob() *extractFailureSignaturesJob {
+	j := &extractFailureSignaturesJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    extractFailureSignaturesJobName,
+				Version: 0,
+			},
+		},
+		env: cedar.GetEnvironment(),
+	}
+	return j
+}
+
+// NewExtractFailureSignaturesJob creates a new amboy job to extract the
+// failure signatures of a buildlogger log closed with a non-zero exit code
+// and store them with the log.
+func NewExtractFailureSignaturesJob(env cedar.Environment, logID string) amboy.Job {
+	j := makeExtractFailureSignaturesJob()
+	j.SetID(fmt.Sprintf("%s.%s", extractFailureSignaturesJobName, logID))
+	j.LogID = logID
+	j.env = env
+	return j
+}
+
+func (j *extractFailureSignaturesJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+	if j.env == nil {
+		j.env = cedar.GetEnvironment()
+	}
+
+	log := &model.Log{ID: j.LogID}
+	log.Setup(j.env)
+	if err := log.Find(ctx); err != nil {
+		j.AddError(errors.Wrapf(err, "finding log '%s'", j.LogID))
+		return
+	}
+	if log.CompletedAt.IsZero() || log.Info.ExitCode == 0 {
+		return
+	}
+
+	if err := log.ExtractFailureSignatures(ctx); err != nil {
+		j.AddError(errors.Wrapf(err, "extracting failure signatures of log '%s'", j.LogID))
+		return
+	}
+	grip.Info(message.Fields{
+		"job_id":     j.ID(),
+		"message":    "extracted buildlogger log failure signatures",
+		"log_id":     j.LogID,
+		"project":    log.Info.Project,
+		"signatures": len(log.FailureSignatures),
+	})
+}
@@ -81,6 +81,21 @@ func StartCrons(ctx context.Context, env cedar.Environment, rpcTLS bool) error {
 
 		return queue.Put(ctx, NewStatsDBCollectionSizeJob(env, utility.RoundPartOfMinute(0).Format(tsFormat)))
 	})
+	amboy.IntervalQueueOperation(ctx, remote, time.Hour, time.Now(), opts, func(ctx context.Context, queue amboy.Queue) error {
+		return queue.Put(ctx, NewRetentionExpiryJob(env, utility.RoundPartOfHour(0).Format(tsFormat)))
+	})
+	amboy.IntervalQueueOperation(ctx, remote, time.Hour, time.Now(), opts, func(ctx context.Context, queue amboy.Queue) error {
+		return queue.Put(ctx, NewMigrateLogChunkIndexJob(env, utility.RoundPartOfHour(0).Format(tsFormat)))
+	})
+	amboy.IntervalQueueOperation(ctx, remote, time.Hour, time.Now(), opts, func(ctx context.Context, queue amboy.Queue) error {
+		return queue.Put(ctx, NewCompactTestResultsJob(env, utility.RoundPartOfHour(0).Format(tsFormat)))
+	})
+	amboy.IntervalQueueOperation(ctx, remote, time.Hour, time.Now(), opts, func(ctx context.Context, queue amboy.Queue) error {
+		return queue.Put(ctx, NewMigrateSimpleLogsJob(env, utility.RoundPartOfHour(0).Format(tsFormat)))
+	})
+	amboy.IntervalQueueOperation(ctx, remote, time.Hour, time.Now(), opts, func(ctx context.Context, queue amboy.Queue) error {
+		return queue.Put(ctx, NewDetectFlakyTestsJob(env, utility.RoundPartOfHour(0).Format(tsFormat)))
+	})
 
 	return nil
 }
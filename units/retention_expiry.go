@@ -0,0 +1,136 @@
+package units
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/anser/db"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const retentionExpiryJobName = "retention-expiry"
+
+type retentionExpiryJob struct {
+	job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
+	env      cedar.Environment
+}
+
+func init() {
+	registry.AddJobType(retentionExpiryJobName,
+		func() amboy.Job { return makeRetentionExpiryJob() })
+}
+
+func makeRetentionExpiryJob() *retentionExpiryJob {
+	j := &retentionExpiryJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    retentionExpiryJobName,
+				Version: 0,
+			},
+		},
+		env: cedar.GetEnvironment(),
+	}
+	return j
+}
+
+// NewRetentionExpiryJob creates a new amboy job to remove the buildlogger
+// logs and test results that expired according to the retention rules in the
+// Cedar configuration, along with their data in offline blob storage. The job
+// is controlled by the batch job controller with the job's name as its ID: it
+// does nothing if the controller does not exist, removes records in batches
+// of the controller's batch size, runs at most the controller's number of
+// iterations per collection, and, if set, only removes records from the
+// controller's collection.
+func NewRetentionExpiryJob(env cedar.Environment, id string) amboy.Job {
+	j := makeRetentionExpiryJob()
+	j.SetID(fmt.Sprintf("%s.%s", retentionExpiryJobName, id))
+	j.env = env
+	return j
+}
+
+func (j *retentionExpiryJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+	if j.env == nil {
+		j.env = cedar.GetEnvironment()
+	}
+
+	controller, err := model.FindBatchJobController(ctx, j.env, retentionExpiryJobName)
+	if db.ResultsNotFound(err) {
+		grip.Debug(message.Fields{
+			"job_id":  j.ID(),
+			"message": "retention expiry is disabled, no batch job controller found",
+		})
+		return
+	}
+	if err != nil {
+		j.AddError(err)
+		return
+	}
+	if controller.BatchSize <= 0 {
+		j.AddError(errors.Errorf("invalid batch size %d for retention expiry", controller.BatchSize))
+		return
+	}
+	if controller.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, controller.Timeout)
+		defer cancel()
+	}
+
+	conf := model.NewCedarConfig(j.env)
+	if err = conf.Find(); err != nil {
+		j.AddError(errors.Wrap(err, "getting application configuration"))
+		return
+	}
+	if len(conf.Retention.Rules) == 0 {
+		return
+	}
+	if err = conf.Retention.Validate(); err != nil {
+		j.AddError(errors.Wrap(err, "invalid retention rules"))
+		return
+	}
+
+	for _, expiry := range []struct {
+		collection string
+		remove     func(context.Context, cedar.Environment, model.RetentionConfig, int) (*model.ExpiredRecords, error)
+	}{
+		{collection: "buildlogs", remove: model.RemoveExpiredLogs},
+		{collection: "test_results", remove: model.RemoveExpiredTestResults},
+	} {
+		if controller.Collection != "" && controller.Collection != expiry.collection {
+			continue
+		}
+
+		var (
+			removed  int
+			projects = map[string]int{}
+		)
+		j.AddError(runBatches(ctx, controller, func(ctx context.Context, batchSize int) (*batchResult, error) {
+			expired, err := expiry.remove(ctx, j.env, conf.Retention, batchSize)
+			err = errors.Wrapf(err, "removing expired records from '%s'", expiry.collection)
+			if expired == nil {
+				return nil, err
+			}
+			removed += len(expired.Removed)
+			for project, count := range expired.Projects {
+				projects[project] += count
+			}
+
+			return &batchResult{Found: expired.Found, Processed: len(expired.Removed)}, err
+		}))
+
+		grip.Info(message.Fields{
+			"job_id":     j.ID(),
+			"message":    "removed expired records",
+			"collection": expiry.collection,
+			"removed":    removed,
+			"projects":   projects,
+		})
+	}
+}
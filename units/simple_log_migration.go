@@ -0,0 +1,101 @@
+package units
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/anser/db"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const migrateSimpleLogsJobName = "migrate-simple-logs"
+
+type migrateSimpleLogsJob struct {
+	job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
+	env      cedar.Environment
+}
+
+func init() {
+	registry.AddJobType(migrateSimpleLogsJobName,
+		func() amboy.Job { return makeMigrateSimpleLogsJob() })
+}
+
+func makeMigrateSimpleLogsJob() *migrateSimpleLogsJob {
+	j := &migrateSimpleLogsJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    migrateSimpleLogsJobName,
+				Version: 0,
+			},
+		},
+		env: cedar.GetEnvironment(),
+	}
+	return j
+}
+
+// NewMigrateSimpleLogsJob creates a new amboy job to copy the legacy simple
+// logs into buildlogger logs, see model.MigrateSimpleLog. The job is
+// controlled by the batch job controller with the job's name as its ID: it
+// does nothing if the controller does not exist, migrates simple logs in
+// batches of the controller's batch size, and runs at most the controller's
+// number of iterations.
+func NewMigrateSimpleLogsJob(env cedar.Environment, id string) amboy.Job {
+	j := makeMigrateSimpleLogsJob()
+	j.SetID(fmt.Sprintf("%s.%s", migrateSimpleLogsJobName, id))
+	j.env = env
+	return j
+}
+
+func (j *migrateSimpleLogsJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+	if j.env == nil {
+		j.env = cedar.GetEnvironment()
+	}
+
+	controller, err := model.FindBatchJobController(ctx, j.env, migrateSimpleLogsJobName)
+	if db.ResultsNotFound(err) {
+		grip.Debug(message.Fields{
+			"job_id":  j.ID(),
+			"message": "simple log migration is disabled, no batch job controller found",
+		})
+		return
+	}
+	if err != nil {
+		j.AddError(err)
+		return
+	}
+	if controller.BatchSize <= 0 {
+		j.AddError(errors.Errorf("invalid batch size %d for simple log migration", controller.BatchSize))
+		return
+	}
+	if controller.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, controller.Timeout)
+		defer cancel()
+	}
+
+	var numMigrated int
+	j.AddError(runBatches(ctx, controller, func(ctx context.Context, batchSize int) (*batchResult, error) {
+		migrated, err := model.MigrateSimpleLogs(ctx, j.env, batchSize)
+		err = errors.Wrap(err, "migrating simple logs")
+		if migrated == nil {
+			return nil, err
+		}
+		numMigrated += len(migrated.Migrated)
+
+		return &batchResult{Found: migrated.Found, Processed: len(migrated.Migrated)}, err
+	}))
+
+	grip.Info(message.Fields{
+		"job_id":   j.ID(),
+		"message":  "migrated simple logs to buildlogger",
+		"migrated": numMigrated,
+	})
+}
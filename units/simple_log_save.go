@@ -8,13 +8,11 @@ import (
 
 	"github.com/evergreen-ci/cedar"
 	"github.com/evergreen-ci/cedar/model"
-	"github.com/evergreen-ci/pail"
 	"github.com/mongodb/amboy"
 	"github.com/mongodb/amboy/job"
 	"github.com/mongodb/amboy/registry"
 	"github.com/mongodb/grip"
 	"github.com/mongodb/grip/message"
-	"github.com/pkg/errors"
 )
 
 const (
@@ -41,7 +39,7 @@ func saveSimpleLogToDBJobFactory() amboy.Job {
 		Base: &job.Base{
 			JobType: amboy.JobType{
 				Name:    saveSimpleLogJobName,
-				Version: 1,
+				Version: 2,
 			},
 		},
 		env: cedar.GetEnvironment(),
@@ -62,6 +60,8 @@ func MakeSaveSimpleLogJob(env cedar.Environment, logID, content string, ts time.
 	return j
 }
 
+// Run appends the content to the buildlogger log storing the simple log. The
+// legacy log segments are no longer written, see model.MigrateSimpleLog.
 func (j *saveSimpleLogToDBJob) Run(ctx context.Context) {
 	defer j.MarkComplete()
 
@@ -69,81 +69,17 @@ func (j *saveSimpleLogToDBJob) Run(ctx context.Context) {
 		j.env = cedar.GetEnvironment()
 	}
 
-	conf := j.env.GetConfig()
-
-	bucket, err := pail.NewS3Bucket(ctx, pail.S3Options{Name: conf.BucketName})
-	if err != nil {
-		j.AddError(errors.WithStack(err))
-		return
-	}
-	grip.Infoln("got S3 bucket object for:", conf.BucketName)
-
-	s3Key := fmt.Sprintf("simple-log/%s.%d", j.LogID, j.Increment)
-
-	writer, err := bucket.Writer(ctx, s3Key)
-	if err != nil {
-		j.AddError(errors.Wrap(err, "constructing bucket object"))
-		return
-	}
-
-	_, err = writer.Write([]byte(strings.Join(j.Content, "\n")))
-	if err != nil {
-		j.AddError(errors.Wrap(err, "writing to S3"))
-		return
-	}
-	if err = writer.Close(); err != nil {
-		j.AddError(errors.Wrap(err, "flushing data to S3"))
-	}
-
-	// if we get here the data is safe in S3 so we can clear this.
-	defer func() { j.Content = []string{} }()
-
-	// in a simple log the log ID and the ID are different
-	doc := &model.LogSegment{
-		LogID:   j.LogID,
-		Segment: j.Increment,
-		URL:     fmt.Sprintf("http://%s.s3.amazonaws.com/%s", conf.BucketName, s3Key),
-		Bucket:  conf.BucketName,
-		KeyName: s3Key,
-		Metrics: model.LogMetrics{
-			NumberLines:       -1,
-			LetterFrequencies: map[string]int{},
-		},
-	}
-
-	if err = doc.Insert(); err != nil {
-		grip.Warning(message.Fields{
-			"message": "problem inserting document for log",
-			"id":      doc.ID,
-			"error":   err,
-			"doc":     fmt.Sprintf("%+v", doc)})
-		j.AddError(errors.Wrap(err, "inserting record for document"))
-		return
-	}
-
-	// TODO: I think this needs to get data out of S3 rather than
-	// get handed to it from memory, which requires a DB round trip.
-	//
-	parser := &parseSimpleLog{Key: j.LogID, Segment: j.Increment, Content: j.Content}
-
-	// TODO: in the future there should be a parser/post-processing
-	// interface that's amboy.Job+Validate(), so we can generate
-	// slices of parsers and run the validate->put steps in a loop.
-	//
-	if err = parser.Validate(); err != nil {
-		err = errors.Wrap(err, "creating parser job")
-		grip.Error(err)
-		j.AddError(err)
-		return
-	}
-
-	q := j.env.GetLocalQueue()
-
-	if err := q.Put(ctx, parser); err != nil {
-		grip.Error(err)
+	if err := model.AppendSimpleLog(ctx, j.env, j.LogID, j.Increment, strings.Join(j.Content, "\n")); err != nil {
+		grip.Warning(message.WrapError(err, message.Fields{
+			"message":   "problem appending simple log content",
+			"log_id":    j.LogID,
+			"increment": j.Increment,
+		}))
 		j.AddError(err)
 		return
 	}
 
-	grip.Noticeln("added parsing job for:", j.LogID)
+	// if we get here the data is safe in the buildlogger log so we can
+	// clear this.
+	j.Content = []string{}
 }
@@ -0,0 +1,99 @@
+package units
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const compactTestResultsPartsJobName = "compact-test-results-parts"
+
+type compactTestResultsPartsJob struct {
+	TestResultsID string `bson:"test_results_id" json:"test_results_id" yaml:"test_results_id"`
+	job.Base      `bson:"metadata" json:"metadata" yaml:"metadata"`
+	env           cedar.Environment
+}
+
+func init() {
+	registry.AddJobType(compactTestResultsPartsJobName,
+		func() amboy.Job { return makeCompactTestResultsPartsJob() })
+}
+
+func makeCompactTestResultsPartsJob() *compactTestResultsPartsJob {
+	j := &compactTestResultsPartsJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    compactTestResultsPartsJobName,
+				Version: 0,
+			},
+		},
+		env: cedar.GetEnvironment(),
+	}
+	return j
+}
+
+// NewCompactTestResultsPartsJob creates a new amboy job to merge the Parquet
+// part files of a closed test results record into the single Parquet file
+// read by Presto. Once the merged parts are past their grace period, a follow
+// up job removes them from offline storage.
+func NewCompactTestResultsPartsJob(env cedar.Environment, testResultsID string) amboy.Job {
+	j := makeCompactTestResultsPartsJob()
+	j.SetID(fmt.Sprintf("%s.%s", compactTestResultsPartsJobName, testResultsID))
+	j.TestResultsID = testResultsID
+	j.env = env
+	return j
+}
+
+func (j *compactTestResultsPartsJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+	if j.env == nil {
+		j.env = cedar.GetEnvironment()
+	}
+
+	record := &model.TestResults{ID: j.TestResultsID}
+	record.Setup(j.env)
+	if err := record.RemoveCompactedParts(ctx, model.CompactedTestResultsPartGracePeriod); err != nil {
+		j.AddError(errors.Wrapf(err, "removing compacted parts of test results record '%s'", j.TestResultsID))
+		return
+	}
+
+	if record.Artifact.Version == 2 && record.Artifact.CompactedAt.IsZero() {
+		compacted, err := record.CompactParts(ctx)
+		if err != nil {
+			j.AddError(errors.Wrapf(err, "compacting parts of test results record '%s'", j.TestResultsID))
+			return
+		}
+		grip.InfoWhen(compacted > 0, message.Fields{
+			"job_id":          j.ID(),
+			"message":         "compacted test results parts",
+			"test_results_id": j.TestResultsID,
+			"compacted":       compacted,
+		})
+	}
+	if len(record.Artifact.CompactedParts) == 0 {
+		return
+	}
+
+	j.AddError(enqueueCompactedTestResultsPartsRemoval(ctx, j.env, j.TestResultsID))
+}
+
+// enqueueCompactedTestResultsPartsRemoval enqueues a follow up job that
+// removes the compacted parts of the given test results record once they are
+// past their grace period.
+func enqueueCompactedTestResultsPartsRemoval(ctx context.Context, env cedar.Environment, testResultsID string) error {
+	waitUntil := time.Now().Add(model.CompactedTestResultsPartGracePeriod)
+	cleanup := NewCompactTestResultsPartsJob(env, testResultsID).(*compactTestResultsPartsJob)
+	cleanup.SetID(fmt.Sprintf("%s.%s", cleanup.ID(), waitUntil.Format(tsFormat)))
+	cleanup.UpdateTimeInfo(amboy.JobTimeInfo{WaitUntil: waitUntil})
+
+	return errors.Wrapf(env.GetRemoteQueue().Put(ctx, cleanup), "enqueueing removal of compacted parts of test results record '%s'", testResultsID)
+}
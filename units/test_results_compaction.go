@@ -0,0 +1,113 @@
+package units
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/anser/db"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const (
+	compactTestResultsJobName = "compact-test-results"
+
+	// compactTestResultsDelay is the minimum amount of time since a test
+	// results record was closed before it is compacted by this job, which
+	// leaves time for the compaction job enqueued on close to run.
+	compactTestResultsDelay = time.Hour
+)
+
+type compactTestResultsJob struct {
+	job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
+	env      cedar.Environment
+}
+
+func init() {
+	registry.AddJobType(compactTestResultsJobName,
+		func() amboy.Job { return makeCompactTestResultsJob() })
+}
+
+func makeCompactTestResultsJob() *compactTestResultsJob {
+	j := &compactTestResultsJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    compactTestResultsJobName,
+				Version: 0,
+			},
+		},
+		env: cedar.GetEnvironment(),
+	}
+	return j
+}
+
+// NewCompactTestResultsJob creates a new amboy job to compact the Parquet
+// part files of closed test results records that were not compacted when
+// they were closed. The job is controlled by the batch job controller with
+// the job's name as its ID: it does nothing if the controller does not exist,
+// compacts records in batches of the controller's batch size, and runs at
+// most the controller's number of iterations.
+func NewCompactTestResultsJob(env cedar.Environment, id string) amboy.Job {
+	j := makeCompactTestResultsJob()
+	j.SetID(fmt.Sprintf("%s.%s", compactTestResultsJobName, id))
+	j.env = env
+	return j
+}
+
+func (j *compactTestResultsJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+	if j.env == nil {
+		j.env = cedar.GetEnvironment()
+	}
+
+	controller, err := model.FindBatchJobController(ctx, j.env, compactTestResultsJobName)
+	if db.ResultsNotFound(err) {
+		grip.Debug(message.Fields{
+			"job_id":  j.ID(),
+			"message": "test results compaction is disabled, no batch job controller found",
+		})
+		return
+	}
+	if err != nil {
+		j.AddError(err)
+		return
+	}
+	if controller.BatchSize <= 0 {
+		j.AddError(errors.Errorf("invalid batch size %d for test results compaction", controller.BatchSize))
+		return
+	}
+	if controller.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, controller.Timeout)
+		defer cancel()
+	}
+
+	completedBefore := time.Now().Add(-compactTestResultsDelay)
+	var numCompacted int
+	j.AddError(runBatches(ctx, controller, func(ctx context.Context, batchSize int) (*batchResult, error) {
+		compacted, err := model.CompactTestResultsParts(ctx, j.env, completedBefore, batchSize)
+		err = errors.Wrap(err, "compacting test results parts")
+		if compacted == nil {
+			return nil, err
+		}
+		numCompacted += len(compacted.Compacted)
+		for _, id := range compacted.Compacted {
+			j.AddError(enqueueCompactedTestResultsPartsRemoval(ctx, j.env, id))
+		}
+
+		return &batchResult{Found: compacted.Found, Processed: len(compacted.Compacted)}, err
+	}))
+
+	grip.Info(message.Fields{
+		"job_id":    j.ID(),
+		"message":   "compacted test results parts",
+		"compacted": numCompacted,
+	})
+}
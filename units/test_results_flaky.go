@@ -0,0 +1,84 @@
+package units
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const detectFlakyTestsJobName = "detect-flaky-tests"
+
+type detectFlakyTestsJob struct {
+	job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
+	env      cedar.Environment
+}
+
+func init() {
+	registry.AddJobType(detectFlakyTestsJobName,
+		func() amboy.Job { return makeDetectFlakyTestsJob() })
+}
+
+func makeDetectFlakyTestsJob() *detectFlakyTestsJob {
+	j := &detectFlakyTestsJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    detectFlakyTestsJobName,
+				Version: 0,
+			},
+		},
+		env: cedar.GetEnvironment(),
+	}
+	return j
+}
+
+// NewDetectFlakyTestsJob creates a new amboy job to score the tests of the
+// recent mainline test history and flag the tests that both passed and failed
+// on the same version, see model.DetectFlakyTests. The job does nothing if
+// the flaky tests configuration has no detection window.
+func NewDetectFlakyTestsJob(env cedar.Environment, id string) amboy.Job {
+	j := makeDetectFlakyTestsJob()
+	j.SetID(fmt.Sprintf("%s.%s", detectFlakyTestsJobName, id))
+	j.env = env
+	return j
+}
+
+func (j *detectFlakyTestsJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+	if j.env == nil {
+		j.env = cedar.GetEnvironment()
+	}
+
+	conf := model.NewCedarConfig(j.env)
+	if err := conf.Find(); err != nil {
+		j.AddError(errors.Wrap(err, "getting application configuration"))
+		return
+	}
+	if conf.FlakyTests.Window <= 0 {
+		grip.Debug(message.Fields{
+			"job_id":  j.ID(),
+			"message": "flaky test detection is disabled, no detection window configured",
+		})
+		return
+	}
+
+	flaky, err := model.DetectFlakyTests(ctx, j.env, conf.FlakyTests)
+	if err != nil {
+		j.AddError(errors.Wrap(err, "detecting flaky tests"))
+		return
+	}
+
+	grip.Info(message.Fields{
+		"job_id":  j.ID(),
+		"message": "detected flaky tests",
+		"flaky":   flaky,
+		"window":  conf.FlakyTests.Window.String(),
+	})
+}